@@ -12,6 +12,6 @@ var version = "dev"
 func main() {
 	if err := cli.Execute(version); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -1,8 +1,10 @@
 package auth
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 
@@ -11,7 +13,7 @@ import (
 
 var templatePattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
 
-func Apply(headers map[string]string, cfg *config.Config, vars map[string]string) error {
+func Apply(ctx context.Context, headers map[string]string, cfg *config.Config, vars map[string]string) error {
 	if cfg == nil {
 		return fmt.Errorf("auth config cannot be nil")
 	}
@@ -71,6 +73,50 @@ func Apply(headers map[string]string, cfg *config.Config, vars map[string]string
 		headers[headerName] = renderTemplate(format, tmplVars)
 		return nil
 
+	case "oauth2":
+		token, err := oauth2AccessToken(ctx, cfg, tmplVars)
+		if err != nil {
+			return err
+		}
+		headerName := defaultHeaderName(cfg.Auth.HeaderName, "Authorization")
+		format := cfg.Auth.HeaderFormat
+		if strings.TrimSpace(format) == "" {
+			format = "Bearer ${TOKEN}"
+		}
+		tmplVars["TOKEN"] = token
+		headers[headerName] = renderTemplate(format, tmplVars)
+		return nil
+
+	case "digest":
+		// The HTTP client answers the server's challenge with these credentials.
+		if _, _, err := DigestCredentials(cfg, tmplVars); err != nil {
+			return err
+		}
+		return nil
+
+	case "aws_sigv4":
+		// Signed per request by RequestSigner once the body is final.
+		return nil
+
+	case "hmac":
+		// Signed per request by RequestSigner; only the settings are checked here.
+		_, err := newHMACSettings(cfg, tmplVars)
+		return err
+
+	case "jwt":
+		token, err := mintJWT(cfg, tmplVars, signingNow())
+		if err != nil {
+			return err
+		}
+		headerName := defaultHeaderName(cfg.Auth.HeaderName, "Authorization")
+		format := cfg.Auth.HeaderFormat
+		if strings.TrimSpace(format) == "" {
+			format = "Bearer ${TOKEN}"
+		}
+		tmplVars["TOKEN"] = token
+		headers[headerName] = renderTemplate(format, tmplVars)
+		return nil
+
 	case "custom":
 		headerName := defaultHeaderName(cfg.Auth.HeaderName, "Authorization")
 		format := cfg.Auth.HeaderFormat
@@ -85,6 +131,53 @@ func Apply(headers map[string]string, cfg *config.Config, vars map[string]string
 	}
 }
 
+// RequestSigner returns the function signing each outgoing request for auth
+// types that sign the whole request (aws_sigv4, hmac) rather than set a
+// static header, or nil.
+func RequestSigner(cfg *config.Config, vars map[string]string) (func(req *http.Request, body []byte) error, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	tmplVars := makeTemplateVars(cfg, vars)
+
+	switch strings.ToLower(strings.TrimSpace(cfg.Auth.Type)) {
+	case "aws_sigv4":
+		creds, err := awsCredentials(cfg, tmplVars)
+		if err != nil {
+			return nil, err
+		}
+		return func(req *http.Request, body []byte) error {
+			return SignV4(req, body, creds, signingNow())
+		}, nil
+
+	case "hmac":
+		settings, err := newHMACSettings(cfg, tmplVars)
+		if err != nil {
+			return nil, err
+		}
+		return func(req *http.Request, body []byte) error {
+			return settings.sign(req, body, signingNow())
+		}, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// DigestCredentials returns the username and password answering HTTP Digest
+// challenges, or empty strings when auth.type is not digest.
+func DigestCredentials(cfg *config.Config, vars map[string]string) (string, string, error) {
+	if cfg == nil || !strings.EqualFold(strings.TrimSpace(cfg.Auth.Type), "digest") {
+		return "", "", nil
+	}
+	username := renderTemplate(cfg.Auth.Username, vars)
+	password := renderTemplate(cfg.Auth.Password, vars)
+	if username == "" || password == "" || templatePattern.MatchString(username+password) {
+		return "", "", fmt.Errorf("digest auth requires username and password")
+	}
+	return username, password, nil
+}
+
 func makeTemplateVars(cfg *config.Config, vars map[string]string) map[string]string {
 	result := make(map[string]string, len(vars)+5)
 	for k, v := range vars {
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"testing"
 
 	"github.com/Tresor-Kasend/apix/internal/config"
@@ -12,7 +13,7 @@ func TestApplyBearer(t *testing.T) {
 	cfg := &config.Config{Auth: config.AuthConfig{Type: "bearer", Token: "abc123"}}
 	headers := map[string]string{}
 
-	if err := Apply(headers, cfg, map[string]string{}); err != nil {
+	if err := Apply(context.Background(), headers, cfg, map[string]string{}); err != nil {
 		t.Fatalf("apply bearer failed: %v", err)
 	}
 
@@ -27,7 +28,7 @@ func TestApplyBasic(t *testing.T) {
 	cfg := &config.Config{Auth: config.AuthConfig{Type: "basic", Username: "john", Password: "secret"}}
 	headers := map[string]string{}
 
-	if err := Apply(headers, cfg, map[string]string{}); err != nil {
+	if err := Apply(context.Background(), headers, cfg, map[string]string{}); err != nil {
 		t.Fatalf("apply basic failed: %v", err)
 	}
 
@@ -46,7 +47,7 @@ func TestApplyAPIKey(t *testing.T) {
 	}}
 	headers := map[string]string{}
 
-	if err := Apply(headers, cfg, map[string]string{}); err != nil {
+	if err := Apply(context.Background(), headers, cfg, map[string]string{}); err != nil {
 		t.Fatalf("apply api_key failed: %v", err)
 	}
 
@@ -67,7 +68,7 @@ func TestApplyCustom(t *testing.T) {
 	}}
 	headers := map[string]string{}
 
-	if err := Apply(headers, cfg, map[string]string{}); err != nil {
+	if err := Apply(context.Background(), headers, cfg, map[string]string{}); err != nil {
 		t.Fatalf("apply custom failed: %v", err)
 	}
 
@@ -82,7 +83,7 @@ func TestApplyBasicMissingCredentials(t *testing.T) {
 	cfg := &config.Config{Auth: config.AuthConfig{Type: "basic", Username: "john"}}
 	headers := map[string]string{}
 
-	if err := Apply(headers, cfg, map[string]string{}); err == nil {
+	if err := Apply(context.Background(), headers, cfg, map[string]string{}); err == nil {
 		t.Fatal("expected error for missing basic password")
 	}
 }
@@ -93,7 +94,25 @@ func TestApplyAPIKeyMissingKey(t *testing.T) {
 	cfg := &config.Config{Auth: config.AuthConfig{Type: "api_key"}}
 	headers := map[string]string{}
 
-	if err := Apply(headers, cfg, map[string]string{}); err == nil {
+	if err := Apply(context.Background(), headers, cfg, map[string]string{}); err == nil {
 		t.Fatal("expected error for missing api key")
 	}
 }
+
+func TestApplyDigestLeavesHeadersToChallenge(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Auth: config.AuthConfig{Type: "digest", Username: "admin", Password: "${DIGEST_PASSWORD}"}}
+	headers := map[string]string{}
+
+	if err := Apply(context.Background(), headers, cfg, map[string]string{}); err == nil {
+		t.Fatal("expected error for unresolved digest password")
+	}
+	if err := Apply(context.Background(), headers, cfg, map[string]string{"DIGEST_PASSWORD": "secret"}); err != nil || len(headers) != 0 {
+		t.Fatalf("expected no static header, got %v (%v)", headers, err)
+	}
+	username, password, err := DigestCredentials(cfg, map[string]string{"DIGEST_PASSWORD": "secret"})
+	if err != nil || username != "admin" || password != "secret" {
+		t.Fatalf("unexpected digest credentials %q/%q (%v)", username, password, err)
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+// DefaultHMACCanonicalString is signed when auth.canonical_string is empty.
+const DefaultHMACCanonicalString = "${METHOD}\n${PATH}\n${TIMESTAMP}\n${BODY_SHA256}"
+
+// hmacSettings is the hmac auth config with its placeholders resolved.
+type hmacSettings struct {
+	Secret          string
+	NewHash         func() hash.Hash
+	Canonical       string
+	HeaderName      string
+	HeaderFormat    string
+	TimestampHeader string
+	Encoding        string
+	vars            map[string]string
+}
+
+func newHMACSettings(cfg *config.Config, vars map[string]string) (hmacSettings, error) {
+	s := hmacSettings{
+		Secret:          renderTemplate(cfg.Auth.Secret, vars),
+		Canonical:       cfg.Auth.CanonicalString,
+		HeaderName:      defaultHeaderName(cfg.Auth.HeaderName, "X-Signature"),
+		HeaderFormat:    cfg.Auth.HeaderFormat,
+		TimestampHeader: defaultHeaderName(cfg.Auth.TimestampHeader, "X-Timestamp"),
+		Encoding:        strings.ToLower(strings.TrimSpace(cfg.Auth.SignatureEncoding)),
+		vars:            vars,
+	}
+	if s.Secret == "" || templatePattern.MatchString(s.Secret) {
+		return s, fmt.Errorf("hmac auth requires secret")
+	}
+	if strings.TrimSpace(s.Canonical) == "" {
+		s.Canonical = DefaultHMACCanonicalString
+	}
+	if strings.TrimSpace(s.HeaderFormat) == "" {
+		s.HeaderFormat = "${SIGNATURE}"
+	}
+
+	algorithm := strings.ToLower(strings.TrimSpace(cfg.Auth.Algorithm))
+	algorithm = strings.TrimPrefix(strings.TrimPrefix(algorithm, "hmac"), "-")
+	switch strings.ReplaceAll(algorithm, "-", "") {
+	case "", "sha256":
+		s.NewHash = sha256.New
+	case "sha1":
+		s.NewHash = sha1.New
+	case "sha512":
+		s.NewHash = sha512.New
+	default:
+		return s, fmt.Errorf("unsupported hmac algorithm %q (expected sha256, sha512 or sha1)", cfg.Auth.Algorithm)
+	}
+
+	switch s.Encoding {
+	case "", "hex", "base64", "base64url":
+	default:
+		return s, fmt.Errorf("unsupported hmac signature_encoding %q (expected hex, base64 or base64url)", cfg.Auth.SignatureEncoding)
+	}
+	return s, nil
+}
+
+// sign renders the canonical string for req and sets the signature and
+// timestamp headers. Besides the request variables, the canonical string and
+// header_format can use ${METHOD}, ${PATH}, ${QUERY}, ${HOST}, ${TIMESTAMP},
+// ${TIMESTAMP_MS}, ${ISO_TIMESTAMP}, ${BODY}, ${BODY_SHA256} and ${BODY_HASH}.
+func (s hmacSettings) sign(req *http.Request, body []byte, now time.Time) error {
+	bodyHash := s.NewHash()
+	bodyHash.Write(body)
+
+	vars := make(map[string]string, len(s.vars)+10)
+	for k, v := range s.vars {
+		vars[k] = v
+	}
+	vars["METHOD"] = req.Method
+	vars["PATH"] = req.URL.EscapedPath()
+	if vars["PATH"] == "" {
+		vars["PATH"] = "/"
+	}
+	vars["QUERY"] = req.URL.RawQuery
+	vars["HOST"] = req.URL.Host
+	vars["TIMESTAMP"] = strconv.FormatInt(now.Unix(), 10)
+	vars["TIMESTAMP_MS"] = strconv.FormatInt(now.UnixMilli(), 10)
+	vars["ISO_TIMESTAMP"] = now.UTC().Format(time.RFC3339)
+	vars["BODY"] = string(body)
+	vars["BODY_SHA256"] = sha256Hex(body)
+	vars["BODY_HASH"] = hex.EncodeToString(bodyHash.Sum(nil))
+
+	mac := hmac.New(s.NewHash, []byte(s.Secret))
+	mac.Write([]byte(renderTemplate(s.Canonical, vars)))
+	sum := mac.Sum(nil)
+	switch s.Encoding {
+	case "base64":
+		vars["SIGNATURE"] = base64.StdEncoding.EncodeToString(sum)
+	case "base64url":
+		vars["SIGNATURE"] = base64.RawURLEncoding.EncodeToString(sum)
+	default:
+		vars["SIGNATURE"] = hex.EncodeToString(sum)
+	}
+
+	if !strings.EqualFold(s.TimestampHeader, "none") {
+		req.Header.Set(s.TimestampHeader, vars["TIMESTAMP"])
+	}
+	req.Header.Set(s.HeaderName, renderTemplate(s.HeaderFormat, vars))
+	return nil
+}
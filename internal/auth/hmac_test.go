@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+func TestRequestSignerHMACDefaultCanonicalString(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cfg := &config.Config{Auth: config.AuthConfig{Type: "hmac", Secret: "${HMAC_SECRET}"}}
+
+	settings, err := newHMACSettings(cfg, map[string]string{"HMAC_SECRET": "s3cret"})
+	if err != nil {
+		t.Fatalf("newHMACSettings() error = %v", err)
+	}
+	body := []byte(`{"amount":10}`)
+	req, _ := http.NewRequest(http.MethodPost, "https://partner.example.com/v1/payments?ref=42", nil)
+	if err := settings.sign(req, body, now); err != nil {
+		t.Fatalf("sign() error = %v", err)
+	}
+
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("POST\n/v1/payments\n1700000000\n" + hex.EncodeToString(bodySum[:])))
+	if got, want := req.Header.Get("X-Signature"), hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Fatalf("X-Signature = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Timestamp"); got != "1700000000" {
+		t.Fatalf("X-Timestamp = %q", got)
+	}
+}
+
+func TestRequestSignerHMACCustomCanonicalString(t *testing.T) {
+	signingNow = func() time.Time { return time.Unix(1700000000, 0) }
+	t.Cleanup(func() { signingNow = time.Now })
+
+	cfg := &config.Config{Auth: config.AuthConfig{
+		Type:              "hmac",
+		Secret:            "s3cret",
+		APIKey:            "partner-1",
+		Algorithm:         "HMAC-SHA512",
+		CanonicalString:   "${API_KEY}|${METHOD}|${PATH}?${QUERY}|${TIMESTAMP_MS}|${BODY_HASH}",
+		HeaderName:        "Authorization",
+		HeaderFormat:      "HMAC ${API_KEY}:${SIGNATURE}",
+		TimestampHeader:   "none",
+		SignatureEncoding: "base64",
+	}}
+	sign, err := RequestSigner(cfg, nil)
+	if err != nil || sign == nil {
+		t.Fatalf("RequestSigner() error = %v (signer set: %t)", err, sign != nil)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://partner.example.com/v1/orders?page=2", nil)
+	if err := sign(req, nil); err != nil {
+		t.Fatalf("sign() error = %v", err)
+	}
+
+	bodySum := sha512.Sum512(nil)
+	mac := hmac.New(sha512.New, []byte("s3cret"))
+	mac.Write([]byte("partner-1|GET|/v1/orders?page=2|1700000000000|" + hex.EncodeToString(bodySum[:])))
+	want := "HMAC partner-1:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+	if req.Header.Get("X-Timestamp") != "" {
+		t.Fatalf("expected no timestamp header, got %v", req.Header)
+	}
+}
+
+func TestApplyHMACValidatesSettings(t *testing.T) {
+	cfg := &config.Config{Auth: config.AuthConfig{Type: "hmac", Secret: "${MISSING}"}}
+	if err := Apply(context.Background(), map[string]string{}, cfg, nil); err == nil || !strings.Contains(err.Error(), "requires secret") {
+		t.Fatalf("expected missing secret error, got %v", err)
+	}
+
+	cfg.Auth.Secret = "s3cret"
+	cfg.Auth.Algorithm = "md5"
+	if err := Apply(context.Background(), map[string]string{}, cfg, nil); err == nil || !strings.Contains(err.Error(), `unsupported hmac algorithm "md5"`) {
+		t.Fatalf("expected unsupported algorithm error, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+// DefaultJWTExpiry is the lifetime of minted tokens when expires_in is empty.
+const DefaultJWTExpiry = 5 * time.Minute
+
+// mintJWT signs a new token with the jwt auth config: iat, exp (now plus
+// expires_in), iss, sub and aud, then the custom claims with their ${VAR}
+// placeholders resolved.
+func mintJWT(cfg *config.Config, vars map[string]string, now time.Time) (string, error) {
+	render := func(value string) string {
+		return strings.TrimSpace(renderTemplate(value, vars))
+	}
+
+	algorithm := strings.ToUpper(render(cfg.Auth.Algorithm))
+	if algorithm == "" {
+		algorithm = "HS256"
+	}
+	sign, err := jwtSigner(algorithm, render(cfg.Auth.Secret), render(cfg.Auth.KeyFile))
+	if err != nil {
+		return "", err
+	}
+
+	expiry := DefaultJWTExpiry
+	if value := render(cfg.Auth.ExpiresIn); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			expiry = time.Duration(seconds) * time.Second
+		} else if expiry, err = time.ParseDuration(value); err != nil {
+			return "", fmt.Errorf("invalid jwt expires_in %q: expected a duration like 5m or seconds", value)
+		}
+	}
+
+	claims := map[string]interface{}{"iat": now.Unix()}
+	if expiry > 0 {
+		claims["exp"] = now.Add(expiry).Unix()
+	}
+	if issuer := render(cfg.Auth.Issuer); issuer != "" {
+		claims["iss"] = issuer
+	}
+	if subject := render(cfg.Auth.Subject); subject != "" {
+		claims["sub"] = subject
+	}
+	if audience := render(cfg.Auth.Audience); audience != "" {
+		claims["aud"] = audience
+	}
+	for name, value := range cfg.Auth.Claims {
+		claims[name] = renderClaim(value, vars)
+	}
+
+	header := map[string]string{"alg": algorithm, "typ": "JWT"}
+	if keyID := render(cfg.Auth.KeyID); keyID != "" {
+		header["kid"] = keyID
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("encoding jwt header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("encoding jwt claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	signature, err := sign([]byte(signingInput))
+	if err != nil {
+		return "", fmt.Errorf("signing jwt: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// renderClaim resolves placeholders in the strings of a claim value, keeping
+// numbers, booleans and the shape of objects and lists.
+func renderClaim(value interface{}, vars map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return renderTemplate(v, vars)
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			rendered[k] = renderClaim(item, vars)
+		}
+		return rendered
+	case map[interface{}]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			rendered[fmt.Sprint(k)] = renderClaim(item, vars)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = renderClaim(item, vars)
+		}
+		return rendered
+	default:
+		return v
+	}
+}
+
+// jwtSigner returns the JWS signature function of algorithm. HS256 uses the
+// secret (or the raw key file); RS256 and ES256 a PEM private key file.
+func jwtSigner(algorithm, secret, keyFile string) (func([]byte) ([]byte, error), error) {
+	switch algorithm {
+	case "HS256":
+		key := []byte(secret)
+		if secret == "" && keyFile != "" {
+			data, err := os.ReadFile(keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading jwt key file: %w", err)
+			}
+			key = []byte(strings.TrimSpace(string(data)))
+		}
+		if len(key) == 0 || templatePattern.Match(key) {
+			return nil, fmt.Errorf("jwt HS256 requires secret or key_file")
+		}
+		return func(input []byte) ([]byte, error) {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(input)
+			return mac.Sum(nil), nil
+		}, nil
+
+	case "RS256", "ES256":
+		if keyFile == "" {
+			return nil, fmt.Errorf("jwt %s requires key_file", algorithm)
+		}
+		key, err := loadPrivateKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		if algorithm == "RS256" {
+			rsaKey, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("jwt RS256 requires an RSA private key in %s", keyFile)
+			}
+			return func(input []byte) ([]byte, error) {
+				digest := sha256.Sum256(input)
+				return rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+			}, nil
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("jwt ES256 requires a P-256 EC private key in %s", keyFile)
+		}
+		return func(input []byte) ([]byte, error) {
+			digest := sha256.Sum256(input)
+			r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+			if err != nil {
+				return nil, err
+			}
+			// JWS uses the fixed-size R || S form, not ASN.1.
+			signature := make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+			return signature, nil
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q (expected HS256, RS256 or ES256)", algorithm)
+	}
+}
+
+func loadPrivateKey(path string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading jwt key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt key file %s is not PEM encoded", path)
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("jwt key file %s has no supported private key (PKCS#8, PKCS#1 or SEC 1)", path)
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+func writeKeyFile(t *testing.T, key crypto.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("encoding key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	return path
+}
+
+// applyJWT mints a token through Apply and returns its decoded parts.
+func applyJWT(t *testing.T, cfg *config.Config, vars map[string]string) (map[string]interface{}, map[string]interface{}, string, []byte) {
+	t.Helper()
+	headers := map[string]string{}
+	if err := Apply(context.Background(), headers, cfg, vars); err != nil {
+		t.Fatalf("apply jwt failed: %v", err)
+	}
+	token, ok := strings.CutPrefix(headers["Authorization"], "Bearer ")
+	parts := strings.Split(token, ".")
+	if !ok || len(parts) != 3 {
+		t.Fatalf("expected a bearer JWT, got %q", headers["Authorization"])
+	}
+
+	decode := func(part string) map[string]interface{} {
+		data, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", part, err)
+		}
+		var out map[string]interface{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("parsing %s: %v", data, err)
+		}
+		return out
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("decoding signature: %v", err)
+	}
+	return decode(parts[0]), decode(parts[1]), parts[0] + "." + parts[1], signature
+}
+
+func TestApplyJWTMintsHS256Token(t *testing.T) {
+	signingNow = func() time.Time { return time.Unix(1700000000, 0) }
+	t.Cleanup(func() { signingNow = time.Now })
+
+	cfg := &config.Config{Auth: config.AuthConfig{
+		Type:      "jwt",
+		Secret:    "${JWT_SECRET}",
+		KeyID:     "k1",
+		Issuer:    "apix",
+		Subject:   "${USER_ID}",
+		Audience:  "orders",
+		ExpiresIn: "10m",
+		Claims: map[string]interface{}{
+			"tenant": "${TENANT}",
+			"admin":  true,
+			"roles":  []interface{}{"reader", "${ROLE}"},
+		},
+	}}
+	vars := map[string]string{"JWT_SECRET": "s3cret", "USER_ID": "u-42", "TENANT": "acme", "ROLE": "writer"}
+
+	header, claims, signingInput, signature := applyJWT(t, cfg, vars)
+	if header["alg"] != "HS256" || header["typ"] != "JWT" || header["kid"] != "k1" {
+		t.Fatalf("unexpected header %v", header)
+	}
+	if claims["iat"] != float64(1700000000) || claims["exp"] != float64(1700000600) ||
+		claims["iss"] != "apix" || claims["sub"] != "u-42" || claims["aud"] != "orders" ||
+		claims["tenant"] != "acme" || claims["admin"] != true {
+		t.Fatalf("unexpected claims %v", claims)
+	}
+	if roles, _ := claims["roles"].([]interface{}); len(roles) != 2 || roles[1] != "writer" {
+		t.Fatalf("unexpected roles claim %v", claims["roles"])
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(signingInput))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		t.Fatalf("HS256 signature does not verify")
+	}
+}
+
+func TestApplyJWTMintsRS256AndES256Tokens(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating EC key: %v", err)
+	}
+
+	cfg := &config.Config{Auth: config.AuthConfig{Type: "jwt", Algorithm: "RS256", KeyFile: writeKeyFile(t, rsaKey), Subject: "svc"}}
+	header, claims, signingInput, signature := applyJWT(t, cfg, nil)
+	digest := sha256.Sum256([]byte(signingInput))
+	if header["alg"] != "RS256" || rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+		t.Fatalf("RS256 token does not verify (header %v)", header)
+	}
+	if exp, iat := claims["exp"].(float64), claims["iat"].(float64); exp-iat != DefaultJWTExpiry.Seconds() {
+		t.Fatalf("expected the default expiry, got iat=%v exp=%v", iat, exp)
+	}
+
+	cfg.Auth.Algorithm = "ES256"
+	cfg.Auth.KeyFile = writeKeyFile(t, ecKey)
+	header, _, signingInput, signature = applyJWT(t, cfg, nil)
+	digest = sha256.Sum256([]byte(signingInput))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if header["alg"] != "ES256" || len(signature) != 64 || !ecdsa.Verify(&ecKey.PublicKey, digest[:], r, s) {
+		t.Fatalf("ES256 token does not verify (header %v)", header)
+	}
+
+	// The key file must match the algorithm.
+	cfg.Auth.Algorithm = "RS256"
+	if err := Apply(context.Background(), map[string]string{}, cfg, nil); err == nil || !strings.Contains(err.Error(), "requires an RSA private key") {
+		t.Fatalf("expected key type error, got %v", err)
+	}
+}
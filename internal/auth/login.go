@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+// DefaultLoginTimeout bounds how long Login waits for the browser redirect.
+const DefaultLoginTimeout = 5 * time.Minute
+
+// Login runs the OAuth 2.0 authorization code flow with PKCE (RFC 7636): it
+// listens on a loopback redirect URI, hands the authorize URL to openURL,
+// exchanges the code it receives at token_url and caches the tokens for the
+// active environment. Cancelling ctx stops waiting for the redirect and
+// aborts the code exchange.
+func Login(ctx context.Context, cfg *config.Config, vars map[string]string, openURL func(authorizeURL string), timeout time.Duration) (*config.OAuthToken, error) {
+	settings := newOAuth2Settings(cfg, makeTemplateVars(cfg, vars))
+	if settings.GrantType != GrantAuthorizationCode {
+		return nil, fmt.Errorf("oauth2 login requires grant_type: authorization_code (got %q)", settings.GrantType)
+	}
+	if settings.AuthorizeURL == "" || settings.TokenURL == "" {
+		return nil, fmt.Errorf("oauth2 login requires authorize_url and token_url")
+	}
+	if settings.ClientID == "" {
+		return nil, fmt.Errorf("oauth2 login requires client_id")
+	}
+	if timeout <= 0 {
+		timeout = DefaultLoginTimeout
+	}
+
+	redirect, listener, err := listenLoopback(settings.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	verifier := randomToken(32)
+	challenge := sha256.Sum256([]byte(verifier))
+	state := randomToken(16)
+
+	authorizeURL, err := url.Parse(settings.AuthorizeURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorize_url: %w", err)
+	}
+	query := authorizeURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", settings.ClientID)
+	query.Set("redirect_uri", redirect.String())
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if len(settings.Scopes) > 0 {
+		query.Set("scope", strings.Join(settings.Scopes, " "))
+	}
+	if settings.Audience != "" {
+		query.Set("audience", settings.Audience)
+	}
+	authorizeURL.RawQuery = query.Encode()
+
+	type callback struct {
+		code string
+		err  error
+	}
+	results := make(chan callback, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirect.Path, func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		var result callback
+		switch {
+		case params.Get("state") != state:
+			result.err = errors.New("oauth2 login: state mismatch in redirect")
+		case params.Get("error") != "":
+			result.err = fmt.Errorf("oauth2 login denied: %s", strings.TrimSpace(params.Get("error")+" "+params.Get("error_description")))
+		case params.Get("code") == "":
+			result.err = errors.New("oauth2 login: redirect has no code")
+		default:
+			result.code = params.Get("code")
+		}
+
+		message := "Login complete. You can close this window and return to the terminal."
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			message = result.err.Error()
+		}
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", html.EscapeString(message))
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if openURL != nil {
+		openURL(authorizeURL.String())
+	}
+
+	var result callback
+	select {
+	case result = <-results:
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("oauth2 login timed out after %s waiting for the redirect", timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	token, err := requestOAuth2Token(ctx, settings, url.Values{
+		"grant_type":    {GrantAuthorizationCode},
+		"code":          {result.code},
+		"redirect_uri":  {redirect.String()},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+	token.Source = settings.source()
+	if err := config.SaveOAuthToken(cfg.ActiveEnv, *token); err != nil {
+		return nil, err
+	}
+	// Keep ${TOKEN} and bearer auth working with the new access token.
+	if err := config.SaveToken(token.AccessToken); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// listenLoopback listens on the configured redirect URI, which must point at
+// the local machine, or on a free 127.0.0.1 port with the /callback path.
+func listenLoopback(redirectURI string) (*url.URL, net.Listener, error) {
+	if redirectURI == "" {
+		redirectURI = "http://127.0.0.1:0/callback"
+	}
+	redirect, err := url.Parse(redirectURI)
+	if err != nil || redirect.Scheme != "http" {
+		return nil, nil, fmt.Errorf("invalid redirect_uri %q: expected http://127.0.0.1:<port>/<path>", redirectURI)
+	}
+	host := redirect.Hostname()
+	if host != "localhost" && !net.ParseIP(host).IsLoopback() {
+		return nil, nil, fmt.Errorf("redirect_uri %q must use a loopback host", redirectURI)
+	}
+	if redirect.Path == "" {
+		redirect.Path = "/"
+	}
+	port := redirect.Port()
+	if port == "" {
+		port = "80"
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, nil, fmt.Errorf("starting oauth2 callback listener: %w", err)
+	}
+	redirect.Host = net.JoinHostPort(host, fmt.Sprint(listener.Addr().(*net.TCPAddr).Port))
+	return redirect, listener, nil
+}
+
+func randomToken(size int) string {
+	b := make([]byte, size)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
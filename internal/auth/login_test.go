@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+// newAuthorizationServer is a stub provider: /authorize redirects back with a
+// code (or with ?error when deny is set) and /token checks the PKCE verifier
+// against the challenge it was given.
+func newAuthorizationServer(t *testing.T, deny bool) *httptest.Server {
+	t.Helper()
+	var challenge, redirectURI string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("response_type") != "code" || q.Get("client_id") != "cli-app" || q.Get("code_challenge_method") != "S256" || q.Get("scope") != "openid profile" {
+			t.Errorf("unexpected authorize request %v", q)
+		}
+		challenge, redirectURI = q.Get("code_challenge"), q.Get("redirect_uri")
+		target, _ := url.Parse(redirectURI)
+		params := url.Values{"state": {q.Get("state")}}
+		if deny {
+			params.Set("error", "access_denied")
+		} else {
+			params.Set("code", "code-123")
+		}
+		target.RawQuery = params.Encode()
+		http.Redirect(w, r, target.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("code") != "code-123" ||
+			r.PostForm.Get("redirect_uri") != redirectURI || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"user-token","refresh_token":"user-refresh","expires_in":600}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func loginConfig(serverURL string) *config.Config {
+	return &config.Config{
+		ActiveEnv: "dev",
+		Auth: config.AuthConfig{
+			Type:         "oauth2",
+			GrantType:    "authorization_code",
+			AuthorizeURL: serverURL + "/authorize",
+			TokenURL:     serverURL + "/token",
+			ClientID:     "cli-app",
+			Scopes:       []string{"openid", "profile"},
+		},
+	}
+}
+
+// followAuthorizeURL plays the browser: it follows the authorize redirect to
+// the loopback listener.
+func followAuthorizeURL(t *testing.T) func(string) {
+	return func(authorizeURL string) {
+		resp, err := http.Get(authorizeURL)
+		if err != nil {
+			t.Errorf("following authorize URL: %v", err)
+			return
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestLoginAuthorizationCodeWithPKCE(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server := newAuthorizationServer(t, false)
+	cfg := loginConfig(server.URL)
+
+	token, err := Login(context.Background(), cfg, nil, followAuthorizeURL(t), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if token.AccessToken != "user-token" || token.RefreshToken != "user-refresh" {
+		t.Fatalf("unexpected token %+v", token)
+	}
+
+	saved, err := os.ReadFile(filepath.Join(".apix", "token"))
+	if err != nil || string(saved) != "user-token" {
+		t.Fatalf("expected access token in .apix/token, got %q (%v)", saved, err)
+	}
+
+	// Requests use the cached token without another token request.
+	server.Close()
+	headers := map[string]string{}
+	if err := Apply(context.Background(), headers, cfg, nil); err != nil {
+		t.Fatalf("apply oauth2 failed: %v", err)
+	}
+	if headers["Authorization"] != "Bearer user-token" {
+		t.Fatalf("expected cached login token, got %q", headers["Authorization"])
+	}
+}
+
+func TestLoginReportsDeniedAuthorization(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server := newAuthorizationServer(t, true)
+
+	_, err := Login(context.Background(), loginConfig(server.URL), nil, followAuthorizeURL(t), 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "access_denied") {
+		t.Fatalf("expected denied login error, got %v", err)
+	}
+}
+
+func TestApplyOAuth2AuthorizationCodeRequiresLogin(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cfg := loginConfig("http://127.0.0.1:1")
+
+	err := Apply(context.Background(), map[string]string{}, cfg, nil)
+	if err == nil || !strings.Contains(err.Error(), "apix auth login") {
+		t.Fatalf("expected a hint to run apix auth login, got %v", err)
+	}
+}
+
+func TestListenLoopbackRejectsRemoteRedirect(t *testing.T) {
+	for _, uri := range []string{"https://127.0.0.1:8080/cb", "http://example.com:8080/cb"} {
+		if _, _, err := listenLoopback(uri); err == nil {
+			t.Fatalf("expected %q to be rejected", uri)
+		}
+	}
+	redirect, listener, err := listenLoopback("http://localhost:0/oauth/callback")
+	if err != nil {
+		t.Fatalf("listenLoopback() error = %v", err)
+	}
+	defer listener.Close()
+	if redirect.Path != "/oauth/callback" || strings.HasSuffix(redirect.Host, ":0") {
+		t.Fatalf("unexpected redirect %s", redirect)
+	}
+}
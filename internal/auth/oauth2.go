@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+	GrantRefreshToken      = "refresh_token"
+	GrantAuthorizationCode = "authorization_code"
+)
+
+// expirySkew renews tokens slightly before they expire so a request never
+// leaves with a token that expires in flight.
+const expirySkew = 30 * time.Second
+
+var (
+	// oauthMu guards the token cache files; it is not held while a token is
+	// requested, so a slow token endpoint does not block other lookups.
+	oauthMu         sync.Mutex
+	tokenHTTPClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// oauth2Settings is the oauth2 auth config with its placeholders resolved.
+type oauth2Settings struct {
+	GrantType    string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Audience     string
+	Username     string
+	Password     string
+	RefreshToken string
+	AuthorizeURL string
+	RedirectURI  string
+}
+
+func newOAuth2Settings(cfg *config.Config, vars map[string]string) oauth2Settings {
+	render := func(value string) string {
+		return strings.TrimSpace(renderTemplate(value, vars))
+	}
+	s := oauth2Settings{
+		GrantType:    strings.ToLower(render(cfg.Auth.GrantType)),
+		TokenURL:     render(cfg.Auth.TokenURL),
+		ClientID:     render(cfg.Auth.ClientID),
+		ClientSecret: render(cfg.Auth.ClientSecret),
+		Audience:     render(cfg.Auth.Audience),
+		Username:     render(cfg.Auth.Username),
+		Password:     render(cfg.Auth.Password),
+		RefreshToken: render(cfg.Auth.RefreshToken),
+		AuthorizeURL: render(cfg.Auth.AuthorizeURL),
+		RedirectURI:  render(cfg.Auth.RedirectURI),
+	}
+	if s.GrantType == "" {
+		s.GrantType = GrantClientCredentials
+		if s.AuthorizeURL != "" {
+			s.GrantType = GrantAuthorizationCode
+		}
+	}
+	for _, scope := range cfg.Auth.Scopes {
+		if scope = render(scope); scope != "" {
+			s.Scopes = append(s.Scopes, scope)
+		}
+	}
+	return s
+}
+
+// source identifies the client a cached token was issued to.
+func (s oauth2Settings) source() string {
+	return strings.Join([]string{s.GrantType, s.TokenURL, s.ClientID, strings.Join(s.Scopes, " "), s.Audience, s.Username}, "|")
+}
+
+// oauth2AccessToken returns a valid access token for the active environment:
+// the cached one while it has not expired, else one obtained with the cached
+// refresh token, else one from a new grant.
+func oauth2AccessToken(ctx context.Context, cfg *config.Config, vars map[string]string) (string, error) {
+	settings := newOAuth2Settings(cfg, vars)
+	if settings.TokenURL == "" {
+		return "", fmt.Errorf("oauth2 auth requires token_url")
+	}
+
+	oauthMu.Lock()
+	cached, err := config.LoadOAuthToken(cfg.ActiveEnv)
+	oauthMu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	if cached != nil && cached.Source != settings.source() {
+		cached = nil
+	}
+	if cached != nil && cached.AccessToken != "" &&
+		(cached.ExpiresAt.IsZero() || time.Until(cached.ExpiresAt) > expirySkew) {
+		return cached.AccessToken, nil
+	}
+
+	var token *config.OAuthToken
+	if cached != nil && cached.RefreshToken != "" {
+		// A rejected refresh token falls back to a new grant below.
+		token, err = requestOAuth2Token(ctx, settings, url.Values{
+			"grant_type":    {GrantRefreshToken},
+			"refresh_token": {cached.RefreshToken},
+		})
+		if err != nil && ctx.Err() != nil {
+			return "", err
+		}
+		if token != nil && token.RefreshToken == "" {
+			token.RefreshToken = cached.RefreshToken
+		}
+	}
+	if token == nil {
+		if settings.GrantType == GrantAuthorizationCode {
+			env := cfg.ActiveEnv
+			if env == "" {
+				env = "default"
+			}
+			return "", fmt.Errorf("no valid oauth2 token for environment %q: run 'apix auth login'", env)
+		}
+		if token, err = settings.grant(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	token.Source = settings.source()
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+	if err := config.SaveOAuthToken(cfg.ActiveEnv, *token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+func (s oauth2Settings) grant(ctx context.Context) (*config.OAuthToken, error) {
+	form := url.Values{"grant_type": {s.GrantType}}
+	switch s.GrantType {
+	case GrantClientCredentials:
+	case GrantPassword:
+		if s.Username == "" || s.Password == "" {
+			return nil, fmt.Errorf("oauth2 password grant requires username and password")
+		}
+		form.Set("username", s.Username)
+		form.Set("password", s.Password)
+	case GrantRefreshToken:
+		if s.RefreshToken == "" {
+			return nil, fmt.Errorf("oauth2 refresh_token grant requires refresh_token")
+		}
+		form.Set("refresh_token", s.RefreshToken)
+	default:
+		return nil, fmt.Errorf("unsupported oauth2 grant_type %q (expected client_credentials, password, refresh_token or authorization_code)", s.GrantType)
+	}
+	return requestOAuth2Token(ctx, s, form)
+}
+
+// requestOAuth2Token posts a token request, authenticating the client with
+// client_id and client_secret form parameters. Cancelling ctx aborts it.
+func requestOAuth2Token(ctx context.Context, s oauth2Settings, form url.Values) (*config.OAuthToken, error) {
+	if s.ClientID != "" {
+		form.Set("client_id", s.ClientID)
+	}
+	if s.ClientSecret != "" {
+		form.Set("client_secret", s.ClientSecret)
+	}
+	if grant := form.Get("grant_type"); len(s.Scopes) > 0 && (grant == GrantClientCredentials || grant == GrantPassword) {
+		form.Set("scope", strings.Join(s.Scopes, " "))
+	}
+	if s.Audience != "" {
+		form.Set("audience", s.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("creating oauth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := tokenHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("reading oauth2 token response: %w", err)
+	}
+
+	var payload struct {
+		AccessToken      string      `json:"access_token"`
+		TokenType        string      `json:"token_type"`
+		RefreshToken     string      `json:"refresh_token"`
+		Scope            string      `json:"scope"`
+		ExpiresIn        json.Number `json:"expires_in"`
+		Error            string      `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil && resp.StatusCode < 400 {
+		return nil, fmt.Errorf("parsing oauth2 token response: %w", err)
+	}
+	if resp.StatusCode >= 400 || payload.Error != "" {
+		reason := payload.Error
+		if reason == "" {
+			reason = resp.Status
+		}
+		if payload.ErrorDescription != "" {
+			reason += ": " + payload.ErrorDescription
+		}
+		return nil, fmt.Errorf("oauth2 token request failed (%s grant): %s", form.Get("grant_type"), reason)
+	}
+	if payload.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2 token response has no access_token")
+	}
+
+	token := &config.OAuthToken{
+		AccessToken:  payload.AccessToken,
+		TokenType:    payload.TokenType,
+		RefreshToken: payload.RefreshToken,
+		Scope:        payload.Scope,
+	}
+	if seconds, err := payload.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(seconds) * time.Second).UTC()
+	}
+	return token, nil
+}
+
+// InvalidateOAuth2Token drops the cached access token of the active
+// environment, keeping its refresh token, so the next request renews it.
+func InvalidateOAuth2Token(cfg *config.Config) error {
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+
+	cached, err := config.LoadOAuthToken(cfg.ActiveEnv)
+	if err != nil || cached == nil {
+		return err
+	}
+	cached.AccessToken = ""
+	return config.SaveOAuthToken(cfg.ActiveEnv, *cached)
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+type tokenServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []url.Values
+}
+
+// newTokenServer issues tok-1, tok-2, ... with a refresh token; the refresh
+// grant answers without a new refresh token.
+func newTokenServer(t *testing.T) *tokenServer {
+	t.Helper()
+	ts := &tokenServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing token request: %v", err)
+		}
+		ts.mu.Lock()
+		ts.requests = append(ts.requests, r.PostForm)
+		n := len(ts.requests)
+		ts.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("client_secret") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"bad client secret"}`))
+			return
+		}
+		if r.PostForm.Get("grant_type") == GrantRefreshToken {
+			fmt.Fprintf(w, `{"access_token":"tok-%d","token_type":"Bearer","expires_in":3600}`, n)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"tok-%d","token_type":"Bearer","expires_in":"3600","refresh_token":"ref-%d"}`, n, n)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *tokenServer) calls() []url.Values {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]url.Values(nil), ts.requests...)
+}
+
+func oauth2Config(tokenURL string) *config.Config {
+	return &config.Config{
+		ActiveEnv: "dev",
+		Auth: config.AuthConfig{
+			Type:         "oauth2",
+			TokenURL:     tokenURL,
+			ClientID:     "apix",
+			ClientSecret: "${CLIENT_SECRET}",
+			Scopes:       []string{"read", "write"},
+			Audience:     "https://api.example.com",
+		},
+	}
+}
+
+func TestApplyOAuth2ClientCredentialsCachesToken(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server := newTokenServer(t)
+	cfg := oauth2Config(server.URL)
+	vars := map[string]string{"CLIENT_SECRET": "s3cret"}
+
+	for i := 0; i < 2; i++ {
+		headers := map[string]string{}
+		if err := Apply(context.Background(), headers, cfg, vars); err != nil {
+			t.Fatalf("apply oauth2 failed: %v", err)
+		}
+		if got := headers["Authorization"]; got != "Bearer tok-1" {
+			t.Fatalf("expected cached token tok-1, got %q", got)
+		}
+	}
+
+	calls := server.calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected one token request, got %d", len(calls))
+	}
+	form := calls[0]
+	if form.Get("grant_type") != "client_credentials" || form.Get("client_id") != "apix" ||
+		form.Get("scope") != "read write" || form.Get("audience") != "https://api.example.com" {
+		t.Fatalf("unexpected token request %v", form)
+	}
+
+	cached, err := config.LoadOAuthToken("dev")
+	if err != nil || cached == nil || cached.RefreshToken != "ref-1" || time.Until(cached.ExpiresAt) < time.Hour-time.Minute {
+		t.Fatalf("unexpected cached token %+v (%v)", cached, err)
+	}
+
+	// Each environment has its own token.
+	cfg.ActiveEnv = "staging"
+	headers := map[string]string{}
+	if err := Apply(context.Background(), headers, cfg, vars); err != nil {
+		t.Fatalf("apply oauth2 failed: %v", err)
+	}
+	if headers["Authorization"] != "Bearer tok-2" {
+		t.Fatalf("expected a new token for staging, got %q", headers["Authorization"])
+	}
+}
+
+func TestApplyOAuth2RefreshesExpiringToken(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server := newTokenServer(t)
+	cfg := oauth2Config(server.URL)
+	cfg.Auth.GrantType = "password"
+	cfg.Auth.Username = "ada"
+	cfg.Auth.Password = "pw"
+	vars := map[string]string{"CLIENT_SECRET": "s3cret"}
+
+	if err := Apply(context.Background(), map[string]string{}, cfg, vars); err != nil {
+		t.Fatalf("apply oauth2 failed: %v", err)
+	}
+	cached, _ := config.LoadOAuthToken("dev")
+	cached.ExpiresAt = time.Now().Add(10 * time.Second)
+	if err := config.SaveOAuthToken("dev", *cached); err != nil {
+		t.Fatalf("saving token: %v", err)
+	}
+
+	headers := map[string]string{}
+	if err := Apply(context.Background(), headers, cfg, vars); err != nil {
+		t.Fatalf("apply oauth2 failed: %v", err)
+	}
+	if headers["Authorization"] != "Bearer tok-2" {
+		t.Fatalf("expected refreshed token, got %q", headers["Authorization"])
+	}
+
+	calls := server.calls()
+	if calls[0].Get("grant_type") != "password" || calls[0].Get("username") != "ada" || calls[0].Get("password") != "pw" {
+		t.Fatalf("unexpected password grant %v", calls[0])
+	}
+	if calls[1].Get("grant_type") != "refresh_token" || calls[1].Get("refresh_token") != "ref-1" {
+		t.Fatalf("unexpected refresh grant %v", calls[1])
+	}
+	if cached, _ := config.LoadOAuthToken("dev"); cached.RefreshToken != "ref-1" {
+		t.Fatalf("expected refresh token to be kept, got %q", cached.RefreshToken)
+	}
+}
+
+func TestRefreshIfNeededInvalidatesOAuth2Token(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server := newTokenServer(t)
+	cfg := oauth2Config(server.URL)
+	vars := map[string]string{"CLIENT_SECRET": "s3cret"}
+
+	if err := Apply(context.Background(), map[string]string{}, cfg, vars); err != nil {
+		t.Fatalf("apply oauth2 failed: %v", err)
+	}
+	retry, err := RefreshIfNeeded(cfg, "get-users", http.StatusUnauthorized, false, false, nil)
+	if err != nil || !retry {
+		t.Fatalf("expected a retry, got %v (%v)", retry, err)
+	}
+
+	headers := map[string]string{}
+	if err := Apply(context.Background(), headers, cfg, vars); err != nil {
+		t.Fatalf("apply oauth2 failed: %v", err)
+	}
+	if headers["Authorization"] != "Bearer tok-2" {
+		t.Fatalf("expected a renewed token, got %q", headers["Authorization"])
+	}
+	if calls := server.calls(); calls[1].Get("grant_type") != "refresh_token" {
+		t.Fatalf("expected renewal through the refresh token, got %v", calls[1])
+	}
+}
+
+func TestApplyOAuth2ReportsTokenErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server := newTokenServer(t)
+	cfg := oauth2Config(server.URL)
+
+	err := Apply(context.Background(), map[string]string{}, cfg, map[string]string{"CLIENT_SECRET": "wrong"})
+	if err == nil || !strings.Contains(err.Error(), "invalid_client: bad client secret") {
+		t.Fatalf("expected token error, got %v", err)
+	}
+
+	cfg.Auth.GrantType = "implicit"
+	err = Apply(context.Background(), map[string]string{}, cfg, map[string]string{"CLIENT_SECRET": "s3cret"})
+	if err == nil || !strings.Contains(err.Error(), `unsupported oauth2 grant_type "implicit"`) {
+		t.Fatalf("expected unsupported grant error, got %v", err)
+	}
+}
+
+func TestApplyOAuth2StopsWhenCancelled(t *testing.T) {
+	t.Chdir(t.TempDir())
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	cfg := oauth2Config(server.URL)
+	vars := map[string]string{"CLIENT_SECRET": "s3cret"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Apply(ctx, map[string]string{}, cfg, vars) }()
+
+	// The token cache must stay usable while the token request is in flight.
+	time.Sleep(50 * time.Millisecond)
+	locked := make(chan struct{})
+	go func() {
+		_ = InvalidateOAuth2Token(cfg)
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("token cache stayed locked during the token request")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("token request did not stop on cancellation")
+	}
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/Tresor-Kasend/apix/internal/config"
 )
@@ -27,7 +28,14 @@ func RefreshIfNeeded(
 
 	loginRequest := cfg.Auth.LoginRequest
 	if loginRequest == "" {
-		return false, nil
+		if !strings.EqualFold(cfg.Auth.Type, "oauth2") {
+			return false, nil
+		}
+		// The server rejected the token before its expiry: renew it once.
+		if err := InvalidateOAuth2Token(cfg); err != nil {
+			return false, err
+		}
+		return true, nil
 	}
 	if requestName == loginRequest {
 		return false, nil
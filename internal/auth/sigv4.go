@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// signingNow is the clock of request signatures and minted tokens.
+var signingNow = time.Now
+
+// unsignedHeaders may be rewritten by the transport or proxies after signing.
+var unsignedHeaders = map[string]bool{
+	"authorization":     true,
+	"user-agent":        true,
+	"content-length":    true,
+	"expect":            true,
+	"connection":        true,
+	"transfer-encoding": true,
+	"x-amzn-trace-id":   true,
+}
+
+// AWSCredentials signs requests with AWS Signature Version 4.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+}
+
+// awsCredentials resolves the aws_sigv4 settings from the auth config, then
+// from variables (env files, --var) and finally from the standard AWS
+// environment variables.
+func awsCredentials(cfg *config.Config, vars map[string]string) (AWSCredentials, error) {
+	lookup := func(configured string, names ...string) string {
+		if value := strings.TrimSpace(renderTemplate(configured, vars)); value != "" {
+			return value
+		}
+		for _, name := range names {
+			if value := strings.TrimSpace(vars[name]); value != "" {
+				return value
+			}
+			if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	creds := AWSCredentials{
+		AccessKeyID:     lookup(cfg.Auth.AccessKeyID, "AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: lookup(cfg.Auth.SecretAccessKey, "AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    lookup(cfg.Auth.SessionToken, "AWS_SESSION_TOKEN"),
+		Region:          lookup(cfg.Auth.Region, "AWS_REGION", "AWS_DEFAULT_REGION"),
+		Service:         lookup(cfg.Auth.Service),
+	}
+	if creds.Service == "" {
+		creds.Service = "execute-api"
+	}
+	switch {
+	case creds.AccessKeyID == "" || creds.SecretAccessKey == "":
+		return creds, fmt.Errorf("aws_sigv4 auth requires access_key_id and secret_access_key (or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	case creds.Region == "":
+		return creds, fmt.Errorf("aws_sigv4 auth requires region (or AWS_REGION)")
+	}
+	return creds, nil
+}
+
+// SignV4 adds the X-Amz-Date, X-Amz-Security-Token and Authorization headers
+// of an AWS Signature Version 4 to req, whose payload is body.
+func SignV4(req *http.Request, body []byte, creds AWSCredentials, now time.Time) error {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	payloadHash := sha256Hex(body)
+	if creds.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	canonicalHeaders, signedHeaders := sigV4Headers(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4Path(req, creds.Service),
+		sigV4Query(req.URL.RawQuery),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, creds.Region, creds.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, creds.Region)
+	key = hmacSHA256(key, creds.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+func sigV4Headers(req *http.Request) (string, string) {
+	values := map[string]string{}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values["host"] = host
+	for name, list := range req.Header {
+		lower := strings.ToLower(name)
+		if unsignedHeaders[lower] || lower == "host" {
+			continue
+		}
+		trimmed := make([]string, len(list))
+		for i, value := range list {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		values[lower] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + values[name] + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// sigV4Path is the URI-encoded path; every service but S3 encodes the
+// already escaped path a second time.
+func sigV4Path(req *http.Request, service string) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+	return sigV4Escape(path, false)
+}
+
+func sigV4Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	type pair struct{ key, value string }
+	var pairs []pair
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		pairs = append(pairs, pair{sigV4Escape(unescapeQuery(key), true), sigV4Escape(unescapeQuery(value), true)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].key != pairs[j].key {
+			return pairs[i].key < pairs[j].key
+		}
+		return pairs[i].value < pairs[j].value
+	})
+	encoded := make([]string, len(pairs))
+	for i, p := range pairs {
+		encoded[i] = p.key + "=" + p.value
+	}
+	return strings.Join(encoded, "&")
+}
+
+// unescapeQuery decodes a query component, keeping it as is when it is not
+// valid percent-encoding.
+func unescapeQuery(value string) string {
+	if decoded, err := url.QueryUnescape(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// sigV4Escape percent-encodes everything but the RFC 3986 unreserved
+// characters (and '/' unless encodeSlash is set), with upper-case hex.
+func sigV4Escape(value string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
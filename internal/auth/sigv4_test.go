@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+)
+
+// Credentials and clock of the AWS Signature Version 4 test suite.
+var (
+	testSuiteCreds = AWSCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+	}
+	testSuiteTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+)
+
+func TestSignV4MatchesTestSuite(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		headers       map[string]string
+		body          string
+		creds         AWSCredentials
+		authorization string
+	}{
+		{
+			name:          "get-vanilla",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/",
+			creds:         testSuiteCreds,
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:          "post-vanilla",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			creds:         testSuiteCreds,
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:          "get-vanilla-query-order-key-case",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			creds:         testSuiteCreds,
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			// The IAM ListUsers example of the AWS General Reference.
+			name:    "iam-list-users",
+			method:  http.MethodGet,
+			url:     "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf-8"},
+			creds: AWSCredentials{
+				AccessKeyID:     "AKIDEXAMPLE",
+				SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+				Region:          "us-east-1",
+				Service:         "iam",
+			},
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if err := SignV4(req, []byte(tt.body), tt.creds, testSuiteTime); err != nil {
+				t.Fatalf("SignV4() error = %v", err)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.authorization {
+				t.Fatalf("Authorization mismatch\n got: %s\nwant: %s", got, tt.authorization)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Fatalf("unexpected X-Amz-Date %q", got)
+			}
+		})
+	}
+}
+
+func TestSignV4SignsSessionToken(t *testing.T) {
+	creds := testSuiteCreds
+	creds.SessionToken = "session-token"
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err := SignV4(req, nil, creds, testSuiteTime); err != nil {
+		t.Fatalf("SignV4() error = %v", err)
+	}
+	if req.Header.Get("X-Amz-Security-Token") != "session-token" ||
+		!strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Fatalf("expected a signed session token, got %v", req.Header)
+	}
+}
+
+func TestRequestSignerReadsAWSEnvironment(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	cfg := &config.Config{Auth: config.AuthConfig{Type: "aws_sigv4", Region: "${REGION}", Service: "service"}}
+
+	sign, err := RequestSigner(cfg, map[string]string{"REGION": "us-east-1"})
+	if err != nil || sign == nil {
+		t.Fatalf("RequestSigner() error = %v (signer set: %t)", err, sign != nil)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err := sign(req, nil); err != nil {
+		t.Fatalf("sign() error = %v", err)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "/us-east-1/service/aws4_request") {
+		t.Fatalf("expected the configured region, got %q", req.Header.Get("Authorization"))
+	}
+
+	cfg.Auth.Type = "bearer"
+	if sign, err := RequestSigner(cfg, nil); sign != nil || err != nil {
+		t.Fatalf("expected no signer for bearer auth, got %v", err)
+	}
+
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	cfg.Auth.Type = "aws_sigv4"
+	if _, err := RequestSigner(cfg, nil); err == nil || !strings.Contains(err.Error(), "secret_access_key") {
+		t.Fatalf("expected missing credentials error, got %v", err)
+	}
+}
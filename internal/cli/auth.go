@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	apixauth "github.com/Tresor-Kasend/apix/internal/auth"
+	"github.com/Tresor-Kasend/apix/internal/config"
+	"github.com/Tresor-Kasend/apix/internal/output"
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"github.com/spf13/cobra"
+)
+
+func newAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage authentication",
+		Long:  "Log in to OAuth 2.0 providers and cache their tokens per environment.",
+	}
+	cmd.AddCommand(newAuthLoginCmd())
+	return cmd
+}
+
+func newAuthLoginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in with the OAuth 2.0 authorization code flow",
+		Long:  "Run the authorization code flow with PKCE: apix listens on a loopback redirect URI, opens the authorize URL in the browser, exchanges the returned code at token_url and caches the tokens for the active environment in .apix/oauth/.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			envOverride, _ := cmd.Flags().GetString("env")
+			varFlags, _ := cmd.Flags().GetStringSlice("var")
+			noBrowser, _ := cmd.Flags().GetBool("no-browser")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+
+			cfg, err := config.LoadWithEnvOverride(envOverride)
+			if err != nil {
+				return err
+			}
+			vars := request.BuildVariableMap(cfg.Variables, cfg.Auth.Token, parseKeyValueSlice(varFlags, "="))
+
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			token, err := apixauth.Login(ctx, cfg, vars, func(authorizeURL string) {
+				output.PrintInfo("Open this URL to log in:\n  " + authorizeURL)
+				if !noBrowser {
+					_ = openBrowser(authorizeURL)
+				}
+			}, timeout)
+			if err != nil {
+				return interrupted(ctx, err)
+			}
+
+			envName := cfg.ActiveEnv
+			if envName == "" {
+				envName = "default"
+			}
+			message := fmt.Sprintf("Logged in for environment %q", envName)
+			if !token.ExpiresAt.IsZero() {
+				message += fmt.Sprintf(" (access token expires at %s)", token.ExpiresAt.Local().Format(time.RFC1123))
+			}
+			output.PrintSuccess(message)
+			return nil
+		},
+	}
+	cmd.Flags().String("env", "", "Log in for a specific environment")
+	cmd.Flags().StringSliceP("var", "V", nil, "Variables (key=value)")
+	cmd.Flags().Bool("no-browser", false, "Print the authorize URL without opening a browser")
+	cmd.Flags().Duration("timeout", apixauth.DefaultLoginTimeout, "How long to wait for the browser redirect")
+	return cmd
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+)
+
+func TestExecuteSignsRequestsWithAWSSigV4(t *testing.T) {
+	withTempDirAsWorkingDirRun(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(authorization, "/eu-west-1/execute-api/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, Signature=") {
+			t.Errorf("attempt %d: unexpected Authorization %q", n, authorization)
+		}
+		if r.Header.Get("X-Amz-Date") == "" || r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("attempt %d: missing signing headers %v", n, r.Header)
+		}
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	config := fmt.Sprintf("project: test\nbase_url: %s\ntimeout: 10\nauth:\n  type: aws_sigv4\n  region: eu-west-1\n  session_token: ${SESSION}\nretry:\n  methods: [POST]\n", server.URL)
+	if err := os.WriteFile("apix.yaml", []byte(config), 0o644); err != nil {
+		t.Fatalf("writing apix.yaml: %v", err)
+	}
+
+	resp, err := executeFromOptionsWithResponse(context.Background(), "POST", "/orders", ExecuteOptions{
+		Headers:        map[string]string{"Content-Type": "application/json"},
+		Body:           `{"id":1}`,
+		Vars:           map[string]string{"SESSION": "session"},
+		Retry:          1,
+		RetryDelay:     time.Millisecond,
+		NoCookies:      true,
+		SuppressOutput: true,
+		SkipSaveLast:   true,
+	})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected signed request to succeed, got %v (%v)", resp, err)
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("expected the retry to be signed and sent, got %d calls", calls)
+	}
+}
+
+func TestExecuteReusesDigestNonceAcrossRequests(t *testing.T) {
+	withTempDirAsWorkingDirRun(t)
+
+	var (
+		mu         sync.Mutex
+		challenges int
+		ncs        []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Digest ") || !strings.Contains(authorization, `nonce="n1"`) {
+			challenges++
+			w.Header().Set("WWW-Authenticate", `Digest realm="api", qop="auth", nonce="n1"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, nc, _ := strings.Cut(authorization, "nc=")
+		ncs = append(ncs, strings.SplitN(nc, ",", 2)[0])
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	files := map[string]string{
+		"apix.yaml":                              fmt.Sprintf("project: test\nbase_url: %s\ntimeout: 10\nauth:\n  type: digest\n  username: admin\n  password: secret\n", server.URL),
+		filepath.Join("requests", "first.yaml"):  "name: first\nmethod: GET\npath: /first\n",
+		filepath.Join("requests", "second.yaml"): "name: second\nmethod: GET\npath: /second\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+	}
+
+	pool := apixhttp.NewPool()
+	defer pool.Close()
+	for _, name := range []string{"first", "second"} {
+		resp, err := executeSavedRequestWithResponse(context.Background(), name, ExecuteOptions{Pool: pool, NoCookies: true, SuppressOutput: true, SkipSaveLast: true})
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected digest auth to succeed, got %v (%v)", name, resp, err)
+		}
+	}
+	// The second request answers with the nonce of the first challenge.
+	if challenges != 1 || len(ncs) != 2 || ncs[0] != "00000001" || ncs[1] != "00000002" {
+		t.Fatalf("expected one challenge and nonce counts 1 and 2, got %d challenge(s) and %v", challenges, ncs)
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
 	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
@@ -26,7 +27,12 @@ func newChainCmd() *cobra.Command {
 				return err
 			}
 
-			result, err := runner.RunChain(args, flagVars, envOverride, func(name string, vars map[string]string, env string) (*apixhttp.Response, error) {
+			pool := apixhttp.NewPool()
+			defer closeRunPool(cmd, pool)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+
+			result, err := runner.RunChain(ctx, args, flagVars, envOverride, func(ctx context.Context, name string, vars map[string]string, env string) (*apixhttp.Response, error) {
 				opts := ExecuteOptions{
 					Vars:        vars,
 					RequestName: name,
@@ -38,14 +44,15 @@ func newChainCmd() *cobra.Command {
 					CertFile:    baseOpts.CertFile,
 					KeyFile:     baseOpts.KeyFile,
 					NoCookies:   baseOpts.NoCookies,
+					Pool:        pool,
 				}
-				return executeSavedRequestWithResponse(name, opts)
+				return executeSavedRequestWithResponse(ctx, name, opts)
 			})
 			if err != nil {
 				if result != nil {
 					output.PrintInfo(fmt.Sprintf("Chain stopped after %d/%d requests", result.Executed, result.Total))
 				}
-				return err
+				return interrupted(ctx, err)
 			}
 
 			output.PrintSuccess(fmt.Sprintf("Chain completed: %d/%d requests succeeded (%d variables captured)", result.Executed, result.Total, result.Captured))
@@ -55,6 +62,6 @@ func newChainCmd() *cobra.Command {
 
 	cmd.Flags().StringSliceP("var", "V", nil, "Variables (key=value)")
 	cmd.Flags().String("env", "", "Use a specific environment for this chain only")
-	addAdvancedNetworkFlags(cmd)
+	addRunFlags(cmd)
 	return cmd
 }
@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	"github.com/Tresor-Kasend/apix/internal/config"
+	"github.com/Tresor-Kasend/apix/internal/history"
 	interopcurl "github.com/Tresor-Kasend/apix/internal/interop/curl"
+	interopopenapi "github.com/Tresor-Kasend/apix/internal/interop/openapi"
 	interoppostman "github.com/Tresor-Kasend/apix/internal/interop/postman"
 	"github.com/Tresor-Kasend/apix/internal/output"
 	"github.com/Tresor-Kasend/apix/internal/request"
@@ -17,12 +19,13 @@ func newExportCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "export",
 		Short: "Export requests to external formats",
-		Long:  "Export saved requests as curl commands or a Postman collection.",
+		Long:  "Export saved requests as curl commands, a Postman collection or an OpenAPI 3.1 document.",
 	}
 
 	cmd.AddCommand(
 		newExportCurlCmd(),
 		newExportPostmanCmd(),
+		newExportOpenAPICmd(),
 	)
 	return cmd
 }
@@ -64,16 +67,9 @@ func newExportPostmanCmd() *cobra.Command {
 				return fmt.Errorf("no saved requests found")
 			}
 
-			collectionRequests := make([]request.SavedRequest, 0, len(names))
-			for _, name := range names {
-				saved, err := request.Load(name)
-				if err != nil {
-					return err
-				}
-				if strings.TrimSpace(saved.Name) == "" {
-					saved.Name = name
-				}
-				collectionRequests = append(collectionRequests, *saved)
+			collectionRequests, err := loadSavedRequests(names)
+			if err != nil {
+				return err
 			}
 
 			collectionName, _ := cmd.Flags().GetString("name")
@@ -109,3 +105,95 @@ func newExportPostmanCmd() *cobra.Command {
 	cmd.Flags().String("output", "", "Write JSON to file instead of stdout")
 	return cmd
 }
+
+func newExportOpenAPICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Export all saved requests as an OpenAPI 3.1 document",
+		Long:  "Build an OpenAPI 3.1 document from requests/*.yaml. Response schemas are inferred from expect blocks and from JSON responses recorded in history.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := request.ListSaved()
+			if err != nil {
+				return err
+			}
+			if len(names) == 0 {
+				return fmt.Errorf("no saved requests found")
+			}
+
+			savedRequests, err := loadSavedRequests(names)
+			if err != nil {
+				return err
+			}
+			// History records requests by their path under requests/, which
+			// also provides the tag for grouped requests.
+			for i := range savedRequests {
+				savedRequests[i].Name = names[i]
+			}
+
+			opts := interopopenapi.ExportOptions{}
+			opts.Title, _ = cmd.Flags().GetString("title")
+			opts.Version, _ = cmd.Flags().GetString("version")
+			opts.Format, _ = cmd.Flags().GetString("format")
+			if cfg, err := config.Load(); err == nil {
+				if strings.TrimSpace(opts.Title) == "" {
+					opts.Title = cfg.Project
+				}
+				opts.ServerURL = cfg.BaseURL
+			}
+
+			entries, err := history.Read(0)
+			if err != nil {
+				return err
+			}
+			samples := make([]interopopenapi.ResponseSample, 0, len(entries))
+			for _, entry := range entries {
+				samples = append(samples, interopopenapi.ResponseSample{
+					Request: entry.Request,
+					Method:  entry.Method,
+					URL:     entry.Path,
+					Status:  entry.Status,
+					Body:    entry.ResponseBody,
+				})
+			}
+
+			data, err := interopopenapi.ExportSpec(savedRequests, samples, opts)
+			if err != nil {
+				return err
+			}
+
+			outputPath, _ := cmd.Flags().GetString("output")
+			if strings.TrimSpace(outputPath) == "" {
+				fmt.Print(string(data))
+				return nil
+			}
+
+			if err := os.WriteFile(outputPath, data, 0o644); err != nil {
+				return fmt.Errorf("writing openapi export %q: %w", outputPath, err)
+			}
+			output.PrintSuccess(fmt.Sprintf("OpenAPI document exported to %s", outputPath))
+			return nil
+		},
+	}
+
+	cmd.Flags().String("title", "", "API title (defaults to the project name)")
+	cmd.Flags().String("version", "1.0.0", "API version written to info.version")
+	cmd.Flags().String("format", "yaml", "Output format: yaml or json")
+	cmd.Flags().String("output", "", "Write the document to file instead of stdout")
+	return cmd
+}
+
+func loadSavedRequests(names []string) ([]request.SavedRequest, error) {
+	out := make([]request.SavedRequest, 0, len(names))
+	for _, name := range names {
+		saved, err := request.Load(name)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(saved.Name) == "" {
+			saved.Name = name
+		}
+		out = append(out, *saved)
+	}
+	return out, nil
+}
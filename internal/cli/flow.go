@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/output"
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"github.com/Tresor-Kasend/apix/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+func newFlowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "flow [name]",
+		Short: "Run a declarative flow",
+		Long:  "Run the steps of flows/<name>.yaml with per-step assertions, captures, conditions and loops. Without a name, list the available flows.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				names, err := runner.ListFlows()
+				if err != nil {
+					return err
+				}
+				if len(names) == 0 {
+					output.PrintInfo("No flows found in flows/.")
+					return nil
+				}
+				for _, name := range names {
+					output.PrintInfo(name)
+				}
+				return nil
+			}
+
+			flow, err := runner.LoadFlow(args[0])
+			if err != nil {
+				return err
+			}
+
+			varFlags, _ := cmd.Flags().GetStringSlice("var")
+			flagVars := parseKeyValueSlice(varFlags, "=")
+			envOverride, _ := cmd.Flags().GetString("env")
+			baseOpts := ExecuteOptions{
+				EnvOverride: envOverride,
+			}
+			if err := applyAdvancedNetworkFlags(cmd, &baseOpts); err != nil {
+				return err
+			}
+
+			pool := apixhttp.NewPool()
+			defer closeRunPool(cmd, pool)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+
+			result, err := runner.RunFlow(ctx, flow, flagVars, envOverride, func(ctx context.Context, name string, saved *request.SavedRequest, vars map[string]string, env string) (*apixhttp.Response, error) {
+				opts := ExecuteOptions{
+					Vars:           vars,
+					EnvOverride:    env,
+					RequestName:    name,
+					Retry:          baseOpts.Retry,
+					RetryDelay:     baseOpts.RetryDelay,
+					Proxy:          baseOpts.Proxy,
+					Insecure:       baseOpts.Insecure,
+					CertFile:       baseOpts.CertFile,
+					KeyFile:        baseOpts.KeyFile,
+					NoCookies:      baseOpts.NoCookies,
+					Pool:           pool,
+					SkipSaveLast:   true,
+					SuppressOutput: true,
+					Silent:         true,
+				}
+				return executeSavedDefinitionWithResponse(ctx, name, saved, opts)
+			})
+			if result != nil {
+				for _, step := range result.Steps {
+					output.PrintFlowStep(step)
+				}
+			}
+			if result != nil && result.Interrupted {
+				output.PrintFlowSummary(*result)
+			}
+			if err != nil {
+				return interrupted(ctx, err)
+			}
+			output.PrintFlowSummary(*result)
+
+			if !result.Success() {
+				return fmt.Errorf("flow %q failed: %d step(s) failed", result.Name, result.Failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceP("var", "V", nil, "Variables (key=value)")
+	cmd.Flags().String("env", "", "Use a specific environment for this flow only")
+	addRunFlags(cmd)
+	return cmd
+}
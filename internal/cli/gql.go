@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tresor-Kasend/apix/internal/graphql"
+	"github.com/Tresor-Kasend/apix/internal/output"
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"github.com/spf13/cobra"
+)
+
+func newGQLCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gql <path> [query]",
+		Short: "Send a GraphQL request",
+		Long:  "Send a GraphQL query or mutation (inline or from a .graphql file) as a JSON POST. The command fails when the response carries a non-empty errors array. --introspect lists the operations exposed by the schema instead.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := optionsFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+
+			introspect, _ := cmd.Flags().GetBool("introspect")
+			if introspect {
+				return interrupted(ctx, introspectSchema(ctx, args[0], opts))
+			}
+			if len(args) < 2 {
+				return fmt.Errorf("a query is required (inline or a .graphql file), or use --introspect")
+			}
+
+			variables, _ := cmd.Flags().GetString("variables")
+			operation, _ := cmd.Flags().GetString("operation")
+			gql, err := request.ParseGraphQLPayload(args[1], variables, operation)
+			if err != nil {
+				return err
+			}
+			opts.GraphQL = gql
+
+			resp, err := executeFromOptionsWithResponse(ctx, "POST", args[0], opts)
+			if err != nil {
+				return interrupted(ctx, err)
+			}
+			if messages := graphql.ResponseErrors(resp.Body); len(messages) > 0 {
+				return fmt.Errorf("graphql response contains %d error(s): %s", len(messages), messages[0])
+			}
+			return nil
+		},
+	}
+	addCommonFlags(cmd)
+	cmd.Flags().String("variables", "", "GraphQL variables as a JSON object (${VAR} placeholders are resolved)")
+	cmd.Flags().String("operation", "", "Operation name to execute when the document defines several")
+	cmd.Flags().Bool("introspect", false, "List the queries, mutations and subscriptions of the schema")
+	return cmd
+}
+
+func introspectSchema(ctx context.Context, path string, opts ExecuteOptions) error {
+	opts.GraphQL = &request.GraphQL{Query: graphql.IntrospectionQuery, OperationName: "IntrospectionQuery"}
+	opts.SuppressOutput = true
+	opts.SkipSaveLast = true
+	opts.Stream = nil
+
+	resp, err := executeFromOptionsWithResponse(ctx, "POST", path, opts)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("introspection failed with status %s", resp.Status)
+	}
+	operations, err := graphql.ParseIntrospection(resp.Body)
+	if err != nil {
+		return err
+	}
+	output.PrintGraphQLOperations(operations)
+	return nil
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteSavedGraphQLRequest(t *testing.T) {
+	withTempDirAsWorkingDirRun(t)
+
+	var (
+		gotMethod      string
+		gotContentType string
+		gotBody        map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotContentType = r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"user":{"id":42}}}`))
+	}))
+	defer server.Close()
+
+	if err := os.WriteFile("apix.yaml", []byte(fmt.Sprintf("project: test\nbase_url: %s\ntimeout: 10\nauth:\n  type: none\n", server.URL)), 0o644); err != nil {
+		t.Fatalf("writing apix.yaml: %v", err)
+	}
+	if err := os.MkdirAll("requests", 0o755); err != nil {
+		t.Fatalf("creating requests dir: %v", err)
+	}
+	query := "query GetUser($id: ID!) {\n  user(id: $id) { id }\n}\n"
+	if err := os.WriteFile(filepath.Join("requests", "user.graphql"), []byte(query), 0o644); err != nil {
+		t.Fatalf("writing query file: %v", err)
+	}
+	saved := `name: get-user
+path: /graphql
+graphql:
+  query: user.graphql
+  operation_name: GetUser
+  variables:
+    id: ${USER_ID}
+    filter:
+      name: ${NAME}
+`
+	if err := os.WriteFile(filepath.Join("requests", "get-user.yaml"), []byte(saved), 0o644); err != nil {
+		t.Fatalf("writing request file: %v", err)
+	}
+
+	resp, err := executeSavedRequestWithResponse(context.Background(), "get-user", ExecuteOptions{
+		Vars:           map[string]string{"USER_ID": "42", "NAME": "Ada"},
+		SuppressOutput: true,
+		SkipSaveLast:   true,
+	})
+	if err != nil {
+		t.Fatalf("executeSavedRequestWithResponse failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	if gotMethod != http.MethodPost {
+		t.Fatalf("expected POST by default, got %s", gotMethod)
+	}
+	if gotContentType != "application/json" {
+		t.Fatalf("expected JSON content type, got %q", gotContentType)
+	}
+	if gotBody["query"] != query || gotBody["operationName"] != "GetUser" {
+		t.Fatalf("unexpected graphql body: %v", gotBody)
+	}
+	variables, _ := gotBody["variables"].(map[string]interface{})
+	if variables["id"] != "42" {
+		t.Fatalf("expected id to be sent as a string for ID!, got %#v", variables["id"])
+	}
+	if filter, _ := variables["filter"].(map[string]interface{}); filter["name"] != "Ada" {
+		t.Fatalf("expected nested variable to be resolved, got %#v", variables["filter"])
+	}
+}
+
+func TestIntrospectSchemaListsOperations(t *testing.T) {
+	withTempDirAsWorkingDirRun(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if !strings.Contains(fmt.Sprint(body["query"]), "__schema") {
+			http.Error(w, "expected introspection", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"__schema":{
+			"queryType":{"name":"Query"},
+			"mutationType":{"name":"Mutation"},
+			"subscriptionType":null,
+			"types":[
+				{"kind":"OBJECT","name":"Query","fields":[
+					{"name":"users","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"LIST","ofType":{"kind":"OBJECT","name":"User"}}}},
+					{"name":"user","description":"Find a user","args":[{"name":"id","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}}}],"type":{"kind":"OBJECT","name":"User"}}
+				]},
+				{"kind":"OBJECT","name":"Mutation","fields":[
+					{"name":"createUser","args":[{"name":"name","type":{"kind":"SCALAR","name":"String"}}],"type":{"kind":"OBJECT","name":"User"}}
+				]}
+			]}}}`))
+	}))
+	defer server.Close()
+
+	if err := os.WriteFile("apix.yaml", []byte(fmt.Sprintf("project: test\nbase_url: %s\ntimeout: 10\nauth:\n  type: none\n", server.URL)), 0o644); err != nil {
+		t.Fatalf("writing apix.yaml: %v", err)
+	}
+
+	if err := introspectSchema(context.Background(), "/graphql", ExecuteOptions{}); err != nil {
+		t.Fatalf("introspectSchema failed: %v", err)
+	}
+}
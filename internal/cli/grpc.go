@@ -0,0 +1,352 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+	"github.com/Tresor-Kasend/apix/internal/grpc"
+	"github.com/Tresor-Kasend/apix/internal/history"
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/output"
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"github.com/spf13/cobra"
+)
+
+func newGRPCCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grpc <host> [service/Method]",
+		Short: "Call a gRPC method",
+		Long:  "Call a unary or server-streaming gRPC method with a JSON request body. Methods are resolved through server reflection, or from --proto files when the server does not expose it. Without a method, the services and methods of the server are listed. Hosts without a scheme use TLS unless --plaintext is set; http:// hosts use plaintext HTTP/2.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			headerFlags, _ := cmd.Flags().GetStringSlice("header")
+			varFlags, _ := cmd.Flags().GetStringSlice("var")
+			envOverride, _ := cmd.Flags().GetString("env")
+			body, _ := cmd.Flags().GetString("data")
+			bodyFile, _ := cmd.Flags().GetString("file")
+			timeoutSeconds, _ := cmd.Flags().GetInt("timeout")
+			insecure, _ := cmd.Flags().GetBool("insecure")
+			outputFile, _ := cmd.Flags().GetString("output")
+			raw, _ := cmd.Flags().GetBool("raw")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			bodyOnly, _ := cmd.Flags().GetBool("body-only")
+			silent, _ := cmd.Flags().GetBool("silent")
+			protos, _ := cmd.Flags().GetStringSlice("proto")
+			importPaths, _ := cmd.Flags().GetStringSlice("import-path")
+			plaintext, _ := cmd.Flags().GetBool("plaintext")
+
+			opts := ExecuteOptions{
+				Headers:     parseKeyValueSlice(headerFlags, ":"),
+				Vars:        parseKeyValueSlice(varFlags, "="),
+				Body:        body,
+				BodyFile:    bodyFile,
+				EnvOverride: envOverride,
+				Insecure:    insecure,
+				Timeout:     time.Duration(timeoutSeconds) * time.Second,
+				OutputFile:  outputFile,
+				Raw:         raw,
+				Verbose:     verbose,
+				BodyOnly:    bodyOnly,
+				Silent:      silent,
+			}
+			if err := validateBodyModes(opts); err != nil {
+				return err
+			}
+			target := request.GRPCOptions{
+				Host:        args[0],
+				Protos:      protos,
+				ImportPaths: importPaths,
+				Plaintext:   plaintext,
+			}
+
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			if len(args) == 1 {
+				return interrupted(ctx, listGRPCServices(ctx, target, opts))
+			}
+			resp, err := executeGRPCCall(ctx, args[1], target, opts)
+			if err != nil {
+				return interrupted(ctx, err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("grpc call failed: %s", resp.Status)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceP("header", "H", nil, "Request metadata (key:value)")
+	cmd.Flags().StringSliceP("var", "V", nil, "Variables (key=value)")
+	cmd.Flags().String("env", "", "Use a specific environment for this call only")
+	cmd.Flags().StringP("data", "d", "", "Request message as JSON")
+	cmd.Flags().StringP("file", "f", "", "Read the request message JSON from a file")
+	cmd.Flags().StringSlice("proto", nil, "Resolve methods from .proto files instead of server reflection")
+	cmd.Flags().StringSlice("import-path", nil, "Directories searched for .proto imports")
+	cmd.Flags().Bool("plaintext", false, "Use HTTP/2 without TLS (h2c)")
+	cmd.Flags().IntP("timeout", "t", 0, "Call deadline in seconds (overrides config)")
+	cmd.Flags().BoolP("insecure", "k", false, "Allow insecure TLS connections")
+	cmd.Flags().StringP("output", "o", "", "Write the response JSON to a file")
+	cmd.Flags().Bool("raw", false, "Print raw response JSON without formatting")
+	cmd.Flags().BoolP("verbose", "v", false, "Show response headers and trailers")
+	cmd.Flags().Bool("body-only", false, "Print response JSON only")
+	cmd.Flags().BoolP("silent", "s", false, "Print only the response JSON")
+	return cmd
+}
+
+// grpcCall is a client connected to the target of a gRPC request, with the
+// resolved metadata and variables.
+type grpcCall struct {
+	ctx      context.Context
+	client   *grpc.Client
+	host     string
+	metadata http.Header
+	vars     map[string]string
+	timeout  time.Duration
+}
+
+func newGRPCCall(ctx context.Context, target request.GRPCOptions, opts ExecuteOptions) (*grpcCall, error) {
+	cfg, err := config.LoadWithEnvOverride(opts.EnvOverride)
+	if err != nil {
+		return nil, err
+	}
+	_, headers, vars, err := resolveTarget(ctx, cfg, "", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	host := request.ResolveVariables(target.Host, vars)
+	if host == "" {
+		host = request.ResolveVariables(cfg.BaseURL, vars)
+	}
+	client, err := grpc.NewClient(host, target.Plaintext, opts.Insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(http.Header, len(headers))
+	for k, v := range headers {
+		metadata.Set(k, v)
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+	return &grpcCall{ctx: ctx, client: client, host: host, metadata: metadata, vars: vars, timeout: timeout}, nil
+}
+
+func (c *grpcCall) context() (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(c.ctx, c.timeout)
+	}
+	return context.WithCancel(c.ctx)
+}
+
+// registry loads the descriptors from .proto files, or from server reflection
+// for the given services (all of them when none is given).
+func (c *grpcCall) registry(ctx context.Context, target request.GRPCOptions, services ...string) (*grpc.Registry, error) {
+	if len(target.Protos) > 0 {
+		return grpc.LoadProtoFiles(target.Protos, target.ImportPaths)
+	}
+	return c.client.Reflect(ctx, c.metadata, services...)
+}
+
+func listGRPCServices(ctx context.Context, target request.GRPCOptions, opts ExecuteOptions) error {
+	call, err := newGRPCCall(ctx, target, opts)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := call.context()
+	defer cancel()
+
+	registry, err := call.registry(ctx, target)
+	if err != nil {
+		return err
+	}
+	output.PrintGRPCServices(registry.Services())
+	return nil
+}
+
+// executeGRPCCall invokes a method and returns the outcome as a response: the
+// gRPC status mapped to an HTTP status code, headers and trailers as headers,
+// and the response message (a JSON array for server-streaming methods) as
+// body. Calls that end with a non-OK status have a body with the code and
+// message of the status.
+func executeGRPCCall(ctx context.Context, methodName string, target request.GRPCOptions, opts ExecuteOptions) (*apixhttp.Response, error) {
+	if err := validateDisplayModes(opts); err != nil {
+		return nil, err
+	}
+	call, err := newGRPCCall(ctx, target, opts)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := call.context()
+	defer cancel()
+
+	methodName = request.ResolveVariables(methodName, call.vars)
+	serviceName, _, err := grpc.SplitMethod(methodName)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := call.registry(ctx, target, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	method, err := registry.FindMethod(methodName)
+	if err != nil {
+		return nil, err
+	}
+
+	body := opts.Body
+	if opts.BodyFile != "" {
+		data, err := os.ReadFile(opts.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading body file: %w", err)
+		}
+		body = string(data)
+	}
+	body = request.ResolveVariables(body, call.vars)
+
+	printMessages := !opts.SuppressOutput && method.ServerStreaming
+	rawMessages := opts.Raw || opts.Silent || opts.BodyOnly
+	start := time.Now()
+	result, err := call.client.Invoke(ctx, method, call.metadata, []byte(body), func(message json.RawMessage) {
+		if printMessages {
+			output.PrintGRPCMessage(message, rawMessages)
+		}
+	})
+	duration := time.Since(start)
+	path := call.host + method.Path()
+	if err != nil {
+		_ = history.Append(history.Entry{
+			Method:     "GRPC",
+			Path:       path,
+			Request:    opts.RequestName,
+			DurationMS: duration.Milliseconds(),
+		})
+		return nil, fmt.Errorf("calling %s: %w", method.FullName(), err)
+	}
+
+	respBody, err := grpcResponseBody(method, result)
+	if err != nil {
+		return nil, err
+	}
+	header := result.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("grpc-status", strconv.Itoa(int(result.Status.Code)))
+	if result.Status.Message != "" {
+		header.Set("grpc-message", result.Status.Message)
+	}
+	resp := &apixhttp.Response{
+		Method:     "GRPC",
+		URL:        path,
+		StatusCode: result.Status.Code.HTTPStatus(),
+		Status:     fmt.Sprintf("%d %s", result.Status.Code.HTTPStatus(), result.Status.Code),
+		Headers:    header,
+		Body:       respBody,
+		Duration:   duration,
+	}
+	if result.Status.Message != "" {
+		resp.Status += ": " + result.Status.Message
+	}
+	_ = history.Append(history.Entry{
+		Method:       "GRPC",
+		Path:         path,
+		Request:      opts.RequestName,
+		Status:       resp.StatusCode,
+		DurationMS:   duration.Milliseconds(),
+		ResponseSize: len(respBody),
+		ResponseBody: history.ResponseSample(respBody),
+	})
+
+	if opts.FailOnHTTPError && result.Status.Code != grpc.OK {
+		return nil, fmt.Errorf("grpc call failed: %s", resp.Status)
+	}
+	if err := writeOutputFile(opts.OutputFile, respBody); err != nil {
+		return nil, err
+	}
+
+	if !opts.SuppressOutput {
+		if !opts.Silent && !opts.BodyOnly {
+			output.PrintStatus("GRPC", method.FullName(), resp.StatusCode, resp.Status, resp.Duration, len(respBody))
+		}
+		if opts.Verbose && !opts.Silent {
+			output.PrintHeaders(resp.Headers)
+		}
+		// Streamed messages were printed as they arrived.
+		if opts.OutputFile == "" && (!method.ServerStreaming || result.Status.Code != grpc.OK) {
+			if opts.Silent || opts.BodyOnly {
+				output.PrintBodyRaw(respBody)
+			} else {
+				output.PrintBody(respBody, opts.Raw)
+			}
+		}
+	}
+
+	if !opts.SkipSaveLast {
+		_ = request.SaveLast(request.SavedRequest{
+			Protocol: request.ProtocolGRPC,
+			Path:     method.FullName(),
+			Headers:  opts.Headers,
+			Body:     body,
+			GRPC:     &target,
+		})
+	}
+	return resp, nil
+}
+
+func grpcResponseBody(method *grpc.Method, result *grpc.Result) ([]byte, error) {
+	if result.Status.Code != grpc.OK {
+		return json.Marshal(struct {
+			Code    int    `json:"code"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}{int(result.Status.Code), result.Status.Code.String(), result.Status.Message})
+	}
+	if method.ServerStreaming {
+		messages := result.Messages
+		if messages == nil {
+			messages = []json.RawMessage{}
+		}
+		return json.Marshal(messages)
+	}
+	if len(result.Messages) == 0 {
+		return nil, fmt.Errorf("%s returned no response message", method.FullName())
+	}
+	return result.Messages[len(result.Messages)-1], nil
+}
+
+// executeGRPCRequest runs a saved request with protocol: grpc.
+func executeGRPCRequest(ctx context.Context, saved *request.SavedRequest, opts ExecuteOptions) (*apixhttp.Response, error) {
+	var target request.GRPCOptions
+	if saved.GRPC != nil {
+		target = *saved.GRPC
+		target.Protos = resolvePaths(saved.Dir, target.Protos)
+		target.ImportPaths = resolvePaths(saved.Dir, target.ImportPaths)
+	}
+	if strings.TrimSpace(saved.Path) == "" {
+		return nil, fmt.Errorf("grpc request %q has no method in path (package.Service/Method)", opts.RequestName)
+	}
+	return executeGRPCCall(ctx, saved.Path, target, opts)
+}
+
+// resolvePaths returns paths relative to dir, the directory of the request
+// file that lists them.
+func resolvePaths(dir string, paths []string) []string {
+	if len(paths) == 0 {
+		return paths
+	}
+	out := make([]string, len(paths))
+	for i, path := range paths {
+		out[i] = request.ResolvePath(dir, path)
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const inventoryProto = `syntax = "proto3";
+package inventory.v1;
+
+service Stock {
+  rpc GetItem(GetItemRequest) returns (Item);
+}
+
+message GetItemRequest { string sku = 1; }
+message Item {
+  string sku = 1;
+  int32 count = 2;
+}
+`
+
+// newStockServer answers inventory.v1.Stock/GetItem over h2c: 3 units for
+// any sku except "missing", which fails with NOT_FOUND.
+func newStockServer(t *testing.T, gotKey *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*gotKey = r.Header.Get("X-Api-Key")
+		data, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/inventory.v1.Stock/GetItem" || len(data) < 7 || data[5] != 0x0a {
+			t.Errorf("unexpected call %s with %x", r.URL.Path, data)
+			return
+		}
+		sku := string(data[7 : 7+int(data[6])])
+
+		w.Header().Set("Content-Type", "application/grpc")
+		if sku == "missing" {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "no item "+sku)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		message := append([]byte{0x0a, byte(len(sku))}, sku...)
+		message = append(message, 0x10, 3)
+		frame := make([]byte, 5)
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+		_, _ = w.Write(append(frame, message...))
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExecuteSavedGRPCRequest(t *testing.T) {
+	withTempDirAsWorkingDirRun(t)
+
+	var gotKey string
+	server := newStockServer(t, &gotKey)
+
+	if err := os.WriteFile("apix.yaml", []byte(fmt.Sprintf("project: test\nbase_url: %s\ntimeout: 10\nauth:\n  type: none\n", server.URL)), 0o644); err != nil {
+		t.Fatalf("writing apix.yaml: %v", err)
+	}
+	for _, dir := range []string{"requests", "protos"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("creating %s dir: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join("protos", "inventory.proto"), []byte(inventoryProto), 0o644); err != nil {
+		t.Fatalf("writing proto: %v", err)
+	}
+	saved := `name: get-item
+protocol: grpc
+path: inventory.v1.Stock/GetItem
+headers:
+  X-Api-Key: ${KEY}
+body: '{"sku": "${SKU}"}'
+grpc:
+  protos: [../protos/inventory.proto]
+`
+	if err := os.WriteFile(filepath.Join("requests", "get-item.yaml"), []byte(saved), 0o644); err != nil {
+		t.Fatalf("writing request file: %v", err)
+	}
+
+	resp, err := executeSavedRequestWithResponse(context.Background(), "get-item", ExecuteOptions{
+		Vars:           map[string]string{"KEY": "k-1", "SKU": "A-1"},
+		SuppressOutput: true,
+		SkipSaveLast:   true,
+	})
+	if err != nil {
+		t.Fatalf("executeSavedRequestWithResponse failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Headers.Get("grpc-status") != "0" {
+		t.Fatalf("expected OK, got %d %s (%v)", resp.StatusCode, resp.Status, resp.Headers)
+	}
+	if string(resp.Body) != `{"sku":"A-1","count":3}` {
+		t.Fatalf("unexpected body %s", resp.Body)
+	}
+	if gotKey != "k-1" {
+		t.Fatalf("expected headers to be sent as metadata, got %q", gotKey)
+	}
+
+	resp, err = executeSavedRequestWithResponse(context.Background(), "get-item", ExecuteOptions{
+		Vars:           map[string]string{"KEY": "k-1", "SKU": "missing"},
+		SuppressOutput: true,
+		SkipSaveLast:   true,
+	})
+	if err != nil {
+		t.Fatalf("executeSavedRequestWithResponse failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound || resp.Status != "404 NOT_FOUND: no item missing" {
+		t.Fatalf("expected NOT_FOUND mapped to 404, got %d %q", resp.StatusCode, resp.Status)
+	}
+	var status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(resp.Body, &status); err != nil || status.Code != 5 || status.Message != "no item missing" {
+		t.Fatalf("unexpected error body %s (%v)", resp.Body, err)
+	}
+}
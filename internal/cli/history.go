@@ -41,13 +41,18 @@ func newHistoryCmd() *cobra.Command {
 				if entry.Status > 0 {
 					status = fmt.Sprintf("%d", entry.Status)
 				}
+				retries := ""
+				if entry.Retries > 0 {
+					retries = fmt.Sprintf(", %d retries", entry.Retries)
+				}
 				fmt.Printf(
-					"  %s  %-6s %-4s %s (%dms)\n",
+					"  %s  %-6s %-4s %s (%dms%s)\n",
 					entry.Timestamp.Format(time.RFC3339),
 					entry.Method,
 					status,
 					entry.Path,
 					entry.DurationMS,
+					retries,
 				)
 			}
 			fmt.Println()
@@ -9,6 +9,7 @@ import (
 
 	interopcurl "github.com/Tresor-Kasend/apix/internal/interop/curl"
 	interopinsomnia "github.com/Tresor-Kasend/apix/internal/interop/insomnia"
+	interopopenapi "github.com/Tresor-Kasend/apix/internal/interop/openapi"
 	interoppostman "github.com/Tresor-Kasend/apix/internal/interop/postman"
 	"github.com/Tresor-Kasend/apix/internal/output"
 	"github.com/Tresor-Kasend/apix/internal/request"
@@ -21,13 +22,14 @@ func newImportCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "import",
 		Short: "Import requests from external formats",
-		Long:  "Import request definitions from Postman, Insomnia, or a curl command.",
+		Long:  "Import request definitions from Postman, Insomnia, OpenAPI/Swagger, or a curl command.",
 	}
 
 	cmd.AddCommand(
 		newImportPostmanCmd(),
 		newImportInsomniaCmd(),
 		newImportCurlCmd(),
+		newImportOpenAPICmd(),
 	)
 	return cmd
 }
@@ -101,12 +103,63 @@ func newImportCurlCmd() *cobra.Command {
 	return cmd
 }
 
+func newImportOpenAPICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "openapi <spec.yaml|spec.json>",
+		Short: "Import operations from an OpenAPI 3 or Swagger 2 spec",
+		Long:  "Generate one saved request per operation. Operations are grouped into requests/<tag>/ subdirectories by their first tag.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			imported, err := interopopenapi.ParseSpecFile(args[0])
+			if err != nil {
+				return err
+			}
+			if len(imported) == 0 {
+				return fmt.Errorf("no importable requests found")
+			}
+
+			flat, _ := cmd.Flags().GetBool("flat")
+			dirs := make([]string, 0)
+			grouped := make(map[string][]request.SavedRequest)
+			for _, item := range imported {
+				dir := item.Dir
+				if flat {
+					dir = ""
+				}
+				if _, ok := grouped[dir]; !ok {
+					dirs = append(dirs, dir)
+				}
+				grouped[dir] = append(grouped[dir], item.Request)
+			}
+
+			total := 0
+			for _, dir := range dirs {
+				count, err := saveImportedRequestsInDir(dir, grouped[dir])
+				total += count
+				if err != nil {
+					return err
+				}
+			}
+			output.PrintSuccess(fmt.Sprintf("Imported %d request(s) from OpenAPI", total))
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("flat", false, "Save all requests directly under requests/ instead of per-tag subdirectories")
+	return cmd
+}
+
 func saveImportedRequests(imported []request.SavedRequest) (int, error) {
+	return saveImportedRequestsInDir("", imported)
+}
+
+func saveImportedRequestsInDir(dir string, imported []request.SavedRequest) (int, error) {
 	if len(imported) == 0 {
 		return 0, fmt.Errorf("no importable requests found")
 	}
 
-	if err := os.MkdirAll("requests", 0o755); err != nil {
+	dir = sanitizeRequestName(dir)
+	if err := os.MkdirAll(filepath.Join("requests", dir), 0o755); err != nil {
 		return 0, fmt.Errorf("creating requests directory: %w", err)
 	}
 
@@ -117,7 +170,10 @@ func saveImportedRequests(imported []request.SavedRequest) (int, error) {
 		if baseName == "" {
 			baseName = deriveRequestName(reqDef, i+1)
 		}
-		name := uniqueRequestName(baseName, used)
+		name := uniqueRequestName(baseName, dir, used)
+		if dir != "" {
+			name = dir + "/" + name
+		}
 
 		reqDef.Name = name
 		if err := request.Save(name, reqDef); err != nil {
@@ -169,7 +225,7 @@ func deriveRequestName(reqDef request.SavedRequest, index int) string {
 	return fmt.Sprintf("%s-%s", method, pathValue)
 }
 
-func uniqueRequestName(base string, used map[string]int) string {
+func uniqueRequestName(base, dir string, used map[string]int) string {
 	base = sanitizeRequestName(base)
 	if base == "" {
 		base = "request"
@@ -184,7 +240,7 @@ func uniqueRequestName(base string, used map[string]int) string {
 			candidate = fmt.Sprintf("%s-%d", base, next+1)
 		}
 
-		if _, err := os.Stat(filepath.Join("requests", candidate+".yaml")); os.IsNotExist(err) {
+		if _, err := os.Stat(filepath.Join("requests", dir, candidate+".yaml")); os.IsNotExist(err) {
 			used[base] = next + 1
 			return candidate
 		}
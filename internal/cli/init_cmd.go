@@ -16,11 +16,16 @@ import (
 )
 
 var allowedAuthTypes = map[string]bool{
-	"none":    true,
-	"bearer":  true,
-	"basic":   true,
-	"api_key": true,
-	"custom":  true,
+	"none":      true,
+	"bearer":    true,
+	"basic":     true,
+	"api_key":   true,
+	"custom":    true,
+	"oauth2":    true,
+	"aws_sigv4": true,
+	"hmac":      true,
+	"jwt":       true,
+	"digest":    true,
 }
 
 func newInitCmd() *cobra.Command {
@@ -122,10 +127,10 @@ func promptInput(label, defaultVal string) string {
 
 func promptAuthType(label, defaultVal string) string {
 	for {
-		value := strings.ToLower(promptInput(label+" (none|bearer|basic|api_key|custom)", defaultVal))
+		value := strings.ToLower(promptInput(label+" (none|bearer|basic|api_key|custom|oauth2|aws_sigv4|hmac|jwt|digest)", defaultVal))
 		if allowedAuthTypes[value] {
 			return value
 		}
-		output.PrintInfo("Invalid auth type. Allowed: none, bearer, basic, api_key, custom")
+		output.PrintInfo("Invalid auth type. Allowed: none, bearer, basic, api_key, custom, oauth2, aws_sigv4, hmac, jwt, digest")
 	}
 }
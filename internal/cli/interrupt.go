@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// ExitInterrupted is the exit code of a run stopped by Ctrl-C or SIGTERM.
+const ExitInterrupted = 130
+
+// ErrInterrupted is returned by the commands that send requests when a
+// signal stops them, once they have printed what they completed.
+var ErrInterrupted = errors.New("interrupted")
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrInterrupted):
+		return ExitInterrupted
+	default:
+		return 1
+	}
+}
+
+// interruptContext returns the context of cmd, cancelled on Ctrl-C or
+// SIGTERM. Signals are only caught once: a second one terminates the process.
+func interruptContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// interrupted returns ErrInterrupted when ctx was cancelled, err otherwise.
+func interrupted(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ErrInterrupted
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// addRunFlags registers the flags of commands running several requests.
+func addRunFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("verbose", "v", false, "Show connection reuse statistics")
+	addAdvancedNetworkFlags(cmd)
+}
+
+// closeRunPool writes the cookie jars of a run once its requests are done and
+// prints how many connections they reused in verbose mode.
+func closeRunPool(cmd *cobra.Command, pool *apixhttp.Pool) {
+	if err := pool.Close(); err != nil {
+		output.PrintError(err)
+	}
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		stats := pool.Stats()
+		output.PrintConnectionStats(stats.Requests, stats.Opened(), stats.Reused)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestChainSharesConnectionsAndFlushesCookiesOnce(t *testing.T) {
+	withTempDirAsWorkingDirRun(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		} else if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	files := map[string]string{
+		"apix.yaml":                             fmt.Sprintf("project: test\nbase_url: %s\ntimeout: 10\nauth:\n  type: none\n", server.URL),
+		filepath.Join("requests", "login.yaml"): "name: login\nmethod: POST\npath: /login\n",
+		filepath.Join("requests", "me.yaml"):    "name: me\nmethod: GET\npath: /me\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+	}
+
+	cmd := newChainCmd()
+	cmd.SetArgs([]string{"login", "me", "me", "-v"})
+	out := captureStdout(t, func() {
+		original := color.Output
+		color.Output = os.Stdout
+		defer func() { color.Output = original }()
+
+		if err := cmd.Execute(); err != nil {
+			t.Errorf("chain failed: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Connections: 3 request(s), 1 opened, 2 reused") {
+		t.Fatalf("expected connection reuse statistics, got:\n%s", out)
+	}
+
+	data, err := os.ReadFile(filepath.Join(".apix", "cookies.jar"))
+	if err != nil || !strings.Contains(string(data), `"session"`) {
+		t.Fatalf("expected the session cookie in the jar, got %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+)
+
+func resolveRateLimit(cfg config.RateLimitConfig) (apixhttp.RateLimit, error) {
+	if cfg.RequestsPerSecond < 0 || cfg.Burst < 0 {
+		return apixhttp.RateLimit{}, fmt.Errorf("rate_limit requests_per_second and burst must be >= 0")
+	}
+	return apixhttp.RateLimit{
+		PerSecond: cfg.RequestsPerSecond,
+		Burst:     cfg.Burst,
+		PerHost:   cfg.PerHost,
+	}, nil
+}
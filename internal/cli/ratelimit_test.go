@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestRateLimitWaitsAreReportedInVerboseMode(t *testing.T) {
+	withTempDirAsWorkingDirRun(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := fmt.Sprintf("project: test\nbase_url: %s\ntimeout: 10\nauth:\n  type: none\nrate_limit:\n  requests_per_second: 19\n  burst: 1\n", server.URL)
+	if err := os.WriteFile("apix.yaml", []byte(config), 0o644); err != nil {
+		t.Fatalf("writing apix.yaml: %v", err)
+	}
+
+	out := captureStdout(t, func() {
+		// Colored lines go to color.Output, which is bound to the real stdout.
+		original := color.Output
+		color.Output = os.Stdout
+		defer func() { color.Output = original }()
+
+		for i := 0; i < 2; i++ {
+			if _, err := executeFromOptionsWithResponse(context.Background(), "GET", "/ping", ExecuteOptions{Verbose: true, NoCookies: true, SkipSaveLast: true}); err != nil {
+				t.Fatalf("request %d failed: %v", i, err)
+			}
+		}
+	})
+	if !strings.Contains(out, "rate limit: waiting") {
+		t.Fatalf("expected the second request to report its wait, got:\n%s", out)
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+)
+
+// resolveRetry merges the retry: policy of apix.yaml and the active env with
+// the one of the saved request; --retry and --retry-delay override both.
+func resolveRetry(base config.RetryConfig, opts ExecuteOptions) (int, time.Duration, apixhttp.RetryPolicy, error) {
+	merged := base
+	if opts.RetryOverride != nil {
+		merged = merged.Merge(config.RetryConfig(*opts.RetryOverride))
+	}
+
+	var policy apixhttp.RetryPolicy
+	attempts := merged.Attempts
+	if opts.Retry > 0 {
+		attempts = opts.Retry
+	}
+	if attempts < 0 {
+		return 0, 0, policy, fmt.Errorf("retry attempts must be >= 0")
+	}
+
+	delay, err := parseRetryDuration("delay", merged.Delay)
+	if err != nil {
+		return 0, 0, policy, err
+	}
+	if opts.RetryDelay > 0 {
+		delay = opts.RetryDelay
+	}
+	if policy.MaxDelay, err = parseRetryDuration("max_delay", merged.MaxDelay); err != nil {
+		return 0, 0, policy, err
+	}
+	if merged.Jitter != nil {
+		policy.Jitter = *merged.Jitter
+	}
+
+	for _, status := range merged.Statuses {
+		if status < 100 || status > 599 {
+			return 0, 0, policy, fmt.Errorf("invalid retry status %d", status)
+		}
+	}
+	policy.Statuses = merged.Statuses
+	for _, method := range merged.Methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method == "" {
+			return 0, 0, policy, fmt.Errorf("retry methods must not be empty")
+		}
+		policy.Methods = append(policy.Methods, method)
+	}
+	return attempts, delay, policy, nil
+}
+
+func parseRetryDuration(field, value string) (time.Duration, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid retry %s %q: expected a duration like 500ms or 2s", field, value)
+	}
+	return d, nil
+}
+
+// retryReason describes why an attempt is retried.
+func retryReason(attempt apixhttp.RetryAttempt) string {
+	if attempt.Err != nil {
+		return attempt.Err.Error()
+	}
+	status := strings.TrimSpace(strings.TrimPrefix(attempt.Status, fmt.Sprint(attempt.StatusCode)))
+	if status == "" {
+		status = http.StatusText(attempt.StatusCode)
+	}
+	return fmt.Sprintf("%d %s", attempt.StatusCode, status)
+}
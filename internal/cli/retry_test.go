@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+	"github.com/Tresor-Kasend/apix/internal/history"
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+func TestSavedRequestRetryPolicyOverridesConfigAndEnv(t *testing.T) {
+	withTempDirAsWorkingDirRun(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 4 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	files := map[string]string{
+		"apix.yaml":                              fmt.Sprintf("project: test\nbase_url: %s\ntimeout: 10\ncurrent_env: dev\nauth:\n  type: none\nretry:\n  attempts: 1\n  delay: 1ms\n  statuses: [503]\n", server.URL),
+		filepath.Join("env", "dev.yaml"):         "retry:\n  attempts: 3\n",
+		filepath.Join("requests", "create.yaml"): "name: create\nmethod: POST\npath: /orders\nbody: '{}'\nretry:\n  methods: [POST]\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+	}
+
+	resp, err := executeSavedRequestWithResponse(context.Background(), "create", ExecuteOptions{NoCookies: true, SuppressOutput: true, SkipSaveLast: true})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected success after retries, got %v (%v)", resp, err)
+	}
+	if calls != 4 {
+		t.Fatalf("expected 4 calls with the env attempts, got %d", calls)
+	}
+
+	entries, err := history.Read(1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("reading history: %v (%d entries)", err, len(entries))
+	}
+	if entries[0].Retries != 3 {
+		t.Fatalf("expected 3 retries in history, got %d", entries[0].Retries)
+	}
+}
+
+func TestResolveRetry(t *testing.T) {
+	jitter := true
+	base := config.RetryConfig{Attempts: 2, Delay: "100ms", MaxDelay: "2s", Jitter: &jitter, Statuses: []int{429}}
+
+	attempts, delay, policy, err := resolveRetry(base, ExecuteOptions{
+		Retry:         5,
+		RetryOverride: &request.Retry{Delay: "1s", Methods: []string{"post", "get"}},
+	})
+	if err != nil {
+		t.Fatalf("resolveRetry returned error: %v", err)
+	}
+	if attempts != 5 || delay != time.Second || policy.MaxDelay != 2*time.Second || !policy.Jitter {
+		t.Fatalf("unexpected retry settings: %d, %v, %+v", attempts, delay, policy)
+	}
+	if len(policy.Statuses) != 1 || policy.Statuses[0] != 429 || policy.Methods[0] != "POST" || policy.Methods[1] != "GET" {
+		t.Fatalf("unexpected retry policy %+v", policy)
+	}
+
+	if _, _, _, err := resolveRetry(config.RetryConfig{MaxDelay: "soon"}, ExecuteOptions{}); err == nil {
+		t.Fatalf("expected invalid max_delay error")
+	}
+	if _, _, _, err := resolveRetry(config.RetryConfig{Statuses: []int{42}}, ExecuteOptions{}); err == nil {
+		t.Fatalf("expected invalid status error")
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/url"
@@ -47,6 +48,7 @@ func rootCmd() *cobra.Command {
 		newOptionsCmd(),
 		newInitCmd(),
 		newEnvCmd(),
+		newAuthCmd(),
 		newHistoryCmd(),
 		newConfigCmd(),
 		newImportCmd(),
@@ -54,6 +56,10 @@ func rootCmd() *cobra.Command {
 		newSaveCmd(),
 		newRunCmd(),
 		newChainCmd(),
+		newFlowCmd(),
+		newGQLCmd(),
+		newGRPCCmd(),
+		newWSCmd(),
 		newTestCmd(),
 		newWatchCmd(),
 		newListCmd(),
@@ -72,6 +78,8 @@ type ExecuteOptions struct {
 	BodyFile   string
 	Form       []apixhttp.FormField
 	URLEncoded []apixhttp.FormField
+	// GraphQL, when set, builds the JSON body from a graphql: block.
+	GraphQL *request.GraphQL
 
 	Raw         bool
 	Verbose     bool
@@ -79,17 +87,26 @@ type ExecuteOptions struct {
 	BodyOnly    bool
 	Silent      bool
 	OutputFile  string
+	// Timing prints the DNS, connect, TLS, wait and transfer waterfall.
+	Timing bool
 
 	Timeout     time.Duration
 	NoFollow    bool
 	EnvOverride string
 	Retry       int
 	RetryDelay  time.Duration
-	Proxy       string
-	Insecure    bool
-	CertFile    string
-	KeyFile     string
-	NoCookies   bool
+	// RetryOverride is the retry: block of the saved request being run.
+	RetryOverride *request.Retry
+	// Pool shares connections and cookie jars between the requests of a
+	// run; without one, the request opens its own and closes it when done.
+	Pool      *apixhttp.Pool
+	Proxy     string
+	Insecure  bool
+	CertFile  string
+	KeyFile   string
+	NoCookies bool
+	// Stream, when set, reads the response as an SSE stream.
+	Stream *request.Stream
 
 	RequestName     string
 	SkipAutoRefresh bool
@@ -98,12 +115,12 @@ type ExecuteOptions struct {
 	SuppressOutput  bool
 }
 
-func executeFromOptions(method, path string, opts ExecuteOptions) error {
-	_, err := executeFromOptionsInternal(method, path, opts, false)
+func executeFromOptions(ctx context.Context, method, path string, opts ExecuteOptions) error {
+	_, err := executeFromOptionsInternal(ctx, method, path, opts, false)
 	return err
 }
 
-func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alreadyRetried bool) (*apixhttp.Response, error) {
+func executeFromOptionsInternal(ctx context.Context, method, path string, opts ExecuteOptions, alreadyRetried bool) (*apixhttp.Response, error) {
 	if err := validateDisplayModes(opts); err != nil {
 		return nil, err
 	}
@@ -116,23 +133,8 @@ func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alread
 		return nil, err
 	}
 
-	urlStr := buildURL(cfg.BaseURL, path)
-
-	headers := make(map[string]string)
-	for k, v := range cfg.Headers {
-		headers[k] = v
-	}
-	for k, v := range opts.Headers {
-		headers[k] = v
-	}
-
-	vars := request.BuildVariableMap(cfg.Variables, cfg.Auth.Token, opts.Vars)
-
-	urlStr = request.ResolveVariables(urlStr, vars)
-	for k, v := range headers {
-		headers[k] = request.ResolveVariables(v, vars)
-	}
-	if err := apixauth.Apply(headers, cfg, vars); err != nil {
+	urlStr, headers, vars, err := resolveTarget(ctx, cfg, path, opts)
+	if err != nil {
 		return nil, err
 	}
 
@@ -153,13 +155,53 @@ func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alread
 	if opts.Timeout > 0 {
 		timeout = opts.Timeout
 	}
+	if opts.Stream != nil {
+		// Streams are bounded by --stream-duration / --max-events instead.
+		timeout = 0
+	}
 
-	client := apixhttp.NewClientWithConfig(apixhttp.ClientConfig{
+	var digest *apixhttp.DigestCredentials
+	username, password, err := apixauth.DigestCredentials(cfg, vars)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		digest = &apixhttp.DigestCredentials{Username: username, Password: password}
+	}
+
+	retry, retryDelay, retryPolicy, err := resolveRetry(cfg.Retry, opts)
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := resolveRateLimit(cfg.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Pool == nil {
+		opts.Pool = apixhttp.NewPool()
+		defer func() { _ = opts.Pool.Close() }()
+	}
+	retries := 0
+	client := opts.Pool.NewClient(apixhttp.ClientConfig{
 		Timeout:         timeout,
 		FollowRedirects: !opts.NoFollow,
+		Digest:          digest,
+		OnRetry: func(attempt apixhttp.RetryAttempt) {
+			retries++
+			if opts.Verbose && !opts.SuppressOutput && !opts.Silent {
+				output.PrintRetry(attempt.Attempt, retry+1, retryReason(attempt), attempt.Delay, attempt.RetryAfter)
+			}
+		},
+		RateLimit: rateLimit,
+		OnRateLimit: func(host string, wait time.Duration) {
+			if opts.Verbose && !opts.SuppressOutput && !opts.Silent {
+				output.PrintRateLimitWait(host, wait)
+			}
+		},
 		Network: apixhttp.NetworkOptions{
-			Retry:         opts.Retry,
-			RetryDelay:    opts.RetryDelay,
+			Retry:         retry,
+			RetryDelay:    retryDelay,
+			RetryPolicy:   retryPolicy,
 			ProxyURL:      opts.Proxy,
 			Insecure:      opts.Insecure,
 			CertFile:      opts.CertFile,
@@ -169,29 +211,47 @@ func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alread
 		},
 	})
 
+	sign, err := apixauth.RequestSigner(cfg, vars)
+	if err != nil {
+		return nil, err
+	}
+
 	requestStart := time.Now()
-	resp, err := client.Send(apixhttp.RequestOptions{
+	sendOpts := apixhttp.RequestOptions{
 		Method:  method,
 		URL:     urlStr,
 		Headers: headers,
 		Query:   query,
 		Body:    bodyReader,
-	})
+		Sign:    sign,
+	}
+	var resp *apixhttp.Response
+	if opts.Stream != nil {
+		resp, err = streamRequest(ctx, client, sendOpts, method, path, opts)
+	} else {
+		resp, err = client.Send(ctx, sendOpts)
+	}
 	if err != nil {
 		_ = history.Append(history.Entry{
 			Method:     strings.ToUpper(method),
 			Path:       urlStr,
+			Request:    opts.RequestName,
 			Status:     0,
 			DurationMS: time.Since(requestStart).Milliseconds(),
+			Retries:    retries,
 		})
 		return nil, err
 	}
 	_ = history.Append(history.Entry{
 		Method:       strings.ToUpper(method),
 		Path:         urlStr,
+		Request:      opts.RequestName,
 		Status:       resp.StatusCode,
 		DurationMS:   resp.Duration.Milliseconds(),
 		ResponseSize: len(resp.Body),
+		ResponseBody: history.ResponseSample(resp.Body),
+		Timing:       resp.Timing.Milliseconds(),
+		Retries:      retries,
 	})
 
 	shouldRetry, refreshErr := apixauth.RefreshIfNeeded(
@@ -201,7 +261,7 @@ func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alread
 		alreadyRetried,
 		opts.SkipAutoRefresh,
 		func(loginRequest string) error {
-			return executeSavedRequest(loginRequest, ExecuteOptions{
+			return executeSavedRequest(ctx, loginRequest, ExecuteOptions{
 				Vars:            opts.Vars,
 				Timeout:         opts.Timeout,
 				NoFollow:        opts.NoFollow,
@@ -213,6 +273,7 @@ func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alread
 				CertFile:        opts.CertFile,
 				KeyFile:         opts.KeyFile,
 				NoCookies:       opts.NoCookies,
+				Pool:            opts.Pool,
 				RequestName:     loginRequest,
 				SkipAutoRefresh: true,
 				SkipSaveLast:    true,
@@ -229,7 +290,7 @@ func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alread
 		if !opts.Silent && !opts.BodyOnly && !opts.HeadersOnly {
 			output.PrintSuccess("Token expired, re-authenticated automatically")
 		}
-		return executeFromOptionsInternal(method, path, opts, true)
+		return executeFromOptionsInternal(ctx, method, path, opts, true)
 	}
 	if alreadyRetried && resp.StatusCode == 401 && cfg.Auth.LoginRequest != "" && !opts.SkipAutoRefresh {
 		return nil, fmt.Errorf("request is still unauthorized after automatic re-authentication")
@@ -242,12 +303,20 @@ func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alread
 		return nil, err
 	}
 
+	// Streamed events were printed as they arrived.
+	streamed := resp.Events != nil
 	shouldPrintStatus := !opts.SuppressOutput && !opts.Silent && !opts.BodyOnly
-	shouldPrintHeaders := !opts.SuppressOutput && !opts.Silent && (opts.HeadersOnly || opts.Verbose || strings.EqualFold(method, "HEAD"))
-	shouldPrintBody := !opts.SuppressOutput && !opts.HeadersOnly && !strings.EqualFold(method, "HEAD") && opts.OutputFile == ""
+	shouldPrintHeaders := !streamed && !opts.SuppressOutput && !opts.Silent && (opts.HeadersOnly || opts.Verbose || strings.EqualFold(method, "HEAD"))
+	shouldPrintBody := !streamed && !opts.SuppressOutput && !opts.HeadersOnly && !strings.EqualFold(method, "HEAD") && opts.OutputFile == ""
 
-	if shouldPrintStatus {
+	switch {
+	case shouldPrintStatus && streamed:
+		output.PrintStreamSummary(len(resp.Events), resp.Duration)
+	case shouldPrintStatus:
 		output.PrintStatus(method, path, resp.StatusCode, resp.Status, resp.Duration, len(resp.Body))
+		if opts.Timing && resp.Timing != nil {
+			output.PrintTiming(timingPhases(resp.Timing), resp.Timing.Total, resp.Timing.Reused)
+		}
 	}
 	if shouldPrintHeaders {
 		output.PrintHeaders(resp.Headers)
@@ -281,21 +350,46 @@ func executeFromOptionsInternal(method, path string, opts ExecuteOptions, alread
 	return resp, nil
 }
 
-func executeSavedRequest(name string, baseOpts ExecuteOptions) error {
-	_, err := executeSavedRequestWithResponse(name, baseOpts)
+// resolveTarget builds the URL, headers (config, request, auth) and variable
+// map of a request against the active configuration.
+func resolveTarget(ctx context.Context, cfg *config.Config, path string, opts ExecuteOptions) (string, map[string]string, map[string]string, error) {
+	urlStr := buildURL(cfg.BaseURL, path)
+
+	headers := make(map[string]string)
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	for k, v := range opts.Headers {
+		headers[k] = v
+	}
+
+	vars := request.BuildVariableMap(cfg.Variables, cfg.Auth.Token, opts.Vars)
+
+	urlStr = request.ResolveVariables(urlStr, vars)
+	for k, v := range headers {
+		headers[k] = request.ResolveVariables(v, vars)
+	}
+	if err := apixauth.Apply(ctx, headers, cfg, vars); err != nil {
+		return "", nil, nil, err
+	}
+	return urlStr, headers, vars, nil
+}
+
+func executeSavedRequest(ctx context.Context, name string, baseOpts ExecuteOptions) error {
+	_, err := executeSavedRequestWithResponse(ctx, name, baseOpts)
 	return err
 }
 
-func executeSavedRequestWithResponse(name string, baseOpts ExecuteOptions) (*apixhttp.Response, error) {
+func executeSavedRequestWithResponse(ctx context.Context, name string, baseOpts ExecuteOptions) (*apixhttp.Response, error) {
 	saved, err := request.Load(name)
 	if err != nil {
 		return nil, fmt.Errorf("loading saved request %q: %w", name, err)
 	}
 
-	return executeSavedDefinitionWithResponse(name, saved, baseOpts)
+	return executeSavedDefinitionWithResponse(ctx, name, saved, baseOpts)
 }
 
-func executeSavedDefinitionWithResponse(name string, saved *request.SavedRequest, baseOpts ExecuteOptions) (*apixhttp.Response, error) {
+func executeSavedDefinitionWithResponse(ctx context.Context, name string, saved *request.SavedRequest, baseOpts ExecuteOptions) (*apixhttp.Response, error) {
 	if saved == nil {
 		return nil, fmt.Errorf("saved request %q is nil", name)
 	}
@@ -307,26 +401,62 @@ func executeSavedDefinitionWithResponse(name string, saved *request.SavedRequest
 	opts.BodyFile = ""
 	opts.Form = nil
 	opts.URLEncoded = nil
+	opts.GraphQL = saved.GraphQL
 	opts.RequestName = name
+	opts.RetryOverride = saved.Retry
+	if opts.Stream == nil {
+		opts.Stream = saved.Stream
+	}
 
-	if strings.EqualFold(saved.Method, "HEAD") && !opts.BodyOnly && !opts.Silent {
+	if strings.EqualFold(saved.Protocol, request.ProtocolWebSocket) {
+		return executeWebSocketSession(ctx, saved, opts)
+	}
+	if strings.EqualFold(saved.Protocol, request.ProtocolGRPC) {
+		return executeGRPCRequest(ctx, saved, opts)
+	}
+	method := saved.Method
+	if method == "" && saved.GraphQL != nil {
+		method = "POST"
+	}
+	if strings.EqualFold(method, "HEAD") && !opts.BodyOnly && !opts.Silent {
 		opts.HeadersOnly = true
 	}
 
-	resp, err := executeFromOptionsWithResponse(saved.Method, saved.Path, opts)
+	resp, err := executeFromOptionsWithResponse(ctx, method, saved.Path, opts)
 	if err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
-func executeFromOptionsWithResponse(method, path string, opts ExecuteOptions) (*apixhttp.Response, error) {
-	return executeFromOptionsInternal(method, path, opts, false)
+func executeFromOptionsWithResponse(ctx context.Context, method, path string, opts ExecuteOptions) (*apixhttp.Response, error) {
+	return executeFromOptionsInternal(ctx, method, path, opts, false)
 }
 
 func executeRequest(cmd *cobra.Command, method string, args []string) error {
-	path := args[0]
+	opts, err := optionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
 
+	if flag := cmd.Flags().Lookup("data"); flag != nil && flag.Changed {
+		opts.Body, _ = cmd.Flags().GetString("data")
+	}
+	if flag := cmd.Flags().Lookup("file"); flag != nil && flag.Changed {
+		opts.BodyFile, _ = cmd.Flags().GetString("file")
+	}
+
+	if strings.EqualFold(method, "HEAD") && !opts.BodyOnly && !opts.Silent {
+		opts.HeadersOnly = true
+	}
+
+	ctx, stop := interruptContext(cmd)
+	defer stop()
+	return interrupted(ctx, executeFromOptions(ctx, method, args[0], opts))
+}
+
+// optionsFromFlags reads the common, display, network and stream flags.
+func optionsFromFlags(cmd *cobra.Command) (ExecuteOptions, error) {
 	headerFlags, _ := cmd.Flags().GetStringSlice("header")
 	queryFlags, _ := cmd.Flags().GetStringSlice("query")
 	varFlags, _ := cmd.Flags().GetStringSlice("var")
@@ -335,11 +465,11 @@ func executeRequest(cmd *cobra.Command, method string, args []string) error {
 
 	formFields, err := parseFieldSlice(formFlags)
 	if err != nil {
-		return err
+		return ExecuteOptions{}, err
 	}
 	urlencodedFields, err := parseFieldSlice(urlencodedFlags)
 	if err != nil {
-		return err
+		return ExecuteOptions{}, err
 	}
 
 	timeoutSeconds, _ := cmd.Flags().GetInt("timeout")
@@ -350,6 +480,7 @@ func executeRequest(cmd *cobra.Command, method string, args []string) error {
 	headersOnly, _ := cmd.Flags().GetBool("headers-only")
 	bodyOnly, _ := cmd.Flags().GetBool("body-only")
 	silent, _ := cmd.Flags().GetBool("silent")
+	timing, _ := cmd.Flags().GetBool("timing")
 
 	opts := ExecuteOptions{
 		Headers:     parseKeyValueSlice(headerFlags, ":"),
@@ -363,25 +494,29 @@ func executeRequest(cmd *cobra.Command, method string, args []string) error {
 		BodyOnly:    bodyOnly,
 		Silent:      silent,
 		OutputFile:  outputFile,
+		Timing:      timing,
 		NoFollow:    noFollow,
 		Timeout:     time.Duration(timeoutSeconds) * time.Second,
 	}
 	if err := applyAdvancedNetworkFlags(cmd, &opts); err != nil {
-		return err
+		return ExecuteOptions{}, err
 	}
-
-	if flag := cmd.Flags().Lookup("data"); flag != nil && flag.Changed {
-		opts.Body, _ = cmd.Flags().GetString("data")
-	}
-	if flag := cmd.Flags().Lookup("file"); flag != nil && flag.Changed {
-		opts.BodyFile, _ = cmd.Flags().GetString("file")
+	if err := applyStreamFlags(cmd, &opts); err != nil {
+		return ExecuteOptions{}, err
 	}
+	return opts, nil
+}
 
-	if strings.EqualFold(method, "HEAD") && !opts.BodyOnly && !opts.Silent {
-		opts.HeadersOnly = true
+// timingPhases lays the phases of t out on the request timeline.
+func timingPhases(t *apixhttp.Timing) []output.TimingPhase {
+	connected := t.DNS + t.Connect + t.TLS
+	return []output.TimingPhase{
+		{Name: "DNS", Start: 0, Duration: t.DNS},
+		{Name: "Connect", Start: t.DNS, Duration: t.Connect},
+		{Name: "TLS", Start: t.DNS + t.Connect, Duration: t.TLS},
+		{Name: "Wait", Start: max(connected, t.TTFB-t.Wait), Duration: t.Wait},
+		{Name: "Transfer", Start: t.TTFB, Duration: t.Transfer},
 	}
-
-	return executeFromOptions(method, path, opts)
 }
 
 func addCommonFlags(cmd *cobra.Command) {
@@ -400,12 +535,14 @@ func addExecutionFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP("output", "o", "", "Write response body to a file")
 	cmd.Flags().IntP("timeout", "t", 0, "Request timeout in seconds (overrides config)")
 	cmd.Flags().Bool("no-follow", false, "Do not follow redirects")
+	cmd.Flags().Bool("timing", false, "Show DNS, connect, TLS, wait and transfer times")
+	addStreamFlags(cmd)
 	addAdvancedNetworkFlags(cmd)
 }
 
 func addAdvancedNetworkFlags(cmd *cobra.Command) {
-	cmd.Flags().Int("retry", 0, "Retry count on network errors and 5xx responses")
-	cmd.Flags().Duration("retry-delay", apixhttp.DefaultRetryDelay, "Base delay between retries (e.g. 200ms, 1s)")
+	cmd.Flags().Int("retry", 0, "Retry count on network errors and retryable statuses (overrides retry.attempts)")
+	cmd.Flags().Duration("retry-delay", apixhttp.DefaultRetryDelay, "Base delay between retries (e.g. 200ms, 1s; overrides retry.delay)")
 	cmd.Flags().String("proxy", "", "Proxy URL (e.g. http://localhost:8080)")
 	cmd.Flags().BoolP("insecure", "k", false, "Allow insecure TLS connections")
 	cmd.Flags().String("cert", "", "Client TLS certificate file")
@@ -438,6 +575,17 @@ func buildRequestBody(opts ExecuteOptions, vars map[string]string) (io.Reader, s
 		return nil, "", "", fmt.Errorf("--data, --file, --form, and --urlencoded are mutually exclusive")
 	}
 
+	if opts.GraphQL != nil {
+		if modeCount > 0 {
+			return nil, "", "", fmt.Errorf("graphql and a request body cannot be combined")
+		}
+		bodyStr, err := opts.GraphQL.BuildBody(vars)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return strings.NewReader(bodyStr), bodyStr, "application/json", nil
+	}
+
 	if opts.BodyFile != "" {
 		data, err := os.ReadFile(opts.BodyFile)
 		if err != nil {
@@ -565,8 +713,10 @@ func hasHeader(headers map[string]string, key string) bool {
 }
 
 func buildURL(base, path string) string {
-	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		return path
+	for _, scheme := range []string{"http://", "https://", "ws://", "wss://"} {
+		if strings.HasPrefix(path, scheme) {
+			return path
+		}
 	}
 	base = strings.TrimRight(base, "/")
 	if !strings.HasPrefix(path, "/") {
@@ -627,7 +777,7 @@ func applyAdvancedNetworkFlags(cmd *cobra.Command, opts *ExecuteOptions) error {
 		opts.Retry = retry
 	}
 
-	if flag := cmd.Flags().Lookup("retry-delay"); flag != nil {
+	if flag := cmd.Flags().Lookup("retry-delay"); flag != nil && flag.Changed {
 		delay, err := cmd.Flags().GetDuration("retry-delay")
 		if err != nil {
 			return err
@@ -23,6 +23,7 @@ func newRunCmd() *cobra.Command {
 			headersOnly, _ := cmd.Flags().GetBool("headers-only")
 			bodyOnly, _ := cmd.Flags().GetBool("body-only")
 			silent, _ := cmd.Flags().GetBool("silent")
+			timing, _ := cmd.Flags().GetBool("timing")
 			envOverride, _ := cmd.Flags().GetString("env")
 
 			varFlags, _ := cmd.Flags().GetStringSlice("var")
@@ -36,6 +37,7 @@ func newRunCmd() *cobra.Command {
 				BodyOnly:    bodyOnly,
 				Silent:      silent,
 				OutputFile:  outputFile,
+				Timing:      timing,
 				NoFollow:    noFollow,
 				Timeout:     time.Duration(timeoutSeconds) * time.Second,
 				EnvOverride: envOverride,
@@ -43,8 +45,13 @@ func newRunCmd() *cobra.Command {
 			if err := applyAdvancedNetworkFlags(cmd, &opts); err != nil {
 				return err
 			}
+			if err := applyStreamFlags(cmd, &opts); err != nil {
+				return err
+			}
 
-			return executeSavedRequest(name, opts)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			return interrupted(ctx, executeSavedRequest(ctx, name, opts))
 		},
 	}
 
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -62,7 +63,7 @@ auth:
 		t.Fatalf("writing request file: %v", err)
 	}
 
-	err := executeSavedRequest("ping", ExecuteOptions{
+	err := executeSavedRequest(context.Background(), "ping", ExecuteOptions{
 		EnvOverride:    "staging",
 		Silent:         true,
 		SuppressOutput: true,
@@ -116,7 +117,7 @@ auth:
 		t.Fatalf("writing request file: %v", err)
 	}
 
-	if err := executeSavedRequest("ping", ExecuteOptions{
+	if err := executeSavedRequest(context.Background(), "ping", ExecuteOptions{
 		Silent:         true,
 		SuppressOutput: true,
 	}); err != nil {
@@ -139,6 +140,9 @@ auth:
 	if entries[0].Path == "" {
 		t.Fatalf("expected non-empty path")
 	}
+	if _, ok := entries[0].Timing["ttfb"]; !ok {
+		t.Fatalf("expected timing in history entry, got %v", entries[0].Timing)
+	}
 }
 
 func containsLine(content, expected string) bool {
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/output"
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"github.com/spf13/cobra"
+)
+
+func addStreamFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("stream", false, "Read the response as a Server-Sent Events stream")
+	cmd.Flags().Int("max-events", 0, "Stop streaming after N events")
+	cmd.Flags().Duration("stream-duration", 0, "Stop streaming after a duration (e.g. 30s)")
+	cmd.Flags().Int("reconnect", 0, "Reconnect up to N times when the stream closes (sends Last-Event-ID)")
+	cmd.Flags().String("last-event-id", "", "Last-Event-ID sent on the first connection")
+}
+
+// applyStreamFlags enables SSE streaming when --stream or any stream limit is
+// given on the command line.
+func applyStreamFlags(cmd *cobra.Command, opts *ExecuteOptions) error {
+	if cmd == nil || opts == nil || cmd.Flags().Lookup("stream") == nil {
+		return nil
+	}
+
+	enabled, _ := cmd.Flags().GetBool("stream")
+	for _, name := range []string{"max-events", "stream-duration", "reconnect", "last-event-id"} {
+		if cmd.Flags().Changed(name) {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return nil
+	}
+
+	maxEvents, _ := cmd.Flags().GetInt("max-events")
+	duration, _ := cmd.Flags().GetDuration("stream-duration")
+	reconnect, _ := cmd.Flags().GetInt("reconnect")
+	lastEventID, _ := cmd.Flags().GetString("last-event-id")
+	if maxEvents < 0 || reconnect < 0 || duration < 0 {
+		return fmt.Errorf("--max-events, --stream-duration and --reconnect must be >= 0")
+	}
+
+	stream := &request.Stream{
+		Type:        request.StreamSSE,
+		MaxEvents:   maxEvents,
+		Reconnect:   reconnect,
+		LastEventID: lastEventID,
+	}
+	if duration > 0 {
+		stream.Duration = duration.String()
+	}
+	opts.Stream = stream
+	return nil
+}
+
+func streamRequest(ctx context.Context, client *apixhttp.Client, reqOpts apixhttp.RequestOptions, method, path string, opts ExecuteOptions) (*apixhttp.Response, error) {
+	if err := opts.Stream.Validate(); err != nil {
+		return nil, err
+	}
+	duration, err := opts.Stream.ParsedDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	printStatus := !opts.SuppressOutput && !opts.Silent && !opts.BodyOnly
+	printHeaders := printStatus && (opts.Verbose || opts.HeadersOnly)
+	printEvents := !opts.SuppressOutput && !opts.HeadersOnly && opts.OutputFile == ""
+	rawEvents := opts.Raw || opts.Silent || opts.BodyOnly
+
+	return client.Stream(ctx, reqOpts, apixhttp.StreamOptions{
+		MaxEvents:   opts.Stream.MaxEvents,
+		Duration:    duration,
+		Reconnect:   opts.Stream.Reconnect,
+		LastEventID: opts.Stream.LastEventID,
+		OnOpen: func(resp *apixhttp.Response) {
+			if printStatus {
+				output.PrintStreamOpen(method, path, resp.StatusCode, resp.Status)
+			}
+			if printHeaders {
+				output.PrintHeaders(resp.Headers)
+			}
+		},
+		OnEvent: func(event apixhttp.SSEEvent) {
+			if printEvents {
+				output.PrintSSEEvent(event.ID, event.Event, event.Data, rawEvents)
+			}
+		},
+	})
+}
@@ -1,7 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
 	"github.com/Tresor-Kasend/apix/internal/output"
@@ -14,7 +18,7 @@ func newTestCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "test [name]",
 		Short: "Run request assertions",
-		Long:  "Run tests from saved requests that define an expect block.",
+		Long:  "Run tests from saved requests that define an expect block. With --openapi, every saved request is also validated against its operation in the spec.",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := ""
@@ -22,6 +26,13 @@ func newTestCmd() *cobra.Command {
 				name = args[0]
 			}
 
+			reporterFlags, _ := cmd.Flags().GetStringSlice("reporter")
+			reportFiles, _ := cmd.Flags().GetStringSlice("report-file")
+			reporters, err := parseReporters(reporterFlags, reportFiles)
+			if err != nil {
+				return err
+			}
+
 			dir, _ := cmd.Flags().GetString("dir")
 			envOverride, _ := cmd.Flags().GetString("env")
 			varFlags, _ := cmd.Flags().GetStringSlice("var")
@@ -33,12 +44,34 @@ func newTestCmd() *cobra.Command {
 				return err
 			}
 
-			suite, err := tester.Run(tester.RunnerOptions{
+			parallel, _ := cmd.Flags().GetInt("parallel")
+			if parallel < 1 {
+				return fmt.Errorf("--parallel must be at least 1")
+			}
+
+			runnerOpts := tester.RunnerOptions{
 				Name:        name,
 				Dir:         dir,
 				Vars:        flagVars,
 				EnvOverride: envOverride,
-			}, func(requestName string, saved *request.SavedRequest, vars map[string]string, env string) (*apixhttp.Response, error) {
+				Parallel:    parallel,
+			}
+			runnerOpts.Data, _ = cmd.Flags().GetString("data")
+			runnerOpts.UpdateSnapshots, _ = cmd.Flags().GetBool("update-snapshots")
+			if specPath, _ := cmd.Flags().GetString("openapi"); specPath != "" {
+				contract, err := tester.LoadOpenAPIContract(specPath)
+				if err != nil {
+					return err
+				}
+				runnerOpts.OpenAPI = contract
+			}
+
+			pool := apixhttp.NewPool()
+			defer closeRunPool(cmd, pool)
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+
+			suite, err := tester.Run(ctx, runnerOpts, func(ctx context.Context, requestName string, saved *request.SavedRequest, vars map[string]string, env string) (*apixhttp.Response, error) {
 				opts := ExecuteOptions{
 					Vars:           vars,
 					EnvOverride:    env,
@@ -50,11 +83,12 @@ func newTestCmd() *cobra.Command {
 					CertFile:       baseOpts.CertFile,
 					KeyFile:        baseOpts.KeyFile,
 					NoCookies:      baseOpts.NoCookies,
+					Pool:           pool,
 					SkipSaveLast:   true,
 					SuppressOutput: true,
 					Silent:         true,
 				}
-				return executeSavedDefinitionWithResponse(requestName, saved, opts)
+				return executeSavedDefinitionWithResponse(ctx, requestName, saved, opts)
 			})
 			if err != nil {
 				return err
@@ -65,10 +99,12 @@ func newTestCmd() *cobra.Command {
 				return nil
 			}
 
-			for _, result := range suite.Results {
-				output.PrintTestResult(result)
+			if err := writeTestReports(reporters, reportFiles, *suite); err != nil {
+				return err
+			}
+			if suite.Interrupted {
+				return ErrInterrupted
 			}
-			output.PrintTestSummary(*suite)
 
 			if suite.ExitCode() != 0 {
 				return fmt.Errorf("%d test(s) failed", suite.Failed)
@@ -80,6 +116,80 @@ func newTestCmd() *cobra.Command {
 	cmd.Flags().String("dir", "", "Directory containing request YAML files to test")
 	cmd.Flags().StringSliceP("var", "V", nil, "Variables (key=value)")
 	cmd.Flags().String("env", "", "Use a specific environment for this test run only")
-	addAdvancedNetworkFlags(cmd)
+	cmd.Flags().Int("parallel", 1, "Number of requests to run concurrently")
+	cmd.Flags().String("data", "", "CSV/JSON/YAML dataset: run each request once per row")
+	cmd.Flags().Bool("update-snapshots", false, "Re-record snapshots of requests with expect.snapshot")
+	cmd.Flags().String("openapi", "", "Validate responses against an OpenAPI 3 / Swagger 2 spec")
+	cmd.Flags().StringSlice("reporter", []string{output.ReporterPretty}, "Result reporter: pretty, junit, json or tap (repeatable)")
+	cmd.Flags().StringSlice("report-file", nil, "Write non-pretty reports to file, in --reporter order (repeatable)")
+	addRunFlags(cmd)
 	return cmd
 }
+
+// parseReporters validates --reporter values. Report files are assigned to
+// the non-pretty reporters in order; reporters without a file write to stdout.
+func parseReporters(names, files []string) ([]string, error) {
+	reporters := make([]string, 0, len(names))
+	fileReporters := 0
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !output.IsTestReporter(name) {
+			return nil, fmt.Errorf("unknown reporter %q (expected pretty, junit, json or tap)", name)
+		}
+		if name != output.ReporterPretty {
+			fileReporters++
+		}
+		reporters = append(reporters, name)
+	}
+	if len(reporters) == 0 {
+		reporters = append(reporters, output.ReporterPretty)
+	}
+	if len(files) > fileReporters {
+		return nil, fmt.Errorf("got %d --report-file value(s) for %d junit/json/tap reporter(s)", len(files), fileReporters)
+	}
+	return reporters, nil
+}
+
+func writeTestReports(reporters, files []string, suite tester.SuiteResult) error {
+	fileIndex := 0
+	for _, reporter := range reporters {
+		if reporter == output.ReporterPretty {
+			for _, result := range suite.Results {
+				output.PrintTestResult(result)
+			}
+			output.PrintTestSummary(suite)
+			continue
+		}
+
+		if fileIndex >= len(files) {
+			if err := output.WriteTestReport(os.Stdout, reporter, suite); err != nil {
+				return err
+			}
+			continue
+		}
+
+		path := files[fileIndex]
+		fileIndex++
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("creating report directory %q: %w", dir, err)
+			}
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating report file %q: %w", path, err)
+		}
+		writeErr := output.WriteTestReport(file, reporter, suite)
+		closeErr := file.Close()
+		if writeErr != nil {
+			return writeErr
+		}
+		if closeErr != nil {
+			return fmt.Errorf("writing report file %q: %w", path, closeErr)
+		}
+	}
+	return nil
+}
@@ -4,10 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"strings"
-	"syscall"
 
 	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
 	"github.com/Tresor-Kasend/apix/internal/output"
@@ -42,7 +40,10 @@ func newWatchCmd() *cobra.Command {
 				return err
 			}
 
-			executor, err := watch.NewExecutor(func(requestName string, vars map[string]string, env string) (*apixhttp.Response, error) {
+			pool := apixhttp.NewPool()
+			defer closeRunPool(cmd, pool)
+
+			executor, err := watch.NewExecutor(func(ctx context.Context, requestName string, vars map[string]string, env string) (*apixhttp.Response, error) {
 				opts := ExecuteOptions{
 					Vars:           vars,
 					EnvOverride:    env,
@@ -54,11 +55,12 @@ func newWatchCmd() *cobra.Command {
 					CertFile:       baseOpts.CertFile,
 					KeyFile:        baseOpts.KeyFile,
 					NoCookies:      baseOpts.NoCookies,
+					Pool:           pool,
 					SkipSaveLast:   true,
 					SuppressOutput: true,
 					Silent:         true,
 				}
-				return executeSavedRequestWithResponse(requestName, opts)
+				return executeSavedRequestWithResponse(ctx, requestName, opts)
 			}, watch.ExecutorOptions{})
 			if err != nil {
 				return err
@@ -70,7 +72,7 @@ func newWatchCmd() *cobra.Command {
 			}
 			output.PrintInfo(fmt.Sprintf("Watching %s (%s). Press Ctrl+C to stop.", watchPath, watchMode))
 
-			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			ctx, stop := interruptContext(cmd)
 			defer stop()
 
 			iteration := 0
@@ -80,7 +82,10 @@ func newWatchCmd() *cobra.Command {
 			}, func(trigger watch.Trigger) {
 				iteration++
 
-				result, runErr := executor.Run(name, flagVars, envOverride)
+				result, runErr := executor.Run(ctx, name, flagVars, envOverride)
+				if ctx.Err() != nil {
+					return
+				}
 				if runErr != nil {
 					output.PrintInfo(fmt.Sprintf("[%s] #%d %s -> error: %v", trigger.Time.Format("15:04:05"), iteration, trigger.Reason, runErr))
 					return
@@ -114,6 +119,6 @@ func newWatchCmd() *cobra.Command {
 	cmd.Flags().StringSliceP("var", "V", nil, "Variables (key=value)")
 	cmd.Flags().String("env", "", "Use a specific environment for this watch session")
 	cmd.Flags().Duration("interval", 0, "Polling interval for file changes (e.g. 5s)")
-	addAdvancedNetworkFlags(cmd)
+	addRunFlags(cmd)
 	return cmd
 }
@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/config"
+	"github.com/Tresor-Kasend/apix/internal/history"
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/output"
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"github.com/Tresor-Kasend/apix/internal/ws"
+	"github.com/spf13/cobra"
+)
+
+func newWSCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ws <path>",
+		Short: "Open a WebSocket connection",
+		Long:  "Connect to a WebSocket endpoint using the configured base URL, headers and auth. Lines read from stdin are sent as text frames and received frames are printed as they arrive; --send sends scripted messages instead.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			headerFlags, _ := cmd.Flags().GetStringSlice("header")
+			queryFlags, _ := cmd.Flags().GetStringSlice("query")
+			varFlags, _ := cmd.Flags().GetStringSlice("var")
+			envOverride, _ := cmd.Flags().GetString("env")
+			sends, _ := cmd.Flags().GetStringArray("send")
+			wait, _ := cmd.Flags().GetDuration("wait")
+			timeoutSeconds, _ := cmd.Flags().GetInt("timeout")
+			insecure, _ := cmd.Flags().GetBool("insecure")
+			subprotocols, _ := cmd.Flags().GetStringSlice("subprotocol")
+			raw, _ := cmd.Flags().GetBool("raw")
+
+			opts := ExecuteOptions{
+				Headers:     parseKeyValueSlice(headerFlags, ":"),
+				Query:       parseQueryFlags(queryFlags),
+				Vars:        parseKeyValueSlice(varFlags, "="),
+				EnvOverride: envOverride,
+				Insecure:    insecure,
+				Timeout:     time.Duration(timeoutSeconds) * time.Second,
+			}
+			cfg, err := config.LoadWithEnvOverride(opts.EnvOverride)
+			if err != nil {
+				return err
+			}
+			ctx, stop := interruptContext(cmd)
+			defer stop()
+			target, headers, vars, err := resolveWebSocketTarget(ctx, cfg, args[0], opts)
+			if err != nil {
+				return interrupted(ctx, err)
+			}
+
+			conn, handshake, err := ws.Dial(ctx, target, ws.DialOptions{
+				Headers:      headers,
+				Timeout:      webSocketTimeout(cfg, opts),
+				Insecure:     opts.Insecure,
+				Subprotocols: subprotocols,
+			})
+			if err != nil {
+				return interrupted(ctx, err)
+			}
+			defer conn.Close()
+			if !raw {
+				output.PrintInfo(fmt.Sprintf("Connected to %s (%s)", target, handshake.Status))
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				for {
+					message, err := conn.ReadMessage()
+					if err != nil {
+						done <- err
+						return
+					}
+					output.PrintWSMessage(false, message.Data, message.Type == ws.BinaryMessage, raw)
+				}
+			}()
+
+			send := func(text string) error {
+				text = request.ResolveVariables(text, vars)
+				if err := conn.WriteText(text); err != nil {
+					return err
+				}
+				output.PrintWSMessage(true, []byte(text), false, raw)
+				return nil
+			}
+			// stdin reads cannot be cancelled, so the messages are sent from
+			// a goroutine and Ctrl-C is watched alongside it.
+			sent := make(chan error, 1)
+			go func() {
+				if len(sends) > 0 {
+					for _, text := range sends {
+						if err := send(text); err != nil {
+							sent <- err
+							return
+						}
+					}
+					sent <- nil
+					return
+				}
+				scanner := bufio.NewScanner(os.Stdin)
+				scanner.Buffer(make([]byte, 64*1024), 16<<20)
+				for scanner.Scan() {
+					if line := scanner.Text(); line != "" {
+						if err := send(line); err != nil {
+							sent <- err
+							return
+						}
+					}
+				}
+				sent <- nil
+			}()
+			select {
+			case err := <-sent:
+				if err != nil {
+					return interrupted(ctx, err)
+				}
+			case <-ctx.Done():
+				return ErrInterrupted
+			}
+
+			select {
+			case err := <-done:
+				if ctx.Err() != nil {
+					return ErrInterrupted
+				}
+				if closeErr, ok := err.(*ws.CloseError); ok {
+					if !raw {
+						output.PrintInfo(closeErr.Error())
+					}
+					return nil
+				}
+				return err
+			case <-ctx.Done():
+				return ErrInterrupted
+			case <-time.After(wait):
+				return nil
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceP("header", "H", nil, "Additional handshake headers (key:value)")
+	cmd.Flags().StringSliceP("query", "q", nil, "Query parameters (key=value or key1=v1&key2=v2)")
+	cmd.Flags().StringSliceP("var", "V", nil, "Variables (key=value)")
+	cmd.Flags().String("env", "", "Use a specific environment for this connection only")
+	cmd.Flags().StringArray("send", nil, "Send a text message instead of reading stdin (repeatable)")
+	cmd.Flags().Duration("wait", time.Second, "How long to keep receiving after the last message is sent")
+	cmd.Flags().StringSlice("subprotocol", nil, "Sec-WebSocket-Protocol values to offer")
+	cmd.Flags().IntP("timeout", "t", 0, "Connection timeout in seconds (overrides config)")
+	cmd.Flags().BoolP("insecure", "k", false, "Allow insecure TLS connections")
+	cmd.Flags().Bool("raw", false, "Print received messages only")
+	return cmd
+}
+
+// executeWebSocketSession plays a saved request with protocol: websocket. The
+// returned response has status 101, the handshake headers and, as body, the
+// JSON list of received messages.
+func executeWebSocketSession(ctx context.Context, saved *request.SavedRequest, opts ExecuteOptions) (*apixhttp.Response, error) {
+	cfg, err := config.LoadWithEnvOverride(opts.EnvOverride)
+	if err != nil {
+		return nil, err
+	}
+	target, headers, vars, err := resolveWebSocketTarget(ctx, cfg, saved.Path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	printMessages := !opts.SuppressOutput
+	raw := opts.Raw || opts.Silent || opts.BodyOnly
+	if printMessages && !raw {
+		output.PrintInfo(fmt.Sprintf("WebSocket %s", target))
+	}
+
+	result, err := ws.RunSession(ctx, ws.Session{
+		URL: target,
+		Dial: ws.DialOptions{
+			Headers:  headers,
+			Timeout:  webSocketTimeout(cfg, opts),
+			Insecure: opts.Insecure,
+		},
+		Messages: saved.Messages,
+		Vars:     vars,
+		OnSend: func(text string) {
+			if printMessages {
+				output.PrintWSMessage(true, []byte(text), false, raw)
+			}
+		},
+		OnReceive: func(message ws.Message) {
+			if printMessages {
+				output.PrintWSMessage(false, message.Data, message.Type == ws.BinaryMessage, raw)
+			}
+		},
+	})
+	if err != nil {
+		_ = history.Append(history.Entry{Method: "WS", Path: target, Request: opts.RequestName})
+		return nil, fmt.Errorf("websocket session: %w", err)
+	}
+
+	body, err := ws.Transcript(result.Received)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apixhttp.Response{
+		Method:     "GET",
+		URL:        target,
+		StatusCode: result.Handshake.StatusCode,
+		Status:     result.Handshake.Status,
+		Headers:    result.Handshake.Header,
+		Body:       body,
+		Duration:   result.Duration,
+	}
+	_ = history.Append(history.Entry{
+		Method:       "WS",
+		Path:         target,
+		Request:      opts.RequestName,
+		Status:       resp.StatusCode,
+		DurationMS:   resp.Duration.Milliseconds(),
+		ResponseSize: len(body),
+	})
+	if printMessages && !raw {
+		output.PrintInfo(fmt.Sprintf("%d message(s) received, %d variable(s) captured in %dms",
+			len(result.Received), len(result.Captured), result.Duration.Milliseconds()))
+	}
+	if err := writeOutputFile(opts.OutputFile, body); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// resolveWebSocketTarget resolves the URL (config base URL, ws scheme, query)
+// and handshake headers of a WebSocket request.
+func resolveWebSocketTarget(ctx context.Context, cfg *config.Config, path string, opts ExecuteOptions) (string, map[string]string, map[string]string, error) {
+	urlStr, headers, vars, err := resolveTarget(ctx, cfg, path, opts)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	target, err := ws.URLFromHTTP(urlStr)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(opts.Query) > 0 {
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("invalid websocket URL %q: %w", target, err)
+		}
+		query := parsed.Query()
+		for k, v := range opts.Query {
+			query.Set(k, request.ResolveVariables(v, vars))
+		}
+		parsed.RawQuery = query.Encode()
+		target = parsed.String()
+	}
+	for k := range headers {
+		if strings.EqualFold(k, "Content-Type") {
+			delete(headers, k)
+		}
+	}
+	return target, headers, vars, nil
+}
+
+func webSocketTimeout(cfg *config.Config, opts ExecuteOptions) time.Duration {
+	if opts.Timeout > 0 {
+		return opts.Timeout
+	}
+	return time.Duration(cfg.Timeout) * time.Second
+}
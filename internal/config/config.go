@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/Tresor-Kasend/apix/internal/env"
+	"github.com/Tresor-Kasend/apix/internal/fsutil"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +22,11 @@ type Config struct {
 	Auth       AuthConfig        `mapstructure:"auth"       yaml:"auth"                   json:"auth"`
 	CurrentEnv string            `mapstructure:"current_env" yaml:"current_env"           json:"current_env"`
 	Variables  map[string]string `mapstructure:"variables"  yaml:"variables,omitempty"    json:"variables,omitempty"`
+	Retry      RetryConfig       `mapstructure:"retry"      yaml:"retry,omitempty"        json:"retry,omitzero"`
+	RateLimit  RateLimitConfig   `mapstructure:"rate_limit" yaml:"rate_limit,omitempty"   json:"rate_limit,omitzero"`
+	// ActiveEnv is the environment overlaid on the config (current_env or an
+	// --env override).
+	ActiveEnv string `mapstructure:"-" yaml:"-" json:"-"`
 }
 
 type AuthConfig struct {
@@ -32,6 +39,79 @@ type AuthConfig struct {
 	Username     string `mapstructure:"username"      yaml:"username,omitempty"     json:"username,omitempty"`
 	Password     string `mapstructure:"password"      yaml:"password,omitempty"     json:"password,omitempty"`
 	APIKey       string `mapstructure:"api_key"       yaml:"api_key,omitempty"      json:"api_key,omitempty"`
+
+	// OAuth 2.0 (type: oauth2)
+	GrantType    string   `mapstructure:"grant_type"    yaml:"grant_type,omitempty"    json:"grant_type,omitempty"`
+	TokenURL     string   `mapstructure:"token_url"     yaml:"token_url,omitempty"     json:"token_url,omitempty"`
+	ClientID     string   `mapstructure:"client_id"     yaml:"client_id,omitempty"     json:"client_id,omitempty"`
+	ClientSecret string   `mapstructure:"client_secret" yaml:"client_secret,omitempty" json:"client_secret,omitempty"`
+	Scopes       []string `mapstructure:"scopes"        yaml:"scopes,omitempty"        json:"scopes,omitempty"`
+	Audience     string   `mapstructure:"audience"      yaml:"audience,omitempty"      json:"audience,omitempty"`
+	RefreshToken string   `mapstructure:"refresh_token" yaml:"refresh_token,omitempty" json:"refresh_token,omitempty"`
+	AuthorizeURL string   `mapstructure:"authorize_url" yaml:"authorize_url,omitempty" json:"authorize_url,omitempty"`
+	RedirectURI  string   `mapstructure:"redirect_uri"  yaml:"redirect_uri,omitempty"  json:"redirect_uri,omitempty"`
+
+	// AWS Signature Version 4 (type: aws_sigv4)
+	Region          string `mapstructure:"region"            yaml:"region,omitempty"            json:"region,omitempty"`
+	Service         string `mapstructure:"service"           yaml:"service,omitempty"           json:"service,omitempty"`
+	AccessKeyID     string `mapstructure:"access_key_id"     yaml:"access_key_id,omitempty"     json:"access_key_id,omitempty"`
+	SecretAccessKey string `mapstructure:"secret_access_key" yaml:"secret_access_key,omitempty" json:"secret_access_key,omitempty"`
+	SessionToken    string `mapstructure:"session_token"     yaml:"session_token,omitempty"     json:"session_token,omitempty"`
+
+	// HMAC request signing (type: hmac) and self-signed JWTs (type: jwt)
+	Secret            string                 `mapstructure:"secret"             yaml:"secret,omitempty"             json:"secret,omitempty"`
+	Algorithm         string                 `mapstructure:"algorithm"          yaml:"algorithm,omitempty"          json:"algorithm,omitempty"`
+	CanonicalString   string                 `mapstructure:"canonical_string"   yaml:"canonical_string,omitempty"   json:"canonical_string,omitempty"`
+	TimestampHeader   string                 `mapstructure:"timestamp_header"   yaml:"timestamp_header,omitempty"   json:"timestamp_header,omitempty"`
+	SignatureEncoding string                 `mapstructure:"signature_encoding" yaml:"signature_encoding,omitempty" json:"signature_encoding,omitempty"`
+	KeyFile           string                 `mapstructure:"key_file"           yaml:"key_file,omitempty"           json:"key_file,omitempty"`
+	KeyID             string                 `mapstructure:"key_id"             yaml:"key_id,omitempty"             json:"key_id,omitempty"`
+	Issuer            string                 `mapstructure:"issuer"             yaml:"issuer,omitempty"             json:"issuer,omitempty"`
+	Subject           string                 `mapstructure:"subject"            yaml:"subject,omitempty"            json:"subject,omitempty"`
+	ExpiresIn         string                 `mapstructure:"expires_in"         yaml:"expires_in,omitempty"         json:"expires_in,omitempty"`
+	Claims            map[string]interface{} `mapstructure:"claims"             yaml:"claims,omitempty"             json:"claims,omitempty"`
+}
+
+// RetryConfig is the retry: policy block of apix.yaml. Env files and saved
+// requests use the same fields and override the ones they set.
+type RetryConfig struct {
+	Attempts int      `mapstructure:"attempts"  yaml:"attempts,omitempty"  json:"attempts,omitempty"`
+	Delay    string   `mapstructure:"delay"     yaml:"delay,omitempty"     json:"delay,omitempty"`
+	MaxDelay string   `mapstructure:"max_delay" yaml:"max_delay,omitempty" json:"max_delay,omitempty"`
+	Jitter   *bool    `mapstructure:"jitter"    yaml:"jitter,omitempty"    json:"jitter,omitempty"`
+	Statuses []int    `mapstructure:"statuses"  yaml:"statuses,omitempty"  json:"statuses,omitempty"`
+	Methods  []string `mapstructure:"methods"   yaml:"methods,omitempty"   json:"methods,omitempty"`
+}
+
+// Merge returns r with the fields set in override replacing its own.
+func (r RetryConfig) Merge(override RetryConfig) RetryConfig {
+	if override.Attempts != 0 {
+		r.Attempts = override.Attempts
+	}
+	if override.Delay != "" {
+		r.Delay = override.Delay
+	}
+	if override.MaxDelay != "" {
+		r.MaxDelay = override.MaxDelay
+	}
+	if override.Jitter != nil {
+		r.Jitter = override.Jitter
+	}
+	if len(override.Statuses) > 0 {
+		r.Statuses = override.Statuses
+	}
+	if len(override.Methods) > 0 {
+		r.Methods = override.Methods
+	}
+	return r
+}
+
+// RateLimitConfig is the rate_limit: block of apix.yaml. An env file with a
+// rate_limit: block replaces it.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `mapstructure:"requests_per_second" yaml:"requests_per_second,omitempty" json:"requests_per_second,omitempty"`
+	Burst             int     `mapstructure:"burst"               yaml:"burst,omitempty"               json:"burst,omitempty"`
+	PerHost           bool    `mapstructure:"per_host"            yaml:"per_host,omitempty"            json:"per_host,omitempty"`
 }
 
 func Load() (*Config, error) {
@@ -53,6 +133,14 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("parsing apix.yaml: %w", err)
 	}
 
+	if len(cfg.Auth.Claims) > 0 {
+		claims, err := readClaims(v.ConfigFileUsed())
+		if err != nil {
+			return nil, err
+		}
+		cfg.Auth.Claims = claims
+	}
+
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30
 	}
@@ -68,12 +156,32 @@ func Load() (*Config, error) {
 	}
 
 	if cfg.CurrentEnv != "" {
-		_ = overlayEnv(&cfg, cfg.CurrentEnv)
+		if overlayEnv(&cfg, cfg.CurrentEnv) == nil {
+			cfg.ActiveEnv = cfg.CurrentEnv
+		}
 	}
 
 	return &cfg, nil
 }
 
+// readClaims reads auth.claims again with yaml.v3: viper lowercases map keys,
+// but JWT claim names are case-sensitive.
+func readClaims(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading apix.yaml: %w", err)
+	}
+	var raw struct {
+		Auth struct {
+			Claims map[string]interface{} `yaml:"claims"`
+		} `yaml:"auth"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing apix.yaml: %w", err)
+	}
+	return raw.Auth.Claims, nil
+}
+
 func LoadWithEnvOverride(envName string) (*Config, error) {
 	cfg, err := Load()
 	if err != nil {
@@ -85,15 +193,23 @@ func LoadWithEnvOverride(envName string) (*Config, error) {
 	if err := overlayEnv(cfg, envName); err != nil {
 		return nil, err
 	}
+	cfg.ActiveEnv = envName
 	return cfg, nil
 }
 
+var tokenMu sync.Mutex
+
+// SaveToken stores the auth token. Writes are serialised and go through a
+// rename so concurrent requests never read a truncated token.
 func SaveToken(token string) error {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+
 	if err := os.MkdirAll(".apix", 0o755); err != nil {
 		return fmt.Errorf("creating .apix directory: %w", err)
 	}
 	path := filepath.Join(".apix", "token")
-	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+	if err := fsutil.WriteFileAtomic(path, []byte(token), 0o600); err != nil {
 		return fmt.Errorf("saving token: %w", err)
 	}
 	return nil
@@ -150,12 +266,101 @@ func overlayEnv(cfg *Config, envName string) error {
 		if envCfg.Auth.APIKey != "" {
 			cfg.Auth.APIKey = envCfg.Auth.APIKey
 		}
+		if envCfg.Auth.GrantType != "" {
+			cfg.Auth.GrantType = envCfg.Auth.GrantType
+		}
+		if envCfg.Auth.TokenURL != "" {
+			cfg.Auth.TokenURL = envCfg.Auth.TokenURL
+		}
+		if envCfg.Auth.ClientID != "" {
+			cfg.Auth.ClientID = envCfg.Auth.ClientID
+		}
+		if envCfg.Auth.ClientSecret != "" {
+			cfg.Auth.ClientSecret = envCfg.Auth.ClientSecret
+		}
+		if len(envCfg.Auth.Scopes) > 0 {
+			cfg.Auth.Scopes = envCfg.Auth.Scopes
+		}
+		if envCfg.Auth.Audience != "" {
+			cfg.Auth.Audience = envCfg.Auth.Audience
+		}
+		if envCfg.Auth.RefreshToken != "" {
+			cfg.Auth.RefreshToken = envCfg.Auth.RefreshToken
+		}
+		if envCfg.Auth.AuthorizeURL != "" {
+			cfg.Auth.AuthorizeURL = envCfg.Auth.AuthorizeURL
+		}
+		if envCfg.Auth.RedirectURI != "" {
+			cfg.Auth.RedirectURI = envCfg.Auth.RedirectURI
+		}
+		if envCfg.Auth.Region != "" {
+			cfg.Auth.Region = envCfg.Auth.Region
+		}
+		if envCfg.Auth.Service != "" {
+			cfg.Auth.Service = envCfg.Auth.Service
+		}
+		if envCfg.Auth.AccessKeyID != "" {
+			cfg.Auth.AccessKeyID = envCfg.Auth.AccessKeyID
+		}
+		if envCfg.Auth.SecretAccessKey != "" {
+			cfg.Auth.SecretAccessKey = envCfg.Auth.SecretAccessKey
+		}
+		if envCfg.Auth.SessionToken != "" {
+			cfg.Auth.SessionToken = envCfg.Auth.SessionToken
+		}
+		if envCfg.Auth.Secret != "" {
+			cfg.Auth.Secret = envCfg.Auth.Secret
+		}
+		if envCfg.Auth.Algorithm != "" {
+			cfg.Auth.Algorithm = envCfg.Auth.Algorithm
+		}
+		if envCfg.Auth.CanonicalString != "" {
+			cfg.Auth.CanonicalString = envCfg.Auth.CanonicalString
+		}
+		if envCfg.Auth.TimestampHeader != "" {
+			cfg.Auth.TimestampHeader = envCfg.Auth.TimestampHeader
+		}
+		if envCfg.Auth.SignatureEncoding != "" {
+			cfg.Auth.SignatureEncoding = envCfg.Auth.SignatureEncoding
+		}
+		if envCfg.Auth.KeyFile != "" {
+			cfg.Auth.KeyFile = envCfg.Auth.KeyFile
+		}
+		if envCfg.Auth.KeyID != "" {
+			cfg.Auth.KeyID = envCfg.Auth.KeyID
+		}
+		if envCfg.Auth.Issuer != "" {
+			cfg.Auth.Issuer = envCfg.Auth.Issuer
+		}
+		if envCfg.Auth.Subject != "" {
+			cfg.Auth.Subject = envCfg.Auth.Subject
+		}
+		if envCfg.Auth.ExpiresIn != "" {
+			cfg.Auth.ExpiresIn = envCfg.Auth.ExpiresIn
+		}
+		if len(envCfg.Auth.Claims) > 0 {
+			claims := make(map[string]interface{}, len(cfg.Auth.Claims)+len(envCfg.Auth.Claims))
+			for k, v := range cfg.Auth.Claims {
+				claims[k] = v
+			}
+			for k, v := range envCfg.Auth.Claims {
+				claims[k] = v
+			}
+			cfg.Auth.Claims = claims
+		}
 	}
 
 	for k, v := range envCfg.Variables {
 		cfg.Variables[k] = v
 	}
 
+	if envCfg.Retry != nil {
+		cfg.Retry = cfg.Retry.Merge(RetryConfig(*envCfg.Retry))
+	}
+	if envCfg.RateLimit != nil {
+		cfg.RateLimit = RateLimitConfig(*envCfg.RateLimit)
+	}
+
 	return nil
 }
 
@@ -218,12 +423,47 @@ func defaultAuthConfig(authType string) map[string]interface{} {
 			"type":        "basic",
 			"header_name": "Authorization",
 		}
+	case "digest":
+		return map[string]interface{}{
+			"type":     "digest",
+			"username": "admin",
+			"password": "${DIGEST_PASSWORD}",
+		}
 	case "api_key":
 		return map[string]interface{}{
 			"type":          "api_key",
 			"header_name":   "X-API-Key",
 			"header_format": "${API_KEY}",
 		}
+	case "oauth2":
+		return map[string]interface{}{
+			"type":          "oauth2",
+			"grant_type":    "client_credentials",
+			"token_url":     "https://auth.example.com/oauth/token",
+			"client_id":     "${CLIENT_ID}",
+			"client_secret": "${CLIENT_SECRET}",
+		}
+	case "aws_sigv4":
+		return map[string]interface{}{
+			"type":    "aws_sigv4",
+			"region":  "us-east-1",
+			"service": "execute-api",
+		}
+	case "hmac":
+		return map[string]interface{}{
+			"type":             "hmac",
+			"secret":           "${HMAC_SECRET}",
+			"algorithm":        "sha256",
+			"canonical_string": "${METHOD}\n${PATH}\n${TIMESTAMP}\n${BODY_SHA256}",
+		}
+	case "jwt":
+		return map[string]interface{}{
+			"type":       "jwt",
+			"algorithm":  "RS256",
+			"key_file":   "keys/private.pem",
+			"subject":    "apix",
+			"expires_in": "5m",
+		}
 	case "custom":
 		return map[string]interface{}{
 			"type":          "custom",
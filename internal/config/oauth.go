@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/fsutil"
+)
+
+// OAuthToken is an OAuth 2.0 token cached per environment under .apix/oauth.
+type OAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	// Source identifies the token endpoint and client the token was issued
+	// for, so a cached token is dropped when the auth config changes.
+	Source string `json:"source,omitempty"`
+}
+
+func oauthTokenPath(envName string) string {
+	if envName == "" {
+		envName = "default"
+	}
+	return filepath.Join(".apix", "oauth", envName+".json")
+}
+
+// SaveOAuthToken caches the token of an environment.
+func SaveOAuthToken(envName string, token OAuthToken) error {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+
+	path := oauthTokenPath(envName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating oauth token directory: %w", err)
+	}
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding oauth token: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("saving oauth token: %w", err)
+	}
+	return nil
+}
+
+// LoadOAuthToken returns the cached token of an environment, or nil when
+// there is none.
+func LoadOAuthToken(envName string) (*OAuthToken, error) {
+	data, err := os.ReadFile(oauthTokenPath(envName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading oauth token: %w", err)
+	}
+	var token OAuthToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("parsing oauth token: %w", err)
+	}
+	return &token, nil
+}
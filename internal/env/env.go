@@ -14,18 +14,62 @@ type EnvConfig struct {
 	Headers   map[string]string `yaml:"headers,omitempty"`
 	Auth      *AuthOverride     `yaml:"auth,omitempty"`
 	Variables map[string]string `yaml:"variables,omitempty"`
+	Retry     *RetryOverride    `yaml:"retry,omitempty"`
+	RateLimit *RateLimit        `yaml:"rate_limit,omitempty"`
+}
+
+type RateLimit struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second,omitempty"`
+	Burst             int     `yaml:"burst,omitempty"`
+	PerHost           bool    `yaml:"per_host,omitempty"`
+}
+
+type RetryOverride struct {
+	Attempts int      `yaml:"attempts,omitempty"`
+	Delay    string   `yaml:"delay,omitempty"`
+	MaxDelay string   `yaml:"max_delay,omitempty"`
+	Jitter   *bool    `yaml:"jitter,omitempty"`
+	Statuses []int    `yaml:"statuses,omitempty"`
+	Methods  []string `yaml:"methods,omitempty"`
 }
 
 type AuthOverride struct {
-	Type         string `yaml:"type,omitempty"`
-	Token        string `yaml:"token,omitempty"`
-	TokenPath    string `yaml:"token_path,omitempty"`
-	HeaderName   string `yaml:"header_name,omitempty"`
-	HeaderFormat string `yaml:"header_format,omitempty"`
-	LoginRequest string `yaml:"login_request,omitempty"`
-	Username     string `yaml:"username,omitempty"`
-	Password     string `yaml:"password,omitempty"`
-	APIKey       string `yaml:"api_key,omitempty"`
+	Type         string   `yaml:"type,omitempty"`
+	Token        string   `yaml:"token,omitempty"`
+	TokenPath    string   `yaml:"token_path,omitempty"`
+	HeaderName   string   `yaml:"header_name,omitempty"`
+	HeaderFormat string   `yaml:"header_format,omitempty"`
+	LoginRequest string   `yaml:"login_request,omitempty"`
+	Username     string   `yaml:"username,omitempty"`
+	Password     string   `yaml:"password,omitempty"`
+	APIKey       string   `yaml:"api_key,omitempty"`
+	GrantType    string   `yaml:"grant_type,omitempty"`
+	TokenURL     string   `yaml:"token_url,omitempty"`
+	ClientID     string   `yaml:"client_id,omitempty"`
+	ClientSecret string   `yaml:"client_secret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	Audience     string   `yaml:"audience,omitempty"`
+	RefreshToken string   `yaml:"refresh_token,omitempty"`
+	AuthorizeURL string   `yaml:"authorize_url,omitempty"`
+	RedirectURI  string   `yaml:"redirect_uri,omitempty"`
+
+	Region          string `yaml:"region,omitempty"`
+	Service         string `yaml:"service,omitempty"`
+	AccessKeyID     string `yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `yaml:"secret_access_key,omitempty"`
+	SessionToken    string `yaml:"session_token,omitempty"`
+
+	Secret            string                 `yaml:"secret,omitempty"`
+	Algorithm         string                 `yaml:"algorithm,omitempty"`
+	CanonicalString   string                 `yaml:"canonical_string,omitempty"`
+	TimestampHeader   string                 `yaml:"timestamp_header,omitempty"`
+	SignatureEncoding string                 `yaml:"signature_encoding,omitempty"`
+	KeyFile           string                 `yaml:"key_file,omitempty"`
+	KeyID             string                 `yaml:"key_id,omitempty"`
+	Issuer            string                 `yaml:"issuer,omitempty"`
+	Subject           string                 `yaml:"subject,omitempty"`
+	ExpiresIn         string                 `yaml:"expires_in,omitempty"`
+	Claims            map[string]interface{} `yaml:"claims,omitempty"`
 }
 
 func Load(name string) (*EnvConfig, error) {
@@ -0,0 +1,212 @@
+// Package graphql holds the GraphQL helpers shared by apix gql, apix test and
+// flows: response error detection and schema introspection.
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IntrospectionQuery fetches the root operation types and their fields.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) {
+        name
+        description
+        args { name type { ...TypeRef } }
+        type { ...TypeRef }
+      }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// Operation kinds, in the order they are listed.
+const (
+	KindQuery        = "query"
+	KindMutation     = "mutation"
+	KindSubscription = "subscription"
+)
+
+type Operation struct {
+	Kind        string
+	Name        string
+	Description string
+	Args        []Argument
+	Type        string
+}
+
+type Argument struct {
+	Name string
+	Type string
+}
+
+// Signature renders the operation as name(arg: Type, ...): ReturnType.
+func (o Operation) Signature() string {
+	var b strings.Builder
+	b.WriteString(o.Name)
+	if len(o.Args) > 0 {
+		parts := make([]string, 0, len(o.Args))
+		for _, arg := range o.Args {
+			parts = append(parts, arg.Name+": "+arg.Type)
+		}
+		b.WriteString("(" + strings.Join(parts, ", ") + ")")
+	}
+	b.WriteString(": " + o.Type)
+	return b.String()
+}
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+func (t *typeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+type introspectionResponse struct {
+	Data struct {
+		Schema struct {
+			QueryType        *struct{ Name string } `json:"queryType"`
+			MutationType     *struct{ Name string } `json:"mutationType"`
+			SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+			Types            []struct {
+				Name   string `json:"name"`
+				Fields []struct {
+					Name        string `json:"name"`
+					Description string `json:"description"`
+					Args        []struct {
+						Name string   `json:"name"`
+						Type *typeRef `json:"type"`
+					} `json:"args"`
+					Type *typeRef `json:"type"`
+				} `json:"fields"`
+			} `json:"types"`
+		} `json:"__schema"`
+	} `json:"data"`
+	Errors []responseError `json:"errors"`
+}
+
+// ParseIntrospection lists the queries, mutations and subscriptions of an
+// introspection response, each group sorted by name.
+func ParseIntrospection(body []byte) ([]Operation, error) {
+	var resp introspectionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing introspection response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s", strings.Join(errorMessages(resp.Errors), "; "))
+	}
+
+	schema := resp.Data.Schema
+	roots := []struct {
+		kind string
+		name string
+	}{
+		{KindQuery, rootName(schema.QueryType)},
+		{KindMutation, rootName(schema.MutationType)},
+		{KindSubscription, rootName(schema.SubscriptionType)},
+	}
+	if roots[0].name == "" {
+		return nil, fmt.Errorf("introspection response has no query type")
+	}
+
+	operations := make([]Operation, 0)
+	for _, root := range roots {
+		if root.name == "" {
+			continue
+		}
+		for _, t := range schema.Types {
+			if t.Name != root.name {
+				continue
+			}
+			group := make([]Operation, 0, len(t.Fields))
+			for _, field := range t.Fields {
+				op := Operation{
+					Kind:        root.kind,
+					Name:        field.Name,
+					Description: strings.TrimSpace(field.Description),
+					Type:        field.Type.String(),
+				}
+				for _, arg := range field.Args {
+					op.Args = append(op.Args, Argument{Name: arg.Name, Type: arg.Type.String()})
+				}
+				group = append(group, op)
+			}
+			sort.Slice(group, func(i, j int) bool { return group[i].Name < group[j].Name })
+			operations = append(operations, group...)
+		}
+	}
+	return operations, nil
+}
+
+func rootName(root *struct{ Name string }) string {
+	if root == nil {
+		return ""
+	}
+	return root.Name
+}
+
+type responseError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path"`
+}
+
+// ResponseErrors returns the messages of the errors array of a GraphQL
+// response, nil when the body has none (or is not a GraphQL response).
+func ResponseErrors(body []byte) []string {
+	var resp struct {
+		Errors []responseError `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	return errorMessages(resp.Errors)
+}
+
+func errorMessages(errs []responseError) []string {
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		message := e.Message
+		if message == "" {
+			message = "unknown error"
+		}
+		if len(e.Path) > 0 {
+			parts := make([]string, 0, len(e.Path))
+			for _, p := range e.Path {
+				parts = append(parts, fmt.Sprint(p))
+			}
+			message = fmt.Sprintf("%s (at %s)", message, strings.Join(parts, "."))
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
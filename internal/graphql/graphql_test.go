@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIntrospection(t *testing.T) {
+	body := []byte(`{"data":{"__schema":{
+		"queryType":{"name":"Query"},
+		"mutationType":{"name":"Mutation"},
+		"subscriptionType":{"name":"Subscription"},
+		"types":[
+			{"kind":"OBJECT","name":"User","fields":[{"name":"id","args":[],"type":{"kind":"SCALAR","name":"ID"}}]},
+			{"kind":"OBJECT","name":"Query","fields":[
+				{"name":"users","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"LIST","ofType":{"kind":"NON_NULL","ofType":{"kind":"OBJECT","name":"User"}}}}},
+				{"name":"user","description":"Find a user\nby id","args":[{"name":"id","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}}}],"type":{"kind":"OBJECT","name":"User"}}
+			]},
+			{"kind":"OBJECT","name":"Mutation","fields":[
+				{"name":"createUser","args":[{"name":"name","type":{"kind":"SCALAR","name":"String"}},{"name":"admin","type":{"kind":"SCALAR","name":"Boolean"}}],"type":{"kind":"OBJECT","name":"User"}}
+			]},
+			{"kind":"OBJECT","name":"Subscription","fields":[
+				{"name":"userCreated","args":[],"type":{"kind":"OBJECT","name":"User"}}
+			]}
+		]}}}`)
+
+	operations, err := ParseIntrospection(body)
+	if err != nil {
+		t.Fatalf("ParseIntrospection returned error: %v", err)
+	}
+
+	got := make([]string, 0, len(operations))
+	for _, op := range operations {
+		got = append(got, op.Kind+" "+op.Signature())
+	}
+	want := []string{
+		"query user(id: ID!): User",
+		"query users: [User!]!",
+		"mutation createUser(name: String, admin: Boolean): User",
+		"subscription userCreated: User",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected operations:\n got %v\nwant %v", got, want)
+	}
+	if operations[0].Description != "Find a user\nby id" {
+		t.Fatalf("unexpected description %q", operations[0].Description)
+	}
+}
+
+func TestParseIntrospectionErrors(t *testing.T) {
+	if _, err := ParseIntrospection([]byte(`{"errors":[{"message":"introspection is disabled"}]}`)); err == nil {
+		t.Fatalf("expected error when introspection is disabled")
+	}
+}
+
+func TestResponseErrors(t *testing.T) {
+	messages := ResponseErrors([]byte(`{"data":null,"errors":[{"message":"not found","path":["user",0,"name"]},{"message":""}]}`))
+	want := []string{"not found (at user.0.name)", "unknown error"}
+	if !reflect.DeepEqual(messages, want) {
+		t.Fatalf("expected %v, got %v", want, messages)
+	}
+
+	for _, body := range []string{`{"data":{"user":null}}`, `{"errors":[]}`, `not json`} {
+		if messages := ResponseErrors([]byte(body)); messages != nil {
+			t.Fatalf("expected no errors for %s, got %v", body, messages)
+		}
+	}
+}
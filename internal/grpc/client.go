@@ -0,0 +1,343 @@
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Code is a gRPC status code.
+type Code int
+
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	NotFound           Code = 5
+	AlreadyExists      Code = 6
+	PermissionDenied   Code = 7
+	ResourceExhausted  Code = 8
+	FailedPrecondition Code = 9
+	Aborted            Code = 10
+	OutOfRange         Code = 11
+	Unimplemented      Code = 12
+	Internal           Code = 13
+	Unavailable        Code = 14
+	DataLoss           Code = 15
+	Unauthenticated    Code = 16
+)
+
+var codeNames = map[Code]string{
+	OK:                 "OK",
+	Canceled:           "CANCELLED",
+	Unknown:            "UNKNOWN",
+	InvalidArgument:    "INVALID_ARGUMENT",
+	DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	NotFound:           "NOT_FOUND",
+	AlreadyExists:      "ALREADY_EXISTS",
+	PermissionDenied:   "PERMISSION_DENIED",
+	ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	FailedPrecondition: "FAILED_PRECONDITION",
+	Aborted:            "ABORTED",
+	OutOfRange:         "OUT_OF_RANGE",
+	Unimplemented:      "UNIMPLEMENTED",
+	Internal:           "INTERNAL",
+	Unavailable:        "UNAVAILABLE",
+	DataLoss:           "DATA_LOSS",
+	Unauthenticated:    "UNAUTHENTICATED",
+}
+
+func (c Code) String() string {
+	if name, ok := codeNames[c]; ok {
+		return name
+	}
+	return "CODE(" + strconv.Itoa(int(c)) + ")"
+}
+
+// HTTPStatus maps the code to the closest HTTP status, so gRPC responses can
+// be asserted on and reported like HTTP ones.
+func (c Code) HTTPStatus() int {
+	switch c {
+	case OK:
+		return http.StatusOK
+	case Canceled:
+		return 499
+	case InvalidArgument, FailedPrecondition, OutOfRange:
+		return http.StatusBadRequest
+	case DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case NotFound:
+		return http.StatusNotFound
+	case AlreadyExists, Aborted:
+		return http.StatusConflict
+	case PermissionDenied:
+		return http.StatusForbidden
+	case ResourceExhausted:
+		return http.StatusTooManyRequests
+	case Unimplemented:
+		return http.StatusNotImplemented
+	case Unavailable:
+		return http.StatusServiceUnavailable
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Status is the outcome of a call, read from the grpc-status and
+// grpc-message trailers.
+type Status struct {
+	Code    Code
+	Message string
+}
+
+// Result is the response to a call. Messages are JSON-encoded; Header holds
+// the response headers and trailers.
+type Result struct {
+	Status   Status
+	Messages []json.RawMessage
+	Header   http.Header
+}
+
+// Client calls methods on one gRPC server over HTTP/2.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	// reflectionPath remembers which reflection service version answered.
+	reflectionPath string
+}
+
+// NewClient connects to target, either host:port or an http:// (plaintext)
+// or https:// URL. Bare host:port targets use TLS unless plaintext is set.
+func NewClient(target string, plaintext, insecure bool) (*Client, error) {
+	baseURL, tlsEnabled, err := targetURL(target, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	protocols := new(http.Protocols)
+	if tlsEnabled {
+		protocols.SetHTTP2(true)
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	transport := &http.Transport{
+		Protocols:         protocols,
+		ForceAttemptHTTP2: true,
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: insecure},
+	}
+	return &Client{baseURL: baseURL, httpClient: &http.Client{Transport: transport}}, nil
+}
+
+func targetURL(target string, plaintext bool) (string, bool, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", false, errors.New("gRPC target is required (host:port)")
+	}
+	if !strings.Contains(target, "://") {
+		scheme := "https"
+		if plaintext {
+			scheme = "http"
+		}
+		target = scheme + "://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return "", false, fmt.Errorf("invalid gRPC target %q", target)
+	}
+	switch u.Scheme {
+	case "http":
+		return "http://" + u.Host, false, nil
+	case "https":
+		if plaintext {
+			return "http://" + u.Host, false, nil
+		}
+		return "https://" + u.Host, true, nil
+	}
+	return "", false, fmt.Errorf("unsupported gRPC target scheme %q", u.Scheme)
+}
+
+// Invoke calls a unary or server-streaming method with a JSON request. For
+// streaming methods onMessage, if set, receives each message as it arrives.
+func (c *Client) Invoke(ctx context.Context, method *Method, metadata http.Header, body []byte, onMessage func(json.RawMessage)) (*Result, error) {
+	if method.ClientStreaming {
+		return nil, fmt.Errorf("%s is a client-streaming method, which is not supported", method.FullName())
+	}
+	payload, err := EncodeJSON(method.Input, body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var decodeErr error
+	header, status, err := c.call(ctx, method.Path(), metadata, payload, func(data []byte) {
+		if decodeErr != nil {
+			return
+		}
+		message, err := DecodeToJSON(method.Output, data)
+		if err != nil {
+			decodeErr = err
+			return
+		}
+		result.Messages = append(result.Messages, message)
+		if onMessage != nil {
+			onMessage(message)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("decoding %s: %w", method.Output.FullName, decodeErr)
+	}
+	result.Header = header
+	result.Status = status
+	return result, nil
+}
+
+// call sends one framed message on a new stream and reads every response
+// message until the trailers arrive.
+func (c *Client) call(ctx context.Context, path string, metadata http.Header, payload []byte, onMessage func([]byte)) (http.Header, Status, error) {
+	frame := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	frame = append(frame, payload...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(frame))
+	if err != nil {
+		return nil, Status{}, fmt.Errorf("creating gRPC request: %w", err)
+	}
+	for key, values := range metadata {
+		if reservedHeader(key) {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("grpc-accept-encoding", "gzip")
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline).Milliseconds(); remaining > 0 {
+			req.Header.Set("grpc-timeout", strconv.FormatInt(remaining, 10)+"m")
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, Status{Code: DeadlineExceeded, Message: "deadline exceeded"}, nil
+		}
+		return nil, Status{}, fmt.Errorf("sending gRPC request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.Header, Status{Code: codeFromHTTP(resp.StatusCode), Message: "HTTP status " + resp.Status}, nil
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/grpc") {
+		return nil, Status{}, fmt.Errorf("unexpected content type %q (is this a gRPC server?)", contentType)
+	}
+
+	compressed := resp.Header.Get("grpc-encoding")
+	prefix := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(resp.Body, prefix); err != nil {
+			if err == io.EOF {
+				break
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, Status{Code: DeadlineExceeded, Message: "deadline exceeded"}, nil
+			}
+			return nil, Status{}, fmt.Errorf("reading gRPC response: %w", err)
+		}
+		data := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+		if _, err := io.ReadFull(resp.Body, data); err != nil {
+			return nil, Status{}, fmt.Errorf("reading gRPC response: %w", err)
+		}
+		if prefix[0] == 1 {
+			if data, err = decompress(compressed, data); err != nil {
+				return nil, Status{}, err
+			}
+		}
+		onMessage(data)
+	}
+
+	header := resp.Header.Clone()
+	for key, values := range resp.Trailer {
+		header[key] = values
+	}
+	return header, statusFromHeader(header), nil
+}
+
+func reservedHeader(key string) bool {
+	switch strings.ToLower(key) {
+	case "content-type", "content-length", "te", "host", "connection", "transfer-encoding", "keep-alive", "upgrade", "user-agent":
+		return true
+	}
+	return false
+}
+
+func decompress(encoding string, data []byte) ([]byte, error) {
+	if encoding != "gzip" {
+		return nil, fmt.Errorf("unsupported gRPC message encoding %q", encoding)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gRPC message: %w", err)
+	}
+	defer reader.Close()
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gRPC message: %w", err)
+	}
+	return out, nil
+}
+
+func statusFromHeader(header http.Header) Status {
+	value := header.Get("grpc-status")
+	if value == "" {
+		return Status{Code: Internal, Message: "server closed the stream without a grpc-status"}
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil {
+		return Status{Code: Unknown, Message: "invalid grpc-status " + value}
+	}
+	message := header.Get("grpc-message")
+	if unescaped, err := url.PathUnescape(message); err == nil {
+		message = unescaped
+	}
+	return Status{Code: Code(code), Message: message}
+}
+
+// codeFromHTTP follows the gRPC spec for servers or proxies that answer with
+// a plain HTTP error.
+func codeFromHTTP(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return Internal
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return PermissionDenied
+	case http.StatusNotFound:
+		return Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return Unavailable
+	}
+	return Unknown
+}
@@ -0,0 +1,282 @@
+package grpc
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// greetDescriptor is the FileDescriptorProto of:
+//
+//	package greet;
+//	message HelloRequest { string name = 1; }
+//	message HelloReply { string message = 1; }
+//	service Greeter {
+//	  rpc SayHello(HelloRequest) returns (HelloReply);
+//	  rpc StreamHello(HelloRequest) returns (stream HelloReply);
+//	}
+func greetDescriptor() []byte {
+	stringField := func(name string) []byte {
+		var f []byte
+		f = appendBytesField(f, 1, []byte(name))
+		f = appendTag(f, 3, wireVarint)
+		f = appendVarint(f, 1)
+		f = appendTag(f, 4, wireVarint)
+		f = appendVarint(f, 1)
+		f = appendTag(f, 5, wireVarint)
+		f = appendVarint(f, uint64(KindString))
+		return f
+	}
+	message := func(name, field string) []byte {
+		var m []byte
+		m = appendBytesField(m, 1, []byte(name))
+		return appendBytesField(m, 2, stringField(field))
+	}
+	method := func(name string, streaming bool) []byte {
+		var m []byte
+		m = appendBytesField(m, 1, []byte(name))
+		m = appendBytesField(m, 2, []byte(".greet.HelloRequest"))
+		m = appendBytesField(m, 3, []byte(".greet.HelloReply"))
+		if streaming {
+			m = appendTag(m, 6, wireVarint)
+			m = appendVarint(m, 1)
+		}
+		return m
+	}
+
+	var service []byte
+	service = appendBytesField(service, 1, []byte("Greeter"))
+	service = appendBytesField(service, 2, method("SayHello", false))
+	service = appendBytesField(service, 2, method("StreamHello", true))
+
+	var file []byte
+	file = appendBytesField(file, 1, []byte("greet.proto"))
+	file = appendBytesField(file, 2, []byte("greet"))
+	file = appendBytesField(file, 4, message("HelloRequest", "name"))
+	file = appendBytesField(file, 4, message("HelloReply", "message"))
+	file = appendBytesField(file, 6, service)
+	return appendBytesField(file, 12, []byte("proto3"))
+}
+
+func writeFrame(w http.ResponseWriter, payload []byte) {
+	prefix := make([]byte, 5)
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(payload)))
+	_, _ = w.Write(append(prefix, payload...))
+	w.(http.Flusher).Flush()
+}
+
+func readFrame(t *testing.T, r *http.Request) []byte {
+	data, err := io.ReadAll(r.Body)
+	if err != nil || len(data) < 5 {
+		t.Errorf("reading request frame: %v", err)
+		return nil
+	}
+	return data[5:]
+}
+
+func firstString(t *testing.T, data []byte, number int) string {
+	fields, err := readFields(data)
+	if err != nil {
+		t.Errorf("decoding request: %v", err)
+	}
+	for _, f := range fields {
+		if f.Number == number {
+			return string(f.Bytes)
+		}
+	}
+	return ""
+}
+
+// newGreeterServer serves the greet.Greeter service and the v1alpha
+// reflection service over h2c; the v1 reflection service is unimplemented.
+func newGreeterServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.Header.Get("Content-Type") != "application/grpc" {
+			http.Error(w, "expected a gRPC request", http.StatusUnsupportedMediaType)
+			return
+		}
+		request := readFrame(t, r)
+		w.Header().Set("Content-Type", "application/grpc")
+
+		switch r.URL.Path {
+		case "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":
+			w.Header().Set("Grpc-Status", "12")
+			w.WriteHeader(http.StatusOK)
+			return
+		case "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo":
+			fields, _ := readFields(request)
+			var resp []byte
+			switch fields[0].Number {
+			case reflectListServices:
+				var list []byte
+				for _, name := range []string{"greet.Greeter", "grpc.reflection.v1alpha.ServerReflection"} {
+					list = appendBytesField(list, 1, appendBytesField(nil, 1, []byte(name)))
+				}
+				resp = appendBytesField(nil, reflectListServicesResponse, list)
+			case reflectFileContainingSymbol:
+				resp = appendBytesField(nil, reflectFileDescriptorResponse, appendBytesField(nil, 1, greetDescriptor()))
+			}
+			writeFrame(w, resp)
+		case "/greet.Greeter/SayHello":
+			name := firstString(t, request, 1)
+			w.Header().Set("X-Seen-Token", r.Header.Get("X-Token"))
+			if name == "" {
+				w.Header().Set(http.TrailerPrefix+"Grpc-Status", "3")
+				w.Header().Set(http.TrailerPrefix+"Grpc-Message", "name%20is%20required")
+				return
+			}
+			writeFrame(w, appendBytesField(nil, 1, []byte("Hello, "+name)))
+		case "/greet.Greeter/StreamHello":
+			name := firstString(t, request, 1)
+			for _, greeting := range []string{"Hi", "Hello", "Bye"} {
+				writeFrame(w, appendBytesField(nil, 1, []byte(greeting+", "+name)))
+			}
+		default:
+			w.Header().Set("Grpc-Status", "12")
+			return
+		}
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReflectAndInvokeUnary(t *testing.T) {
+	server := newGreeterServer(t)
+	client, err := NewClient(server.URL, false, false)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	registry, err := client.Reflect(ctx, nil)
+	if err != nil {
+		t.Fatalf("Reflect() error = %v", err)
+	}
+	services := registry.Services()
+	if len(services) != 1 || services[0].FullName != "greet.Greeter" || len(services[0].Methods) != 2 {
+		t.Fatalf("unexpected services: %+v", services)
+	}
+
+	method, err := registry.FindMethod("greet.Greeter/SayHello")
+	if err != nil {
+		t.Fatalf("FindMethod() error = %v", err)
+	}
+	metadata := http.Header{"X-Token": {"secret"}, "Content-Type": {"application/json"}}
+	result, err := client.Invoke(ctx, method, metadata, []byte(`{"name":"Ada"}`), nil)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.Status.Code != OK {
+		t.Fatalf("unexpected status %+v", result.Status)
+	}
+	if len(result.Messages) != 1 || string(result.Messages[0]) != `{"message":"Hello, Ada"}` {
+		t.Fatalf("unexpected messages %s", result.Messages)
+	}
+	if result.Header.Get("X-Seen-Token") != "secret" {
+		t.Fatalf("expected metadata to be sent, got headers %v", result.Header)
+	}
+	if result.Header.Get("Grpc-Status") != "0" {
+		t.Fatalf("expected trailers in headers, got %v", result.Header)
+	}
+}
+
+func TestInvokeServerStreaming(t *testing.T) {
+	server := newGreeterServer(t)
+	client, err := NewClient(server.URL, false, false)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+	registry, err := client.Reflect(ctx, nil, "greet.Greeter")
+	if err != nil {
+		t.Fatalf("Reflect() error = %v", err)
+	}
+	method, _ := registry.FindMethod("greet.Greeter/StreamHello")
+
+	var received []string
+	result, err := client.Invoke(ctx, method, nil, []byte(`{"name":"Bob"}`), func(message json.RawMessage) {
+		received = append(received, string(message))
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(received) != 3 || received[2] != `{"message":"Bye, Bob"}` || len(result.Messages) != 3 {
+		t.Fatalf("unexpected streamed messages %v", received)
+	}
+}
+
+func TestInvokeReturnsStatusFromTrailers(t *testing.T) {
+	server := newGreeterServer(t)
+	client, err := NewClient(server.URL, false, false)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	registry, err := client.Reflect(context.Background(), nil, "greet.Greeter")
+	if err != nil {
+		t.Fatalf("Reflect() error = %v", err)
+	}
+	method, _ := registry.FindMethod("greet.Greeter/SayHello")
+
+	result, err := client.Invoke(context.Background(), method, nil, []byte(`{}`), nil)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.Status.Code != InvalidArgument || result.Status.Message != "name is required" {
+		t.Fatalf("unexpected status %+v", result.Status)
+	}
+	if result.Status.Code.HTTPStatus() != http.StatusBadRequest {
+		t.Fatalf("expected INVALID_ARGUMENT to map to 400")
+	}
+}
+
+func TestInvokeRejectsNonGRPCServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, false, false)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	_, err = client.Reflect(context.Background(), nil)
+	if err == nil {
+		t.Fatalf("expected an error from a plain HTTP/1 server")
+	}
+}
+
+func TestTargetURL(t *testing.T) {
+	cases := []struct {
+		target    string
+		plaintext bool
+		want      string
+		tls       bool
+	}{
+		{"localhost:50051", false, "https://localhost:50051", true},
+		{"localhost:50051", true, "http://localhost:50051", false},
+		{"http://api.local:8080/ignored", false, "http://api.local:8080", false},
+		{"https://api.example.com", false, "https://api.example.com", true},
+	}
+	for _, tc := range cases {
+		got, tlsEnabled, err := targetURL(tc.target, tc.plaintext)
+		if err != nil || got != tc.want || tlsEnabled != tc.tls {
+			t.Fatalf("targetURL(%q, %v) = %q, %v, %v", tc.target, tc.plaintext, got, tlsEnabled, err)
+		}
+	}
+	if _, _, err := targetURL("ftp://host", false); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Fatalf("expected unsupported scheme error, got %v", err)
+	}
+}
@@ -0,0 +1,793 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeJSON encodes a JSON object as a protobuf message of type m, following
+// the proto3 JSON mapping (lowerCamelCase or original field names, 64-bit
+// integers as numbers or strings, enums by name or number, bytes as base64).
+func EncodeJSON(m *Message, data []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("parsing request JSON: %w", err)
+	}
+	return encodeMessage(m, value)
+}
+
+func encodeMessage(m *Message, value interface{}) ([]byte, error) {
+	switch m.FullName {
+	case "google.protobuf.Timestamp":
+		return encodeTimestamp(value)
+	case "google.protobuf.Duration":
+		return encodeDuration(value)
+	case "google.protobuf.FieldMask":
+		return encodeFieldMask(m, value)
+	case "google.protobuf.Struct":
+		return encodeWrapped(m, "fields", value)
+	case "google.protobuf.ListValue":
+		return encodeWrapped(m, "values", value)
+	case "google.protobuf.Value":
+		return encodeValue(m, value)
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue", "google.protobuf.Int64Value",
+		"google.protobuf.UInt64Value", "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return encodeWrapped(m, "value", value)
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a JSON object, got %s", m.FullName, jsonTypeName(value))
+	}
+	return encodeFields(m, obj)
+}
+
+func encodeFields(m *Message, obj map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		if m.FieldByName(key) == nil {
+			return nil, fmt.Errorf("%s: unknown field %q", m.FullName, key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return m.FieldByName(keys[i]).Number < m.FieldByName(keys[j]).Number })
+
+	var out []byte
+	for _, key := range keys {
+		f := m.FieldByName(key)
+		v := obj[key]
+		if v == nil && (f.Message == nil || f.Message.FullName != "google.protobuf.Value") {
+			continue
+		}
+
+		var err error
+		switch {
+		case f.IsMap():
+			out, err = appendMap(out, f, v)
+		case f.Repeated:
+			out, err = appendRepeated(out, f, v, m.Packed)
+		default:
+			out, err = appendField(out, f, v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", m.FullName, f.Name, err)
+		}
+	}
+	return out, nil
+}
+
+func encodeWrapped(m *Message, field string, value interface{}) ([]byte, error) {
+	return encodeFields(m, map[string]interface{}{field: value})
+}
+
+func encodeValue(m *Message, value interface{}) ([]byte, error) {
+	var field string
+	switch value.(type) {
+	case nil:
+		field, value = "nullValue", json.Number("0")
+	case json.Number:
+		field = "numberValue"
+	case string:
+		field = "stringValue"
+	case bool:
+		field = "boolValue"
+	case map[string]interface{}:
+		field = "structValue"
+	case []interface{}:
+		field = "listValue"
+	default:
+		return nil, fmt.Errorf("unsupported JSON value %v", value)
+	}
+	return encodeWrapped(m, field, value)
+}
+
+func appendMap(b []byte, f *Field, value interface{}) ([]byte, error) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object for a map, got %s", jsonTypeName(value))
+	}
+	keyField := f.Message.FieldByNumber(1)
+	valueField := f.Message.FieldByNumber(2)
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var keyValue interface{} = key
+		if keyField.Kind != KindString {
+			keyValue = json.Number(key)
+			if keyField.Kind == KindBool {
+				keyValue = key == "true"
+			}
+		}
+		entry, err := appendField(nil, keyField, keyValue)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+		if obj[key] != nil || valueField.Message != nil {
+			entry, err = appendField(entry, valueField, obj[key])
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", key, err)
+			}
+		}
+		b = appendBytesField(b, f.Number, entry)
+	}
+	return b, nil
+}
+
+func appendRepeated(b []byte, f *Field, value interface{}, defaultPacked bool) ([]byte, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON array, got %s", jsonTypeName(value))
+	}
+	if f.packed(defaultPacked) && packable(f.Kind) {
+		var packed []byte
+		for i, item := range items {
+			var err error
+			if packed, err = appendScalar(packed, f, item); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		if len(items) == 0 {
+			return b, nil
+		}
+		return appendBytesField(b, f.Number, packed), nil
+	}
+	for i, item := range items {
+		var err error
+		if b, err = appendField(b, f, item); err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return b, nil
+}
+
+func packable(kind Kind) bool {
+	switch kind {
+	case KindString, KindBytes, KindMessage, KindGroup:
+		return false
+	}
+	return true
+}
+
+func wireTypeOf(kind Kind) int {
+	switch kind {
+	case KindDouble, KindFixed64, KindSfixed64:
+		return wireFixed64
+	case KindFloat, KindFixed32, KindSfixed32:
+		return wireFixed32
+	case KindString, KindBytes, KindMessage:
+		return wireBytes
+	default:
+		return wireVarint
+	}
+}
+
+// appendField appends one value with its tag.
+func appendField(b []byte, f *Field, value interface{}) ([]byte, error) {
+	switch f.Kind {
+	case KindMessage:
+		encoded, err := encodeMessage(f.Message, value)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytesField(b, f.Number, encoded), nil
+	case KindString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %s", jsonTypeName(value))
+		}
+		return appendBytesField(b, f.Number, []byte(s)), nil
+	case KindBytes:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a base64 string, got %s", jsonTypeName(value))
+		}
+		data, err := decodeBase64(s)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytesField(b, f.Number, data), nil
+	}
+	b = appendTag(b, f.Number, wireTypeOf(f.Kind))
+	return appendScalar(b, f, value)
+}
+
+// appendScalar appends the untagged encoding of a numeric, bool or enum value.
+func appendScalar(b []byte, f *Field, value interface{}) ([]byte, error) {
+	switch f.Kind {
+	case KindBool:
+		switch v := value.(type) {
+		case bool:
+			if v {
+				return appendVarint(b, 1), nil
+			}
+			return appendVarint(b, 0), nil
+		case string:
+			if v == "true" || v == "false" {
+				return appendScalar(b, f, v == "true")
+			}
+		}
+		return nil, fmt.Errorf("expected a boolean, got %s", jsonTypeName(value))
+	case KindEnum:
+		return appendEnum(b, f.Enum, value)
+	case KindFloat, KindDouble:
+		v, err := floatValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if f.Kind == KindFloat {
+			return appendFixed32(b, float32Bits(v)), nil
+		}
+		return appendFixed64(b, math.Float64bits(v)), nil
+	}
+
+	unsigned := f.Kind == KindUint32 || f.Kind == KindUint64 || f.Kind == KindFixed32 || f.Kind == KindFixed64
+	bits := 64
+	if f.Kind == KindInt32 || f.Kind == KindUint32 || f.Kind == KindSint32 || f.Kind == KindFixed32 || f.Kind == KindSfixed32 {
+		bits = 32
+	}
+	text, err := integerText(value)
+	if err != nil {
+		return nil, err
+	}
+	if unsigned {
+		v, err := strconv.ParseUint(text, 10, bits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uint%d %q", bits, text)
+		}
+		switch f.Kind {
+		case KindFixed32:
+			return appendFixed32(b, uint32(v)), nil
+		case KindFixed64:
+			return appendFixed64(b, v), nil
+		default:
+			return appendVarint(b, v), nil
+		}
+	}
+	v, err := strconv.ParseInt(text, 10, bits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid int%d %q", bits, text)
+	}
+	switch f.Kind {
+	case KindSint32:
+		return appendVarint(b, zigzag32(int32(v))), nil
+	case KindSint64:
+		return appendVarint(b, zigzag64(v)), nil
+	case KindSfixed32:
+		return appendFixed32(b, uint32(int32(v))), nil
+	case KindSfixed64:
+		return appendFixed64(b, uint64(v)), nil
+	default:
+		return appendVarint(b, uint64(v)), nil
+	}
+}
+
+func appendEnum(b []byte, enum *Enum, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		if number, ok := enum.byName[v]; ok {
+			return appendVarint(b, uint64(int64(number))), nil
+		}
+		if _, err := strconv.ParseInt(v, 10, 32); err != nil {
+			return nil, fmt.Errorf("unknown %s value %q", enum.FullName, v)
+		}
+		return appendEnum(b, enum, json.Number(v))
+	case json.Number:
+		number, err := strconv.ParseInt(v.String(), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %s", enum.FullName, v)
+		}
+		return appendVarint(b, uint64(number)), nil
+	}
+	return nil, fmt.Errorf("expected an enum name or number, got %s", jsonTypeName(value))
+}
+
+func integerText(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case json.Number:
+		text := v.String()
+		if strings.ContainsAny(text, ".eE") {
+			f, err := v.Float64()
+			if err != nil || f != math.Trunc(f) {
+				return "", fmt.Errorf("expected an integer, got %s", text)
+			}
+			return strconv.FormatFloat(f, 'f', 0, 64), nil
+		}
+		return text, nil
+	case string:
+		return integerText(json.Number(strings.TrimSpace(v)))
+	}
+	return "", fmt.Errorf("expected an integer, got %s", jsonTypeName(value))
+}
+
+func floatValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Float64()
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("expected a number, got %s", jsonTypeName(value))
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if data, err := encoding.DecodeString(s); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64 value %q", s)
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case json.Number, float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func encodeTimestamp(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("google.protobuf.Timestamp: expected an RFC 3339 string, got %s", jsonTypeName(value))
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, fmt.Errorf("google.protobuf.Timestamp: %w", err)
+	}
+	return appendSecondsNanos(nil, t.Unix(), int32(t.Nanosecond())), nil
+}
+
+func encodeDuration(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok || !strings.HasSuffix(s, "s") {
+		return nil, fmt.Errorf("google.protobuf.Duration: expected a string such as \"1.5s\", got %v", value)
+	}
+	text := strings.TrimSuffix(s, "s")
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+	whole, fraction, _ := strings.Cut(text, ".")
+	seconds, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || len(fraction) > 9 {
+		return nil, fmt.Errorf("google.protobuf.Duration: invalid value %q", s)
+	}
+	var nanos int64
+	if fraction != "" {
+		if nanos, err = strconv.ParseInt(fraction+strings.Repeat("0", 9-len(fraction)), 10, 32); err != nil {
+			return nil, fmt.Errorf("google.protobuf.Duration: invalid value %q", s)
+		}
+	}
+	if negative {
+		seconds, nanos = -seconds, -nanos
+	}
+	return appendSecondsNanos(nil, seconds, int32(nanos)), nil
+}
+
+func appendSecondsNanos(b []byte, seconds int64, nanos int32) []byte {
+	if seconds != 0 {
+		b = appendTag(b, 1, wireVarint)
+		b = appendVarint(b, uint64(seconds))
+	}
+	if nanos != 0 {
+		b = appendTag(b, 2, wireVarint)
+		b = appendVarint(b, uint64(int64(nanos)))
+	}
+	return b
+}
+
+func encodeFieldMask(m *Message, value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("google.protobuf.FieldMask: expected a string, got %s", jsonTypeName(value))
+	}
+	var b []byte
+	for _, path := range strings.Split(s, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		var snake strings.Builder
+		for _, c := range path {
+			if c >= 'A' && c <= 'Z' {
+				snake.WriteByte('_')
+				c += 'a' - 'A'
+			}
+			snake.WriteRune(c)
+		}
+		b = appendBytesField(b, 1, []byte(snake.String()))
+	}
+	return b, nil
+}
+
+// jsonObject is a JSON object that keeps the order of its members.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	Key   string
+	Value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(member.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(member.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o jsonObject) get(key string) (interface{}, bool) {
+	for _, member := range o {
+		if member.Key == key {
+			return member.Value, true
+		}
+	}
+	return nil, false
+}
+
+// DecodeToJSON decodes a protobuf message of type m into JSON. Fields are
+// listed in field-number order; unset fields without presence are included
+// with their default value.
+func DecodeToJSON(m *Message, data []byte) ([]byte, error) {
+	value, err := decodeMessage(m, data)
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encoding response JSON: %w", err)
+	}
+	return out, nil
+}
+
+func decodeMessage(m *Message, data []byte) (interface{}, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.FullName, err)
+	}
+
+	switch m.FullName {
+	case "google.protobuf.Timestamp":
+		seconds, nanos := secondsNanos(fields)
+		return time.Unix(seconds, int64(nanos)).UTC().Format(time.RFC3339Nano), nil
+	case "google.protobuf.Duration":
+		return formatDuration(secondsNanos(fields)), nil
+	}
+
+	byNumber := make(map[int][]wireField)
+	for _, field := range fields {
+		byNumber[field.Number] = append(byNumber[field.Number], field)
+	}
+
+	obj := make(jsonObject, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		values := byNumber[f.Number]
+		var (
+			value interface{}
+			err   error
+		)
+		switch {
+		case len(values) == 0:
+			if f.Presence || !f.Repeated && f.Kind == KindMessage {
+				continue
+			}
+			value = defaultValue(f)
+		case f.IsMap():
+			value, err = decodeMap(f, values)
+		case f.Repeated:
+			value, err = decodeRepeated(f, values)
+		default:
+			value, err = decodeScalar(f, values[len(values)-1])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", m.FullName, f.Name, err)
+		}
+		obj = append(obj, jsonMember{Key: f.JSONName, Value: value})
+	}
+
+	switch m.FullName {
+	case "google.protobuf.Struct":
+		return unwrap(obj, "fields", jsonObject{}), nil
+	case "google.protobuf.ListValue":
+		return unwrap(obj, "values", []interface{}{}), nil
+	case "google.protobuf.Value":
+		if len(obj) == 0 || obj[0].Key == "nullValue" {
+			return nil, nil
+		}
+		return obj[0].Value, nil
+	case "google.protobuf.FieldMask":
+		paths, _ := unwrap(obj, "paths", []interface{}{}).([]interface{})
+		parts := make([]string, 0, len(paths))
+		for _, path := range paths {
+			parts = append(parts, jsonName(fmt.Sprint(path)))
+		}
+		return strings.Join(parts, ","), nil
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue", "google.protobuf.Int64Value",
+		"google.protobuf.UInt64Value", "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return unwrap(obj, "value", nil), nil
+	}
+	return obj, nil
+}
+
+func unwrap(obj jsonObject, key string, fallback interface{}) interface{} {
+	if value, ok := obj.get(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func secondsNanos(fields []wireField) (int64, int32) {
+	var seconds int64
+	var nanos int32
+	for _, field := range fields {
+		switch field.Number {
+		case 1:
+			seconds = int64(field.Varint)
+		case 2:
+			nanos = int32(field.Varint)
+		}
+	}
+	return seconds, nanos
+}
+
+func formatDuration(seconds int64, nanos int32) string {
+	sign := ""
+	if seconds < 0 || nanos < 0 {
+		sign = "-"
+		seconds, nanos = -seconds, -nanos
+	}
+	if nanos == 0 {
+		return fmt.Sprintf("%s%ds", sign, seconds)
+	}
+	fraction := fmt.Sprintf("%09d", nanos)
+	for strings.HasSuffix(fraction, "000") {
+		fraction = strings.TrimSuffix(fraction, "000")
+	}
+	return fmt.Sprintf("%s%d.%ss", sign, seconds, fraction)
+}
+
+func defaultValue(f *Field) interface{} {
+	switch {
+	case f.IsMap():
+		return jsonObject{}
+	case f.Repeated:
+		return []interface{}{}
+	}
+	switch f.Kind {
+	case KindBool:
+		return false
+	case KindString, KindBytes:
+		return ""
+	case KindInt64, KindUint64, KindSint64, KindFixed64, KindSfixed64:
+		return "0"
+	case KindEnum:
+		if f.Enum != nil {
+			if name, ok := f.Enum.byNumber[0]; ok {
+				return name
+			}
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+func decodeMap(f *Field, values []wireField) (interface{}, error) {
+	keyField := f.Message.FieldByNumber(1)
+	valueField := f.Message.FieldByNumber(2)
+	obj := make(jsonObject, 0, len(values))
+	index := make(map[string]int, len(values))
+	for _, entry := range values {
+		if entry.Type != wireBytes {
+			return nil, fmt.Errorf("invalid map entry")
+		}
+		entryFields, err := readFields(entry.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key := defaultValue(keyField)
+		value := defaultValue(valueField)
+		if valueField.Kind == KindMessage {
+			value, _ = decodeMessage(valueField.Message, nil)
+		}
+		for _, ef := range entryFields {
+			switch ef.Number {
+			case 1:
+				if key, err = decodeScalar(keyField, ef); err != nil {
+					return nil, err
+				}
+			case 2:
+				if value, err = decodeScalar(valueField, ef); err != nil {
+					return nil, err
+				}
+			}
+		}
+		keyText := fmt.Sprint(key)
+		if i, exists := index[keyText]; exists {
+			obj[i].Value = value
+			continue
+		}
+		index[keyText] = len(obj)
+		obj = append(obj, jsonMember{Key: keyText, Value: value})
+	}
+	return obj, nil
+}
+
+func decodeRepeated(f *Field, values []wireField) (interface{}, error) {
+	items := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if value.Type == wireBytes && packable(f.Kind) {
+			unpacked, err := unpack(f, value.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, unpacked...)
+			continue
+		}
+		item, err := decodeScalar(f, value)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func unpack(f *Field, data []byte) ([]interface{}, error) {
+	items := make([]interface{}, 0)
+	wireType := wireTypeOf(f.Kind)
+	for len(data) > 0 {
+		field := wireField{Number: f.Number, Type: wireType}
+		switch wireType {
+		case wireVarint:
+			v, n, err := readVarint(data)
+			if err != nil {
+				return nil, err
+			}
+			field.Varint = v
+			data = data[n:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return nil, errTruncated
+			}
+			field.Varint = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return nil, errTruncated
+			}
+			field.Varint = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		}
+		item, err := decodeScalar(f, field)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func decodeScalar(f *Field, w wireField) (interface{}, error) {
+	if expected := wireTypeOf(f.Kind); w.Type != expected {
+		return nil, fmt.Errorf("wire type %d does not match field type", w.Type)
+	}
+	switch f.Kind {
+	case KindMessage:
+		return decodeMessage(f.Message, w.Bytes)
+	case KindString:
+		return string(w.Bytes), nil
+	case KindBytes:
+		return base64.StdEncoding.EncodeToString(w.Bytes), nil
+	case KindBool:
+		return w.Varint != 0, nil
+	case KindEnum:
+		number := int32(w.Varint)
+		if f.Enum != nil {
+			if name, ok := f.Enum.byNumber[number]; ok {
+				return name, nil
+			}
+		}
+		return number, nil
+	case KindInt32:
+		return int32(w.Varint), nil
+	case KindSint32:
+		return unzigzag32(w.Varint), nil
+	case KindUint32, KindFixed32:
+		return uint32(w.Varint), nil
+	case KindSfixed32:
+		return int32(uint32(w.Varint)), nil
+	case KindInt64, KindSfixed64:
+		return strconv.FormatInt(int64(w.Varint), 10), nil
+	case KindSint64:
+		return strconv.FormatInt(unzigzag64(w.Varint), 10), nil
+	case KindUint64, KindFixed64:
+		return strconv.FormatUint(w.Varint, 10), nil
+	case KindFloat:
+		return jsonFloat(float64(math.Float32frombits(uint32(w.Varint))), 32), nil
+	case KindDouble:
+		return jsonFloat(math.Float64frombits(w.Varint), 64), nil
+	}
+	return nil, fmt.Errorf("unsupported field type %d", f.Kind)
+}
+
+func jsonFloat(v float64, bits int) interface{} {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	return json.Number(strconv.FormatFloat(v, 'g', -1, bits))
+}
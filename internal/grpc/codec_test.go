@@ -0,0 +1,218 @@
+package grpc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProto = `
+syntax = "proto3";
+
+package shop.v1;
+
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/wrappers.proto";
+import "google/protobuf/struct.proto";
+
+// Orders of the shop.
+service OrderService {
+  rpc GetOrder(GetOrderRequest) returns (Order);
+  rpc WatchOrders(GetOrderRequest) returns (stream Order) {}
+}
+
+message GetOrderRequest {
+  string order_id = 1;
+}
+
+message Order {
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    PAID = 1;
+    SHIPPED = 2;
+  }
+  message Line {
+    string sku = 1;
+    uint32 quantity = 2;
+  }
+
+  string id = 1;
+  int64 total_cents = 2;
+  Status status = 3;
+  repeated Line lines = 4;
+  repeated int32 tags = 5;
+  map<string, string> labels = 6;
+  google.protobuf.Timestamp created_at = 7;
+  google.protobuf.StringValue note = 8;
+  google.protobuf.Struct metadata = 9;
+  bytes signature = 10;
+  double ratio = 11;
+  sint64 delta = 12;
+  optional bool gift = 13;
+  oneof payment {
+    string card = 14;
+    string voucher = 15;
+  }
+}
+`
+
+func loadTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shop.proto")
+	if err := os.WriteFile(path, []byte(testProto), 0o644); err != nil {
+		t.Fatalf("write proto: %v", err)
+	}
+	registry, err := LoadProtoFiles([]string{path}, nil)
+	if err != nil {
+		t.Fatalf("LoadProtoFiles() error = %v", err)
+	}
+	return registry
+}
+
+func TestLoadProtoFilesResolvesServices(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	services := registry.Services()
+	if len(services) != 1 || services[0].FullName != "shop.v1.OrderService" {
+		t.Fatalf("unexpected services: %+v", services)
+	}
+	for _, name := range []string{"shop.v1.OrderService/GetOrder", "/shop.v1.OrderService/GetOrder", "shop.v1.OrderService.GetOrder"} {
+		method, err := registry.FindMethod(name)
+		if err != nil {
+			t.Fatalf("FindMethod(%q) error = %v", name, err)
+		}
+		if method.Input.FullName != "shop.v1.GetOrderRequest" || method.Output.FullName != "shop.v1.Order" {
+			t.Fatalf("unexpected method types: %s -> %s", method.InputType, method.OutputType)
+		}
+	}
+
+	watch, err := registry.FindMethod("shop.v1.OrderService/WatchOrders")
+	if err != nil || !watch.ServerStreaming || watch.ClientStreaming {
+		t.Fatalf("expected server-streaming WatchOrders, got %+v (%v)", watch, err)
+	}
+	if watch.Path() != "/shop.v1.OrderService/WatchOrders" {
+		t.Fatalf("unexpected path %q", watch.Path())
+	}
+
+	order := registry.Message("shop.v1.Order")
+	if f := order.FieldByName("totalCents"); f == nil || f.Name != "total_cents" {
+		t.Fatalf("expected totalCents to resolve to total_cents, got %+v", f)
+	}
+	if f := order.FieldByName("lines"); f.Message == nil || f.Message.FullName != "shop.v1.Order.Line" {
+		t.Fatalf("expected nested message type, got %+v", f)
+	}
+	if f := order.FieldByName("labels"); !f.IsMap() {
+		t.Fatalf("expected labels to be a map")
+	}
+	if !order.FieldByName("gift").Presence || !order.FieldByName("card").Presence {
+		t.Fatalf("expected optional and oneof fields to track presence")
+	}
+}
+
+func TestLoadProtoFilesReportsMissingImport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.proto")
+	source := "syntax = \"proto3\";\nimport \"missing/b.proto\";\nmessage A {}\n"
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatalf("write proto: %v", err)
+	}
+	_, err := LoadProtoFiles([]string{path}, nil)
+	if err == nil || !strings.Contains(err.Error(), `import "missing/b.proto" not found`) {
+		t.Fatalf("expected missing import error, got %v", err)
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	registry := loadTestRegistry(t)
+	order := registry.Message("shop.v1.Order")
+
+	input := `{
+		"id": "ord_1",
+		"total_cents": "9007199254740993",
+		"status": "SHIPPED",
+		"lines": [{"sku": "A-1", "quantity": 2}, {"sku": "B-2"}],
+		"tags": [1, -2, 300],
+		"labels": {"b": "2", "a": "1"},
+		"createdAt": "2024-05-01T10:00:00.5Z",
+		"note": "leave at door",
+		"metadata": {"source": "web", "retries": 2, "flags": [true, null]},
+		"signature": "AQID",
+		"ratio": 0.25,
+		"delta": -5,
+		"gift": false,
+		"voucher": "SPRING"
+	}`
+	data, err := EncodeJSON(order, []byte(input))
+	if err != nil {
+		t.Fatalf("EncodeJSON() error = %v", err)
+	}
+	out, err := DecodeToJSON(order, data)
+	if err != nil {
+		t.Fatalf("DecodeToJSON() error = %v", err)
+	}
+
+	want := `{"id":"ord_1","totalCents":"9007199254740993","status":"SHIPPED",` +
+		`"lines":[{"sku":"A-1","quantity":2},{"sku":"B-2","quantity":0}],"tags":[1,-2,300],` +
+		`"labels":{"a":"1","b":"2"},"createdAt":"2024-05-01T10:00:00.5Z","note":"leave at door",` +
+		`"metadata":{"flags":[true,null],"retries":2,"source":"web"},"signature":"AQID","ratio":0.25,` +
+		`"delta":"-5","gift":false,"voucher":"SPRING"}`
+	if string(out) != want {
+		t.Fatalf("unexpected JSON\n got: %s\nwant: %s", out, want)
+	}
+}
+
+func TestDecodeEmitsDefaults(t *testing.T) {
+	registry := loadTestRegistry(t)
+	out, err := DecodeToJSON(registry.Message("shop.v1.Order"), nil)
+	if err != nil {
+		t.Fatalf("DecodeToJSON() error = %v", err)
+	}
+	want := `{"id":"","totalCents":"0","status":"STATUS_UNSPECIFIED","lines":[],"tags":[],"labels":{},"signature":"","ratio":0,"delta":"0"}`
+	if string(out) != want {
+		t.Fatalf("unexpected JSON\n got: %s\nwant: %s", out, want)
+	}
+}
+
+func TestDecodeAcceptsUnpackedRepeatedFields(t *testing.T) {
+	registry := loadTestRegistry(t)
+	var data []byte
+	for _, v := range []uint64{7, 8} {
+		data = appendTag(data, 5, wireVarint)
+		data = appendVarint(data, v)
+	}
+	out, err := DecodeToJSON(registry.Message("shop.v1.Order"), data)
+	if err != nil {
+		t.Fatalf("DecodeToJSON() error = %v", err)
+	}
+	var decoded struct {
+		Tags []int `json:"tags"`
+	}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(decoded.Tags) != 2 || decoded.Tags[0] != 7 || decoded.Tags[1] != 8 {
+		t.Fatalf("unexpected tags %v", decoded.Tags)
+	}
+}
+
+func TestEncodeJSONRejectsInvalidInput(t *testing.T) {
+	registry := loadTestRegistry(t)
+	order := registry.Message("shop.v1.Order")
+
+	cases := map[string]string{
+		`{"unknown": 1}`:        `unknown field "unknown"`,
+		`{"status": "LOST"}`:    `unknown shop.v1.Order.Status value "LOST"`,
+		`{"lines": {"sku": 1}}`: "expected a JSON array",
+		`{"tags": [1.5]}`:       "expected an integer",
+		`{"id": 3}`:             "expected a string",
+	}
+	for input, want := range cases {
+		_, err := EncodeJSON(order, []byte(input))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("EncodeJSON(%s) error = %v, want %q", input, err, want)
+		}
+	}
+}
@@ -0,0 +1,623 @@
+package grpc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind is the type of a message field, numbered as in descriptor.proto.
+type Kind int
+
+const (
+	KindDouble   Kind = 1
+	KindFloat    Kind = 2
+	KindInt64    Kind = 3
+	KindUint64   Kind = 4
+	KindInt32    Kind = 5
+	KindFixed64  Kind = 6
+	KindFixed32  Kind = 7
+	KindBool     Kind = 8
+	KindString   Kind = 9
+	KindGroup    Kind = 10
+	KindMessage  Kind = 11
+	KindBytes    Kind = 12
+	KindUint32   Kind = 13
+	KindEnum     Kind = 14
+	KindSfixed32 Kind = 15
+	KindSfixed64 Kind = 16
+	KindSint32   Kind = 17
+	KindSint64   Kind = 18
+)
+
+var scalarKinds = map[string]Kind{
+	"double":   KindDouble,
+	"float":    KindFloat,
+	"int64":    KindInt64,
+	"uint64":   KindUint64,
+	"int32":    KindInt32,
+	"fixed64":  KindFixed64,
+	"fixed32":  KindFixed32,
+	"bool":     KindBool,
+	"string":   KindString,
+	"bytes":    KindBytes,
+	"uint32":   KindUint32,
+	"sfixed32": KindSfixed32,
+	"sfixed64": KindSfixed64,
+	"sint32":   KindSint32,
+	"sint64":   KindSint64,
+}
+
+type File struct {
+	Name         string
+	Package      string
+	Syntax       string
+	Dependencies []string
+	Messages     []*Message
+	Enums        []*Enum
+	Services     []*Service
+}
+
+type Message struct {
+	FullName string
+	Fields   []*Field
+	Nested   []*Message
+	Enums    []*Enum
+	MapEntry bool
+	// Packed reports whether repeated scalars are packed by default (proto3).
+	Packed bool
+
+	byNumber map[int]*Field
+	byName   map[string]*Field
+}
+
+type Field struct {
+	Name     string
+	JSONName string
+	Number   int
+	Kind     Kind
+	Repeated bool
+	// Packed overrides the file default ([packed = ...]).
+	Packed *bool
+	// Presence is set for proto3 optional fields and oneof members, which
+	// are omitted from JSON output when unset.
+	Presence bool
+	TypeName string
+	Message  *Message
+	Enum     *Enum
+
+	scope string
+}
+
+type Enum struct {
+	FullName string
+	Values   []EnumValue
+
+	byName   map[string]int32
+	byNumber map[int32]string
+}
+
+type EnumValue struct {
+	Name   string
+	Number int32
+}
+
+type Service struct {
+	FullName string
+	Methods  []*Method
+}
+
+type Method struct {
+	Name            string
+	Service         *Service
+	InputType       string
+	OutputType      string
+	Input           *Message
+	Output          *Message
+	ClientStreaming bool
+	ServerStreaming bool
+
+	scope string
+}
+
+// FullName returns package.Service/Method, the form used on the command line.
+func (m *Method) FullName() string {
+	return m.Service.FullName + "/" + m.Name
+}
+
+// Path returns the HTTP/2 path of the method.
+func (m *Method) Path() string {
+	return "/" + m.FullName()
+}
+
+// IsMap reports whether the field is a map<K, V>.
+func (f *Field) IsMap() bool {
+	return f.Repeated && f.Message != nil && f.Message.MapEntry
+}
+
+func (f *Field) packed(defaultPacked bool) bool {
+	if f.Packed != nil {
+		return *f.Packed
+	}
+	return defaultPacked
+}
+
+func (m *Message) FieldByNumber(number int) *Field {
+	return m.byNumber[number]
+}
+
+// FieldByName finds a field by its proto name or its JSON name.
+func (m *Message) FieldByName(name string) *Field {
+	return m.byName[name]
+}
+
+// Registry holds the descriptors loaded from .proto files or reflection.
+type Registry struct {
+	files    map[string]*File
+	messages map[string]*Message
+	enums    map[string]*Enum
+	services map[string]*Service
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		files:    make(map[string]*File),
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+		services: make(map[string]*Service),
+	}
+}
+
+// HasFile reports whether a file with this name was already added.
+func (r *Registry) HasFile(name string) bool {
+	_, ok := r.files[name]
+	return ok
+}
+
+// AddFile registers the types of f. Call Link once every file is added.
+func (r *Registry) AddFile(f *File) error {
+	if r.HasFile(f.Name) {
+		return nil
+	}
+	r.files[f.Name] = f
+
+	packed := f.Syntax != "" && f.Syntax != "proto2"
+	var addMessage func(m *Message) error
+	addMessage = func(m *Message) error {
+		if _, exists := r.messages[m.FullName]; exists {
+			return fmt.Errorf("duplicate message %s", m.FullName)
+		}
+		m.Packed = packed
+		r.messages[m.FullName] = m
+		for _, nested := range m.Nested {
+			if err := addMessage(nested); err != nil {
+				return err
+			}
+		}
+		for _, e := range m.Enums {
+			if err := r.addEnum(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, m := range f.Messages {
+		if err := addMessage(m); err != nil {
+			return err
+		}
+	}
+	for _, e := range f.Enums {
+		if err := r.addEnum(e); err != nil {
+			return err
+		}
+	}
+	for _, s := range f.Services {
+		if _, exists := r.services[s.FullName]; exists {
+			return fmt.Errorf("duplicate service %s", s.FullName)
+		}
+		r.services[s.FullName] = s
+	}
+	return nil
+}
+
+func (r *Registry) addEnum(e *Enum) error {
+	if _, exists := r.enums[e.FullName]; exists {
+		return fmt.Errorf("duplicate enum %s", e.FullName)
+	}
+	e.byName = make(map[string]int32, len(e.Values))
+	e.byNumber = make(map[int32]string, len(e.Values))
+	for _, v := range e.Values {
+		e.byName[v.Name] = v.Number
+		if _, exists := e.byNumber[v.Number]; !exists {
+			e.byNumber[v.Number] = v.Name
+		}
+	}
+	r.enums[e.FullName] = e
+	return nil
+}
+
+// Link resolves the type references of every field and method.
+func (r *Registry) Link() error {
+	for _, m := range r.messages {
+		m.byNumber = make(map[int]*Field, len(m.Fields))
+		m.byName = make(map[string]*Field, 2*len(m.Fields))
+		for _, f := range m.Fields {
+			m.byNumber[f.Number] = f
+			m.byName[f.Name] = f
+			if f.JSONName == "" {
+				f.JSONName = jsonName(f.Name)
+			}
+			m.byName[f.JSONName] = f
+
+			if f.TypeName == "" || f.Message != nil || f.Enum != nil {
+				continue
+			}
+			name, ok := r.resolve(f.TypeName, f.scope)
+			if !ok {
+				return fmt.Errorf("%s.%s: unknown type %s", m.FullName, f.Name, f.TypeName)
+			}
+			f.TypeName = "." + name
+			if msg, ok := r.messages[name]; ok {
+				if f.Kind != KindGroup {
+					f.Kind = KindMessage
+				}
+				f.Message = msg
+			} else {
+				f.Kind = KindEnum
+				f.Enum = r.enums[name]
+			}
+		}
+		sort.SliceStable(m.Fields, func(i, j int) bool { return m.Fields[i].Number < m.Fields[j].Number })
+	}
+
+	for _, s := range r.services {
+		for _, method := range s.Methods {
+			method.Service = s
+			for _, ref := range []struct {
+				name   *string
+				target **Message
+			}{{&method.InputType, &method.Input}, {&method.OutputType, &method.Output}} {
+				name, ok := r.resolve(*ref.name, method.scope)
+				msg := r.messages[name]
+				if !ok || msg == nil {
+					return fmt.Errorf("%s/%s: unknown message type %s", s.FullName, method.Name, *ref.name)
+				}
+				*ref.name = "." + name
+				*ref.target = msg
+			}
+		}
+	}
+	return nil
+}
+
+// resolve looks a type name up following the protobuf scoping rules: names
+// starting with a dot are absolute, others are searched from the innermost
+// scope outwards.
+func (r *Registry) resolve(name, scope string) (string, bool) {
+	if strings.HasPrefix(name, ".") {
+		name = strings.TrimPrefix(name, ".")
+		return name, r.known(name)
+	}
+	for {
+		candidate := name
+		if scope != "" {
+			candidate = scope + "." + name
+		}
+		if r.known(candidate) {
+			return candidate, true
+		}
+		if scope == "" {
+			return "", false
+		}
+		if i := strings.LastIndex(scope, "."); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+func (r *Registry) known(name string) bool {
+	if _, ok := r.messages[name]; ok {
+		return true
+	}
+	_, ok := r.enums[name]
+	return ok
+}
+
+// Services returns the registered services sorted by name.
+func (r *Registry) Services() []*Service {
+	out := make([]*Service, 0, len(r.services))
+	for _, s := range r.services {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FullName < out[j].FullName })
+	return out
+}
+
+func (r *Registry) Service(name string) *Service {
+	return r.services[strings.TrimPrefix(name, ".")]
+}
+
+func (r *Registry) Message(name string) *Message {
+	return r.messages[strings.TrimPrefix(name, ".")]
+}
+
+// FindMethod accepts package.Service/Method, package.Service.Method and the
+// HTTP/2 path form /package.Service/Method.
+func (r *Registry) FindMethod(name string) (*Method, error) {
+	serviceName, methodName, err := SplitMethod(name)
+	if err != nil {
+		return nil, err
+	}
+	service := r.services[serviceName]
+	if service == nil {
+		return nil, fmt.Errorf("service %q not found", serviceName)
+	}
+	for _, m := range service.Methods {
+		if m.Name == methodName {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("method %q not found in service %s", methodName, serviceName)
+}
+
+// SplitMethod splits a method name into its service and method parts.
+func SplitMethod(name string) (string, string, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	if i := strings.LastIndex(name, "/"); i > 0 {
+		return name[:i], name[i+1:], nil
+	}
+	if i := strings.LastIndex(name, "."); i > 0 {
+		return name[:i], name[i+1:], nil
+	}
+	return "", "", fmt.Errorf("invalid method %q (expected package.Service/Method)", name)
+}
+
+// jsonName converts a field name to lowerCamelCase as protoc does.
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, c := range name {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// decodeFileDescriptor decodes a serialized google.protobuf.FileDescriptorProto
+// as returned by server reflection.
+func decodeFileDescriptor(data []byte) (*File, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{}
+	var messages, enums, services [][]byte
+	for _, field := range fields {
+		switch field.Number {
+		case 1:
+			f.Name = string(field.Bytes)
+		case 2:
+			f.Package = string(field.Bytes)
+		case 3:
+			f.Dependencies = append(f.Dependencies, string(field.Bytes))
+		case 4:
+			messages = append(messages, field.Bytes)
+		case 5:
+			enums = append(enums, field.Bytes)
+		case 6:
+			services = append(services, field.Bytes)
+		case 12:
+			f.Syntax = string(field.Bytes)
+		}
+	}
+
+	for _, data := range messages {
+		m, err := decodeMessageDescriptor(data, f.Package)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		f.Messages = append(f.Messages, m)
+	}
+	for _, data := range enums {
+		e, err := decodeEnumDescriptor(data, f.Package)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		f.Enums = append(f.Enums, e)
+	}
+	for _, data := range services {
+		s, err := decodeServiceDescriptor(data, f.Package)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		f.Services = append(f.Services, s)
+	}
+	return f, nil
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func decodeMessageDescriptor(data []byte, scope string) (*Message, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, err
+	}
+	var name string
+	var rawFields, nested, enums [][]byte
+	mapEntry := false
+	for _, field := range fields {
+		switch field.Number {
+		case 1:
+			name = string(field.Bytes)
+		case 2:
+			rawFields = append(rawFields, field.Bytes)
+		case 3:
+			nested = append(nested, field.Bytes)
+		case 4:
+			enums = append(enums, field.Bytes)
+		case 7:
+			options, err := readFields(field.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, option := range options {
+				if option.Number == 7 && option.Varint != 0 {
+					mapEntry = true
+				}
+			}
+		}
+	}
+
+	m := &Message{FullName: qualify(scope, name), MapEntry: mapEntry}
+	for _, data := range rawFields {
+		f, err := decodeFieldDescriptor(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.FullName, err)
+		}
+		f.scope = m.FullName
+		m.Fields = append(m.Fields, f)
+	}
+	for _, data := range nested {
+		child, err := decodeMessageDescriptor(data, m.FullName)
+		if err != nil {
+			return nil, err
+		}
+		m.Nested = append(m.Nested, child)
+	}
+	for _, data := range enums {
+		e, err := decodeEnumDescriptor(data, m.FullName)
+		if err != nil {
+			return nil, err
+		}
+		m.Enums = append(m.Enums, e)
+	}
+	return m, nil
+}
+
+func decodeFieldDescriptor(data []byte) (*Field, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, err
+	}
+	f := &Field{}
+	hasOneof := false
+	for _, field := range fields {
+		switch field.Number {
+		case 1:
+			f.Name = string(field.Bytes)
+		case 3:
+			f.Number = int(field.Varint)
+		case 4:
+			f.Repeated = field.Varint == 3
+		case 5:
+			f.Kind = Kind(field.Varint)
+		case 6:
+			f.TypeName = string(field.Bytes)
+		case 9:
+			hasOneof = true
+		case 10:
+			f.JSONName = string(field.Bytes)
+		case 17:
+			f.Presence = field.Varint != 0
+		case 8:
+			options, err := readFields(field.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, option := range options {
+				if option.Number == 2 {
+					packed := option.Varint != 0
+					f.Packed = &packed
+				}
+			}
+		}
+	}
+	if hasOneof {
+		f.Presence = true
+	}
+	if f.Kind == KindGroup {
+		return nil, fmt.Errorf("field %s: groups are not supported", f.Name)
+	}
+	return f, nil
+}
+
+func decodeEnumDescriptor(data []byte, scope string) (*Enum, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, err
+	}
+	e := &Enum{}
+	for _, field := range fields {
+		switch field.Number {
+		case 1:
+			e.FullName = qualify(scope, string(field.Bytes))
+		case 2:
+			valueFields, err := readFields(field.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			var value EnumValue
+			for _, vf := range valueFields {
+				switch vf.Number {
+				case 1:
+					value.Name = string(vf.Bytes)
+				case 2:
+					value.Number = int32(vf.Varint)
+				}
+			}
+			e.Values = append(e.Values, value)
+		}
+	}
+	return e, nil
+}
+
+func decodeServiceDescriptor(data []byte, scope string) (*Service, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, err
+	}
+	s := &Service{}
+	for _, field := range fields {
+		switch field.Number {
+		case 1:
+			s.FullName = qualify(scope, string(field.Bytes))
+		case 2:
+			methodFields, err := readFields(field.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			m := &Method{scope: scope}
+			for _, mf := range methodFields {
+				switch mf.Number {
+				case 1:
+					m.Name = string(mf.Bytes)
+				case 2:
+					m.InputType = string(mf.Bytes)
+				case 3:
+					m.OutputType = string(mf.Bytes)
+				case 5:
+					m.ClientStreaming = mf.Varint != 0
+				case 6:
+					m.ServerStreaming = mf.Varint != 0
+				}
+			}
+			s.Methods = append(s.Methods, m)
+		}
+	}
+	return s, nil
+}
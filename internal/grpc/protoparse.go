@@ -0,0 +1,782 @@
+package grpc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LoadProtoFiles parses .proto files and their imports into a linked
+// registry. Imports are searched in importPaths, then in the directory of
+// each file; the well-known google/protobuf types are built in.
+func LoadProtoFiles(files []string, importPaths []string) (*Registry, error) {
+	loader := &protoLoader{
+		registry:    NewRegistry(),
+		importPaths: append([]string(nil), importPaths...),
+		loaded:      make(map[string]bool),
+	}
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if !containsPath(loader.importPaths, dir) {
+			loader.importPaths = append(loader.importPaths, dir)
+		}
+	}
+	for _, file := range files {
+		if err := loader.loadFile(file, importName(file, loader.importPaths)); err != nil {
+			return nil, err
+		}
+	}
+	if err := loader.registry.Link(); err != nil {
+		return nil, err
+	}
+	return loader.registry, nil
+}
+
+type protoLoader struct {
+	registry    *Registry
+	importPaths []string
+	loaded      map[string]bool
+	stack       []string
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if filepath.Clean(p) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// importName returns the name a file is known by in import statements: its
+// path relative to the first import path containing it.
+func importName(file string, importPaths []string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	for _, dir := range importPaths {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(absDir, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(filepath.Base(file))
+}
+
+func (l *protoLoader) loadFile(path, name string) error {
+	if l.loaded[name] {
+		return nil
+	}
+	for _, pending := range l.stack {
+		if pending == name {
+			return fmt.Errorf("import cycle: %s -> %s", strings.Join(l.stack, " -> "), name)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading proto file %q: %w", path, err)
+	}
+	return l.loadSource(name, string(data))
+}
+
+func (l *protoLoader) loadSource(name, source string) error {
+	l.stack = append(l.stack, name)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	file, err := parseProto(name, source)
+	if err != nil {
+		return err
+	}
+	for _, dep := range file.Dependencies {
+		if err := l.loadImport(dep); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	l.loaded[name] = true
+	return l.registry.AddFile(file)
+}
+
+func (l *protoLoader) loadImport(name string) error {
+	if l.loaded[name] {
+		return nil
+	}
+	for _, dir := range l.importPaths {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(path); err == nil {
+			return l.loadFile(path, name)
+		}
+	}
+	if source, ok := wellKnownProtos[name]; ok {
+		return l.loadSource(name, source)
+	}
+	return fmt.Errorf("import %q not found (use --import-path)", name)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+func tokenize(source string) ([]token, error) {
+	tokens := make([]token, 0, len(source)/4)
+	line := 1
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(c):
+			i++
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			i += 2
+		case c == '"' || c == '\'':
+			quote := c
+			start := line
+			var b strings.Builder
+			i++
+			for i < len(runes) && runes[i] != quote {
+				if runes[i] == '\n' {
+					return nil, fmt.Errorf("line %d: unterminated string", start)
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						b.WriteRune('\n')
+					case 't':
+						b.WriteRune('\t')
+					case 'r':
+						b.WriteRune('\r')
+					default:
+						b.WriteRune(runes[i])
+					}
+					i++
+					continue
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated string", start)
+			}
+			i++
+			// Adjacent string literals are concatenated.
+			if n := len(tokens); n > 0 && tokens[n-1].kind == tokenString {
+				tokens[n-1].text += b.String()
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), line: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), line: line})
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '.' ||
+				((runes[i] == '+' || runes[i] == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), line: line})
+		default:
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(c), line: line})
+			i++
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, line: line})
+	return tokens, nil
+}
+
+type protoParser struct {
+	name   string
+	tokens []token
+	pos    int
+	file   *File
+}
+
+func parseProto(name, source string) (*File, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	p := &protoParser{name: name, tokens: tokens, file: &File{Name: name, Syntax: "proto2"}}
+	if err := p.parseFile(); err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+func (p *protoParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *protoParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *protoParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", p.name, p.peek().line, fmt.Sprintf(format, args...))
+}
+
+func (p *protoParser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokenSymbol || t.kind == tokenIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *protoParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q, found %q", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *protoParser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokenIdent {
+		return "", p.errorf("expected identifier, found %q", t.text)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// fullIdent reads a dotted name, keeping a leading dot (absolute reference).
+func (p *protoParser) fullIdent() (string, error) {
+	var b strings.Builder
+	if p.accept(".") {
+		b.WriteString(".")
+	}
+	name, err := p.ident()
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(name)
+	for p.accept(".") {
+		name, err := p.ident()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("." + name)
+	}
+	return b.String(), nil
+}
+
+func (p *protoParser) stringLiteral() (string, error) {
+	t := p.peek()
+	if t.kind != tokenString {
+		return "", p.errorf("expected string, found %q", t.text)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *protoParser) integer() (int64, error) {
+	negative := p.accept("-")
+	t := p.peek()
+	if t.kind != tokenNumber {
+		return 0, p.errorf("expected number, found %q", t.text)
+	}
+	p.pos++
+	v, err := strconv.ParseInt(t.text, 0, 64)
+	if err != nil {
+		return 0, p.errorf("invalid number %q", t.text)
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// skipStatement skips tokens up to the end of the statement, including a
+// nested {...} block.
+func (p *protoParser) skipStatement() error {
+	depth := 0
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokenEOF:
+			return p.errorf("unexpected end of file")
+		case t.text == "{" && t.kind == tokenSymbol:
+			depth++
+		case t.text == "}" && t.kind == tokenSymbol:
+			depth--
+			if depth == 0 {
+				p.accept(";")
+				return nil
+			}
+		case t.text == ";" && t.kind == tokenSymbol && depth == 0:
+			return nil
+		}
+	}
+}
+
+func (p *protoParser) parseFile() error {
+	for p.peek().kind != tokenEOF {
+		t := p.peek()
+		switch {
+		case p.accept(";"):
+		case t.text == "syntax" || t.text == "edition":
+			p.next()
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			value, err := p.stringLiteral()
+			if err != nil {
+				return err
+			}
+			if t.text == "edition" {
+				value = "editions"
+			}
+			p.file.Syntax = value
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case t.text == "package":
+			p.next()
+			name, err := p.fullIdent()
+			if err != nil {
+				return err
+			}
+			p.file.Package = name
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case t.text == "import":
+			p.next()
+			if !p.accept("public") {
+				p.accept("weak")
+			}
+			path, err := p.stringLiteral()
+			if err != nil {
+				return err
+			}
+			p.file.Dependencies = append(p.file.Dependencies, path)
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case t.text == "option" || t.text == "extend":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		case t.text == "message":
+			p.next()
+			m, err := p.parseMessage(p.file.Package)
+			if err != nil {
+				return err
+			}
+			p.file.Messages = append(p.file.Messages, m)
+		case t.text == "enum":
+			p.next()
+			e, err := p.parseEnum(p.file.Package)
+			if err != nil {
+				return err
+			}
+			p.file.Enums = append(p.file.Enums, e)
+		case t.text == "service":
+			p.next()
+			s, err := p.parseService()
+			if err != nil {
+				return err
+			}
+			p.file.Services = append(p.file.Services, s)
+		default:
+			return p.errorf("unexpected %q", t.text)
+		}
+	}
+	return nil
+}
+
+func (p *protoParser) parseMessage(scope string) (*Message, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{FullName: qualify(scope, name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if err := p.parseMessageBody(m, false); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseMessageBody reads fields and nested declarations up to the closing
+// brace. Inside a oneof, fields have no label and always track presence.
+func (p *protoParser) parseMessageBody(m *Message, oneof bool) error {
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenEOF:
+			return p.errorf("unexpected end of file in message %s", m.FullName)
+		case p.accept("}"):
+			return nil
+		case p.accept(";"):
+		case t.text == "option" || t.text == "reserved" || t.text == "extensions" || t.text == "extend":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		case t.text == "message" && !oneof:
+			p.next()
+			nested, err := p.parseMessage(m.FullName)
+			if err != nil {
+				return err
+			}
+			m.Nested = append(m.Nested, nested)
+		case t.text == "enum" && !oneof:
+			p.next()
+			e, err := p.parseEnum(m.FullName)
+			if err != nil {
+				return err
+			}
+			m.Enums = append(m.Enums, e)
+		case t.text == "oneof" && !oneof:
+			p.next()
+			if _, err := p.ident(); err != nil {
+				return err
+			}
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			if err := p.parseMessageBody(m, true); err != nil {
+				return err
+			}
+		case t.text == "map" && p.tokens[p.pos+1].text == "<":
+			p.next()
+			if err := p.parseMapField(m); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseField(m, oneof); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *protoParser) parseField(m *Message, oneof bool) error {
+	f := &Field{scope: m.FullName, Presence: oneof}
+	switch {
+	case p.accept("repeated"):
+		f.Repeated = true
+	case p.accept("optional"):
+		f.Presence = true
+	case p.accept("required"):
+	}
+	if p.peek().text == "group" {
+		return p.errorf("groups are not supported")
+	}
+
+	typeName, err := p.fullIdent()
+	if err != nil {
+		return err
+	}
+	if kind, ok := scalarKinds[typeName]; ok {
+		f.Kind = kind
+	} else {
+		f.TypeName = typeName
+	}
+
+	if f.Name, err = p.ident(); err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	number, err := p.integer()
+	if err != nil {
+		return err
+	}
+	f.Number = int(number)
+	if err := p.parseFieldOptions(f); err != nil {
+		return err
+	}
+	m.Fields = append(m.Fields, f)
+	return p.expect(";")
+}
+
+// parseMapField turns map<K, V> name = N; into a repeated field of a
+// synthetic NameEntry message, as protoc does.
+func (p *protoParser) parseMapField(m *Message) error {
+	if err := p.expect("<"); err != nil {
+		return err
+	}
+	keyType, err := p.ident()
+	if err != nil {
+		return err
+	}
+	keyKind, ok := scalarKinds[keyType]
+	if !ok || keyKind == KindDouble || keyKind == KindFloat || keyKind == KindBytes {
+		return p.errorf("invalid map key type %q", keyType)
+	}
+	if err := p.expect(","); err != nil {
+		return err
+	}
+	valueType, err := p.fullIdent()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(">"); err != nil {
+		return err
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	number, err := p.integer()
+	if err != nil {
+		return err
+	}
+
+	entryName := strings.ToUpper(jsonName(name)[:1]) + jsonName(name)[1:] + "Entry"
+	entry := &Message{FullName: m.FullName + "." + entryName, MapEntry: true}
+	entry.Fields = append(entry.Fields, &Field{Name: "key", Number: 1, Kind: keyKind, scope: m.FullName})
+	value := &Field{Name: "value", Number: 2, scope: m.FullName}
+	if kind, ok := scalarKinds[valueType]; ok {
+		value.Kind = kind
+	} else {
+		value.TypeName = valueType
+	}
+	entry.Fields = append(entry.Fields, value)
+	m.Nested = append(m.Nested, entry)
+
+	f := &Field{
+		Name:     name,
+		Number:   int(number),
+		Repeated: true,
+		Kind:     KindMessage,
+		TypeName: "." + entry.FullName,
+		scope:    m.FullName,
+	}
+	if err := p.parseFieldOptions(f); err != nil {
+		return err
+	}
+	m.Fields = append(m.Fields, f)
+	return p.expect(";")
+}
+
+// parseFieldOptions reads [packed = ..., json_name = "..."]; other options
+// are skipped.
+func (p *protoParser) parseFieldOptions(f *Field) error {
+	if !p.accept("[") {
+		return nil
+	}
+	for {
+		var name string
+		if p.accept("(") {
+			for !p.accept(")") {
+				if p.next().kind == tokenEOF {
+					return p.errorf("unterminated option name")
+				}
+			}
+			for p.accept(".") {
+				if _, err := p.ident(); err != nil {
+					return err
+				}
+			}
+		} else {
+			var err error
+			if name, err = p.fullIdent(); err != nil {
+				return err
+			}
+		}
+		if err := p.expect("="); err != nil {
+			return err
+		}
+
+		value := p.peek()
+		if value.text == "{" {
+			depth := 0
+			for {
+				t := p.next()
+				if t.kind == tokenEOF {
+					return p.errorf("unterminated option value")
+				}
+				if t.text == "{" {
+					depth++
+				} else if t.text == "}" {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+		} else {
+			p.accept("-")
+			p.next()
+		}
+
+		switch name {
+		case "packed":
+			packed := value.text == "true"
+			f.Packed = &packed
+		case "json_name":
+			f.JSONName = value.text
+		}
+
+		if p.accept("]") {
+			return nil
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *protoParser) parseEnum(scope string) (*Enum, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	e := &Enum{FullName: qualify(scope, name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenEOF:
+			return nil, p.errorf("unexpected end of file in enum %s", e.FullName)
+		case p.accept("}"):
+			return e, nil
+		case p.accept(";"):
+		case t.text == "option" || t.text == "reserved":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		default:
+			valueName, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			number, err := p.integer()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.parseFieldOptions(&Field{}); err != nil {
+				return nil, err
+			}
+			e.Values = append(e.Values, EnumValue{Name: valueName, Number: int32(number)})
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (p *protoParser) parseService() (*Service, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	s := &Service{FullName: qualify(p.file.Package, name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenEOF:
+			return nil, p.errorf("unexpected end of file in service %s", s.FullName)
+		case p.accept("}"):
+			return s, nil
+		case p.accept(";"):
+		case t.text == "option":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case p.accept("rpc"):
+			m := &Method{scope: p.file.Package}
+			if m.Name, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if m.ClientStreaming, m.InputType, err = p.parseRPCType(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("returns"); err != nil {
+				return nil, err
+			}
+			if m.ServerStreaming, m.OutputType, err = p.parseRPCType(); err != nil {
+				return nil, err
+			}
+			if p.peek().text == "{" {
+				if err := p.skipStatement(); err != nil {
+					return nil, err
+				}
+			} else if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+			s.Methods = append(s.Methods, m)
+		default:
+			return nil, p.errorf("unexpected %q in service %s", t.text, s.FullName)
+		}
+	}
+}
+
+func (p *protoParser) parseRPCType() (bool, string, error) {
+	if err := p.expect("("); err != nil {
+		return false, "", err
+	}
+	stream := false
+	// "stream" is also a valid message name: it is a keyword only when a
+	// type name follows.
+	if p.peek().text == "stream" && p.tokens[p.pos+1].kind == tokenIdent || p.peek().text == "stream" && p.tokens[p.pos+1].text == "." {
+		p.next()
+		stream = true
+	}
+	name, err := p.fullIdent()
+	if err != nil {
+		return false, "", err
+	}
+	return stream, name, p.expect(")")
+}
@@ -0,0 +1,211 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var reflectionPaths = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+// ServerReflectionRequest and ServerReflectionResponse field numbers.
+const (
+	reflectFileByFilename       = 3
+	reflectFileContainingSymbol = 4
+	reflectListServices         = 7
+
+	reflectFileDescriptorResponse = 4
+	reflectListServicesResponse   = 6
+	reflectErrorResponse          = 7
+)
+
+type reflectionResponse struct {
+	files    [][]byte
+	services []string
+}
+
+// Reflect loads descriptors through the server reflection service. When
+// services is empty every service the server lists is loaded.
+func (c *Client) Reflect(ctx context.Context, metadata http.Header, services ...string) (*Registry, error) {
+	if len(services) == 0 {
+		resp, err := c.reflect(ctx, metadata, reflectListServices, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range resp.services {
+			if !strings.HasPrefix(name, "grpc.reflection.") {
+				services = append(services, name)
+			}
+		}
+		sort.Strings(services)
+	}
+
+	files := make(map[string]*File)
+	var order []string
+	add := func(raw [][]byte) error {
+		for _, data := range raw {
+			file, err := decodeFileDescriptor(data)
+			if err != nil {
+				return fmt.Errorf("decoding file descriptor: %w", err)
+			}
+			if _, seen := files[file.Name]; !seen {
+				files[file.Name] = file
+				order = append(order, file.Name)
+			}
+		}
+		return nil
+	}
+
+	for _, service := range services {
+		resp, err := c.reflect(ctx, metadata, reflectFileContainingSymbol, service)
+		if err != nil {
+			return nil, fmt.Errorf("resolving service %s: %w", service, err)
+		}
+		if err := add(resp.files); err != nil {
+			return nil, err
+		}
+	}
+
+	// Servers usually send the transitive dependencies along with a file;
+	// fetch whatever is still missing by name.
+	for i := 0; i < len(order); i++ {
+		for _, dep := range files[order[i]].Dependencies {
+			if _, ok := files[dep]; ok {
+				continue
+			}
+			resp, err := c.reflect(ctx, metadata, reflectFileByFilename, dep)
+			if err == nil {
+				err = add(resp.files)
+			}
+			if _, ok := files[dep]; ok {
+				continue
+			}
+			source, known := wellKnownProtos[dep]
+			if !known {
+				if err == nil {
+					err = fmt.Errorf("not returned by the server")
+				}
+				return nil, fmt.Errorf("resolving import %s: %w", dep, err)
+			}
+			file, parseErr := parseProto(dep, source)
+			if parseErr != nil {
+				return nil, parseErr
+			}
+			files[dep] = file
+			order = append(order, dep)
+		}
+	}
+
+	registry := NewRegistry()
+	for _, name := range order {
+		if err := registry.AddFile(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := registry.Link(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// reflect sends one ServerReflectionRequest, trying the v1 service first and
+// falling back to v1alpha for older servers.
+func (c *Client) reflect(ctx context.Context, metadata http.Header, field int, value string) (*reflectionResponse, error) {
+	var payload []byte
+	if field == reflectListServices {
+		payload = appendBytesField(nil, field, []byte("*"))
+	} else {
+		payload = appendBytesField(nil, field, []byte(value))
+	}
+
+	paths := reflectionPaths
+	if c.reflectionPath != "" {
+		paths = []string{c.reflectionPath}
+	}
+	var lastStatus Status
+	for _, path := range paths {
+		var messages [][]byte
+		_, status, err := c.call(ctx, path, metadata, payload, func(data []byte) {
+			messages = append(messages, data)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if status.Code == Unimplemented {
+			lastStatus = status
+			continue
+		}
+		if status.Code != OK {
+			return nil, fmt.Errorf("server reflection failed: %s: %s", status.Code, status.Message)
+		}
+		c.reflectionPath = path
+		if len(messages) == 0 {
+			return nil, fmt.Errorf("server reflection returned no response")
+		}
+		return decodeReflectionResponse(messages[0])
+	}
+	return nil, fmt.Errorf("server reflection is not available (%s: %s); pass --proto files instead", lastStatus.Code, lastStatus.Message)
+}
+
+func decodeReflectionResponse(data []byte) (*reflectionResponse, error) {
+	fields, err := readFields(data)
+	if err != nil {
+		return nil, err
+	}
+	resp := &reflectionResponse{}
+	for _, field := range fields {
+		switch field.Number {
+		case reflectFileDescriptorResponse:
+			inner, err := readFields(field.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range inner {
+				if f.Number == 1 {
+					resp.files = append(resp.files, f.Bytes)
+				}
+			}
+		case reflectListServicesResponse:
+			inner, err := readFields(field.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range inner {
+				if f.Number != 1 {
+					continue
+				}
+				service, err := readFields(f.Bytes)
+				if err != nil {
+					return nil, err
+				}
+				for _, sf := range service {
+					if sf.Number == 1 {
+						resp.services = append(resp.services, string(sf.Bytes))
+					}
+				}
+			}
+		case reflectErrorResponse:
+			inner, err := readFields(field.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			var code uint64
+			var message string
+			for _, f := range inner {
+				switch f.Number {
+				case 1:
+					code = f.Varint
+				case 2:
+					message = string(f.Bytes)
+				}
+			}
+			return nil, fmt.Errorf("%s: %s", Code(code), message)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireGroupS  = 3
+	wireGroupE  = 4
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("protobuf: truncated message")
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, number int, wireType int) []byte {
+	return appendVarint(b, uint64(number)<<3|uint64(wireType))
+}
+
+func appendBytesField(b []byte, number int, data []byte) []byte {
+	b = appendTag(b, number, wireBytes)
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendFixed32(b []byte, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(b, v)
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+func zigzag32(v int32) uint64 { return uint64(uint32((v << 1) ^ (v >> 31))) }
+func zigzag64(v int64) uint64 { return uint64((v << 1) ^ (v >> 63)) }
+
+func unzigzag32(v uint64) int32 { return int32(uint32(v)>>1) ^ -int32(v&1) }
+func unzigzag64(v uint64) int64 { return int64(v>>1) ^ -int64(v&1) }
+
+// wireField is one decoded field of a message: Varint holds varint and fixed
+// values, Bytes the payload of length-delimited fields.
+type wireField struct {
+	Number int
+	Type   int
+	Varint uint64
+	Bytes  []byte
+}
+
+func readVarint(b []byte) (uint64, int, error) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7F) << (7 * i)
+		if b[i] < 0x80 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errTruncated
+}
+
+// readFields splits an encoded message into its fields, in wire order.
+func readFields(b []byte) ([]wireField, error) {
+	fields := make([]wireField, 0)
+	for len(b) > 0 {
+		tag, n, err := readVarint(b)
+		if err != nil {
+			return nil, err
+		}
+		b = b[n:]
+		field := wireField{Number: int(tag >> 3), Type: int(tag & 7)}
+		if field.Number <= 0 {
+			return nil, fmt.Errorf("protobuf: invalid field number %d", field.Number)
+		}
+
+		switch field.Type {
+		case wireVarint:
+			field.Varint, n, err = readVarint(b)
+			if err != nil {
+				return nil, err
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return nil, errTruncated
+			}
+			field.Varint = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return nil, errTruncated
+			}
+			field.Varint = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		case wireBytes:
+			length, n, err := readVarint(b)
+			if err != nil {
+				return nil, err
+			}
+			b = b[n:]
+			if uint64(len(b)) < length {
+				return nil, errTruncated
+			}
+			field.Bytes = b[:length]
+			b = b[length:]
+		case wireGroupS, wireGroupE:
+			return nil, fmt.Errorf("protobuf: groups are not supported (field %d)", field.Number)
+		default:
+			return nil, fmt.Errorf("protobuf: unknown wire type %d", field.Type)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func float32Bits(v float64) uint32 { return math.Float32bits(float32(v)) }
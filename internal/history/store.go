@@ -2,26 +2,57 @@ package history
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 const filePath = ".apix/history.jsonl"
 
+// fileMu serialises writers so concurrent requests append whole lines.
+var fileMu sync.Mutex
+
+// MaxResponseSampleSize bounds the JSON response bodies kept in history.
+const MaxResponseSampleSize = 16 * 1024
+
 type Entry struct {
-	Method       string    `json:"method"`
-	Path         string    `json:"path"`
-	Status       int       `json:"status"`
-	DurationMS   int64     `json:"duration_ms"`
-	ResponseSize int       `json:"response_size"`
-	Timestamp    time.Time `json:"timestamp"`
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Request      string          `json:"request,omitempty"`
+	Status       int             `json:"status"`
+	DurationMS   int64           `json:"duration_ms"`
+	ResponseSize int             `json:"response_size"`
+	ResponseBody json.RawMessage `json:"response_body,omitempty"`
+	// Timing holds the request phases in milliseconds (dns, connect, tls,
+	// wait, ttfb, transfer, total).
+	Timing map[string]int64 `json:"timing_ms,omitempty"`
+	// Retries is the number of attempts retried by the retry policy.
+	Retries   int       `json:"retries,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ResponseSample returns body when it is a JSON document small enough to be
+// recorded in history, and nil otherwise.
+func ResponseSample(body []byte) json.RawMessage {
+	if len(body) == 0 || len(body) > MaxResponseSampleSize || !json.Valid(body) {
+		return nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err != nil {
+		return nil
+	}
+	return compact.Bytes()
 }
 
 func Append(entry Entry) error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
 	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
 		return fmt.Errorf("creating history directory: %w", err)
 	}
@@ -91,6 +122,9 @@ func Read(limit int) ([]Entry, error) {
 }
 
 func Clear() error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
 			return nil
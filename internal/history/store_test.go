@@ -2,6 +2,8 @@ package history
 
 import (
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -79,3 +81,45 @@ func withTempDirAsWorkingDirHistory(t *testing.T) {
 		t.Fatalf("changing to temp dir: %v", err)
 	}
 }
+
+func TestResponseSampleKeepsSmallJSONOnly(t *testing.T) {
+	if got := string(ResponseSample([]byte("{\n  \"ok\": true\n}"))); got != `{"ok":true}` {
+		t.Fatalf("expected compacted JSON sample, got %q", got)
+	}
+	if got := ResponseSample([]byte("<html></html>")); got != nil {
+		t.Fatalf("expected nil sample for non-JSON body, got %q", got)
+	}
+	large := make([]byte, MaxResponseSampleSize+1)
+	for i := range large {
+		large[i] = ' '
+	}
+	large[0], large[1] = '[', ']'
+	if got := ResponseSample(large); got != nil {
+		t.Fatalf("expected nil sample for oversized body")
+	}
+}
+
+func TestAppendConcurrentWritersKeepWholeLines(t *testing.T) {
+	withTempDirAsWorkingDirHistory(t)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := Append(Entry{Method: "GET", Path: strings.Repeat("x", 4096), Status: 200 + i}); err != nil {
+				t.Errorf("append: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err := Read(0)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(entries) != writers {
+		t.Fatalf("expected %d entries, got %d", writers, len(entries))
+	}
+}
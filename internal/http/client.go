@@ -2,13 +2,10 @@ package apixhttp
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
-	"net/url"
-	"strings"
 	"time"
 )
 
@@ -17,16 +14,29 @@ type Doer interface {
 }
 
 type Client struct {
-	httpClient Doer
-	retry      int
-	retryDelay time.Duration
-	initErr    error
+	httpClient  Doer
+	retry       int
+	retryDelay  time.Duration
+	policy      RetryPolicy
+	onRetry     func(RetryAttempt)
+	rateLimit   RateLimit
+	onRateLimit func(host string, wait time.Duration)
+	digest      *digestSession
+	pool        *Pool
+	initErr     error
 }
 
 type ClientConfig struct {
 	Timeout         time.Duration
 	FollowRedirects bool
 	Network         NetworkOptions
+	Digest          *DigestCredentials
+	// OnRetry, when set, is called before Send waits to retry an attempt.
+	OnRetry func(RetryAttempt)
+	// RateLimit throttles every attempt, shared with the other clients of
+	// the process; OnRateLimit is called before a request waits for it.
+	RateLimit   RateLimit
+	OnRateLimit func(host string, wait time.Duration)
 }
 
 type RequestOptions struct {
@@ -35,99 +45,8 @@ type RequestOptions struct {
 	Headers map[string]string
 	Query   map[string]string
 	Body    io.Reader
-}
-
-func NewClient(timeout time.Duration) *Client {
-	return NewClientWithConfig(ClientConfig{
-		Timeout:         timeout,
-		FollowRedirects: true,
-	})
-}
-
-func NewClientWithConfig(cfg ClientConfig) *Client {
-	client := &Client{}
-
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		ForceAttemptHTTP2:     true,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}
-
-	if strings.TrimSpace(cfg.Network.ProxyURL) != "" {
-		parsedProxy, err := url.Parse(strings.TrimSpace(cfg.Network.ProxyURL))
-		if err != nil {
-			client.initErr = fmt.Errorf("invalid proxy URL %q: %w", cfg.Network.ProxyURL, err)
-			return client
-		}
-		transport.Proxy = http.ProxyURL(parsedProxy)
-	}
-
-	tlsConfig := &tls.Config{}
-	if cfg.Network.Insecure {
-		tlsConfig.InsecureSkipVerify = true
-	}
-	if cfg.Network.CertFile != "" || cfg.Network.KeyFile != "" {
-		if cfg.Network.CertFile == "" || cfg.Network.KeyFile == "" {
-			client.initErr = fmt.Errorf("--cert and --key must be provided together")
-			return client
-		}
-		cert, err := tls.LoadX509KeyPair(cfg.Network.CertFile, cfg.Network.KeyFile)
-		if err != nil {
-			client.initErr = fmt.Errorf("loading client TLS certificate/key: %w", err)
-			return client
-		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
-	}
-	if cfg.Network.Insecure || len(tlsConfig.Certificates) > 0 {
-		transport.TLSClientConfig = tlsConfig
-	}
-
-	var jar http.CookieJar
-	if !cfg.Network.NoCookies {
-		jarPath := cfg.Network.CookieJarPath
-		if strings.TrimSpace(jarPath) == "" {
-			jarPath = DefaultCookieJarPath
-		}
-		persistentJar, err := NewPersistentCookieJar(jarPath)
-		if err != nil {
-			client.initErr = err
-			return client
-		}
-		jar = persistentJar
-	}
-
-	httpClient := &http.Client{
-		Timeout:   cfg.Timeout,
-		Transport: transport,
-		Jar:       jar,
-	}
-
-	if !cfg.FollowRedirects {
-		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}
-	}
-
-	retry := cfg.Network.Retry
-	if retry < 0 {
-		retry = 0
-	}
-	retryDelay := cfg.Network.RetryDelay
-	if retry > 0 && retryDelay <= 0 {
-		retryDelay = DefaultRetryDelay
-	}
-
-	client.httpClient = httpClient
-	client.retry = retry
-	client.retryDelay = retryDelay
-	return client
+	// Sign, when set, signs every attempt once it is built (aws_sigv4).
+	Sign func(req *http.Request, body []byte) error
 }
 
 func NewClientWithDoer(d Doer) *Client {
@@ -137,7 +56,8 @@ func NewClientWithDoer(d Doer) *Client {
 }
 
 // Send builds and executes an HTTP request, returning the parsed response.
-func (c *Client) Send(opts RequestOptions) (*Response, error) {
+// Cancelling ctx aborts the request in flight as well as a retry backoff.
+func (c *Client) Send(ctx context.Context, opts RequestOptions) (*Response, error) {
 	if c.initErr != nil {
 		return nil, c.initErr
 	}
@@ -148,13 +68,12 @@ func (c *Client) Send(opts RequestOptions) (*Response, error) {
 	}
 
 	attempts := c.retry + 1
-	if attempts < 1 {
+	if attempts < 1 || !c.policy.retriesMethod(opts.Method) {
 		attempts = 1
 	}
 
-	var lastErr error
-	for attempt := 1; attempt <= attempts; attempt++ {
-		req, err := BuildRequest(RequestOptions{
+	build := func() (*http.Request, error) {
+		req, err := BuildRequest(ctx, RequestOptions{
 			Method:  opts.Method,
 			URL:     opts.URL,
 			Headers: opts.Headers,
@@ -164,27 +83,50 @@ func (c *Client) Send(opts RequestOptions) (*Response, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := signRequest(opts.Sign, req, bodyBytes); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		req, err := build()
+		if err != nil {
+			return nil, err
+		}
 
 		start := time.Now()
-		resp, err := c.httpClient.Do(req)
+		req, resp, trace, err := c.do(req, bodyBytes, build)
 		duration := time.Since(start)
 
 		if err != nil {
 			lastErr = err
 			if attempt < attempts && shouldRetryNetworkError(err) {
-				sleepRetryDelay(c.retryDelay, attempt)
+				if err := c.waitRetry(ctx, RetryAttempt{Attempt: attempt, Err: err}, nil); err != nil {
+					return nil, fmt.Errorf("sending request: %w", err)
+				}
 				continue
 			}
 			return nil, fmt.Errorf("sending request: %w", err)
 		}
 
-		if attempt < attempts && shouldRetryStatus(resp.StatusCode) {
+		if attempt < attempts && c.policy.retriesStatus(resp.StatusCode) {
 			_ = resp.Body.Close()
-			sleepRetryDelay(c.retryDelay, attempt)
+			if err := c.waitRetry(ctx, RetryAttempt{Attempt: attempt, StatusCode: resp.StatusCode, Status: resp.Status}, resp.Header); err != nil {
+				return nil, fmt.Errorf("sending request: %w", err)
+			}
 			continue
 		}
 
-		return ParseResponse(resp, duration)
+		parsed, err := ParseResponse(resp, duration)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Method = req.Method
+		parsed.URL = req.URL.String()
+		parsed.Timing = trace.timing(time.Now())
+		return parsed, nil
 	}
 
 	if lastErr != nil {
@@ -193,8 +135,9 @@ func (c *Client) Send(opts RequestOptions) (*Response, error) {
 	return nil, fmt.Errorf("request failed after retries")
 }
 
-func BuildRequest(opts RequestOptions) (*http.Request, error) {
-	req, err := http.NewRequest(opts.Method, opts.URL, opts.Body)
+// BuildRequest returns the request described by opts, bound to ctx.
+func BuildRequest(ctx context.Context, opts RequestOptions) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, opts.Method, opts.URL, opts.Body)
 	if err != nil {
 		return nil, fmt.Errorf("building request: %w", err)
 	}
@@ -214,6 +157,47 @@ func BuildRequest(opts RequestOptions) (*http.Request, error) {
 	return req, nil
 }
 
+// do sends req once the rate limit allows it, tracing the timing of the
+// request that produced the returned response. When the client has Digest
+// credentials, it answers the server's challenge by sending the request built
+// again by rebuild.
+func (c *Client) do(req *http.Request, body []byte, rebuild func() (*http.Request, error)) (*http.Request, *http.Response, *timingTrace, error) {
+	resp, trace, err := c.send(req, body)
+	if err != nil || !c.digest.challenged(req, resp) {
+		return req, resp, trace, err
+	}
+	_ = resp.Body.Close()
+
+	if req, err = rebuild(); err != nil {
+		return nil, nil, nil, err
+	}
+	resp, trace, err = c.send(req, body)
+	return req, resp, trace, err
+}
+
+func (c *Client) send(req *http.Request, body []byte) (*http.Response, *timingTrace, error) {
+	if err := c.throttle(req.Context(), req.URL.Host); err != nil {
+		return nil, nil, err
+	}
+	c.digest.authorize(req, body)
+	req, trace := traceTiming(req)
+	resp, err := c.httpClient.Do(req)
+	if err == nil {
+		c.pool.record(trace)
+	}
+	return resp, trace, err
+}
+
+func signRequest(sign func(*http.Request, []byte) error, req *http.Request, body []byte) error {
+	if sign == nil {
+		return nil
+	}
+	if err := sign(req, body); err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+	return nil
+}
+
 func readBodyBytes(body io.Reader) ([]byte, error) {
 	if body == nil {
 		return nil, nil
@@ -221,9 +205,16 @@ func readBodyBytes(body io.Reader) ([]byte, error) {
 	return io.ReadAll(body)
 }
 
-func sleepRetryDelay(base time.Duration, attempt int) {
-	delay := retryDelayForAttempt(base, attempt)
-	if delay > 0 {
-		time.Sleep(delay)
+// waitRetry reports the failed attempt to the OnRetry hook and sleeps until
+// the next one, honouring the Retry-After header of a response. It returns
+// the error of ctx when ctx is done before then.
+func (c *Client) waitRetry(ctx context.Context, failed RetryAttempt, header http.Header) error {
+	failed.Delay, failed.RetryAfter = c.policy.delay(c.retryDelay, failed.Attempt, header, time.Now())
+	if c.onRetry != nil {
+		c.onRetry(failed)
+	}
+	if failed.Delay > 0 {
+		sleepContext(ctx, failed.Delay)
 	}
+	return ctx.Err()
 }
@@ -3,15 +3,33 @@ package apixhttp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// newTestClient returns a client of a pool closed at the end of the test,
+// with its cookie jar under a temporary directory.
+func newTestClient(t *testing.T, cfg ClientConfig) *Client {
+	t.Helper()
+	if cfg.Network.CookieJarPath == "" {
+		cfg.Network.CookieJarPath = filepath.Join(t.TempDir(), "cookies.json")
+	}
+	pool := NewPool()
+	t.Cleanup(func() {
+		if err := pool.Close(); err != nil {
+			t.Errorf("closing pool: %v", err)
+		}
+	})
+	return pool.NewClient(cfg)
+}
+
 func TestClientNoFollowRedirect(t *testing.T) {
 	t.Parallel()
 
@@ -25,12 +43,12 @@ func TestClientNoFollowRedirect(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	client := NewClientWithConfig(ClientConfig{
+	client := newTestClient(t, ClientConfig{
 		Timeout:         2 * time.Second,
 		FollowRedirects: false,
 	})
 
-	resp, err := client.Send(RequestOptions{
+	resp, err := client.Send(context.Background(), RequestOptions{
 		Method: http.MethodGet,
 		URL:    srv.URL + "/redirect",
 	})
@@ -41,6 +59,9 @@ func TestClientNoFollowRedirect(t *testing.T) {
 	if resp.StatusCode != http.StatusFound {
 		t.Fatalf("expected status %d, got %d", http.StatusFound, resp.StatusCode)
 	}
+	if resp.Method != http.MethodGet || resp.URL != srv.URL+"/redirect" {
+		t.Fatalf("expected response to record the sent request, got %s %s", resp.Method, resp.URL)
+	}
 }
 
 func TestClientTimeout(t *testing.T) {
@@ -53,12 +74,12 @@ func TestClientTimeout(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	client := NewClientWithConfig(ClientConfig{
+	client := newTestClient(t, ClientConfig{
 		Timeout:         20 * time.Millisecond,
 		FollowRedirects: true,
 	})
 
-	_, err := client.Send(RequestOptions{
+	_, err := client.Send(context.Background(), RequestOptions{
 		Method: http.MethodGet,
 		URL:    srv.URL,
 	})
@@ -87,7 +108,7 @@ func TestClientRetryPolicyOn5xx(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	client := NewClientWithConfig(ClientConfig{
+	client := newTestClient(t, ClientConfig{
 		Timeout:         2 * time.Second,
 		FollowRedirects: true,
 		Network: NetworkOptions{
@@ -96,7 +117,7 @@ func TestClientRetryPolicyOn5xx(t *testing.T) {
 		},
 	})
 
-	resp, err := client.Send(RequestOptions{
+	resp, err := client.Send(context.Background(), RequestOptions{
 		Method: http.MethodGet,
 		URL:    srv.URL + "/unstable",
 	})
@@ -111,6 +132,55 @@ func TestClientRetryPolicyOn5xx(t *testing.T) {
 	}
 }
 
+func TestClientSignsEveryRetryAttempt(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		if want := fmt.Sprintf("signed-%d:%s", n, body); r.Header.Get("Authorization") != want {
+			t.Errorf("attempt %d: expected Authorization %q, got %q", n, want, r.Header.Get("Authorization"))
+		}
+		if n < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := newTestClient(t, ClientConfig{
+		Timeout:         2 * time.Second,
+		FollowRedirects: true,
+		Network: NetworkOptions{
+			Retry:       1,
+			RetryDelay:  time.Millisecond,
+			RetryPolicy: RetryPolicy{Methods: []string{http.MethodPost}},
+			NoCookies:   true,
+		},
+	})
+
+	var signed int
+	resp, err := client.Send(context.Background(), RequestOptions{
+		Method:  http.MethodPost,
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "stale"},
+		Body:    strings.NewReader(`{"id":1}`),
+		Sign: func(req *http.Request, body []byte) error {
+			signed++
+			req.Header.Set("Authorization", fmt.Sprintf("signed-%d:%s", signed, body))
+			return nil
+		},
+	})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected success after retry, got %v (%v)", resp, err)
+	}
+	if signed != 2 {
+		t.Fatalf("expected each attempt to be signed, got %d signatures", signed)
+	}
+}
+
 func TestClientProxyUsage(t *testing.T) {
 	t.Parallel()
 
@@ -122,7 +192,7 @@ func TestClientProxyUsage(t *testing.T) {
 	}))
 	defer proxy.Close()
 
-	client := NewClientWithConfig(ClientConfig{
+	client := newTestClient(t, ClientConfig{
 		Timeout:         2 * time.Second,
 		FollowRedirects: true,
 		Network: NetworkOptions{
@@ -130,7 +200,7 @@ func TestClientProxyUsage(t *testing.T) {
 		},
 	})
 
-	resp, err := client.Send(RequestOptions{
+	resp, err := client.Send(context.Background(), RequestOptions{
 		Method: http.MethodGet,
 		URL:    "http://example.com/users",
 	})
@@ -154,25 +224,25 @@ func TestClientInsecureTLS(t *testing.T) {
 	}))
 	defer tlsServer.Close()
 
-	secureClient := NewClientWithConfig(ClientConfig{
+	secureClient := newTestClient(t, ClientConfig{
 		Timeout:         2 * time.Second,
 		FollowRedirects: true,
 	})
-	if _, err := secureClient.Send(RequestOptions{
+	if _, err := secureClient.Send(context.Background(), RequestOptions{
 		Method: http.MethodGet,
 		URL:    tlsServer.URL,
 	}); err == nil {
 		t.Fatal("expected TLS validation error without --insecure")
 	}
 
-	insecureClient := NewClientWithConfig(ClientConfig{
+	insecureClient := newTestClient(t, ClientConfig{
 		Timeout:         2 * time.Second,
 		FollowRedirects: true,
 		Network: NetworkOptions{
 			Insecure: true,
 		},
 	})
-	resp, err := insecureClient.Send(RequestOptions{
+	resp, err := insecureClient.Send(context.Background(), RequestOptions{
 		Method: http.MethodGet,
 		URL:    tlsServer.URL,
 	})
@@ -11,6 +11,8 @@ import (
 	"sort"
 	"strings"
 	"sync"
+
+	"github.com/Tresor-Kasend/apix/internal/fsutil"
 )
 
 type persistedCookieSet struct {
@@ -18,11 +20,16 @@ type persistedCookieSet struct {
 	Cookies []*http.Cookie `json:"cookies"`
 }
 
+// cookieFileLocks serialises reads and writes of a jar file across every
+// PersistentCookieJar of the process that points at it.
+var cookieFileLocks sync.Map
+
 type PersistentCookieJar struct {
-	mu   sync.Mutex
-	jar  *cookiejar.Jar
-	path string
-	urls map[string]struct{}
+	mu    sync.Mutex
+	jar   *cookiejar.Jar
+	path  string
+	urls  map[string]struct{}
+	dirty map[string]struct{}
 }
 
 func NewPersistentCookieJar(path string) (*PersistentCookieJar, error) {
@@ -36,9 +43,10 @@ func NewPersistentCookieJar(path string) (*PersistentCookieJar, error) {
 	}
 
 	out := &PersistentCookieJar{
-		jar:  jar,
-		path: path,
-		urls: make(map[string]struct{}),
+		jar:   jar,
+		path:  path,
+		urls:  make(map[string]struct{}),
+		dirty: make(map[string]struct{}),
 	}
 	if err := out.load(); err != nil {
 		return nil, err
@@ -52,7 +60,22 @@ func (p *PersistentCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
 
 	p.jar.SetCookies(u, cookies)
 	p.urls[cookieScope(u)] = struct{}{}
-	_ = p.saveLocked()
+	p.dirty[cookieScope(u)] = struct{}{}
+}
+
+// Flush writes the cookies set since the last flush to the jar file.
+func (p *PersistentCookieJar) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.dirty) == 0 {
+		return nil
+	}
+	if err := p.saveLocked(); err != nil {
+		return err
+	}
+	p.dirty = make(map[string]struct{})
+	return nil
 }
 
 func (p *PersistentCookieJar) Cookies(u *url.URL) []*http.Cookie {
@@ -62,20 +85,13 @@ func (p *PersistentCookieJar) Cookies(u *url.URL) []*http.Cookie {
 }
 
 func (p *PersistentCookieJar) load() error {
-	data, err := os.ReadFile(p.path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("reading cookie jar %q: %w", p.path, err)
-	}
-	if len(strings.TrimSpace(string(data))) == 0 {
-		return nil
-	}
+	lock := cookieFileLock(p.path)
+	lock.Lock()
+	defer lock.Unlock()
 
-	var entries []persistedCookieSet
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return fmt.Errorf("parsing cookie jar %q: %w", p.path, err)
+	entries, err := readCookieFile(p.path)
+	if err != nil {
+		return err
 	}
 
 	for _, entry := range entries {
@@ -89,30 +105,49 @@ func (p *PersistentCookieJar) load() error {
 	return nil
 }
 
+// saveLocked merges the scopes this jar changed into the file on disk, so
+// that jars of concurrent requests do not drop each other's cookies.
 func (p *PersistentCookieJar) saveLocked() error {
+	lock := cookieFileLock(p.path)
+	lock.Lock()
+	defer lock.Unlock()
+
 	if err := os.MkdirAll(filepath.Dir(p.path), 0o755); err != nil {
 		return fmt.Errorf("creating cookie jar directory: %w", err)
 	}
 
-	keys := make([]string, 0, len(p.urls))
-	for raw := range p.urls {
-		keys = append(keys, raw)
+	merged := make(map[string][]*http.Cookie)
+	owned := p.dirty
+	if entries, err := readCookieFile(p.path); err == nil {
+		for _, entry := range entries {
+			merged[entry.URL] = entry.Cookies
+		}
+	} else {
+		owned = p.urls
 	}
-	sort.Strings(keys)
-
-	entries := make([]persistedCookieSet, 0, len(keys))
-	for _, raw := range keys {
+	for raw := range owned {
 		u, err := url.Parse(raw)
 		if err != nil {
 			continue
 		}
-		cookies := p.jar.Cookies(u)
-		if len(cookies) == 0 {
-			continue
+		if cookies := p.jar.Cookies(u); len(cookies) > 0 {
+			merged[raw] = cookies
+		} else {
+			delete(merged, raw)
 		}
+	}
+
+	keys := make([]string, 0, len(merged))
+	for raw := range merged {
+		keys = append(keys, raw)
+	}
+	sort.Strings(keys)
+
+	entries := make([]persistedCookieSet, 0, len(keys))
+	for _, raw := range keys {
 		entries = append(entries, persistedCookieSet{
 			URL:     raw,
-			Cookies: cookies,
+			Cookies: merged[raw],
 		})
 	}
 
@@ -120,12 +155,39 @@ func (p *PersistentCookieJar) saveLocked() error {
 	if err != nil {
 		return fmt.Errorf("encoding cookie jar: %w", err)
 	}
-	if err := os.WriteFile(p.path, data, 0o644); err != nil {
+	if err := fsutil.WriteFileAtomic(p.path, data, 0o644); err != nil {
 		return fmt.Errorf("writing cookie jar %q: %w", p.path, err)
 	}
 	return nil
 }
 
+func readCookieFile(path string) ([]persistedCookieSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading cookie jar %q: %w", path, err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+
+	var entries []persistedCookieSet
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing cookie jar %q: %w", path, err)
+	}
+	return entries, nil
+}
+
+func cookieFileLock(path string) *sync.Mutex {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	lock, _ := cookieFileLocks.LoadOrStore(path, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
 func cookieScope(u *url.URL) string {
 	scope := u.Scheme + "://" + u.Host
 	p := strings.TrimSpace(u.Path)
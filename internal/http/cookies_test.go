@@ -1,9 +1,12 @@
 package apixhttp
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -27,6 +30,9 @@ func TestPersistentCookieJarPersistsCookies(t *testing.T) {
 			Path:  "/",
 		},
 	})
+	if err := jarOne.Flush(); err != nil {
+		t.Fatalf("flush first cookie jar: %v", err)
+	}
 
 	jarTwo, err := NewPersistentCookieJar(jarPath)
 	if err != nil {
@@ -40,3 +46,41 @@ func TestPersistentCookieJarPersistsCookies(t *testing.T) {
 		t.Fatalf("unexpected cookie loaded: %+v", cookies[0])
 	}
 }
+
+func TestPersistentCookieJarConcurrentJarsMergeWrites(t *testing.T) {
+	t.Parallel()
+
+	jarPath := filepath.Join(t.TempDir(), "cookies.jar")
+	const jars = 8
+
+	var wg sync.WaitGroup
+	for i := 0; i < jars; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jar, err := NewPersistentCookieJar(jarPath)
+			if err != nil {
+				t.Errorf("create cookie jar: %v", err)
+				return
+			}
+			target, _ := url.Parse(fmt.Sprintf("https://host%d.example.com/", i))
+			jar.SetCookies(target, []*http.Cookie{{Name: "id", Value: strconv.Itoa(i), Path: "/"}})
+			if err := jar.Flush(); err != nil {
+				t.Errorf("flush cookie jar: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	reloaded, err := NewPersistentCookieJar(jarPath)
+	if err != nil {
+		t.Fatalf("reload cookie jar: %v", err)
+	}
+	for i := 0; i < jars; i++ {
+		target, _ := url.Parse(fmt.Sprintf("https://host%d.example.com/", i))
+		cookies := reloaded.Cookies(target)
+		if len(cookies) != 1 || cookies[0].Value != strconv.Itoa(i) {
+			t.Fatalf("expected cookie for host%d to survive concurrent writes, got %+v", i, cookies)
+		}
+	}
+}
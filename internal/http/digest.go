@@ -0,0 +1,228 @@
+package apixhttp
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DigestCredentials enable HTTP Digest authentication (RFC 7616): the client
+// answers a 401 Digest challenge and reuses its nonce for later requests.
+type DigestCredentials struct {
+	Username string
+	Password string
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	stale     bool
+}
+
+// digestSession is the challenge last received for a set of credentials and
+// the number of requests sent with its nonce. It is shared by the clients of
+// a Pool.
+type digestSession struct {
+	creds DigestCredentials
+
+	mu        sync.Mutex
+	challenge *digestChallenge
+	nc        uint32
+}
+
+// authorize answers the current challenge, if any, on req.
+func (s *digestSession) authorize(req *http.Request, body []byte) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	challenge := s.challenge
+	if challenge == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.nc++
+	nc := s.nc
+	s.mu.Unlock()
+	req.Header.Set("Authorization", digestAuthorization(s.creds, challenge, req, body, nc, randomHex(16)))
+}
+
+// challenged records the Digest challenge of a 401 response and reports
+// whether the request should be sent again with it. A request that already
+// answered the same nonce is not retried unless the server marks it stale.
+func (s *digestSession) challenged(req *http.Request, resp *http.Response) bool {
+	if s == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	var challenge *digestChallenge
+	for _, header := range resp.Header.Values("WWW-Authenticate") {
+		if parsed := parseDigestChallenge(header); parsed != nil && supportedDigestAlgorithm(parsed.algorithm) {
+			challenge = parsed
+			break
+		}
+	}
+	if challenge == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	answered := s.challenge != nil && req.Header.Get("Authorization") != "" && s.challenge.nonce == challenge.nonce
+	if answered && !challenge.stale {
+		return false
+	}
+	if s.challenge == nil || s.challenge.nonce != challenge.nonce {
+		s.nc = 0
+	}
+	s.challenge = challenge
+	return true
+}
+
+func parseDigestChallenge(header string) *digestChallenge {
+	scheme, params, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Digest") {
+		return nil
+	}
+	values := parseAuthParams(params)
+	if values["nonce"] == "" {
+		return nil
+	}
+	challenge := &digestChallenge{
+		realm:     values["realm"],
+		nonce:     values["nonce"],
+		opaque:    values["opaque"],
+		algorithm: values["algorithm"],
+		stale:     strings.EqualFold(values["stale"], "true"),
+	}
+	if challenge.algorithm == "" {
+		challenge.algorithm = "MD5"
+	}
+	if qop := values["qop"]; qop != "" {
+		for _, option := range strings.Split(qop, ",") {
+			option = strings.TrimSpace(option)
+			if option == "auth" {
+				challenge.qop = "auth"
+				break
+			}
+			if option == "auth-int" {
+				challenge.qop = "auth-int"
+			}
+		}
+	}
+	return challenge
+}
+
+// parseAuthParams splits comma-separated key=value pairs whose values may be
+// quoted strings containing commas.
+func parseAuthParams(params string) map[string]string {
+	values := make(map[string]string)
+	for len(params) > 0 {
+		params = strings.TrimLeft(params, " ,")
+		key, rest, ok := strings.Cut(params, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " ")
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value = b.String()
+			if i < len(rest) {
+				i++
+			}
+			params = rest[i:]
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			value = strings.TrimSpace(rest[:end])
+			params = rest[end:]
+		}
+		values[key] = value
+	}
+	return values
+}
+
+func supportedDigestAlgorithm(algorithm string) bool {
+	switch strings.ToUpper(algorithm) {
+	case "MD5", "MD5-SESS", "SHA-256", "SHA-256-SESS":
+		return true
+	}
+	return false
+}
+
+func digestAuthorization(creds DigestCredentials, c *digestChallenge, req *http.Request, body []byte, nc uint32, cnonce string) string {
+	var newHash func() hash.Hash = md5.New
+	algorithm := strings.ToUpper(c.algorithm)
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(parts ...string) string {
+		sum := newHash()
+		sum.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	uri := req.URL.RequestURI()
+	ncValue := fmt.Sprintf("%08x", nc)
+	ha1 := h(creds.Username, c.realm, creds.Password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1, c.nonce, cnonce)
+	}
+	ha2 := h(req.Method, uri)
+	if c.qop == "auth-int" {
+		ha2 = h(req.Method, uri, h(string(body)))
+	}
+
+	var response string
+	if c.qop == "" {
+		response = h(ha1, c.nonce, ha2)
+	} else {
+		response = h(ha1, c.nonce, ncValue, cnonce, c.qop, ha2)
+	}
+
+	fields := []string{
+		fmt.Sprintf(`username="%s"`, quoteDigest(creds.Username)),
+		fmt.Sprintf(`realm="%s"`, quoteDigest(c.realm)),
+		fmt.Sprintf(`nonce="%s"`, quoteDigest(c.nonce)),
+		fmt.Sprintf(`uri="%s"`, quoteDigest(uri)),
+		"algorithm=" + c.algorithm,
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if c.qop != "" {
+		fields = append(fields, "qop="+c.qop, "nc="+ncValue, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if c.opaque != "" {
+		fields = append(fields, fmt.Sprintf(`opaque="%s"`, quoteDigest(c.opaque)))
+	}
+	return "Digest " + strings.Join(fields, ", ")
+}
+
+func quoteDigest(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
+
+func randomHex(size int) string {
+	b := make([]byte, size)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,129 @@
+package apixhttp
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDigestAuthorizationMatchesRFCExamples(t *testing.T) {
+	tests := []struct {
+		name      string
+		creds     DigestCredentials
+		challenge digestChallenge
+		cnonce    string
+		response  string
+	}{
+		{
+			// RFC 2617 section 3.5
+			name:      "rfc2617-md5",
+			creds:     DigestCredentials{Username: "Mufasa", Password: "Circle Of Life"},
+			challenge: digestChallenge{realm: "testrealm@host.com", nonce: "dcd98b7102dd2f0e8b11d0f600bfb0c093", algorithm: "MD5", qop: "auth"},
+			cnonce:    "0a4f113b",
+			response:  "6629fae49393a05397450978507c4ef1",
+		},
+		{
+			// RFC 7616 section 3.9.1
+			name:      "rfc7616-md5",
+			creds:     DigestCredentials{Username: "Mufasa", Password: "Circle of Life"},
+			challenge: digestChallenge{realm: "http-auth@example.org", nonce: "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", algorithm: "MD5", qop: "auth"},
+			cnonce:    "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+			response:  "8ca523f5e9506fed4657c9700eebdbec",
+		},
+		{
+			// RFC 7616 section 3.9.1
+			name:      "rfc7616-sha256",
+			creds:     DigestCredentials{Username: "Mufasa", Password: "Circle of Life"},
+			challenge: digestChallenge{realm: "http-auth@example.org", nonce: "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", algorithm: "SHA-256", qop: "auth"},
+			cnonce:    "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+			response:  "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://example.org/dir/index.html", nil)
+			header := digestAuthorization(tt.creds, &tt.challenge, req, nil, 1, tt.cnonce)
+			if !strings.Contains(header, `response="`+tt.response+`"`) || !strings.Contains(header, "nc=00000001") {
+				t.Fatalf("unexpected Authorization %s", header)
+			}
+		})
+	}
+}
+
+// newDigestServer protects every path with MD5-sess Digest auth and records
+// the nonce counts it accepts.
+func newDigestServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var (
+		mu  sync.Mutex
+		ncs []string
+	)
+	const realm, nonce = "devices", "abc123"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, params, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		values := parseAuthParams(params)
+		h := func(parts ...string) string {
+			sum := md5.Sum([]byte(strings.Join(parts, ":")))
+			return hex.EncodeToString(sum[:])
+		}
+		ha1 := h(h("admin", realm, "secret"), nonce, values["cnonce"])
+		want := h(ha1, nonce, values["nc"], values["cnonce"], "auth", h(r.Method, r.URL.RequestURI()))
+		if scheme != "Digest" || values["response"] != want || values["opaque"] != "op,aque" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="devices"`)
+			w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth,auth-int", nonce="%s", opaque="op,aque", algorithm=MD5-sess`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		ncs = append(ncs, values["nc"])
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ncs...)
+	}
+}
+
+func TestClientAnswersDigestChallenge(t *testing.T) {
+	t.Parallel()
+	server, accepted := newDigestServer(t)
+
+	client := newTestClient(t, ClientConfig{
+		Timeout:         2 * time.Second,
+		FollowRedirects: true,
+		Network:         NetworkOptions{NoCookies: true},
+		Digest:          &DigestCredentials{Username: "admin", Password: "secret"},
+	})
+	for i := 0; i < 2; i++ {
+		resp, err := client.Send(context.Background(), RequestOptions{Method: http.MethodPost, URL: server.URL + "/config", Body: strings.NewReader(`{"on":true}`)})
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: expected digest auth to succeed, got %v (%v)", i, resp, err)
+		}
+	}
+	// The second request reuses the nonce with the next count, without a new challenge.
+	if got := accepted(); len(got) != 2 || got[0] != "00000001" || got[1] != "00000002" {
+		t.Fatalf("unexpected nonce counts %v", got)
+	}
+
+	wrong := newTestClient(t, ClientConfig{
+		Timeout:         2 * time.Second,
+		FollowRedirects: true,
+		Network:         NetworkOptions{NoCookies: true},
+		Digest:          &DigestCredentials{Username: "admin", Password: "wrong"},
+	})
+	resp, err := wrong.Send(context.Background(), RequestOptions{Method: http.MethodGet, URL: server.URL + "/config"})
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected wrong credentials to end with 401, got %v (%v)", resp, err)
+	}
+}
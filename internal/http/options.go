@@ -10,6 +10,7 @@ const (
 type NetworkOptions struct {
 	Retry         int
 	RetryDelay    time.Duration
+	RetryPolicy   RetryPolicy
 	ProxyURL      string
 	Insecure      bool
 	CertFile      string
@@ -0,0 +1,251 @@
+package apixhttp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pool shares transports, cookie jars and Digest sessions between the
+// clients of a run (test suite, chain, flow, watch session). Clients with the
+// same network options reuse keep-alive connections and TLS sessions, each
+// cookie jar file is read once and written once, by Close, and clients with
+// the same Digest credentials reuse the last nonce.
+type Pool struct {
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+	jars       map[string]*PersistentCookieJar
+	digests    map[DigestCredentials]*digestSession
+
+	requests atomic.Int64
+	reused   atomic.Int64
+}
+
+// ConnStats counts the requests sent through a Pool and how many of them
+// reused an idle connection.
+type ConnStats struct {
+	Requests int64
+	Reused   int64
+}
+
+// Opened is the number of connections dialed for the requests.
+func (s ConnStats) Opened() int64 {
+	return s.Requests - s.Reused
+}
+
+type transportKey struct {
+	proxyURL string
+	insecure bool
+	certFile string
+	keyFile  string
+}
+
+func NewPool() *Pool {
+	return &Pool{
+		transports: make(map[transportKey]*http.Transport),
+		jars:       make(map[string]*PersistentCookieJar),
+		digests:    make(map[DigestCredentials]*digestSession),
+	}
+}
+
+// NewClient returns a client sending its requests through the transport and
+// cookie jar of the pool matching cfg.Network.
+func (p *Pool) NewClient(cfg ClientConfig) *Client {
+	client := &Client{
+		digest:      p.digestSession(cfg.Digest),
+		onRetry:     cfg.OnRetry,
+		rateLimit:   cfg.RateLimit,
+		onRateLimit: cfg.OnRateLimit,
+		pool:        p,
+	}
+
+	transport, err := p.transport(cfg.Network)
+	if err != nil {
+		client.initErr = err
+		return client
+	}
+
+	var jar http.CookieJar
+	if !cfg.Network.NoCookies {
+		persistentJar, err := p.cookieJar(cfg.Network.CookieJarPath)
+		if err != nil {
+			client.initErr = err
+			return client
+		}
+		jar = persistentJar
+	}
+
+	httpClient := &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+		Jar:       jar,
+	}
+
+	if !cfg.FollowRedirects {
+		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	retry := cfg.Network.Retry
+	if retry < 0 {
+		retry = 0
+	}
+	retryDelay := cfg.Network.RetryDelay
+	if retry > 0 && retryDelay <= 0 {
+		retryDelay = DefaultRetryDelay
+	}
+
+	client.httpClient = httpClient
+	client.retry = retry
+	client.retryDelay = retryDelay
+	client.policy = cfg.Network.RetryPolicy
+	return client
+}
+
+// Stats returns the connection reuse of the requests sent so far.
+func (p *Pool) Stats() ConnStats {
+	return ConnStats{Requests: p.requests.Load(), Reused: p.reused.Load()}
+}
+
+// Close writes the cookie jars and closes the idle connections of the pool.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	jars := make([]*PersistentCookieJar, 0, len(p.jars))
+	for _, jar := range p.jars {
+		jars = append(jars, jar)
+	}
+	transports := make([]*http.Transport, 0, len(p.transports))
+	for _, transport := range p.transports {
+		transports = append(transports, transport)
+	}
+	p.mu.Unlock()
+
+	var errs []error
+	for _, jar := range jars {
+		if err := jar.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, transport := range transports {
+		transport.CloseIdleConnections()
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Pool) record(trace *timingTrace) {
+	if p == nil || trace == nil {
+		return
+	}
+	p.requests.Add(1)
+	trace.mu.Lock()
+	reused := trace.reused
+	trace.mu.Unlock()
+	if reused {
+		p.reused.Add(1)
+	}
+}
+
+func (p *Pool) transport(opts NetworkOptions) (*http.Transport, error) {
+	key := transportKey{
+		proxyURL: strings.TrimSpace(opts.ProxyURL),
+		insecure: opts.Insecure,
+		certFile: opts.CertFile,
+		keyFile:  opts.KeyFile,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if transport, ok := p.transports[key]; ok {
+		return transport, nil
+	}
+	transport, err := newTransport(key)
+	if err != nil {
+		return nil, err
+	}
+	p.transports[key] = transport
+	return transport, nil
+}
+
+func (p *Pool) digestSession(creds *DigestCredentials) *digestSession {
+	if creds == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	session, ok := p.digests[*creds]
+	if !ok {
+		session = &digestSession{creds: *creds}
+		p.digests[*creds] = session
+	}
+	return session
+}
+
+func (p *Pool) cookieJar(path string) (*PersistentCookieJar, error) {
+	if strings.TrimSpace(path) == "" {
+		path = DefaultCookieJarPath
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if jar, ok := p.jars[path]; ok {
+		return jar, nil
+	}
+	jar, err := NewPersistentCookieJar(path)
+	if err != nil {
+		return nil, err
+	}
+	p.jars[path] = jar
+	return jar, nil
+}
+
+func newTransport(key transportKey) (*http.Transport, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if key.proxyURL != "" {
+		parsedProxy, err := url.Parse(key.proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", key.proxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(parsedProxy)
+	}
+
+	tlsConfig := &tls.Config{}
+	if key.insecure {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if key.certFile != "" || key.keyFile != "" {
+		if key.certFile == "" || key.keyFile == "" {
+			return nil, fmt.Errorf("--cert and --key must be provided together")
+		}
+		cert, err := tls.LoadX509KeyPair(key.certFile, key.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client TLS certificate/key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if key.insecure || len(tlsConfig.Certificates) > 0 {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
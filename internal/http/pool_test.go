@@ -0,0 +1,83 @@
+package apixhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPoolReusesConnectionsAcrossClients(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	jarPath := filepath.Join(t.TempDir(), "cookies.jar")
+	pool := NewPool()
+	for i := 0; i < 3; i++ {
+		client := pool.NewClient(ClientConfig{
+			Timeout:         2 * time.Second,
+			FollowRedirects: true,
+			Network:         NetworkOptions{CookieJarPath: jarPath},
+		})
+		resp, err := client.Send(context.Background(), RequestOptions{Method: http.MethodGet, URL: srv.URL})
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if reused := resp.Timing.Reused; reused != (i > 0) {
+			t.Fatalf("request %d: expected reused=%v, got %v", i, i > 0, reused)
+		}
+	}
+
+	if stats := pool.Stats(); stats.Requests != 3 || stats.Reused != 2 || stats.Opened() != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if _, err := os.Stat(jarPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the cookie jar to be written on Close only, got %v", err)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	jar, err := NewPersistentCookieJar(jarPath)
+	if err != nil {
+		t.Fatalf("reload cookie jar: %v", err)
+	}
+	if cookies := jar.Cookies(mustParseURL(t, srv.URL)); len(cookies) != 1 || cookies[0].Value != "abc" {
+		t.Fatalf("expected the session cookie to be flushed, got %+v", cookies)
+	}
+}
+
+func TestPoolKeysTransportsByNetworkOptions(t *testing.T) {
+	pool := NewPool()
+	plain, err := pool.transport(NetworkOptions{})
+	if err != nil {
+		t.Fatalf("transport: %v", err)
+	}
+	again, _ := pool.transport(NetworkOptions{Retry: 3})
+	insecure, _ := pool.transport(NetworkOptions{Insecure: true})
+	if plain != again {
+		t.Fatalf("expected options without network effect to share the transport")
+	}
+	if plain == insecure {
+		t.Fatalf("expected insecure requests to use their own transport")
+	}
+	if _, err := pool.transport(NetworkOptions{ProxyURL: "://bad"}); err == nil {
+		t.Fatalf("expected invalid proxy error")
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	return u
+}
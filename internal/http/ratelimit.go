@@ -0,0 +1,105 @@
+package apixhttp
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit bounds the requests sent by every Client of the process: a token
+// bucket refilled with PerSecond tokens a second and holding up to Burst.
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+	// PerHost gives each host its own bucket.
+	PerHost bool
+}
+
+func (l RateLimit) enabled() bool {
+	return l.PerSecond > 0
+}
+
+type rateLimitKey struct {
+	perSecond float64
+	burst     int
+	host      string
+}
+
+// Buckets are shared by the clients of a process with the same limit, so
+// parallel tests and chained requests draw from the same tokens.
+var (
+	bucketsMu sync.Mutex
+	buckets   = make(map[rateLimitKey]*tokenBucket)
+)
+
+func sharedBucket(limit RateLimit, host string) *tokenBucket {
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	key := rateLimitKey{perSecond: limit.PerSecond, burst: burst}
+	if limit.PerHost {
+		key.host = strings.ToLower(host)
+	}
+
+	bucketsMu.Lock()
+	defer bucketsMu.Unlock()
+	bucket, ok := buckets[key]
+	if !ok {
+		bucket = &tokenBucket{rate: limit.PerSecond, burst: float64(burst), tokens: float64(burst)}
+		buckets[key] = bucket
+	}
+	return bucket
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long the caller must wait for it.
+// Tokens go negative while callers queue, so concurrent waits are staggered.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// throttle waits for the rate limit of host, returning early with the error
+// of ctx when it is done.
+func (c *Client) throttle(ctx context.Context, host string) error {
+	if !c.rateLimit.enabled() {
+		return nil
+	}
+	wait := sharedBucket(c.rateLimit, host).reserve(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	if c.onRateLimit != nil {
+		c.onRateLimit(host, wait)
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,102 @@
+package apixhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	start := time.Now()
+	bucket := &tokenBucket{rate: 10, burst: 2, tokens: 2}
+
+	waits := []time.Duration{
+		bucket.reserve(start),
+		bucket.reserve(start),
+		bucket.reserve(start),
+		bucket.reserve(start),
+	}
+	want := []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Fatalf("reserve %d: expected wait %v, got %v", i, want[i], waits[i])
+		}
+	}
+
+	// After a second the bucket is full again, but never above its burst.
+	later := start.Add(time.Second)
+	if wait := bucket.reserve(later); wait != 0 {
+		t.Fatalf("expected refilled bucket, got wait %v", wait)
+	}
+	bucket.reserve(later)
+	if wait := bucket.reserve(later); wait != 100*time.Millisecond {
+		t.Fatalf("expected burst of 2 after refill, got wait %v", wait)
+	}
+}
+
+func resetRateLimitBuckets(t *testing.T) {
+	t.Helper()
+	bucketsMu.Lock()
+	buckets = make(map[rateLimitKey]*tokenBucket)
+	bucketsMu.Unlock()
+}
+
+func TestRateLimitIsSharedByClients(t *testing.T) {
+	resetRateLimitBuckets(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var waits []time.Duration
+	limit := RateLimit{PerSecond: 25, Burst: 1}
+	newClient := func() *Client {
+		return newTestClient(t, ClientConfig{
+			Timeout:         2 * time.Second,
+			FollowRedirects: true,
+			Network:         NetworkOptions{NoCookies: true},
+			RateLimit:       limit,
+			OnRateLimit: func(host string, wait time.Duration) {
+				mu.Lock()
+				waits = append(waits, wait)
+				mu.Unlock()
+			},
+		})
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := newClient().Send(context.Background(), RequestOptions{Method: http.MethodGet, URL: srv.URL}); err != nil {
+				t.Errorf("Send returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// One token up front, then one every 40ms for the three other requests.
+	if elapsed := time.Since(start); elapsed < 110*time.Millisecond {
+		t.Fatalf("expected requests of separate clients to share the limit, took %v", elapsed)
+	}
+	if len(waits) != 3 {
+		t.Fatalf("expected 3 throttled requests, got %d (%v)", len(waits), waits)
+	}
+}
+
+func TestRateLimitPerHost(t *testing.T) {
+	resetRateLimitBuckets(t)
+	limit := RateLimit{PerSecond: 0.5, Burst: 1, PerHost: true}
+	if sharedBucket(limit, "a.example").reserve(time.Now()) != 0 || sharedBucket(limit, "B.example").reserve(time.Now()) != 0 {
+		t.Fatalf("expected each host to start with its own burst")
+	}
+	if sharedBucket(limit, "A.EXAMPLE").reserve(time.Now()) == 0 {
+		t.Fatalf("expected the second request to the same host to wait")
+	}
+}
@@ -6,16 +6,26 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/jsonpath"
 )
 
 type Response struct {
+	Method     string
+	URL        string
 	StatusCode int
 	Status     string
 	Headers    http.Header
 	Body       []byte
 	Duration   time.Duration
+	// Timing is the phase breakdown of the request, set by Send.
+	Timing *Timing
+	// Events holds the events collected by Stream.
+	Events []SSEEvent
 }
 
 func ParseResponse(resp *http.Response, duration time.Duration) (*Response, error) {
@@ -40,6 +50,60 @@ func (r *Response) IsJSON() bool {
 	return strings.Contains(ct, "application/json") || json.Valid(r.Body)
 }
 
+// Capture extracts a value for a capture rule. The source is a JSON path into
+// the body (so a bare "status" is the body's status field) unless it uses one
+// of the prefixes:
+//
+//	response:status       status code
+//	response:duration_ms  response time in milliseconds
+//	header:<name>      response header (multiple values joined with ", ")
+//	cookie:<name>      cookie set by the response (Set-Cookie)
+//	regex:<pattern>    first match in the raw body (first group when present)
+func (r *Response) Capture(source string) (string, error) {
+	source = strings.TrimSpace(source)
+	prefix, arg, _ := strings.Cut(source, ":")
+	switch strings.ToLower(prefix) {
+	case "response":
+		switch field := strings.ToLower(strings.TrimSpace(arg)); field {
+		case "status":
+			return strconv.Itoa(r.StatusCode), nil
+		case "duration_ms":
+			return strconv.FormatInt(r.Duration.Milliseconds(), 10), nil
+		default:
+			return "", fmt.Errorf("unknown response field %q (expected status or duration_ms)", field)
+		}
+	case "header":
+		name := strings.TrimSpace(arg)
+		values := r.Headers.Values(name)
+		if name == "" || len(values) == 0 {
+			return "", fmt.Errorf("no %q header in response", name)
+		}
+		return strings.Join(values, ", "), nil
+	case "cookie":
+		name := strings.TrimSpace(arg)
+		for _, cookie := range (&http.Response{Header: r.Headers}).Cookies() {
+			if cookie.Name == name {
+				return cookie.Value, nil
+			}
+		}
+		return "", fmt.Errorf("no %q cookie set by response", name)
+	case "regex":
+		pattern, err := regexp.Compile(arg)
+		if err != nil {
+			return "", fmt.Errorf("invalid capture regex %q: %w", arg, err)
+		}
+		match := pattern.FindSubmatch(r.Body)
+		if match == nil {
+			return "", fmt.Errorf("regex %q did not match the response body", arg)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	}
+	return r.ExtractField(source)
+}
+
 func (r *Response) ExtractField(path string) (string, error) {
 	if len(r.Body) == 0 {
 		return "", fmt.Errorf("empty response body")
@@ -50,18 +114,12 @@ func (r *Response) ExtractField(path string) (string, error) {
 		return "", fmt.Errorf("response is not JSON: %w", err)
 	}
 
-	parts := strings.Split(path, ".")
-	current := obj
-
-	for _, part := range parts {
-		m, ok := current.(map[string]interface{})
-		if !ok {
-			return "", fmt.Errorf("cannot navigate path %q: not an object at %q", path, part)
-		}
-		current, ok = m[part]
-		if !ok {
-			return "", fmt.Errorf("field %q not found in path %q", part, path)
-		}
+	current, exists, err := jsonpath.Lookup(obj, path)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("no value found at path %q", path)
 	}
 
 	switch v := current.(type) {
@@ -1,13 +1,121 @@
 package apixhttp
 
-import "time"
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func shouldRetryStatus(statusCode int) bool {
-	return statusCode >= 500 && statusCode <= 599
+// DefaultRetryMaxDelay caps backoff and Retry-After waits when a policy sets
+// no max_delay.
+const DefaultRetryMaxDelay = 30 * time.Second
+
+// DefaultRetryMethods are the idempotent methods retried when a policy lists
+// none; POST and PATCH are only retried when opted in.
+var DefaultRetryMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodTrace,
+}
+
+// RetryPolicy decides which attempts Send retries and how long it waits. The
+// zero value retries 429 and 5xx responses and network errors of idempotent
+// methods with exponential backoff.
+type RetryPolicy struct {
+	// Statuses are the retried status codes (429 and 5xx when empty).
+	Statuses []int
+	// Methods are the retried methods (DefaultRetryMethods when empty).
+	Methods  []string
+	MaxDelay time.Duration
+	// Jitter waits a random duration between zero and the backoff delay.
+	Jitter bool
+}
+
+// RetryAttempt describes a failed attempt that Send is about to retry.
+type RetryAttempt struct {
+	Attempt    int
+	StatusCode int
+	Status     string
+	Err        error
+	Delay      time.Duration
+	// RetryAfter reports that Delay comes from the Retry-After header.
+	RetryAfter bool
+}
+
+func (p RetryPolicy) retriesMethod(method string) bool {
+	if method == "" {
+		method = http.MethodGet
+	}
+	methods := p.Methods
+	if len(methods) == 0 {
+		methods = DefaultRetryMethods
+	}
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) retriesStatus(statusCode int) bool {
+	if len(p.Statuses) == 0 {
+		return statusCode == http.StatusTooManyRequests || (statusCode >= 500 && statusCode <= 599)
+	}
+	for _, status := range p.Statuses {
+		if status == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the wait before the attempt following attempt: the
+// Retry-After of the response when it has one, the backoff otherwise.
+func (p RetryPolicy) delay(base time.Duration, attempt int, header http.Header, now time.Time) (time.Duration, bool) {
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+	if after, ok := parseRetryAfter(header.Get("Retry-After"), now); ok {
+		return min(after, maxDelay), true
+	}
+	delay := min(retryDelayForAttempt(base, attempt), maxDelay)
+	if p.Jitter && delay > 0 {
+		delay = rand.N(delay + 1)
+	}
+	return delay, false
+}
+
+// parseRetryAfter reads a Retry-After value in seconds or as an HTTP-date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(at.Sub(now), 0), true
 }
 
 func shouldRetryNetworkError(err error) bool {
-	return err != nil
+	return err != nil && !errors.Is(err, context.Canceled)
 }
 
 func retryDelayForAttempt(base time.Duration, attempt int) time.Duration {
@@ -19,7 +127,7 @@ func retryDelayForAttempt(base time.Duration, attempt int) time.Duration {
 	}
 
 	delay := base
-	for i := 1; i < attempt; i++ {
+	for i := 1; i < attempt && delay < math.MaxInt64/2; i++ {
 		delay *= 2
 	}
 	return delay
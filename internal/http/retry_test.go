@@ -0,0 +1,146 @@
+package apixhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "3", want: 3 * time.Second, ok: true},
+		{value: " 0 ", want: 0, ok: true},
+		{value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, ok: true},
+		{value: "-1"},
+		{value: "soon"},
+		{value: ""},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	now := time.Now()
+	policy := RetryPolicy{MaxDelay: 5 * time.Second}
+
+	if delay, retryAfter := policy.delay(time.Second, 3, http.Header{}, now); delay != 4*time.Second || retryAfter {
+		t.Fatalf("expected 4s backoff, got %v (retry-after %v)", delay, retryAfter)
+	}
+	if delay, _ := policy.delay(time.Second, 10, http.Header{}, now); delay != 5*time.Second {
+		t.Fatalf("expected backoff capped at 5s, got %v", delay)
+	}
+	header := http.Header{"Retry-After": []string{"120"}}
+	if delay, retryAfter := policy.delay(time.Second, 1, header, now); delay != 5*time.Second || !retryAfter {
+		t.Fatalf("expected Retry-After capped at 5s, got %v (retry-after %v)", delay, retryAfter)
+	}
+
+	policy.Jitter = true
+	for i := 0; i < 50; i++ {
+		if delay, _ := policy.delay(time.Second, 2, http.Header{}, now); delay < 0 || delay > 2*time.Second {
+			t.Fatalf("expected jittered delay within [0, 2s], got %v", delay)
+		}
+	}
+}
+
+func TestClientRetryPolicyMethodsAndStatuses(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/throttled":
+			if n == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var retried []RetryAttempt
+	newClient := func(policy RetryPolicy) *Client {
+		return newTestClient(t, ClientConfig{
+			Timeout:         2 * time.Second,
+			FollowRedirects: true,
+			Network:         NetworkOptions{Retry: 2, RetryDelay: time.Millisecond, RetryPolicy: policy, NoCookies: true},
+			OnRetry:         func(attempt RetryAttempt) { retried = append(retried, attempt) },
+		})
+	}
+	send := func(client *Client, method, path string) *Response {
+		t.Helper()
+		atomic.StoreInt32(&calls, 0)
+		retried = nil
+		resp, err := client.Send(context.Background(), RequestOptions{Method: method, URL: srv.URL + path, Body: strings.NewReader("{}")})
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		return resp
+	}
+
+	if resp := send(newClient(RetryPolicy{}), http.MethodPost, "/unavailable"); resp.StatusCode != http.StatusServiceUnavailable || calls != 1 {
+		t.Fatalf("expected POST not to be retried by default, got %d after %d calls", resp.StatusCode, calls)
+	}
+	if send(newClient(RetryPolicy{Methods: []string{"post"}}), http.MethodPost, "/unavailable"); calls != 3 || len(retried) != 2 {
+		t.Fatalf("expected opted-in POST to be retried twice, got %d calls and %d retries", calls, len(retried))
+	}
+	if send(newClient(RetryPolicy{Statuses: []int{http.StatusTooManyRequests}}), http.MethodGet, "/unavailable"); calls != 1 {
+		t.Fatalf("expected 503 not to be retried when only 429 is listed, got %d calls", calls)
+	}
+
+	resp := send(newClient(RetryPolicy{Statuses: []int{http.StatusTooManyRequests}}), http.MethodGet, "/throttled")
+	if resp.StatusCode != http.StatusOK || len(retried) != 1 {
+		t.Fatalf("expected one retry of the 429, got %d with %d retries", resp.StatusCode, len(retried))
+	}
+	if got := retried[0]; got.Attempt != 1 || got.StatusCode != http.StatusTooManyRequests || !got.RetryAfter || got.Delay != 0 {
+		t.Fatalf("unexpected retry attempt %+v", got)
+	}
+}
+
+func TestSendCancelledDuringRetryBackoff(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newTestClient(t, ClientConfig{
+		Timeout:         2 * time.Second,
+		FollowRedirects: true,
+		Network:         NetworkOptions{Retry: 3, RetryDelay: time.Minute, NoCookies: true},
+		OnRetry:         func(RetryAttempt) { cancel() },
+	})
+
+	start := time.Now()
+	_, err := client.Send(ctx, RequestOptions{Method: http.MethodGet, URL: srv.URL})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the backoff to be cut short, took %v", elapsed)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single attempt before cancellation, got %d", calls)
+	}
+}
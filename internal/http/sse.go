@@ -0,0 +1,292 @@
+package apixhttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSSERetry is the reconnection delay used until the server sends a
+// retry: field.
+const DefaultSSERetry = time.Second
+
+// SSEEvent is one dispatched Server-Sent Event.
+type SSEEvent struct {
+	ID    string `json:"id,omitempty"`
+	Event string `json:"event"`
+	Data  string `json:"data"`
+}
+
+// StreamOptions bounds an SSE stream. Without MaxEvents or Duration the stream
+// is read until the server closes it (and Reconnect attempts are used up).
+type StreamOptions struct {
+	MaxEvents   int
+	Duration    time.Duration
+	Reconnect   int
+	LastEventID string
+	// OnOpen is called once the first connection is established, before any
+	// event is read.
+	OnOpen  func(resp *Response)
+	OnEvent func(event SSEEvent)
+}
+
+// Stream sends an SSE request and collects events as they arrive. The
+// returned Response carries the collected events and, as Body, their JSON
+// representation (data decoded when it is JSON) so expect and capture rules
+// work on them. Non event-stream responses are returned as by Send. Cancelling
+// ctx ends the stream like its duration does, keeping the events collected.
+func (c *Client) Stream(ctx context.Context, opts RequestOptions, stream StreamOptions) (*Response, error) {
+	if c.initErr != nil {
+		return nil, c.initErr
+	}
+
+	bodyBytes, err := readBodyBytes(opts.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+
+	if stream.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, stream.Duration)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var result *Response
+	lastEventID := stream.LastEventID
+	retryDelay := DefaultSSERetry
+	events := make([]SSEEvent, 0)
+
+	for attempt := 0; ; attempt++ {
+		headers := make(map[string]string, len(opts.Headers)+3)
+		for k, v := range opts.Headers {
+			headers[k] = v
+		}
+		if _, ok := headerValue(headers, "Accept"); !ok {
+			headers["Accept"] = "text/event-stream"
+		}
+		headers["Cache-Control"] = "no-cache"
+		if lastEventID != "" {
+			headers["Last-Event-ID"] = lastEventID
+		}
+
+		build := func() (*http.Request, error) {
+			req, err := BuildRequest(ctx, RequestOptions{
+				Method:  opts.Method,
+				URL:     opts.URL,
+				Headers: headers,
+				Query:   opts.Query,
+				Body:    bytes.NewReader(bodyBytes),
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := signRequest(opts.Sign, req, bodyBytes); err != nil {
+				return nil, err
+			}
+			return req, nil
+		}
+		req, err := build()
+		if err != nil {
+			return nil, err
+		}
+
+		req, resp, _, err := c.do(req, bodyBytes, build)
+		if err != nil {
+			if result != nil && ctx.Err() != nil {
+				break
+			}
+			if result != nil && attempt < stream.Reconnect {
+				if !sleepContext(ctx, retryDelay) {
+					break
+				}
+				continue
+			}
+			return nil, fmt.Errorf("sending request: %w", err)
+		}
+
+		if result == nil {
+			if !isEventStream(resp) {
+				parsed, err := ParseResponse(resp, time.Since(start))
+				if err != nil {
+					return nil, err
+				}
+				parsed.Method = req.Method
+				parsed.URL = req.URL.String()
+				return parsed, nil
+			}
+			result = &Response{
+				Method:     req.Method,
+				URL:        req.URL.String(),
+				StatusCode: resp.StatusCode,
+				Status:     resp.Status,
+				Headers:    resp.Header,
+			}
+			if stream.OnOpen != nil {
+				stream.OnOpen(result)
+			}
+		} else if resp.StatusCode != http.StatusOK || !isEventStream(resp) {
+			// Per the SSE spec a non-200 answer ends reconnection.
+			_ = resp.Body.Close()
+			break
+		}
+
+		done, readErr := readEvents(resp.Body, func(event SSEEvent, id string, retry time.Duration) bool {
+			if id != "" {
+				lastEventID = id
+			}
+			if retry > 0 {
+				retryDelay = retry
+			}
+			if event.Data == "" && event.Event == "" {
+				return false
+			}
+			events = append(events, event)
+			if stream.OnEvent != nil {
+				stream.OnEvent(event)
+			}
+			return stream.MaxEvents > 0 && len(events) >= stream.MaxEvents
+		})
+		_ = resp.Body.Close()
+
+		if done || ctx.Err() != nil {
+			break
+		}
+		if readErr != nil && !errors.Is(readErr, io.EOF) && attempt >= stream.Reconnect {
+			return nil, fmt.Errorf("reading event stream: %w", readErr)
+		}
+		if attempt >= stream.Reconnect || !sleepContext(ctx, retryDelay) {
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+	result.Events = events
+	body, err := eventsJSON(events)
+	if err != nil {
+		return nil, err
+	}
+	result.Body = body
+	return result, nil
+}
+
+// readEvents parses an event stream and calls dispatch for each event (and
+// for blocks that only carry id/retry fields). It stops when dispatch returns
+// true, reporting done.
+func readEvents(body io.Reader, dispatch func(event SSEEvent, id string, retry time.Duration) bool) (bool, error) {
+	reader := bufio.NewReader(body)
+	var (
+		event   SSEEvent
+		data    []string
+		hasData bool
+		id      string
+		retry   time.Duration
+	)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
+			return false, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if hasData || event.Event != "" || id != "" || retry > 0 {
+				event.Data = strings.Join(data, "\n")
+				if event.Event == "" && hasData {
+					event.Event = "message"
+				}
+				if !hasData {
+					event = SSEEvent{}
+				}
+				if dispatch(event, id, retry) {
+					return true, nil
+				}
+			}
+			event, data, hasData, id, retry = SSEEvent{}, nil, false, "", 0
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(line, ":") {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event.Event = value
+			case "data":
+				data = append(data, value)
+				hasData = true
+			case "id":
+				if !strings.Contains(value, "\x00") {
+					event.ID = value
+					id = value
+				}
+			case "retry":
+				if ms, convErr := strconv.Atoi(value); convErr == nil && ms >= 0 {
+					retry = time.Duration(ms) * time.Millisecond
+				}
+			}
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+func eventsJSON(events []SSEEvent) ([]byte, error) {
+	out := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		item := map[string]interface{}{
+			"event": event.Event,
+			"data":  event.Data,
+		}
+		if event.ID != "" {
+			item["id"] = event.ID
+		}
+		var decoded interface{}
+		if json.Unmarshal([]byte(event.Data), &decoded) == nil {
+			item["data"] = decoded
+		}
+		out = append(out, item)
+	}
+	body, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("encoding events: %w", err)
+	}
+	return body, nil
+}
+
+func isEventStream(resp *http.Response) bool {
+	return resp.StatusCode < 300 && strings.HasPrefix(strings.ToLower(resp.Header.Get("Content-Type")), "text/event-stream")
+}
+
+func headerValue(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,138 @@
+package apixhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientStreamCollectsEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "text/event-stream" {
+			t.Errorf("expected Accept: text/event-stream, got %q", got)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive comment\n\n")
+		fmt.Fprint(w, "id: 1\nevent: token\ndata: {\"text\":\"Hel\"}\n\n")
+		fmt.Fprint(w, "id: 2\r\ndata: line one\r\ndata: line two\r\n\r\n")
+		fmt.Fprint(w, "event: done\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, ClientConfig{Network: NetworkOptions{NoCookies: true}})
+	var seen []SSEEvent
+	resp, err := client.Stream(context.Background(), RequestOptions{Method: http.MethodGet, URL: server.URL}, StreamOptions{
+		OnEvent: func(event SSEEvent) { seen = append(seen, event) },
+	})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+
+	want := []SSEEvent{
+		{ID: "1", Event: "token", Data: `{"text":"Hel"}`},
+		{ID: "2", Event: "message", Data: "line one\nline two"},
+		{Event: "done", Data: "[DONE]"},
+	}
+	if len(resp.Events) != len(want) || len(seen) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), resp.Events)
+	}
+	for i := range want {
+		if resp.Events[i] != want[i] {
+			t.Fatalf("event %d: expected %+v, got %+v", i, want[i], resp.Events[i])
+		}
+	}
+	if !strings.Contains(string(resp.Body), `"data":{"text":"Hel"}`) {
+		t.Fatalf("expected JSON data to be decoded in body, got %s", resp.Body)
+	}
+}
+
+func TestClientStreamReconnectsWithLastEventID(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		lastEventIDs []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		connection := len(lastEventIDs)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "retry: 5\nid: %d\ndata: event %d\n\n", connection, connection)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, ClientConfig{Network: NetworkOptions{NoCookies: true}})
+	resp, err := client.Stream(context.Background(), RequestOptions{Method: http.MethodGet, URL: server.URL}, StreamOptions{
+		MaxEvents:   3,
+		Reconnect:   5,
+		LastEventID: "0",
+	})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if len(resp.Events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", resp.Events)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(lastEventIDs, ",") != "0,1,2" {
+		t.Fatalf("expected Last-Event-ID 0,1,2 across reconnections, got %v", lastEventIDs)
+	}
+}
+
+func TestClientStreamStopsAfterDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: tick %d\n\n", i); err != nil {
+				return
+			}
+			flusher.Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, ClientConfig{Network: NetworkOptions{NoCookies: true}})
+	start := time.Now()
+	resp, err := client.Stream(context.Background(), RequestOptions{Method: http.MethodGet, URL: server.URL}, StreamOptions{Duration: 80 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected stream to stop after its duration, took %s", elapsed)
+	}
+	if len(resp.Events) == 0 {
+		t.Fatalf("expected some events before the deadline")
+	}
+}
+
+func TestClientStreamNonEventStreamResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"unauthorized"}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, ClientConfig{Network: NetworkOptions{NoCookies: true}})
+	resp, err := client.Stream(context.Background(), RequestOptions{Method: http.MethodGet, URL: server.URL}, StreamOptions{})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || resp.Events != nil || string(resp.Body) != `{"error":"unauthorized"}` {
+		t.Fatalf("expected plain response, got %+v", resp)
+	}
+}
@@ -0,0 +1,126 @@
+package apixhttp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TimingPhases are the keys of Timing.Milliseconds, in waterfall order.
+var TimingPhases = []string{"dns", "connect", "tls", "wait", "ttfb", "transfer", "total"}
+
+// Timing breaks the final attempt of a request down into the phases traced
+// by net/http/httptrace. A reused connection has no DNS, connect or TLS time.
+type Timing struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// Wait is the server time, from the request being written to the first
+	// response byte; TTFB is the time from the start to the first byte.
+	Wait     time.Duration
+	TTFB     time.Duration
+	Transfer time.Duration
+	Total    time.Duration
+	Reused   bool
+}
+
+// Milliseconds returns the phases in milliseconds, keyed by TimingPhases.
+func (t *Timing) Milliseconds() map[string]int64 {
+	if t == nil {
+		return nil
+	}
+	return map[string]int64{
+		"dns":      t.DNS.Milliseconds(),
+		"connect":  t.Connect.Milliseconds(),
+		"tls":      t.TLS.Milliseconds(),
+		"wait":     t.Wait.Milliseconds(),
+		"ttfb":     t.TTFB.Milliseconds(),
+		"transfer": t.Transfer.Milliseconds(),
+		"total":    t.Total.Milliseconds(),
+	}
+}
+
+// timingTrace records the httptrace events of a request. Dials may race (IPv4
+// and IPv6), so the first start and the last completion of a phase are kept.
+type timingTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+func traceTiming(req *http.Request) (*http.Request, *timingTrace) {
+	t := &timingTrace{start: time.Now()}
+	first := func(at *time.Time) func() {
+		return func() {
+			t.mu.Lock()
+			if at.IsZero() {
+				*at = time.Now()
+			}
+			t.mu.Unlock()
+		}
+	}
+	last := func(at *time.Time) func() {
+		return func() {
+			t.mu.Lock()
+			*at = time.Now()
+			t.mu.Unlock()
+		}
+	}
+
+	dnsStart, dnsDone := first(&t.dnsStart), last(&t.dnsDone)
+	connectStart, connectDone := first(&t.connectStart), last(&t.connectDone)
+	tlsStart, tlsDone := first(&t.tlsStart), last(&t.tlsDone)
+	wroteRequest, firstByte := last(&t.wroteRequest), last(&t.firstByte)
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { dnsStart() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { dnsDone() },
+		ConnectStart:         func(string, string) { connectStart() },
+		ConnectDone:          func(string, string, error) { connectDone() },
+		TLSHandshakeStart:    tlsStart,
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { tlsDone() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { wroteRequest() },
+		GotFirstResponseByte: firstByte,
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), t
+}
+
+// timing returns the phases of the traced request whose body was read at end.
+func (t *timingTrace) timing(end time.Time) *Timing {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	between := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	return &Timing{
+		DNS:      between(t.dnsStart, t.dnsDone),
+		Connect:  between(t.connectStart, t.connectDone),
+		TLS:      between(t.tlsStart, t.tlsDone),
+		Wait:     between(t.wroteRequest, t.firstByte),
+		TTFB:     between(t.start, t.firstByte),
+		Transfer: between(t.firstByte, end),
+		Total:    between(t.start, end),
+		Reused:   t.reused,
+	}
+}
@@ -0,0 +1,59 @@
+package apixhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSendRecordsTiming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, ClientConfig{
+		Timeout:         5 * time.Second,
+		FollowRedirects: true,
+		Network:         NetworkOptions{NoCookies: true},
+	})
+
+	first, err := client.Send(context.Background(), RequestOptions{Method: http.MethodGet, URL: server.URL})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	timing := first.Timing
+	if timing == nil {
+		t.Fatalf("expected timing on response")
+	}
+	if timing.Reused {
+		t.Fatalf("expected a new connection for the first request")
+	}
+	if timing.Connect <= 0 {
+		t.Fatalf("expected connect time, got %v", timing.Connect)
+	}
+	if timing.Wait < 20*time.Millisecond || timing.TTFB < timing.Wait {
+		t.Fatalf("expected wait >= 20ms and ttfb >= wait, got wait %v ttfb %v", timing.Wait, timing.TTFB)
+	}
+	if timing.Total < timing.TTFB {
+		t.Fatalf("expected total >= ttfb, got %v < %v", timing.Total, timing.TTFB)
+	}
+
+	second, err := client.Send(context.Background(), RequestOptions{Method: http.MethodGet, URL: server.URL})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if !second.Timing.Reused || second.Timing.Connect != 0 {
+		t.Fatalf("expected a reused connection without connect time, got %+v", second.Timing)
+	}
+
+	ms := second.Timing.Milliseconds()
+	for _, phase := range TimingPhases {
+		if _, ok := ms[phase]; !ok {
+			t.Fatalf("expected %q in Milliseconds, got %v", phase, ms)
+		}
+	}
+}
@@ -11,6 +11,19 @@ import (
 
 func ToCommand(req request.SavedRequest) (string, error) {
 	method := strings.TrimSpace(strings.ToUpper(req.Method))
+	body := req.Body
+	headers := req.Headers
+	if req.GraphQL != nil {
+		var err error
+		body, err = req.GraphQL.TemplateBody()
+		if err != nil {
+			return "", err
+		}
+		if method == "" {
+			method = "POST"
+		}
+		headers = withContentType(headers, "application/json")
+	}
 	if method == "" {
 		method = "GET"
 	}
@@ -27,25 +40,37 @@ func ToCommand(req request.SavedRequest) (string, error) {
 		quoteArg(targetURL),
 	}
 
-	if len(req.Headers) > 0 {
-		keys := make([]string, 0, len(req.Headers))
-		for key := range req.Headers {
+	if len(headers) > 0 {
+		keys := make([]string, 0, len(headers))
+		for key := range headers {
 			keys = append(keys, key)
 		}
 		sort.Strings(keys)
 		for _, key := range keys {
-			value := strings.TrimSpace(req.Headers[key])
+			value := strings.TrimSpace(headers[key])
 			parts = append(parts, "-H", quoteArg(fmt.Sprintf("%s: %s", key, value)))
 		}
 	}
 
-	if req.Body != "" {
-		parts = append(parts, "--data-raw", quoteArg(req.Body))
+	if body != "" {
+		parts = append(parts, "--data-raw", quoteArg(body))
 	}
 
 	return strings.Join(parts, " "), nil
 }
 
+func withContentType(headers map[string]string, contentType string) map[string]string {
+	out := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		if strings.EqualFold(key, "Content-Type") {
+			return headers
+		}
+		out[key] = value
+	}
+	out["Content-Type"] = contentType
+	return out
+}
+
 func buildURL(pathValue string, query map[string]string) (string, error) {
 	base := strings.TrimSpace(pathValue)
 	if base == "" {
@@ -52,3 +52,21 @@ func TestToCommandAndParseRoundTrip(t *testing.T) {
 		t.Fatalf("expected non-empty body after roundtrip")
 	}
 }
+
+func TestToCommandGraphQLKeepsPlaceholders(t *testing.T) {
+	in := request.SavedRequest{
+		Path: "/graphql",
+		GraphQL: &request.GraphQL{
+			Query:     "query Users($limit: Int) { users(limit: $limit) { id } }",
+			Variables: map[string]interface{}{"limit": "json:${LIMIT}"},
+		},
+	}
+
+	command, err := ToCommand(in)
+	if err != nil {
+		t.Fatalf("to command: %v", err)
+	}
+	if !strings.Contains(command, `"variables":{"limit":${LIMIT}}`) || !strings.Contains(command, "-X 'POST'") {
+		t.Fatalf("unexpected command output: %s", command)
+	}
+}
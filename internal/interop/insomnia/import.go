@@ -40,7 +40,14 @@ type parameterField struct {
 }
 
 type insomniaBody struct {
-	Text string `json:"text"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type graphQLText struct {
+	Query         string      `json:"query"`
+	Variables     interface{} `json:"variables"`
+	OperationName string      `json:"operationName"`
 }
 
 func ParseExportFile(filePath string) ([]request.SavedRequest, error) {
@@ -91,19 +98,41 @@ func ParseExport(data []byte) ([]request.SavedRequest, error) {
 			method = "GET"
 		}
 
-		out = append(out, request.SavedRequest{
+		req := request.SavedRequest{
 			Name:    strings.TrimSpace(res.Name),
 			Method:  method,
 			Path:    pathValue,
 			Headers: headers,
 			Query:   query,
 			Body:    body,
-		})
+		}
+		if gql := graphQLFromResource(res); gql != nil {
+			req.Body = ""
+			req.GraphQL = gql
+		}
+		out = append(out, req)
 	}
 
 	return out, nil
 }
 
+// graphQLFromResource maps an application/graphql body (a JSON document with
+// query, variables and operationName) onto a graphql block.
+func graphQLFromResource(res resource) *request.GraphQL {
+	if res.Body == nil || !strings.EqualFold(strings.TrimSpace(res.Body.MimeType), "application/graphql") {
+		return nil
+	}
+	var text graphQLText
+	if err := json.Unmarshal([]byte(res.Body.Text), &text); err != nil || strings.TrimSpace(text.Query) == "" {
+		return nil
+	}
+	gql, err := request.ParseGraphQLPayload(text.Query, text.Variables, text.OperationName)
+	if err != nil {
+		return nil
+	}
+	return gql
+}
+
 func bodyFromResource(res resource) string {
 	if res.Body != nil && strings.TrimSpace(res.Body.Text) != "" {
 		return res.Body.Text
@@ -32,3 +32,23 @@ func TestParseExportFile(t *testing.T) {
 		t.Fatalf("expected body mapped from insomnia payload")
 	}
 }
+
+func TestParseExportGraphQLBody(t *testing.T) {
+	data := []byte(`{"resources":[{"_type":"request","name":"Get user","method":"POST","url":"https://api.example.com/graphql",
+		"body":{"mimeType":"application/graphql","text":"{\"query\":\"query GetUser($id: ID!) { user(id: $id) { id } }\",\"variables\":{\"id\":\"42\"},\"operationName\":\"GetUser\"}"}}]}`)
+
+	requests, err := ParseExport(data)
+	if err != nil {
+		t.Fatalf("parse insomnia export: %v", err)
+	}
+	got := requests[0]
+	if got.GraphQL == nil {
+		t.Fatalf("expected graphql block, got body %q", got.Body)
+	}
+	if got.Body != "" {
+		t.Fatalf("expected raw body to be replaced by the graphql block, got %q", got.Body)
+	}
+	if got.GraphQL.OperationName != "GetUser" || got.GraphQL.Variables["id"] != "42" {
+		t.Fatalf("unexpected graphql block: %+v", got.GraphQL)
+	}
+}
@@ -0,0 +1,159 @@
+package openapi
+
+import (
+	"sort"
+)
+
+const maxExampleDepth = 8
+
+// ExampleValue builds a representative value for schema, preferring explicit
+// examples, defaults and enum members over synthesized placeholders.
+func (s *Spec) ExampleValue(schema map[string]interface{}) interface{} {
+	return s.exampleValue(schema, 0)
+}
+
+func (s *Spec) exampleValue(schema map[string]interface{}, depth int) interface{} {
+	if schema == nil || depth > maxExampleDepth {
+		return nil
+	}
+	schema = s.Resolve(schema)
+
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if examples := listField(schema, "examples"); len(examples) > 0 {
+		return examples[0]
+	}
+	if enum := listField(schema, "enum"); len(enum) > 0 {
+		return enum[0]
+	}
+
+	if allOf := listField(schema, "allOf"); len(allOf) > 0 {
+		merged := make(map[string]interface{})
+		for _, part := range allOf {
+			if obj, ok := s.exampleValue(asMap(part), depth+1).(map[string]interface{}); ok {
+				for key, value := range obj {
+					merged[key] = value
+				}
+			}
+		}
+		if props := mapField(schema, "properties"); len(props) > 0 {
+			for key, value := range s.objectExample(schema, depth) {
+				merged[key] = value
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if variants := listField(schema, key); len(variants) > 0 {
+			return s.exampleValue(asMap(variants[0]), depth+1)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		return s.objectExample(schema, depth)
+	case "array":
+		item := s.exampleValue(mapField(schema, "items"), depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "string":
+		return stringExample(stringField(schema, "format"))
+	case "integer":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+		return 0
+	case "number":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+		return 0.0
+	case "boolean":
+		return false
+	case "null":
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (s *Spec) objectExample(schema map[string]interface{}, depth int) map[string]interface{} {
+	props := mapField(schema, "properties")
+	out := make(map[string]interface{}, len(props))
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		prop := s.Resolve(asMap(props[key]))
+		if boolField(prop, "readOnly") {
+			continue
+		}
+		out[key] = s.exampleValue(prop, depth+1)
+	}
+	return out
+}
+
+// schemaType returns the primary JSON type of schema, inferring "object" and
+// "array" from structural keywords when "type" is omitted.
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && name != "null" {
+				return name
+			}
+		}
+		if len(t) > 0 {
+			if name, ok := t[0].(string); ok {
+				return name
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return ""
+}
+
+func stringExample(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "c3RyaW5n"
+	case "binary":
+		return ""
+	case "password":
+		return "secret"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,516 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"gopkg.in/yaml.v3"
+)
+
+const exportVersion = "3.1.0"
+
+var templateVarPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// ResponseSample is one recorded response (typically from apix history) used
+// to infer response schemas for the saved request it came from.
+type ResponseSample struct {
+	Request string
+	Method  string
+	URL     string
+	Status  int
+	Body    json.RawMessage
+}
+
+type ExportOptions struct {
+	Title     string
+	Version   string
+	ServerURL string
+	Format    string
+}
+
+type exportDocument struct {
+	OpenAPI string                                 `json:"openapi" yaml:"openapi"`
+	Info    exportInfo                             `json:"info" yaml:"info"`
+	Servers []exportServer                         `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths   map[string]map[string]*exportOperation `json:"paths" yaml:"paths"`
+}
+
+type exportInfo struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type exportServer struct {
+	URL string `json:"url" yaml:"url"`
+}
+
+type exportOperation struct {
+	OperationID string                     `json:"operationId" yaml:"operationId"`
+	Summary     string                     `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Parameters  []exportParameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *exportRequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*exportResponse `json:"responses" yaml:"responses"`
+}
+
+type exportParameter struct {
+	Name     string                 `json:"name" yaml:"name"`
+	In       string                 `json:"in" yaml:"in"`
+	Required bool                   `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   map[string]interface{} `json:"schema" yaml:"schema"`
+	Example  interface{}            `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+type exportRequestBody struct {
+	Content map[string]exportMediaType `json:"content" yaml:"content"`
+}
+
+type exportMediaType struct {
+	Schema  map[string]interface{} `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example interface{}            `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+type exportResponse struct {
+	Description string                     `json:"description" yaml:"description"`
+	Headers     map[string]exportHeader    `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Content     map[string]exportMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type exportHeader struct {
+	Schema map[string]interface{} `json:"schema" yaml:"schema"`
+}
+
+// ExportSpec builds an OpenAPI 3.1 document describing the saved requests.
+func ExportSpec(requests []request.SavedRequest, samples []ResponseSample, opts ExportOptions) ([]byte, error) {
+	doc := buildExportDocument(requests, samples, opts)
+
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "json":
+		out, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("encoding openapi export: %w", err)
+		}
+		return out, nil
+	case "", "yaml", "yml":
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("encoding openapi export: %w", err)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported openapi export format %q (expected yaml or json)", opts.Format)
+	}
+}
+
+func buildExportDocument(requests []request.SavedRequest, samples []ResponseSample, opts ExportOptions) exportDocument {
+	title := strings.TrimSpace(opts.Title)
+	if title == "" {
+		title = "apix export"
+	}
+	version := strings.TrimSpace(opts.Version)
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	doc := exportDocument{
+		OpenAPI: exportVersion,
+		Info:    exportInfo{Title: title, Version: version},
+		Paths:   make(map[string]map[string]*exportOperation),
+	}
+	if strings.TrimSpace(opts.ServerURL) != "" {
+		doc.Servers = []exportServer{{URL: strings.TrimSpace(opts.ServerURL)}}
+	}
+
+	for _, req := range requests {
+		pathKey, extraQuery, params := exportPath(req.Path)
+		method := strings.ToLower(strings.TrimSpace(req.Method))
+		if method == "" {
+			method = "get"
+		}
+		if doc.Paths[pathKey] == nil {
+			doc.Paths[pathKey] = make(map[string]*exportOperation)
+		}
+		if _, exists := doc.Paths[pathKey][method]; exists {
+			continue
+		}
+
+		op := &exportOperation{
+			OperationID: lowerCamel(strings.ReplaceAll(req.Name, "/", "-")),
+			Summary:     req.Name,
+			Parameters:  params,
+			Responses:   make(map[string]*exportResponse),
+		}
+		if dir, _, ok := strings.Cut(req.Name, "/"); ok {
+			op.Tags = []string{dir}
+		}
+
+		query := make(map[string]string, len(req.Query)+len(extraQuery))
+		for key, value := range extraQuery {
+			query[key] = value
+		}
+		for key, value := range req.Query {
+			query[key] = value
+		}
+		op.Parameters = append(op.Parameters, valueParameters("query", query)...)
+		op.Parameters = append(op.Parameters, valueParameters("header", exportableHeaders(req.Headers))...)
+
+		if body := strings.TrimSpace(req.Body); body != "" {
+			op.RequestBody = exportRequestBodyFor(body, headerValue(req.Headers, "Content-Type"))
+		}
+
+		addExportResponses(op, req, matchingSamples(req, samples))
+		doc.Paths[pathKey][method] = op
+	}
+
+	return doc
+}
+
+// exportPath turns a saved request path into an OpenAPI path template. Any
+// ${VAR} segment becomes a {var} path parameter; absolute URLs are reduced to
+// their path and inline query strings are returned separately.
+func exportPath(raw string) (string, map[string]string, []exportParameter) {
+	raw = strings.TrimSpace(raw)
+	var query map[string]string
+
+	if strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://") {
+		if schemeEnd := strings.Index(raw, "://"); schemeEnd >= 0 {
+			rest := raw[schemeEnd+3:]
+			if slash := strings.Index(rest, "/"); slash >= 0 {
+				raw = rest[slash:]
+			} else {
+				raw = "/"
+			}
+		}
+	}
+	if pathPart, rawQuery, ok := strings.Cut(raw, "?"); ok {
+		raw = pathPart
+		if values, err := url.ParseQuery(rawQuery); err == nil {
+			query = make(map[string]string, len(values))
+			for key := range values {
+				query[key] = values.Get(key)
+			}
+		}
+	}
+	if !strings.HasPrefix(raw, "/") {
+		raw = "/" + raw
+	}
+
+	params := make([]exportParameter, 0)
+	seen := make(map[string]bool)
+	pathKey := templateVarPattern.ReplaceAllStringFunc(raw, func(match string) string {
+		name := lowerCamel(templateVarPattern.FindStringSubmatch(match)[1])
+		if !seen[name] {
+			seen[name] = true
+			params = append(params, exportParameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   map[string]interface{}{"type": "string"},
+			})
+		}
+		return "{" + name + "}"
+	})
+	return pathKey, query, params
+}
+
+func valueParameters(in string, values map[string]string) []exportParameter {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := make([]exportParameter, 0, len(keys))
+	for _, key := range keys {
+		value := values[key]
+		param := exportParameter{
+			Name:   key,
+			In:     in,
+			Schema: map[string]interface{}{"type": "string"},
+		}
+		if templateVarPattern.MatchString(value) {
+			param.Required = true
+		} else {
+			param.Schema = scalarSchema(value)
+			if value != "" {
+				param.Example = value
+			}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+func scalarSchema(value string) map[string]interface{} {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return map[string]interface{}{"type": "integer"}
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return map[string]interface{}{"type": "number"}
+	}
+	if value == "true" || value == "false" {
+		return map[string]interface{}{"type": "boolean"}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+func exportableHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string)
+	for key, value := range headers {
+		switch strings.ToLower(key) {
+		case "accept", "content-type", "authorization", "content-length", "cookie":
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+func exportRequestBodyFor(body, contentType string) *exportRequestBody {
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(placeholdersToStrings(body)), &value); err == nil && (mediaType == "" || IsJSONMediaType(mediaType)) {
+		if mediaType == "" {
+			mediaType = "application/json"
+		}
+		media := exportMediaType{Schema: InferSchema(value)}
+		if !templateVarPattern.MatchString(body) {
+			media.Example = value
+		}
+		return &exportRequestBody{Content: map[string]exportMediaType{mediaType: media}}
+	}
+
+	if mediaType == "" {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(body); err == nil {
+			props := make(map[string]interface{}, len(values))
+			for key := range values {
+				props[key] = map[string]interface{}{"type": "string"}
+			}
+			return &exportRequestBody{Content: map[string]exportMediaType{
+				mediaType: {Schema: map[string]interface{}{"type": "object", "properties": props}},
+			}}
+		}
+	}
+	return &exportRequestBody{Content: map[string]exportMediaType{
+		mediaType: {Schema: map[string]interface{}{"type": "string"}},
+	}}
+}
+
+// placeholdersToStrings quotes ${VAR} placeholders that appear outside JSON
+// strings so templated bodies such as {"id": ${ID}} still parse.
+func placeholdersToStrings(body string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if inString {
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(body) {
+				i++
+				b.WriteByte(body[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+			b.WriteByte(c)
+			continue
+		}
+		if c == '$' {
+			if loc := templateVarPattern.FindStringIndex(body[i:]); loc != nil && loc[0] == 0 {
+				b.WriteString(strconv.Quote(body[i : i+loc[1]]))
+				i += loc[1] - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func addExportResponses(op *exportOperation, req request.SavedRequest, samples []ResponseSample) {
+	statuses := make([]string, 0)
+	if req.Expect != nil {
+		if code, ok := expectedStatus(req.Expect.Status); ok {
+			statuses = append(statuses, strconv.Itoa(code))
+		}
+	}
+	for _, sample := range samples {
+		if sample.Status > 0 {
+			statuses = append(statuses, strconv.Itoa(sample.Status))
+		}
+	}
+	if len(statuses) == 0 {
+		statuses = append(statuses, "200")
+	}
+
+	for _, status := range statuses {
+		if _, ok := op.Responses[status]; ok {
+			continue
+		}
+		code, _ := strconv.Atoi(status)
+		description := http.StatusText(code)
+		if description == "" {
+			description = "Response"
+		}
+		op.Responses[status] = &exportResponse{Description: description}
+	}
+
+	bodySchemas := make(map[string]map[string]interface{})
+	for _, sample := range samples {
+		if len(sample.Body) == 0 {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(sample.Body, &value); err != nil {
+			continue
+		}
+		status := strconv.Itoa(sample.Status)
+		bodySchemas[status] = MergeSchemas(bodySchemas[status], InferSchema(value))
+	}
+
+	if req.Expect != nil {
+		primary := statuses[0]
+		if schema := SchemaFromExpect(req.Expect.Body); schema != nil {
+			bodySchemas[primary] = mergeExpectSchema(bodySchemas[primary], schema)
+		}
+		if len(req.Expect.Headers) > 0 {
+			resp := op.Responses[primary]
+			resp.Headers = make(map[string]exportHeader, len(req.Expect.Headers))
+			for name := range req.Expect.Headers {
+				resp.Headers[name] = exportHeader{Schema: map[string]interface{}{"type": "string"}}
+			}
+		}
+	}
+
+	for status, schema := range bodySchemas {
+		resp, ok := op.Responses[status]
+		if !ok {
+			continue
+		}
+		resp.Content = map[string]exportMediaType{"application/json": {Schema: schema}}
+	}
+}
+
+// mergeExpectSchema overlays the schema declared through expect assertions on
+// top of the one inferred from history, keeping inferred detail where the
+// assertions are silent.
+func mergeExpectSchema(inferred, declared map[string]interface{}) map[string]interface{} {
+	if inferred == nil {
+		return declared
+	}
+	if schemaType(inferred) != "object" || schemaType(declared) != "object" {
+		return inferred
+	}
+	out := make(map[string]interface{}, len(inferred))
+	for key, value := range inferred {
+		out[key] = value
+	}
+	props := make(map[string]interface{})
+	for key, value := range mapField(inferred, "properties") {
+		props[key] = value
+	}
+	for key, value := range mapField(declared, "properties") {
+		props[key] = mergeExpectSchema(asMap(props[key]), asMap(value))
+	}
+	out["properties"] = props
+	return out
+}
+
+func expectedStatus(rule request.AssertionRule) (int, bool) {
+	switch v := rule["eq"].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func matchingSamples(req request.SavedRequest, samples []ResponseSample) []ResponseSample {
+	pattern := samplePathPattern(req.Path)
+	method := strings.ToUpper(strings.TrimSpace(req.Method))
+
+	out := make([]ResponseSample, 0)
+	for _, sample := range samples {
+		if sample.Request != "" {
+			if sample.Request == req.Name {
+				out = append(out, sample)
+			}
+			continue
+		}
+		if !strings.EqualFold(sample.Method, method) || pattern == nil {
+			continue
+		}
+		samplePath := sample.URL
+		if parsed, err := url.Parse(sample.URL); err == nil {
+			samplePath = parsed.Path
+		}
+		if pattern.MatchString(samplePath) {
+			out = append(out, sample)
+		}
+	}
+	return out
+}
+
+// samplePathPattern matches recorded URLs whose path ends with the saved
+// request path, with each ${VAR} standing in for one path segment.
+func samplePathPattern(path string) *regexp.Regexp {
+	path, _, _ = strings.Cut(strings.TrimSpace(path), "?")
+	if path == "" || strings.Contains(path, "://") {
+		return nil
+	}
+	parts := templateVarPattern.Split(path, -1)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	pattern, err := regexp.Compile(strings.Join(parts, "[^/]+") + "$")
+	if err != nil {
+		return nil
+	}
+	return pattern
+}
+
+func lowerCamel(value string) string {
+	words := strings.Split(splitWords(value, ' '), " ")
+	var b strings.Builder
+	for i, word := range words {
+		if word == "" {
+			continue
+		}
+		word = strings.ToLower(word)
+		if i > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
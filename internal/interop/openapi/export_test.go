@@ -0,0 +1,131 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+func TestExportSpecRoundTrip(t *testing.T) {
+	requests := []request.SavedRequest{
+		{
+			Name:    "users/get-user",
+			Method:  "GET",
+			Path:    "/users/${USER_ID}",
+			Query:   map[string]string{"expand": "profile", "token": "${TOKEN}"},
+			Headers: map[string]string{"Accept": "application/json", "X-Tenant": "acme"},
+			Expect: &request.Expect{
+				Status:  request.AssertionRule{"eq": 200},
+				Headers: map[string]request.AssertionRule{"X-Request-Id": {"exists": true}},
+				Body: map[string]request.AssertionRule{
+					"data.email": {"is_string": true},
+				},
+			},
+		},
+		{
+			Name:    "users/create-user",
+			Method:  "POST",
+			Path:    "/users",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    `{"name": "Jane", "age": ${AGE}}`,
+		},
+	}
+	samples := []ResponseSample{
+		{Request: "users/get-user", Method: "GET", URL: "/users/1", Status: 200, Body: json.RawMessage(`{"data":{"id":1,"email":"a@b.c"}}`)},
+		{Method: "POST", URL: "https://api.example.com/users", Status: 201, Body: json.RawMessage(`{"id":7}`)},
+	}
+
+	data, err := ExportSpec(requests, samples, ExportOptions{Title: "Demo", ServerURL: "https://api.example.com"})
+	if err != nil {
+		t.Fatalf("export spec: %v", err)
+	}
+
+	spec, err := LoadSpec(data)
+	if err != nil {
+		t.Fatalf("reload exported spec: %v\n%s", err, data)
+	}
+	if spec.Version != "3.1.0" || spec.Title != "Demo" {
+		t.Fatalf("unexpected version/title: %q %q", spec.Version, spec.Title)
+	}
+	if !reflect.DeepEqual(spec.Servers, []string{"https://api.example.com"}) {
+		t.Fatalf("unexpected servers: %v", spec.Servers)
+	}
+	if len(spec.Operations) != 2 {
+		t.Fatalf("expected 2 operations, got %d", len(spec.Operations))
+	}
+
+	get := spec.Operations[1]
+	if get.Method != "GET" || get.Path != "/users/{userId}" || get.OperationID != "usersGetUser" {
+		t.Fatalf("unexpected get operation: %+v", get)
+	}
+	if !reflect.DeepEqual(get.Tags, []string{"users"}) {
+		t.Fatalf("expected users tag, got %v", get.Tags)
+	}
+	params := make(map[string]Parameter)
+	for _, param := range get.Parameters {
+		params[param.In+":"+param.Name] = param
+	}
+	if !params["path:userId"].Required {
+		t.Fatalf("expected required userId path parameter, got %+v", get.Parameters)
+	}
+	if p := params["query:expand"]; !p.HasExample || p.Example != "profile" {
+		t.Fatalf("expected expand example, got %+v", p)
+	}
+	if p := params["query:token"]; !p.Required || p.HasExample {
+		t.Fatalf("expected templated token to be required without example, got %+v", p)
+	}
+	if _, ok := params["header:X-Tenant"]; !ok {
+		t.Fatalf("expected X-Tenant header parameter, got %+v", get.Parameters)
+	}
+	if _, ok := params["header:Accept"]; ok {
+		t.Fatalf("did not expect Accept header parameter")
+	}
+
+	ok := get.Responses["200"]
+	if _, found := ok.Headers["X-Request-Id"]; !found {
+		t.Fatalf("expected X-Request-Id response header, got %+v", ok.Headers)
+	}
+	data200 := mapField(mapField(ok.Content["application/json"].Schema, "properties"), "data")
+	props := mapField(data200, "properties")
+	if schemaType(asMap(props["id"])) != "integer" || schemaType(asMap(props["email"])) != "string" {
+		t.Fatalf("unexpected inferred response schema: %v", data200)
+	}
+
+	create := spec.Operations[0]
+	if create.Method != "POST" || create.RequestBody == nil {
+		t.Fatalf("unexpected create operation: %+v", create)
+	}
+	bodySchema := create.RequestBody.Content["application/json"].Schema
+	bodyProps := mapField(bodySchema, "properties")
+	if schemaType(asMap(bodyProps["name"])) != "string" {
+		t.Fatalf("unexpected request schema: %v", bodySchema)
+	}
+	created, found := create.Responses["201"]
+	if !found || created.Content["application/json"].Schema == nil {
+		t.Fatalf("expected 201 response from path-matched sample, got %+v", create.Responses)
+	}
+}
+
+func TestMergeSchemasWidensTypes(t *testing.T) {
+	merged := MergeSchemas(InferSchema(map[string]interface{}{"a": 1.0, "b": "x"}), InferSchema(map[string]interface{}{"a": 1.5}))
+	props := mapField(merged, "properties")
+	if schemaType(asMap(props["a"])) != "number" {
+		t.Fatalf("expected integer+number to widen to number, got %v", props["a"])
+	}
+	if !reflect.DeepEqual(merged["required"], []interface{}{"a"}) {
+		t.Fatalf("expected required to be intersected, got %v", merged["required"])
+	}
+
+	mixed := MergeSchemas(InferSchema("x"), InferSchema(nil))
+	if !reflect.DeepEqual(mixed["type"], []interface{}{"null", "string"}) {
+		t.Fatalf("expected type list, got %v", mixed["type"])
+	}
+}
+
+func TestExportSpecRejectsUnknownFormat(t *testing.T) {
+	if _, err := ExportSpec(nil, nil, ExportOptions{Format: "xml"}); err == nil {
+		t.Fatalf("expected unsupported format error")
+	}
+}
@@ -0,0 +1,263 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// ImportedRequest is a saved request generated from one operation, together
+// with the directory (derived from the first operation tag) it belongs in.
+type ImportedRequest struct {
+	Dir     string
+	Request request.SavedRequest
+}
+
+func ParseSpecFile(filePath string) ([]ImportedRequest, error) {
+	spec, err := LoadSpecFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertSpec(spec), nil
+}
+
+func ParseSpec(data []byte) ([]ImportedRequest, error) {
+	spec, err := LoadSpec(data)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertSpec(spec), nil
+}
+
+func ConvertSpec(spec *Spec) []ImportedRequest {
+	out := make([]ImportedRequest, 0, len(spec.Operations))
+	for _, op := range spec.Operations {
+		dir := ""
+		if len(op.Tags) > 0 {
+			dir = kebabCase(op.Tags[0])
+		}
+		out = append(out, ImportedRequest{
+			Dir:     dir,
+			Request: spec.toSavedRequest(op),
+		})
+	}
+	return out
+}
+
+func (s *Spec) toSavedRequest(op Operation) request.SavedRequest {
+	req := request.SavedRequest{
+		Name:   operationName(op),
+		Method: op.Method,
+		Path:   TemplatePath(op.Path),
+	}
+
+	for _, param := range op.Parameters {
+		value, ok := s.parameterValue(param)
+		if !ok {
+			continue
+		}
+		switch param.In {
+		case "query":
+			if req.Query == nil {
+				req.Query = make(map[string]string)
+			}
+			req.Query[param.Name] = value
+		case "header":
+			switch strings.ToLower(param.Name) {
+			case "accept", "content-type", "authorization":
+				continue
+			}
+			if req.Headers == nil {
+				req.Headers = make(map[string]string)
+			}
+			req.Headers[param.Name] = value
+		}
+	}
+
+	if op.RequestBody != nil {
+		if mediaType, media, ok := PreferredMediaType(op.RequestBody.Content); ok {
+			if body := s.exampleBody(mediaType, media); body != "" {
+				req.Body = body
+				if req.Headers == nil {
+					req.Headers = make(map[string]string)
+				}
+				req.Headers["Content-Type"] = mediaType
+			}
+		}
+	}
+
+	if status := successStatusRule(op.Responses); status != nil {
+		req.Expect = &request.Expect{Status: status}
+	}
+	return req
+}
+
+// TemplatePath rewrites OpenAPI path templates ({userId}) into apix
+// variables (${USER_ID}).
+func TemplatePath(path string) string {
+	return pathParamPattern.ReplaceAllStringFunc(path, func(match string) string {
+		return "${" + VariableName(match[1:len(match)-1]) + "}"
+	})
+}
+
+// VariableName converts a parameter name such as "userId" or "user-id" into
+// the upper snake case form used for apix variables.
+func VariableName(name string) string {
+	return strings.ToUpper(splitWords(name, '_'))
+}
+
+func (s *Spec) parameterValue(param Parameter) (string, bool) {
+	if param.HasExample {
+		return formatParamValue(param.Example), true
+	}
+	schema := s.Resolve(param.Schema)
+	for _, key := range []string{"example", "default"} {
+		if value, ok := schema[key]; ok {
+			return formatParamValue(value), true
+		}
+	}
+	if enum := listField(schema, "enum"); len(enum) > 0 {
+		return formatParamValue(enum[0]), true
+	}
+	if param.Required {
+		return "${" + VariableName(param.Name) + "}", true
+	}
+	return "", false
+}
+
+func (s *Spec) exampleBody(mediaType string, media MediaType) string {
+	value := media.Example
+	if !media.HasExample {
+		value = s.ExampleValue(media.Schema)
+	}
+	if value == nil {
+		return ""
+	}
+
+	if IsJSONMediaType(mediaType) {
+		encoded, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	}
+
+	if strings.HasPrefix(mediaType, "application/x-www-form-urlencoded") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		values := url.Values{}
+		for key, item := range obj {
+			values.Set(key, formatParamValue(item))
+		}
+		return values.Encode()
+	}
+
+	if text, ok := value.(string); ok {
+		return text
+	}
+	return ""
+}
+
+func successStatusRule(responses map[string]Response) request.AssertionRule {
+	codes := make([]int, 0)
+	wildcard := false
+	for code := range responses {
+		if n, err := strconv.Atoi(code); err == nil {
+			if n >= 200 && n < 300 {
+				codes = append(codes, n)
+			}
+			continue
+		}
+		if strings.EqualFold(code, "2XX") {
+			wildcard = true
+		}
+	}
+	if len(codes) > 0 {
+		sort.Ints(codes)
+		return request.AssertionRule{"eq": codes[0]}
+	}
+	if wildcard {
+		return request.AssertionRule{"gte": 200, "lt": 300}
+	}
+	return nil
+}
+
+func operationName(op Operation) string {
+	if strings.TrimSpace(op.OperationID) != "" {
+		return kebabCase(op.OperationID)
+	}
+
+	parts := []string{strings.ToLower(op.Method)}
+	for _, segment := range strings.Split(op.Path, "/") {
+		segment = strings.Trim(segment, "{}")
+		if segment != "" {
+			parts = append(parts, kebabCase(segment))
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func formatParamValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, formatParamValue(item))
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func kebabCase(value string) string {
+	return strings.ToLower(splitWords(value, '-'))
+}
+
+// splitWords breaks camelCase, snake_case and punctuated identifiers into
+// words joined by sep.
+func splitWords(value string, sep rune) string {
+	var b strings.Builder
+	runes := []rune(strings.TrimSpace(value))
+	pendingSep := false
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingSep = b.Len() > 0
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 && b.Len() > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				pendingSep = true
+			}
+		}
+		if pendingSep {
+			b.WriteRune(sep)
+			pendingSep = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
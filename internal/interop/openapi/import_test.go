@@ -0,0 +1,124 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSpecFileOpenAPI3(t *testing.T) {
+	imported, err := ParseSpecFile(filepath.Join("testdata", "petstore.yaml"))
+	if err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	if len(imported) != 4 {
+		t.Fatalf("expected 4 operations, got %d", len(imported))
+	}
+
+	byName := make(map[string]ImportedRequest)
+	for _, item := range imported {
+		byName[item.Request.Name] = item
+	}
+
+	list, ok := byName["list-pets"]
+	if !ok {
+		t.Fatalf("expected list-pets operation, got %+v", imported)
+	}
+	if list.Dir != "pets" {
+		t.Fatalf("expected tag directory pets, got %q", list.Dir)
+	}
+	if list.Request.Method != "GET" || list.Request.Path != "/pets" {
+		t.Fatalf("unexpected method/path: %s %s", list.Request.Method, list.Request.Path)
+	}
+	wantQuery := map[string]string{"limit": "20", "status": "available"}
+	if !reflect.DeepEqual(list.Request.Query, wantQuery) {
+		t.Fatalf("expected query %v, got %v", wantQuery, list.Request.Query)
+	}
+	if list.Request.Expect == nil || list.Request.Expect.Status["eq"] != 200 {
+		t.Fatalf("expected expect.status eq 200, got %+v", list.Request.Expect)
+	}
+
+	create := byName["create-pet"]
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(create.Request.Body), &body); err != nil {
+		t.Fatalf("expected JSON example body, got %q: %v", create.Request.Body, err)
+	}
+	if body["name"] != "Rex" || body["birthday"] != "2024-01-01" {
+		t.Fatalf("unexpected example body: %v", body)
+	}
+	if create.Request.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("expected JSON content type, got %v", create.Request.Headers)
+	}
+	if create.Request.Expect.Status["eq"] != 201 {
+		t.Fatalf("expected expect.status eq 201, got %+v", create.Request.Expect.Status)
+	}
+
+	show := byName["show-pet-by-id"]
+	if show.Request.Path != "/pets/${PET_ID}" {
+		t.Fatalf("expected templated path, got %q", show.Request.Path)
+	}
+	if show.Request.Headers["X-Request-ID"] != "${X_REQUEST_ID}" {
+		t.Fatalf("expected header placeholder, got %v", show.Request.Headers)
+	}
+
+	health := byName["get-health"]
+	if health.Dir != "" {
+		t.Fatalf("expected untagged operation at top level, got %q", health.Dir)
+	}
+	if health.Request.Expect.Status["gte"] != 200 || health.Request.Expect.Status["lt"] != 300 {
+		t.Fatalf("expected 2XX range assertion, got %+v", health.Request.Expect.Status)
+	}
+}
+
+func TestParseSpecFileSwagger2(t *testing.T) {
+	spec, err := LoadSpecFile(filepath.Join("testdata", "swagger.json"))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0] != "https://legacy.example.com/api" {
+		t.Fatalf("unexpected servers: %v", spec.Servers)
+	}
+
+	imported := ConvertSpec(spec)
+	if len(imported) != 2 {
+		t.Fatalf("expected 2 operations, got %d", len(imported))
+	}
+
+	login, update := imported[0].Request, imported[1]
+	if login.Body != "password=secret&username=string" {
+		t.Fatalf("unexpected form body %q", login.Body)
+	}
+	if login.Headers["Content-Type"] != "application/x-www-form-urlencoded" {
+		t.Fatalf("unexpected form content type %v", login.Headers)
+	}
+
+	if update.Dir != "user-accounts" {
+		t.Fatalf("expected tag directory user-accounts, got %q", update.Dir)
+	}
+	if update.Request.Name != "update-user" {
+		t.Fatalf("expected name update-user, got %q", update.Request.Name)
+	}
+	if update.Request.Path != "/users/${USER_ID}" {
+		t.Fatalf("expected templated path, got %q", update.Request.Path)
+	}
+	if update.Request.Query["dry_run"] != "true" {
+		t.Fatalf("expected x-example query value, got %v", update.Request.Query)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(update.Request.Body), &body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if body["email"] != "user@example.com" {
+		t.Fatalf("unexpected example body: %v", body)
+	}
+	if update.Request.Expect.Status["eq"] != 202 {
+		t.Fatalf("expected expect.status eq 202, got %+v", update.Request.Expect.Status)
+	}
+}
+
+func TestLoadSpecRejectsUnknownDocument(t *testing.T) {
+	if _, err := LoadSpec([]byte(`{"info":{"title":"x"}}`)); err == nil {
+		t.Fatal("expected error for document without version field")
+	}
+}
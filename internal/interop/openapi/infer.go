@@ -0,0 +1,314 @@
+package openapi
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+// InferSchema derives a JSON Schema describing value.
+func InferSchema(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{"type": "null"}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return map[string]interface{}{"type": "integer"}
+		}
+		return map[string]interface{}{"type": "number"}
+	case int, int64:
+		return map[string]interface{}{"type": "integer"}
+	case string:
+		return map[string]interface{}{"type": "string"}
+	case []interface{}:
+		var items map[string]interface{}
+		for _, item := range v {
+			items = MergeSchemas(items, InferSchema(item))
+		}
+		if items == nil {
+			items = map[string]interface{}{}
+		}
+		return map[string]interface{}{"type": "array", "items": items}
+	case map[string]interface{}:
+		props := make(map[string]interface{}, len(v))
+		required := make([]string, 0, len(v))
+		for key, item := range v {
+			props[key] = InferSchema(item)
+			required = append(required, key)
+		}
+		sort.Strings(required)
+		out := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			out["required"] = toInterfaceList(required)
+		}
+		return out
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// MergeSchemas combines two inferred schemas so that the result accepts
+// instances of both: object properties are unioned, required keys intersected
+// and differing types widened into a type list.
+func MergeSchemas(a, b map[string]interface{}) map[string]interface{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	typesA, typesB := schemaTypes(a), schemaTypes(b)
+	if len(typesA) == 1 && len(typesB) == 1 {
+		switch {
+		case typesA[0] == typesB[0]:
+			return mergeSameType(typesA[0], a, b)
+		case isNumericPair(typesA[0], typesB[0]):
+			return map[string]interface{}{"type": "number"}
+		}
+	}
+
+	merged := make(map[string]interface{})
+	for key, value := range a {
+		merged[key] = value
+	}
+	for key, value := range b {
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+		}
+	}
+	union := make([]string, 0, len(typesA)+len(typesB))
+	seen := make(map[string]bool)
+	for _, t := range append(typesA, typesB...) {
+		if t != "" && !seen[t] {
+			seen[t] = true
+			union = append(union, t)
+		}
+	}
+	sort.Strings(union)
+	if len(union) == 1 {
+		merged["type"] = union[0]
+	} else if len(union) > 1 {
+		merged["type"] = toInterfaceList(union)
+	}
+	return merged
+}
+
+func mergeSameType(schemaType string, a, b map[string]interface{}) map[string]interface{} {
+	switch schemaType {
+	case "object":
+		propsA, propsB := mapField(a, "properties"), mapField(b, "properties")
+		props := make(map[string]interface{}, len(propsA)+len(propsB))
+		for key, value := range propsA {
+			props[key] = value
+		}
+		for key, value := range propsB {
+			props[key] = MergeSchemas(asMap(props[key]), asMap(value))
+		}
+		out := map[string]interface{}{"type": "object", "properties": props}
+		requiredB := make(map[string]bool)
+		for _, key := range stringList(listField(b, "required")) {
+			requiredB[key] = true
+		}
+		required := make([]string, 0)
+		for _, key := range stringList(listField(a, "required")) {
+			if requiredB[key] {
+				required = append(required, key)
+			}
+		}
+		if len(required) > 0 {
+			out["required"] = toInterfaceList(required)
+		}
+		return out
+	case "array":
+		return map[string]interface{}{
+			"type":  "array",
+			"items": MergeSchemas(mapField(a, "items"), mapField(b, "items")),
+		}
+	default:
+		out := make(map[string]interface{}, len(a))
+		for key, value := range a {
+			out[key] = value
+		}
+		return out
+	}
+}
+
+func schemaTypes(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		return stringList(t)
+	default:
+		return nil
+	}
+}
+
+func isNumericPair(a, b string) bool {
+	return (a == "integer" && b == "number") || (a == "number" && b == "integer")
+}
+
+// SchemaFromExpect builds a response body schema from the JSON path
+// assertions of an expect block. Only definite dotted/indexed paths are used.
+func SchemaFromExpect(body map[string]request.AssertionRule) map[string]interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+
+	root := map[string]interface{}{}
+	paths := make([]string, 0, len(body))
+	for path := range body {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		segments, ok := splitDefinitePath(path)
+		if !ok {
+			continue
+		}
+		rule := body[path]
+		if exists, ok := rule["exists"].(bool); ok && !exists {
+			continue
+		}
+		leaf := schemaFromRule(rule)
+		insertSchemaPath(root, segments, leaf)
+	}
+
+	if len(root) == 0 {
+		return nil
+	}
+	return root
+}
+
+func splitDefinitePath(path string) ([]string, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" || strings.ContainsAny(path, "*?:,()") || strings.Contains(path, "..") {
+		return nil, false
+	}
+	path = strings.NewReplacer("[", ".", "]", "", "'", "", "\"", "").Replace(path)
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(path, ".") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			return nil, false
+		}
+		segments = append(segments, segment)
+	}
+	return segments, true
+}
+
+func schemaFromRule(rule request.AssertionRule) map[string]interface{} {
+	if inline := asMap(normalize(rule["schema"])); inline != nil {
+		return inline
+	}
+	typeByOperator := map[string]string{
+		"is_number": "number",
+		"is_string": "string",
+		"is_array":  "array",
+		"is_bool":   "boolean",
+		"is_null":   "null",
+	}
+	for op, schemaType := range typeByOperator {
+		if flag, ok := rule[op].(bool); ok && flag {
+			return map[string]interface{}{"type": schemaType}
+		}
+	}
+	if expected, ok := rule["eq"]; ok {
+		return InferSchema(normalizeYAMLNumber(expected))
+	}
+	for _, op := range []string{"gt", "gte", "lt", "lte"} {
+		if _, ok := rule[op]; ok {
+			return map[string]interface{}{"type": "number"}
+		}
+	}
+	if _, ok := rule["length"]; ok {
+		return map[string]interface{}{"type": []interface{}{"array", "string"}}
+	}
+	return map[string]interface{}{}
+}
+
+func normalizeYAMLNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		return value
+	}
+}
+
+func insertSchemaPath(node map[string]interface{}, segments []string, leaf map[string]interface{}) {
+	segment := segments[0]
+	last := len(segments) == 1
+
+	if _, err := strconv.Atoi(segment); err == nil {
+		node["type"] = "array"
+		items := mapField(node, "items")
+		if items == nil {
+			items = map[string]interface{}{}
+			node["items"] = items
+		}
+		if last {
+			node["items"] = MergeSchemas(nilIfEmpty(items), leaf)
+			return
+		}
+		insertSchemaPath(items, segments[1:], leaf)
+		return
+	}
+
+	node["type"] = "object"
+	props := mapField(node, "properties")
+	if props == nil {
+		props = map[string]interface{}{}
+		node["properties"] = props
+	}
+	required := stringList(listField(node, "required"))
+	if !containsString(required, segment) {
+		required = append(required, segment)
+		sort.Strings(required)
+		node["required"] = toInterfaceList(required)
+	}
+
+	if last {
+		props[segment] = MergeSchemas(nilIfEmpty(asMap(props[segment])), leaf)
+		return
+	}
+	child := asMap(props[segment])
+	if child == nil {
+		child = map[string]interface{}{}
+		props[segment] = child
+	}
+	insertSchemaPath(child, segments[1:], leaf)
+}
+
+func nilIfEmpty(m map[string]interface{}) map[string]interface{} {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+func toInterfaceList(values []string) []interface{} {
+	out := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		out = append(out, value)
+	}
+	return out
+}
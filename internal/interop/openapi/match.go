@@ -0,0 +1,148 @@
+package openapi
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MatchOperation finds the operation documenting a concrete request. The
+// request path is matched against each path template, with and without the
+// path prefix of the declared servers; literal segments win over templated
+// ones (/pets/mine before /pets/{petId}).
+func (s *Spec) MatchOperation(method, rawURL string) (*Operation, bool) {
+	requestPath := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		requestPath = parsed.Path
+	}
+	if requestPath == "" {
+		requestPath = "/"
+	}
+
+	candidates := []string{requestPath}
+	for _, server := range s.Servers {
+		prefix := strings.TrimRight(serverPathPrefix(server), "/")
+		if prefix != "" && strings.HasPrefix(requestPath, prefix+"/") {
+			candidates = append(candidates, requestPath[len(prefix):])
+		}
+	}
+
+	if op, ok := s.matchOperation(method, candidates, true); ok {
+		return op, true
+	}
+	// base_url may carry a prefix the spec does not declare in servers.
+	return s.matchOperation(method, candidates, false)
+}
+
+func (s *Spec) matchOperation(method string, candidates []string, anchored bool) (*Operation, bool) {
+	var best *Operation
+	bestScore := -1
+	for i := range s.Operations {
+		op := &s.Operations[i]
+		if !strings.EqualFold(op.Method, method) {
+			continue
+		}
+		pattern := pathTemplatePattern(op.Path, anchored)
+		if pattern == nil {
+			continue
+		}
+		for _, candidate := range candidates {
+			if !pattern.MatchString(candidate) {
+				continue
+			}
+			score := len(pathParamPattern.ReplaceAllString(op.Path, ""))
+			if score > bestScore {
+				best, bestScore = op, score
+			}
+			break
+		}
+	}
+	return best, best != nil
+}
+
+func pathTemplatePattern(template string, anchored bool) *regexp.Regexp {
+	template = strings.TrimRight(template, "/")
+	parts := pathParamPattern.Split(template, -1)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := strings.Join(parts, "[^/]+") + "/?$"
+	if anchored {
+		expr = "^" + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil
+	}
+	return pattern
+}
+
+func serverPathPrefix(server string) string {
+	server = strings.TrimSpace(server)
+	if idx := strings.Index(server, "://"); idx >= 0 {
+		rest := server[idx+3:]
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			return ""
+		}
+		server = rest[slash:]
+	}
+	if !strings.HasPrefix(server, "/") {
+		return ""
+	}
+	return server
+}
+
+// ResponseFor returns the documented response for status, falling back to
+// the NXX range and then to "default". The matched response key is returned
+// alongside.
+func (op *Operation) ResponseFor(status int) (Response, string, bool) {
+	code := strconv.Itoa(status)
+	if resp, ok := op.Responses[code]; ok {
+		return resp, code, true
+	}
+	for key, resp := range op.Responses {
+		if len(key) == 3 && strings.EqualFold(key[1:], "XX") && key[0] == code[0] {
+			return resp, key, true
+		}
+	}
+	if resp, ok := op.Responses["default"]; ok {
+		return resp, "default", true
+	}
+	return Response{}, "", false
+}
+
+// MediaTypeFor picks the documented media type matching a Content-Type
+// header, honouring type/* and */* wildcards.
+func MediaTypeFor(content map[string]MediaType, contentType string) (string, MediaType, bool) {
+	base := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	keys := sortedMediaKeys(content)
+	for _, key := range keys {
+		if strings.ToLower(strings.TrimSpace(strings.SplitN(key, ";", 2)[0])) == base {
+			return key, content[key], true
+		}
+	}
+	if slash := strings.Index(base, "/"); slash > 0 {
+		wildcard := base[:slash] + "/*"
+		for _, key := range keys {
+			if strings.EqualFold(key, wildcard) {
+				return key, content[key], true
+			}
+		}
+	}
+	if media, ok := content["*/*"]; ok {
+		return "*/*", media, true
+	}
+	return "", MediaType{}, false
+}
+
+func sortedMediaKeys(content map[string]MediaType) []string {
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,53 @@
+package openapi
+
+import "testing"
+
+func TestMatchOperation(t *testing.T) {
+	spec, err := LoadSpec([]byte(`
+openapi: 3.1.0
+info: {title: t, version: "1"}
+servers:
+  - url: https://api.example.com/v2
+paths:
+  /pets:
+    get: {responses: {"200": {description: ok}}}
+  /pets/{petId}:
+    get: {responses: {"2XX": {description: ok}, default: {description: error}}}
+  /pets/mine:
+    get: {responses: {"200": {description: ok}}}
+`))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		url    string
+		want   string
+	}{
+		{"GET", "https://api.example.com/v2/pets", "/pets"},
+		{"GET", "https://api.example.com/v2/pets/42?x=1", "/pets/{petId}"},
+		{"GET", "/v2/pets/mine", "/pets/mine"},
+		{"GET", "http://localhost:9000/proxy/pets/7/", "/pets/{petId}"},
+		{"POST", "https://api.example.com/v2/pets", ""},
+		{"GET", "https://api.example.com/v2/owners", ""},
+	}
+	for _, tt := range tests {
+		op, ok := spec.MatchOperation(tt.method, tt.url)
+		got := ""
+		if ok {
+			got = op.Path
+		}
+		if got != tt.want {
+			t.Fatalf("%s %s: expected %q, got %q", tt.method, tt.url, tt.want, got)
+		}
+	}
+
+	op, _ := spec.MatchOperation("GET", "/v2/pets/1")
+	if _, key, ok := op.ResponseFor(204); !ok || key != "2XX" {
+		t.Fatalf("expected 2XX range response, got %q", key)
+	}
+	if _, key, ok := op.ResponseFor(500); !ok || key != "default" {
+		t.Fatalf("expected default response, got %q", key)
+	}
+}
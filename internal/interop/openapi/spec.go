@@ -0,0 +1,520 @@
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var methodOrder = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+type Spec struct {
+	Version    string
+	Title      string
+	Servers    []string
+	Operations []Operation
+
+	root map[string]interface{}
+}
+
+type Operation struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Tags        []string
+	Parameters  []Parameter
+	RequestBody *RequestBody
+	Responses   map[string]Response
+}
+
+type Parameter struct {
+	Name       string
+	In         string
+	Required   bool
+	Schema     map[string]interface{}
+	Example    interface{}
+	HasExample bool
+}
+
+type RequestBody struct {
+	Required bool
+	Content  map[string]MediaType
+}
+
+type MediaType struct {
+	Schema     map[string]interface{}
+	Example    interface{}
+	HasExample bool
+}
+
+type Response struct {
+	Description string
+	Headers     map[string]Header
+	Content     map[string]MediaType
+}
+
+type Header struct {
+	Required bool
+	Schema   map[string]interface{}
+}
+
+func LoadSpecFile(filePath string) (*Spec, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading openapi file %q: %w", filePath, err)
+	}
+	return LoadSpec(data)
+}
+
+// LoadSpec parses an OpenAPI 3.x or Swagger 2.0 document (YAML or JSON).
+func LoadSpec(data []byte) (*Spec, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing openapi document: %w", err)
+	}
+	root, ok := normalize(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("parsing openapi document: expected an object at the top level")
+	}
+
+	spec := &Spec{root: root}
+	switch {
+	case stringField(root, "openapi") != "":
+		spec.Version = stringField(root, "openapi")
+	case stringField(root, "swagger") != "":
+		spec.Version = stringField(root, "swagger")
+	default:
+		return nil, fmt.Errorf("parsing openapi document: missing \"openapi\" or \"swagger\" version field")
+	}
+	spec.Title = stringField(mapField(root, "info"), "title")
+	spec.Servers = spec.collectServers()
+
+	paths := mapField(root, "paths")
+	pathKeys := sortedKeys(paths)
+	for _, pathKey := range pathKeys {
+		item := spec.Resolve(mapField(paths, pathKey))
+		shared := listField(item, "parameters")
+		for _, method := range methodOrder {
+			rawOp := mapField(item, method)
+			if rawOp == nil {
+				continue
+			}
+			spec.Operations = append(spec.Operations, spec.buildOperation(method, pathKey, rawOp, shared))
+		}
+	}
+
+	return spec, nil
+}
+
+// Root returns the normalized document so callers can resolve schema references.
+func (s *Spec) Root() map[string]interface{} {
+	return s.root
+}
+
+func (s *Spec) IsSwagger2() bool {
+	return strings.HasPrefix(s.Version, "2")
+}
+
+// Resolve follows local "$ref" pointers until it reaches a concrete node.
+func (s *Spec) Resolve(node map[string]interface{}) map[string]interface{} {
+	seen := make(map[string]bool)
+	for node != nil {
+		ref := stringField(node, "$ref")
+		if ref == "" || seen[ref] {
+			return node
+		}
+		seen[ref] = true
+		target, ok := ResolvePointer(s.root, ref).(map[string]interface{})
+		if !ok {
+			return node
+		}
+		node = target
+	}
+	return node
+}
+
+// ResolvePointer resolves a local JSON pointer reference ("#/a/b") against root.
+func ResolvePointer(root interface{}, ref string) interface{} {
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return root
+	}
+
+	current := root
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil
+			}
+			current = next
+		case []interface{}:
+			index := 0
+			if _, err := fmt.Sscanf(token, "%d", &index); err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			current = v[index]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+func (s *Spec) collectServers() []string {
+	if s.IsSwagger2() {
+		host := stringField(s.root, "host")
+		if host == "" {
+			return nil
+		}
+		scheme := "https"
+		if schemes := listField(s.root, "schemes"); len(schemes) > 0 {
+			if first, ok := schemes[0].(string); ok && first != "" {
+				scheme = first
+			}
+		}
+		return []string{scheme + "://" + host + stringField(s.root, "basePath")}
+	}
+
+	servers := make([]string, 0)
+	for _, raw := range listField(s.root, "servers") {
+		server, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if serverURL := stringField(server, "url"); serverURL != "" {
+			servers = append(servers, serverURL)
+		}
+	}
+	return servers
+}
+
+func (s *Spec) buildOperation(method, pathKey string, rawOp map[string]interface{}, shared []interface{}) Operation {
+	op := Operation{
+		Method:      strings.ToUpper(method),
+		Path:        pathKey,
+		OperationID: stringField(rawOp, "operationId"),
+		Summary:     stringField(rawOp, "summary"),
+		Responses:   make(map[string]Response),
+	}
+	for _, tag := range listField(rawOp, "tags") {
+		if value, ok := tag.(string); ok && strings.TrimSpace(value) != "" {
+			op.Tags = append(op.Tags, value)
+		}
+	}
+
+	rawParams := make([]map[string]interface{}, 0)
+	indexByKey := make(map[string]int)
+	for _, list := range [][]interface{}{shared, listField(rawOp, "parameters")} {
+		for _, raw := range list {
+			param := s.Resolve(asMap(raw))
+			if param == nil {
+				continue
+			}
+			key := stringField(param, "in") + ":" + stringField(param, "name")
+			if index, ok := indexByKey[key]; ok {
+				rawParams[index] = param
+				continue
+			}
+			indexByKey[key] = len(rawParams)
+			rawParams = append(rawParams, param)
+		}
+	}
+
+	if s.IsSwagger2() {
+		s.applySwagger2Operation(&op, rawOp, rawParams)
+		return op
+	}
+
+	for _, param := range rawParams {
+		op.Parameters = append(op.Parameters, s.buildParameter(param))
+	}
+	if rawBody := s.Resolve(mapField(rawOp, "requestBody")); rawBody != nil {
+		op.RequestBody = &RequestBody{
+			Required: boolField(rawBody, "required"),
+			Content:  s.buildContent(mapField(rawBody, "content")),
+		}
+	}
+	responses := mapField(rawOp, "responses")
+	for _, code := range sortedKeys(responses) {
+		rawResp := s.Resolve(mapField(responses, code))
+		if rawResp == nil {
+			continue
+		}
+		resp := Response{
+			Description: stringField(rawResp, "description"),
+			Content:     s.buildContent(mapField(rawResp, "content")),
+			Headers:     make(map[string]Header),
+		}
+		headers := mapField(rawResp, "headers")
+		for _, name := range sortedKeys(headers) {
+			rawHeader := s.Resolve(mapField(headers, name))
+			resp.Headers[name] = Header{
+				Required: boolField(rawHeader, "required"),
+				Schema:   mapField(rawHeader, "schema"),
+			}
+		}
+		op.Responses[code] = resp
+	}
+	return op
+}
+
+func (s *Spec) buildParameter(param map[string]interface{}) Parameter {
+	out := Parameter{
+		Name:     stringField(param, "name"),
+		In:       stringField(param, "in"),
+		Required: boolField(param, "required"),
+		Schema:   mapField(param, "schema"),
+	}
+	if example, ok := param["example"]; ok {
+		out.Example, out.HasExample = example, true
+	} else if examples := mapField(param, "examples"); len(examples) > 0 {
+		first := s.Resolve(mapField(examples, sortedKeys(examples)[0]))
+		if value, ok := first["value"]; ok {
+			out.Example, out.HasExample = value, true
+		}
+	}
+	return out
+}
+
+func (s *Spec) buildContent(content map[string]interface{}) map[string]MediaType {
+	if len(content) == 0 {
+		return nil
+	}
+	out := make(map[string]MediaType, len(content))
+	for mediaType, raw := range content {
+		media := asMap(raw)
+		entry := MediaType{Schema: mapField(media, "schema")}
+		if example, ok := media["example"]; ok {
+			entry.Example, entry.HasExample = example, true
+		} else if examples := mapField(media, "examples"); len(examples) > 0 {
+			first := s.Resolve(mapField(examples, sortedKeys(examples)[0]))
+			if value, ok := first["value"]; ok {
+				entry.Example, entry.HasExample = value, true
+			}
+		}
+		out[mediaType] = entry
+	}
+	return out
+}
+
+func (s *Spec) applySwagger2Operation(op *Operation, rawOp map[string]interface{}, rawParams []map[string]interface{}) {
+	consumes := stringList(listField(rawOp, "consumes"))
+	if len(consumes) == 0 {
+		consumes = stringList(listField(s.root, "consumes"))
+	}
+	produces := stringList(listField(rawOp, "produces"))
+	if len(produces) == 0 {
+		produces = stringList(listField(s.root, "produces"))
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+
+	formSchema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	formRequired := make([]interface{}, 0)
+	formMedia := "application/x-www-form-urlencoded"
+
+	for _, param := range rawParams {
+		switch stringField(param, "in") {
+		case "body":
+			media := "application/json"
+			if len(consumes) > 0 {
+				media = consumes[0]
+			}
+			op.RequestBody = &RequestBody{
+				Required: boolField(param, "required"),
+				Content:  map[string]MediaType{media: {Schema: mapField(param, "schema")}},
+			}
+		case "formData":
+			props := formSchema["properties"].(map[string]interface{})
+			props[stringField(param, "name")] = swagger2ParamSchema(param)
+			if boolField(param, "required") {
+				formRequired = append(formRequired, stringField(param, "name"))
+			}
+			if stringField(param, "type") == "file" {
+				formMedia = "multipart/form-data"
+			}
+		default:
+			out := Parameter{
+				Name:     stringField(param, "name"),
+				In:       stringField(param, "in"),
+				Required: boolField(param, "required"),
+				Schema:   swagger2ParamSchema(param),
+			}
+			if example, ok := param["x-example"]; ok {
+				out.Example, out.HasExample = example, true
+			}
+			op.Parameters = append(op.Parameters, out)
+		}
+	}
+
+	if props := formSchema["properties"].(map[string]interface{}); len(props) > 0 {
+		if len(formRequired) > 0 {
+			formSchema["required"] = formRequired
+		}
+		op.RequestBody = &RequestBody{Content: map[string]MediaType{formMedia: {Schema: formSchema}}}
+	}
+
+	responses := mapField(rawOp, "responses")
+	for _, code := range sortedKeys(responses) {
+		rawResp := s.Resolve(mapField(responses, code))
+		if rawResp == nil {
+			continue
+		}
+		resp := Response{
+			Description: stringField(rawResp, "description"),
+			Headers:     make(map[string]Header),
+		}
+		if schema := mapField(rawResp, "schema"); schema != nil {
+			resp.Content = make(map[string]MediaType, len(produces))
+			examples := mapField(rawResp, "examples")
+			for _, media := range produces {
+				entry := MediaType{Schema: schema}
+				if example, ok := examples[media]; ok {
+					entry.Example, entry.HasExample = example, true
+				}
+				resp.Content[media] = entry
+			}
+		}
+		headers := mapField(rawResp, "headers")
+		for _, name := range sortedKeys(headers) {
+			resp.Headers[name] = Header{Schema: swagger2ParamSchema(mapField(headers, name))}
+		}
+		op.Responses[code] = resp
+	}
+}
+
+// swagger2ParamSchema lifts the inline type keywords of a Swagger 2 parameter
+// or header into a standalone schema object.
+func swagger2ParamSchema(param map[string]interface{}) map[string]interface{} {
+	if schema := mapField(param, "schema"); schema != nil {
+		return schema
+	}
+	schema := make(map[string]interface{})
+	for _, key := range []string{"type", "format", "items", "enum", "default", "minimum", "maximum", "pattern", "minLength", "maxLength"} {
+		if value, ok := param[key]; ok {
+			schema[key] = value
+		}
+	}
+	if schema["type"] == "file" {
+		schema["type"] = "string"
+		schema["format"] = "binary"
+	}
+	return schema
+}
+
+// PreferredMediaType picks the JSON media type when available, falling back
+// to the first one in lexical order.
+func PreferredMediaType(content map[string]MediaType) (string, MediaType, bool) {
+	if len(content) == 0 {
+		return "", MediaType{}, false
+	}
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if IsJSONMediaType(key) {
+			return key, content[key], true
+		}
+	}
+	return keys[0], content[keys[0]], true
+}
+
+func IsJSONMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0]))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || mediaType == "*/*"
+}
+
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[fmt.Sprint(key)] = normalize(item)
+		}
+		return out
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func mapField(m map[string]interface{}, key string) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	return asMap(m[key])
+}
+
+func listField(m map[string]interface{}, key string) []interface{} {
+	if m == nil {
+		return nil
+	}
+	list, _ := m[key].([]interface{})
+	return list
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	if m == nil {
+		return ""
+	}
+	value, _ := m[key].(string)
+	return value
+}
+
+func boolField(m map[string]interface{}, key string) bool {
+	if m == nil {
+		return false
+	}
+	value, _ := m[key].(bool)
+	return value
+}
+
+func stringList(values []interface{}) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
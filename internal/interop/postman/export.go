@@ -38,8 +38,9 @@ type exportURL struct {
 }
 
 type exportBodyWrap struct {
-	Mode string `json:"mode"`
-	Raw  string `json:"raw"`
+	Mode    string       `json:"mode"`
+	Raw     string       `json:"raw,omitempty"`
+	GraphQL *graphQLBody `json:"graphql,omitempty"`
 }
 
 const postmanSchemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
@@ -130,9 +131,27 @@ func toPostmanRequest(req request.SavedRequest) exportRequest {
 			Raw:  req.Body,
 		}
 	}
+	if req.GraphQL != nil {
+		out.Body = toPostmanGraphQL(req.GraphQL)
+		if out.Method == "" {
+			out.Method = "POST"
+		}
+	}
 	return out
 }
 
+func toPostmanGraphQL(gql *request.GraphQL) *exportBodyWrap {
+	query, err := gql.LoadQuery()
+	if err != nil {
+		query = gql.Query
+	}
+	body := &graphQLBody{Query: query}
+	if variables, err := gql.TemplateVariables(); err == nil {
+		body.Variables = variables
+	}
+	return &exportBodyWrap{Mode: "graphql", GraphQL: body}
+}
+
 func buildRawURL(pathValue string, query map[string]string) string {
 	base := strings.TrimSpace(pathValue)
 	if base == "" {
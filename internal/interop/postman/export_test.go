@@ -1,6 +1,7 @@
 package postman
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/Tresor-Kasend/apix/internal/request"
@@ -52,3 +53,26 @@ func TestExportCollectionAndReparse(t *testing.T) {
 		t.Fatalf("expected body to survive roundtrip")
 	}
 }
+
+func TestExportCollectionGraphQLVariables(t *testing.T) {
+	in := []request.SavedRequest{{
+		Name: "users",
+		Path: "/graphql",
+		GraphQL: &request.GraphQL{
+			Query:     "query Users($limit: Int) { users(limit: $limit) { id } }",
+			Variables: map[string]interface{}{"limit": "json:${LIMIT}", "name": "${NAME}"},
+		},
+	}}
+
+	data, err := ExportCollection(in, "apix tests")
+	if err != nil {
+		t.Fatalf("export collection: %v", err)
+	}
+	out := string(data)
+	if strings.Contains(out, "json:") {
+		t.Fatalf("expected the json: prefix to be stripped, got:\n%s", out)
+	}
+	if !strings.Contains(out, `\"limit\": ${LIMIT}`) || !strings.Contains(out, `\"name\": \"${NAME}\"`) {
+		t.Fatalf("expected raw and string placeholders, got:\n%s", out)
+	}
+}
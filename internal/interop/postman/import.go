@@ -40,8 +40,14 @@ type header struct {
 }
 
 type bodyObject struct {
-	Mode string `json:"mode"`
-	Raw  string `json:"raw"`
+	Mode    string       `json:"mode"`
+	Raw     string       `json:"raw"`
+	GraphQL *graphQLBody `json:"graphql"`
+}
+
+type graphQLBody struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables"`
 }
 
 type urlObject struct {
@@ -113,8 +119,8 @@ func flattenItems(it item, prefix string, out *[]request.SavedRequest) {
 		Path:    pathValue,
 		Headers: headers,
 		Query:   query,
-		Body:    parseRequestBody(it.Request.Body),
 	}
+	req.Body, req.GraphQL = parseRequestBody(it.Request.Body)
 	*out = append(*out, req)
 }
 
@@ -209,12 +215,29 @@ func mergeQuery(query map[string]string, extra []queryParam) {
 	}
 }
 
-func parseRequestBody(body bodyObject) string {
+func parseRequestBody(body bodyObject) (string, *request.GraphQL) {
 	mode := strings.ToLower(strings.TrimSpace(body.Mode))
 	switch mode {
-	case "", "raw":
-		return body.Raw
+	case "graphql":
+		if body.GraphQL == nil {
+			return body.Raw, nil
+		}
+		gql, err := request.ParseGraphQLPayload(body.GraphQL.Query, body.GraphQL.Variables, "")
+		if err != nil {
+			// Variables that are not plain JSON (e.g. {{placeholders}}) are
+			// kept as they would have been sent.
+			return graphQLRawBody(body.GraphQL), nil
+		}
+		return "", gql
 	default:
-		return body.Raw
+		return body.Raw, nil
+	}
+}
+
+func graphQLRawBody(body *graphQLBody) string {
+	query, _ := json.Marshal(body.Query)
+	if strings.TrimSpace(body.Variables) == "" {
+		return fmt.Sprintf(`{"query":%s}`, query)
 	}
+	return fmt.Sprintf(`{"query":%s,"variables":%s}`, query, body.Variables)
 }
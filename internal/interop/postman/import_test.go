@@ -54,3 +54,36 @@ func TestParseCollectionInvalidJSON(t *testing.T) {
 		t.Fatalf("expected parse error")
 	}
 }
+
+func TestParseCollectionGraphQLBody(t *testing.T) {
+	data := []byte(`{"item":[
+		{"name":"Users","request":{"method":"POST","url":"https://api.example.com/graphql",
+			"body":{"mode":"graphql","graphql":{"query":"query Users($first: Int) { users(first: $first) { id } }","variables":"{\"first\": 10}"}}}},
+		{"name":"Templated","request":{"method":"POST","url":"https://api.example.com/graphql",
+			"body":{"mode":"graphql","graphql":{"query":"{ me { id } }","variables":"{\"id\": {{userId}}}"}}}}
+	]}`)
+
+	requests, err := ParseCollection(data)
+	if err != nil {
+		t.Fatalf("parse collection: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+
+	first := requests[0]
+	if first.GraphQL == nil || first.Body != "" {
+		t.Fatalf("expected graphql block, got graphql=%+v body=%q", first.GraphQL, first.Body)
+	}
+	if first.GraphQL.Variables["first"] != float64(10) {
+		t.Fatalf("expected parsed variables, got %+v", first.GraphQL.Variables)
+	}
+
+	second := requests[1]
+	if second.GraphQL != nil {
+		t.Fatalf("expected templated variables to fall back to a raw body")
+	}
+	if second.Body != `{"query":"{ me { id } }","variables":{"id": {{userId}}}}` {
+		t.Fatalf("unexpected fallback body %q", second.Body)
+	}
+}
@@ -0,0 +1,511 @@
+package jsonpath
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Tresor-Kasend/apix/internal/jsonvalue"
+)
+
+type filterExpr interface {
+	test(node, root interface{}) bool
+}
+
+type operand interface {
+	eval(node, root interface{}) (interface{}, bool)
+}
+
+type orExpr struct {
+	left, right filterExpr
+}
+
+func (e orExpr) test(node, root interface{}) bool {
+	return e.left.test(node, root) || e.right.test(node, root)
+}
+
+type andExpr struct {
+	left, right filterExpr
+}
+
+func (e andExpr) test(node, root interface{}) bool {
+	return e.left.test(node, root) && e.right.test(node, root)
+}
+
+type notExpr struct {
+	inner filterExpr
+}
+
+func (e notExpr) test(node, root interface{}) bool {
+	return !e.inner.test(node, root)
+}
+
+type existsExpr struct {
+	operand operand
+}
+
+func (e existsExpr) test(node, root interface{}) bool {
+	switch o := e.operand.(type) {
+	case literalOperand:
+		return truthy(o.value)
+	case pathOperand:
+		value, exists := o.eval(node, root)
+		if !exists {
+			return false
+		}
+		if !o.path.definite {
+			matches, _ := value.([]interface{})
+			return len(matches) > 0
+		}
+		return true
+	}
+	return false
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+	pattern     *regexp.Regexp
+}
+
+func (e compareExpr) test(node, root interface{}) bool {
+	left, leftExists := e.left.eval(node, root)
+	right, rightExists := e.right.eval(node, root)
+
+	switch e.op {
+	case "==":
+		if !leftExists || !rightExists {
+			return leftExists == rightExists
+		}
+		return valuesEqual(left, right)
+	case "!=":
+		if !leftExists || !rightExists {
+			return leftExists != rightExists
+		}
+		return !valuesEqual(left, right)
+	}
+
+	if !leftExists || !rightExists {
+		return false
+	}
+
+	if e.op == "=~" {
+		text, ok := left.(string)
+		if !ok {
+			return false
+		}
+		pattern := e.pattern
+		if pattern == nil {
+			source, ok := right.(string)
+			if !ok {
+				return false
+			}
+			compiled, err := regexp.Compile(source)
+			if err != nil {
+				return false
+			}
+			pattern = compiled
+		}
+		return pattern.MatchString(text)
+	}
+
+	cmp, ok := compareOrdered(left, right)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+type literalOperand struct {
+	value interface{}
+}
+
+func (o literalOperand) eval(_, _ interface{}) (interface{}, bool) {
+	return o.value, true
+}
+
+type pathOperand struct {
+	relative bool
+	path     *Path
+}
+
+func (o pathOperand) eval(node, root interface{}) (interface{}, bool) {
+	if o.relative {
+		return o.path.Lookup(node)
+	}
+	return o.path.Lookup(root)
+}
+
+func parseFilter(src string) (filterExpr, error) {
+	tokens, err := tokenizeFilter(src)
+	if err != nil {
+		return nil, err
+	}
+	fp := &filterParser{tokens: tokens}
+	expr, err := fp.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if fp.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q in filter", fp.peek().text)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPath
+	tokLiteral
+	tokRegex
+	tokCompare
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+}
+
+func tokenizeFilter(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "("})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")"})
+			i++
+
+		case c == '@' || c == '$':
+			end := scanPathEnd(src, i)
+			tokens = append(tokens, token{kind: tokPath, text: src[i:end]})
+			i = end
+
+		case c == '\'' || c == '"':
+			end, err := scanQuoted(src, i)
+			if err != nil {
+				return nil, err
+			}
+			value, err := unquote(src[i:end])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokLiteral, text: src[i:end], value: value})
+			i = end
+
+		case c == '/' && len(tokens) > 0 && tokens[len(tokens)-1].text == "=~":
+			end := i + 1
+			for end < len(src) && src[end] != '/' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated regular expression in filter")
+			}
+			pattern := src[i+1 : end]
+			end++
+			flagsStart := end
+			for end < len(src) && strings.ContainsRune("imsU", rune(src[end])) {
+				end++
+			}
+			if flags := src[flagsStart:end]; flags != "" {
+				pattern = "(?" + flags + ")" + pattern
+			}
+			tokens = append(tokens, token{kind: tokRegex, text: src[i:end], value: pattern})
+			i = end
+
+		case strings.HasPrefix(src[i:], "&&"):
+			tokens = append(tokens, token{kind: tokAnd, text: "&&"})
+			i += 2
+
+		case strings.HasPrefix(src[i:], "||"):
+			tokens = append(tokens, token{kind: tokOr, text: "||"})
+			i += 2
+
+		case strings.HasPrefix(src[i:], "=="), strings.HasPrefix(src[i:], "!="),
+			strings.HasPrefix(src[i:], "<="), strings.HasPrefix(src[i:], ">="),
+			strings.HasPrefix(src[i:], "=~"):
+			tokens = append(tokens, token{kind: tokCompare, text: src[i : i+2]})
+			i += 2
+
+		case c == '<' || c == '>':
+			tokens = append(tokens, token{kind: tokCompare, text: string(c)})
+			i++
+
+		case c == '!':
+			tokens = append(tokens, token{kind: tokNot, text: "!"})
+			i++
+
+		default:
+			end := i
+			for end < len(src) && !strings.ContainsRune(" \t\r\n()=!<>&|", rune(src[end])) {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("unexpected character %q in filter", c)
+			}
+			word := src[i:end]
+			value, err := parseLiteralWord(word)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokLiteral, text: word, value: value})
+			i = end
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+func scanPathEnd(src string, start int) int {
+	depth := 0
+	var quote byte
+	i := start
+	for ; i < len(src); i++ {
+		c := src[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if depth > 0 {
+			switch c {
+			case '\'', '"':
+				quote = c
+			case '[':
+				depth++
+			case ']':
+				depth--
+			}
+			continue
+		}
+		if c == '[' {
+			depth++
+			continue
+		}
+		if strings.ContainsRune(" \t\r\n()=!<>&|", rune(c)) {
+			break
+		}
+	}
+	return i
+}
+
+func scanQuoted(src string, start int) (int, error) {
+	quote := src[start]
+	for i := start + 1; i < len(src); i++ {
+		if src[i] == '\\' {
+			i++
+			continue
+		}
+		if src[i] == quote {
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string in filter")
+}
+
+func parseLiteralWord(word string) (interface{}, error) {
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected %q in filter", word)
+	}
+	return number, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	switch p.peek().kind {
+	case tokNot:
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner: inner}, nil
+
+	case tokLParen:
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("missing ')' in filter")
+		}
+		return inner, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokCompare {
+		return existsExpr{operand: left}, nil
+	}
+
+	op := p.next().text
+	expr := compareExpr{op: op, left: left}
+	if op == "=~" && (p.peek().kind == tokRegex || p.peek().kind == tokLiteral) {
+		if source, ok := p.peek().value.(string); ok {
+			pattern, err := regexp.Compile(source)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", source, err)
+			}
+			expr.pattern = pattern
+		}
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	expr.right = right
+	return expr, nil
+}
+
+func (p *filterParser) parseOperand() (operand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokPath:
+		path, err := Compile("$" + t.text[1:])
+		if err != nil {
+			return nil, err
+		}
+		return pathOperand{relative: t.text[0] == '@', path: path}, nil
+	case tokLiteral, tokRegex:
+		return literalOperand{value: t.value}, nil
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of filter")
+	default:
+		return nil, fmt.Errorf("unexpected %q in filter", t.text)
+	}
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
+}
+
+func valuesEqual(a, b interface{}) bool {
+	aNumber, aIsNumber := jsonvalue.Float64(a)
+	bNumber, bIsNumber := jsonvalue.Float64(b)
+	if aIsNumber && bIsNumber {
+		return aNumber == bNumber
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compareOrdered(a, b interface{}) (int, bool) {
+	aNumber, aIsNumber := jsonvalue.Float64(a)
+	bNumber, bIsNumber := jsonvalue.Float64(b)
+	if aIsNumber && bIsNumber {
+		switch {
+		case aNumber < bNumber:
+			return -1, true
+		case aNumber > bNumber:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	aString, aIsString := a.(string)
+	bString, bIsString := b.(string)
+	if aIsString && bIsString {
+		return strings.Compare(aString, bString), true
+	}
+	return 0, false
+}
@@ -0,0 +1,265 @@
+// Package jsonpath evaluates JSONPath expressions against decoded JSON values.
+//
+// The dotted shorthand used throughout apix (data.items.0.id) is accepted as-is,
+// alongside the bracket notation from RFC 9535: array indexes and slices,
+// wildcards, recursive descent and filter predicates.
+package jsonpath
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Path struct {
+	expr     string
+	steps    []step
+	definite bool
+}
+
+type step struct {
+	recursive bool
+	sel       selector
+}
+
+type selector interface {
+	selectFrom(node, root interface{}, out []interface{}) []interface{}
+}
+
+// Compile parses expr into a reusable Path.
+func Compile(expr string) (*Path, error) {
+	trimmed := strings.TrimSpace(expr)
+	if trimmed == "" {
+		return nil, fmt.Errorf("path cannot be empty")
+	}
+
+	p := &parser{src: trimmed}
+	steps, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", expr, err)
+	}
+
+	definite := true
+	for _, st := range steps {
+		if st.recursive {
+			definite = false
+			break
+		}
+		switch st.sel.(type) {
+		case nameSelector, indexSelector:
+		default:
+			definite = false
+		}
+	}
+
+	return &Path{expr: trimmed, steps: steps, definite: definite}, nil
+}
+
+// Lookup compiles expr and evaluates it against root. See Path.Lookup.
+func Lookup(root interface{}, expr string) (interface{}, bool, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, false, err
+	}
+	value, exists := p.Lookup(root)
+	return value, exists, nil
+}
+
+func (p *Path) String() string {
+	return p.expr
+}
+
+// IsDefinite reports whether the path can match at most one node.
+func (p *Path) IsDefinite() bool {
+	return p.definite
+}
+
+// Find returns every node matched by the path, in document order.
+func (p *Path) Find(root interface{}) []interface{} {
+	nodes := []interface{}{root}
+	for _, st := range p.steps {
+		next := make([]interface{}, 0, len(nodes))
+		for _, node := range nodes {
+			if st.recursive {
+				next = selectRecursive(st.sel, node, root, next)
+				continue
+			}
+			next = st.sel.selectFrom(node, root, next)
+		}
+		nodes = next
+		if len(nodes) == 0 {
+			break
+		}
+	}
+	return nodes
+}
+
+// Lookup evaluates the path against root. Definite paths return the single
+// matched value and whether it exists. Paths containing wildcards, slices,
+// filters or recursive descent always exist and return the list of matches.
+func (p *Path) Lookup(root interface{}) (interface{}, bool) {
+	matches := p.Find(root)
+	if p.definite {
+		if len(matches) == 0 {
+			return nil, false
+		}
+		return matches[0], true
+	}
+	if matches == nil {
+		matches = []interface{}{}
+	}
+	return matches, true
+}
+
+func selectRecursive(sel selector, node, root interface{}, out []interface{}) []interface{} {
+	out = sel.selectFrom(node, root, out)
+	for _, child := range children(node) {
+		out = selectRecursive(sel, child, root, out)
+	}
+	return out
+}
+
+func children(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := slices.Sorted(maps.Keys(v))
+		out := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			out = append(out, v[key])
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+type nameSelector struct {
+	name string
+}
+
+func (s nameSelector) selectFrom(node, _ interface{}, out []interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if value, ok := v[s.name]; ok {
+			out = append(out, value)
+		}
+	case []interface{}:
+		// Dotted shorthand: data.items.0.id addresses array elements.
+		if index, err := strconv.Atoi(s.name); err == nil {
+			return indexSelector{index: index}.selectFrom(node, nil, out)
+		}
+	}
+	return out
+}
+
+type indexSelector struct {
+	index int
+}
+
+func (s indexSelector) selectFrom(node, _ interface{}, out []interface{}) []interface{} {
+	switch v := node.(type) {
+	case []interface{}:
+		index := s.index
+		if index < 0 {
+			index += len(v)
+		}
+		if index >= 0 && index < len(v) {
+			out = append(out, v[index])
+		}
+	case map[string]interface{}:
+		if value, ok := v[strconv.Itoa(s.index)]; ok {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(node, _ interface{}, out []interface{}) []interface{} {
+	return append(out, children(node)...)
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(node, _ interface{}, out []interface{}) []interface{} {
+	items, ok := node.([]interface{})
+	if !ok || s.step == 0 {
+		return out
+	}
+
+	n := len(items)
+	normalize := func(i int) int {
+		if i < 0 {
+			return i + n
+		}
+		return i
+	}
+
+	if s.step > 0 {
+		lower, upper := 0, n
+		if s.start != nil {
+			lower = clamp(normalize(*s.start), 0, n)
+		}
+		if s.end != nil {
+			upper = clamp(normalize(*s.end), 0, n)
+		}
+		for i := lower; i < upper; i += s.step {
+			out = append(out, items[i])
+		}
+		return out
+	}
+
+	upper, lower := n-1, -1
+	if s.start != nil {
+		upper = clamp(normalize(*s.start), -1, n-1)
+	}
+	if s.end != nil {
+		lower = clamp(normalize(*s.end), -1, n-1)
+	}
+	for i := upper; i > lower; i += s.step {
+		out = append(out, items[i])
+	}
+	return out
+}
+
+func clamp(value, lower, upper int) int {
+	if value < lower {
+		return lower
+	}
+	if value > upper {
+		return upper
+	}
+	return value
+}
+
+type unionSelector struct {
+	selectors []selector
+}
+
+func (s unionSelector) selectFrom(node, root interface{}, out []interface{}) []interface{} {
+	for _, sel := range s.selectors {
+		out = sel.selectFrom(node, root, out)
+	}
+	return out
+}
+
+type filterSelector struct {
+	expr filterExpr
+}
+
+func (s filterSelector) selectFrom(node, root interface{}, out []interface{}) []interface{} {
+	for _, child := range children(node) {
+		if s.expr.test(child, root) {
+			out = append(out, child)
+		}
+	}
+	return out
+}
@@ -0,0 +1,166 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const sampleDocument = `{
+  "data": {
+    "token": "abc",
+    "items": [
+      {"id": 1, "name": "alpha", "tags": ["a"]},
+      {"id": 2, "name": "beta"},
+      {"id": 3, "name": "gamma", "tags": ["b", "c"]}
+    ]
+  },
+  "users": [
+    {"id": "u1", "role": "admin", "age": 40, "email": "root@example.com"},
+    {"id": "u2", "role": "member", "age": 17, "email": "kid@example.org"},
+    {"id": "u3", "role": "admin", "age": 22}
+  ],
+  "0": "zero-key"
+}`
+
+func decodeSample(t *testing.T) interface{} {
+	t.Helper()
+	var root interface{}
+	if err := json.Unmarshal([]byte(sampleDocument), &root); err != nil {
+		t.Fatalf("decoding sample: %v", err)
+	}
+	return root
+}
+
+func mustCompile(t *testing.T, expr string) *Path {
+	t.Helper()
+	p, err := Compile(expr)
+	if err != nil {
+		t.Fatalf("compile %q: %v", expr, err)
+	}
+	return p
+}
+
+func TestLookupDefinitePaths(t *testing.T) {
+	root := decodeSample(t)
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"data.token", "abc"},
+		{"$.data.token", "abc"},
+		{"data.items.0.id", float64(1)},
+		{"data.items[1].name", "beta"},
+		{"data.items[-1].name", "gamma"},
+		{"$['data']['items'][0]['name']", "alpha"},
+		{"data.items.-1.id", float64(3)},
+		{"0", "zero-key"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			got, exists, err := Lookup(root, tc.expr)
+			if err != nil {
+				t.Fatalf("lookup failed: %v", err)
+			}
+			if !exists {
+				t.Fatalf("expected %q to exist", tc.expr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookupMissingDefinitePath(t *testing.T) {
+	root := decodeSample(t)
+
+	for _, expr := range []string{"data.missing", "data.items[10].id", "data.token.nested", "users[-4]"} {
+		_, exists, err := Lookup(root, expr)
+		if err != nil {
+			t.Fatalf("lookup %q failed: %v", expr, err)
+		}
+		if exists {
+			t.Fatalf("expected %q to be missing", expr)
+		}
+	}
+}
+
+func TestLookupIndefinitePaths(t *testing.T) {
+	root := decodeSample(t)
+
+	tests := []struct {
+		expr string
+		want []interface{}
+	}{
+		{"data.items[*].name", []interface{}{"alpha", "beta", "gamma"}},
+		{"data.items.*.id", []interface{}{float64(1), float64(2), float64(3)}},
+		{"data.items[0:2].id", []interface{}{float64(1), float64(2)}},
+		{"data.items[::-1].id", []interface{}{float64(3), float64(2), float64(1)}},
+		{"data.items[0,2].name", []interface{}{"alpha", "gamma"}},
+		{"$..tags[0]", []interface{}{"a", "b"}},
+		{`users[?(@.role=="admin")].id`, []interface{}{"u1", "u3"}},
+		{`users[?(@.role == 'admin' && @.age < 30)].id`, []interface{}{"u3"}},
+		{`users[?(@.age >= 18 || @.role != "member")].id`, []interface{}{"u1", "u3"}},
+		{`users[?(@.email)].id`, []interface{}{"u1", "u2"}},
+		{`users[?(!@.email)].id`, []interface{}{"u3"}},
+		{`users[?(@.email =~ /\.org$/)].id`, []interface{}{"u2"}},
+		{`data.items[?(@.id > $.data.items[0].id)].name`, []interface{}{"beta", "gamma"}},
+		{`users[?(@.role=="owner")].id`, []interface{}{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			got, exists, err := Lookup(root, tc.expr)
+			if err != nil {
+				t.Fatalf("lookup failed: %v", err)
+			}
+			if !exists {
+				t.Fatalf("expected indefinite path %q to always exist", tc.expr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRecursiveDescentFindsNestedKeys(t *testing.T) {
+	root := decodeSample(t)
+
+	got := mustCompile(t, "$..id").Find(root)
+	if len(got) != 6 {
+		t.Fatalf("expected 6 ids, got %d: %v", len(got), got)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"data..",
+		"data.",
+		"data[",
+		"data[]",
+		"data[0:1:0]",
+		`users[?(@.role == "admin"]`,
+		`users[?(@.role ==)]`,
+		`users[?(@.email =~ /[/)]`,
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Fatalf("expected compile error for %q", expr)
+		}
+	}
+}
+
+func TestIsDefinite(t *testing.T) {
+	if !mustCompile(t, "data.items[0].id").IsDefinite() {
+		t.Fatal("expected index path to be definite")
+	}
+	for _, expr := range []string{"data.items[*]", "$..id", "data.items[0:1]", "data.items[?(@.id)]", "data.items[0,1]"} {
+		if mustCompile(t, expr).IsDefinite() {
+			t.Fatalf("expected %q to be indefinite", expr)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) parse() ([]step, error) {
+	steps := make([]step, 0)
+
+	if p.peek() == '$' {
+		p.pos++
+	} else if p.peek() != '.' && p.peek() != '[' {
+		// Bare dotted shorthand: "data.token" is read as "$.data.token".
+		name, err := p.readName()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step{sel: name})
+	}
+
+	for !p.done() {
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], ".."):
+			p.pos += 2
+			sel, err := p.parseSegmentAfterDot()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step{recursive: true, sel: sel})
+
+		case p.peek() == '.':
+			p.pos++
+			sel, err := p.parseSegmentAfterDot()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step{sel: sel})
+
+		case p.peek() == '[':
+			sel, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step{sel: sel})
+
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", p.peek(), p.pos)
+		}
+	}
+
+	return steps, nil
+}
+
+func (p *parser) parseSegmentAfterDot() (selector, error) {
+	switch p.peek() {
+	case '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case '[':
+		return p.parseBracket()
+	default:
+		return p.readName()
+	}
+}
+
+func (p *parser) readName() (selector, error) {
+	start := p.pos
+	for !p.done() && p.peek() != '.' && p.peek() != '[' {
+		p.pos++
+	}
+	name := strings.TrimSpace(p.src[start:p.pos])
+	if name == "" {
+		return nil, fmt.Errorf("path segment cannot be empty")
+	}
+	return nameSelector{name: name}, nil
+}
+
+func (p *parser) parseBracket() (selector, error) {
+	end, err := matchingBracket(p.src, p.pos)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(p.src[p.pos+1 : end])
+	p.pos = end + 1
+
+	if content == "" {
+		return nil, fmt.Errorf("empty brackets")
+	}
+	if strings.HasPrefix(content, "?") {
+		expr, err := parseFilter(strings.TrimSpace(content[1:]))
+		if err != nil {
+			return nil, err
+		}
+		return filterSelector{expr: expr}, nil
+	}
+
+	items := splitTopLevel(content, ',')
+	if len(items) == 1 {
+		return parseBracketItem(items[0])
+	}
+
+	union := unionSelector{selectors: make([]selector, 0, len(items))}
+	for _, item := range items {
+		sel, err := parseBracketItem(item)
+		if err != nil {
+			return nil, err
+		}
+		union.selectors = append(union.selectors, sel)
+	}
+	return union, nil
+}
+
+func parseBracketItem(item string) (selector, error) {
+	item = strings.TrimSpace(item)
+	switch {
+	case item == "":
+		return nil, fmt.Errorf("empty bracket selector")
+	case item == "*":
+		return wildcardSelector{}, nil
+	case item[0] == '\'' || item[0] == '"':
+		name, err := unquote(item)
+		if err != nil {
+			return nil, err
+		}
+		return nameSelector{name: name}, nil
+	case strings.Contains(item, ":"):
+		return parseSlice(item)
+	}
+
+	if index, err := strconv.Atoi(item); err == nil {
+		return indexSelector{index: index}, nil
+	}
+	return nameSelector{name: item}, nil
+}
+
+func parseSlice(item string) (selector, error) {
+	parts := strings.Split(item, ":")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid slice %q", item)
+	}
+
+	out := sliceSelector{step: 1}
+	bounds := make([]*int, 3)
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slice %q: %w", item, err)
+		}
+		bounds[i] = &n
+	}
+	out.start, out.end = bounds[0], bounds[1]
+	if bounds[2] != nil {
+		if *bounds[2] == 0 {
+			return nil, fmt.Errorf("slice step cannot be zero")
+		}
+		out.step = *bounds[2]
+	}
+	return out, nil
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+// matchingBracket returns the offset of the ']' closing the '[' at open,
+// skipping over quoted strings and nested brackets or parentheses.
+func matchingBracket(src string, open int) (int, error) {
+	depth := 0
+	var quote byte
+	for i := open; i < len(src); i++ {
+		c := src[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+			if depth == 0 {
+				if c != ']' {
+					return 0, fmt.Errorf("unbalanced parentheses at offset %d", i)
+				}
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated '[' at offset %d", open)
+}
+
+func splitTopLevel(src string, sep byte) []string {
+	parts := make([]string, 0, 1)
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, src[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, src[start:])
+}
+
+func unquote(value string) (string, error) {
+	if len(value) < 2 || value[len(value)-1] != value[0] {
+		return "", fmt.Errorf("unterminated string %s", value)
+	}
+	if value[0] == '"' {
+		out, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s: %w", value, err)
+		}
+		return out, nil
+	}
+
+	inner := value[1 : len(value)-1]
+	var b strings.Builder
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == '\\' && i+1 < len(inner) {
+			i++
+		}
+		b.WriteByte(inner[i])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,59 @@
+package jsonschema
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnamePattern = regexp.MustCompile(`^(?i:[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)(\.(?i:[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?))*$`)
+)
+
+// formatCheckers asserts the common "format" values. Unknown formats are
+// treated as annotations and always pass.
+var formatCheckers = map[string]func(string) bool{
+	"date-time": func(value string) bool {
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	},
+	"date": func(value string) bool {
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	},
+	"time": func(value string) bool {
+		_, err := time.Parse(time.RFC3339Nano, "2000-01-01T"+value)
+		return err == nil
+	},
+	"email": func(value string) bool {
+		addr, err := mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	},
+	"uuid": uuidPattern.MatchString,
+	"uri": func(value string) bool {
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.Scheme != ""
+	},
+	"uri-reference": func(value string) bool {
+		_, err := url.Parse(value)
+		return err == nil
+	},
+	"ipv4": func(value string) bool {
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	},
+	"ipv6": func(value string) bool {
+		return net.ParseIP(value) != nil && strings.Contains(value, ":")
+	},
+	"hostname": func(value string) bool {
+		return len(value) <= 253 && hostnamePattern.MatchString(value)
+	},
+	"regex": func(value string) bool {
+		_, err := regexp.Compile(value)
+		return err == nil
+	},
+}
@@ -0,0 +1,307 @@
+// Package jsonschema validates JSON instances against JSON Schema draft
+// 2020-12 documents.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Tresor-Kasend/apix/internal/jsonvalue"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultBaseURI = "apix:///schema.json"
+	maxDepth       = 256
+)
+
+// Violation is one failed schema constraint. InstancePath is a JSON pointer
+// into the validated value ("" for the value itself) and KeywordLocation the
+// pointer to the failing keyword inside the schema.
+type Violation struct {
+	InstancePath    string
+	KeywordLocation string
+	Keyword         string
+	Expected        interface{}
+	Actual          interface{}
+	Message         string
+}
+
+func (v Violation) Error() string {
+	path := v.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, v.Message)
+}
+
+type resource struct {
+	node interface{}
+	base *url.URL
+}
+
+// Validator holds a root schema document together with every schema
+// resource ($id) and anchor it declares, so $ref can be resolved while
+// validating.
+type Validator struct {
+	mu        sync.Mutex
+	root      interface{}
+	base      *url.URL
+	resources map[string]resource
+	anchors   map[string]resource
+}
+
+// New returns a validator for an in-memory schema. Relative $ref values that
+// point at other files are resolved from the current directory.
+func New(schema interface{}) *Validator {
+	base, _ := url.Parse(defaultBaseURI)
+	if cwd, err := os.Getwd(); err == nil {
+		base = fileURL(filepath.Join(cwd, "schema.json"))
+	}
+	return newValidator(Normalize(schema), base)
+}
+
+// LoadFile reads a JSON or YAML schema document from disk.
+func LoadFile(path string) (*Validator, error) {
+	schema, err := readSchemaFile(path)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving schema path %q: %w", path, err)
+	}
+	return newValidator(schema, fileURL(abs)), nil
+}
+
+func newValidator(root interface{}, base *url.URL) *Validator {
+	v := &Validator{
+		root:      root,
+		base:      base,
+		resources: make(map[string]resource),
+		anchors:   make(map[string]resource),
+	}
+	v.index(root, base)
+	return v
+}
+
+// Root returns the normalized schema document.
+func (v *Validator) Root() interface{} {
+	return v.root
+}
+
+// Validate checks instance against the root schema.
+func (v *Validator) Validate(instance interface{}) ([]Violation, error) {
+	return v.ValidateNode(v.root, instance)
+}
+
+// ValidateNode checks instance against schema, a node that lives inside the
+// validator's root document (for example a schema embedded in an OpenAPI
+// spec), so that its $ref values resolve against that document.
+func (v *Validator) ValidateNode(schema interface{}, instance interface{}) ([]Violation, error) {
+	e := &evaluation{v: v}
+	res := e.validate(Normalize(schema), normalizeInstance(instance), v.base, "", "", 0)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return res.violations, nil
+}
+
+func (v *Validator) index(node interface{}, base *url.URL) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if id, ok := n["$id"].(string); ok {
+			if ref, err := url.Parse(id); err == nil {
+				base = base.ResolveReference(ref)
+				base.Fragment = ""
+			}
+		}
+		if _, exists := v.resources[base.String()]; !exists {
+			v.resources[base.String()] = resource{node: n, base: base}
+		}
+		for _, key := range []string{"$anchor", "$dynamicAnchor"} {
+			if anchor, ok := n[key].(string); ok {
+				v.anchors[base.String()+"#"+anchor] = resource{node: n, base: base}
+			}
+		}
+		for key, child := range n {
+			switch key {
+			case "enum", "const", "default", "examples", "example":
+				continue
+			}
+			v.index(child, base)
+		}
+	case []interface{}:
+		for _, child := range n {
+			v.index(child, base)
+		}
+	}
+}
+
+// resolveRef finds the schema a $ref points to, loading sibling files on
+// demand for file-based schemas.
+func (v *Validator) resolveRef(ref string, base *url.URL) (interface{}, *url.URL, error) {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+	}
+	target := base.ResolveReference(parsed)
+	fragment := target.Fragment
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	docURL := *target
+	docURL.Fragment = ""
+	docURL.RawFragment = ""
+
+	doc, ok := v.resources[docURL.String()]
+	if !ok {
+		if docURL.Scheme != "file" {
+			return nil, nil, fmt.Errorf("cannot resolve $ref %q", ref)
+		}
+		loaded, err := readSchemaFile(docURL.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving $ref %q: %w", ref, err)
+		}
+		docBase := docURL
+		v.index(loaded, &docBase)
+		doc, ok = v.resources[docURL.String()]
+		if !ok {
+			doc = resource{node: loaded, base: &docBase}
+		}
+	}
+
+	if fragment == "" {
+		return doc.node, doc.base, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		anchored, ok := v.anchors[docURL.String()+"#"+fragment]
+		if !ok {
+			return nil, nil, fmt.Errorf("cannot resolve anchor in $ref %q", ref)
+		}
+		return anchored.node, anchored.base, nil
+	}
+
+	node := doc.node
+	nodeBase := doc.base
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch current := node.(type) {
+		case map[string]interface{}:
+			next, ok := current[token]
+			if !ok {
+				return nil, nil, fmt.Errorf("cannot resolve $ref %q", ref)
+			}
+			node = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil, nil, fmt.Errorf("cannot resolve $ref %q", ref)
+			}
+			node = current[index]
+		default:
+			return nil, nil, fmt.Errorf("cannot resolve $ref %q", ref)
+		}
+		if m, ok := node.(map[string]interface{}); ok {
+			if id, ok := m["$id"].(string); ok {
+				if idRef, err := url.Parse(id); err == nil {
+					nodeBase = nodeBase.ResolveReference(idRef)
+					nodeBase.Fragment = ""
+				}
+			}
+		}
+	}
+	return node, nodeBase, nil
+}
+
+func readSchemaFile(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema file %q: %w", path, err)
+	}
+
+	var schema interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("parsing schema file %q: %w", path, err)
+		}
+	} else if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("parsing schema file %q: %w", path, err)
+	}
+	return Normalize(schema), nil
+}
+
+func fileURL(path string) *url.URL {
+	return &url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+}
+
+// Normalize converts YAML-decoded values (maps with interface{} keys) into
+// the map[string]interface{} form produced by encoding/json.
+func Normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = Normalize(item)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[fmt.Sprint(key)] = Normalize(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = Normalize(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func normalizeInstance(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return value
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = normalizeInstance(item)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[fmt.Sprint(key)] = normalizeInstance(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalizeInstance(item)
+		}
+		return out
+	}
+	if number, ok := jsonvalue.Float64(value); ok {
+		return number
+	}
+	// Round-trip anything else (typed slices, structs) through JSON.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
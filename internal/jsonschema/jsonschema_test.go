@@ -0,0 +1,144 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func decodeJSON(t *testing.T, raw string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		t.Fatalf("decode %q: %v", raw, err)
+	}
+	return value
+}
+
+func violationPaths(violations []Violation) []string {
+	paths := make([]string, 0, len(violations))
+	for _, v := range violations {
+		paths = append(paths, v.InstancePath+" "+v.Keyword)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestValidateKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		want     []string
+	}{
+		{"type ok", `{"type": "string"}`, `"x"`, nil},
+		{"type mismatch", `{"type": "string"}`, `1`, []string{" type"}},
+		{"integer accepts whole float", `{"type": "integer"}`, `1.0`, nil},
+		{"integer rejects fraction", `{"type": "integer"}`, `1.5`, []string{" type"}},
+		{"type list", `{"type": ["string", "null"]}`, `null`, nil},
+		{"enum", `{"enum": ["a", 1]}`, `2`, []string{" enum"}},
+		{"const object", `{"const": {"a": [1, 2]}}`, `{"a": [1, 2.0]}`, nil},
+		{"bounds", `{"minimum": 2, "exclusiveMaximum": 5}`, `5`, []string{" exclusiveMaximum"}},
+		{"multipleOf decimal", `{"multipleOf": 0.01}`, `0.07`, nil},
+		{"string length counts runes", `{"maxLength": 2}`, `"éé"`, nil},
+		{"pattern", `{"pattern": "^a+$"}`, `"ab"`, []string{" pattern"}},
+		{"format", `{"format": "date-time"}`, `"yesterday"`, []string{" format"}},
+		{"unknown format passes", `{"format": "color"}`, `"blue"`, nil},
+		{"required and additional", `{"required": ["a"], "properties": {"b": {}}, "additionalProperties": false}`, `{"b": 1, "c": 2}`, []string{"/a required", "/c additionalProperties"}},
+		{"pattern properties", `{"patternProperties": {"^x-": {"type": "string"}}}`, `{"x-a": 1}`, []string{"/x-a type"}},
+		{"dependentRequired", `{"dependentRequired": {"a": ["b"]}}`, `{"a": 1}`, []string{" dependentRequired"}},
+		{"prefixItems and items", `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}`, `["a", 1, "b"]`, []string{"/2 type"}},
+		{"contains bounds", `{"contains": {"const": 1}, "maxContains": 1}`, `[1, 1]`, []string{" maxContains"}},
+		{"uniqueItems", `{"uniqueItems": true}`, `[1, 2, 1]`, []string{" uniqueItems"}},
+		{"anyOf", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `true`, []string{" anyOf"}},
+		{"oneOf ambiguous", `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `1`, []string{" oneOf"}},
+		{"not", `{"not": {"type": "null"}}`, `null`, []string{" not"}},
+		{"if then else", `{"if": {"properties": {"kind": {"const": "a"}}}, "then": {"required": ["a"]}, "else": {"required": ["b"]}}`, `{"kind": "c"}`, []string{"/b required"}},
+		{"false schema", `{"properties": {"a": false}}`, `{"a": 1}`, []string{"/a false"}},
+		{"unevaluatedProperties", `{"allOf": [{"properties": {"a": {}}}], "unevaluatedProperties": false}`, `{"a": 1, "b": 2}`, []string{"/b unevaluatedProperties"}},
+		{"unevaluatedItems", `{"prefixItems": [{}], "unevaluatedItems": false}`, `[1, 2]`, []string{"/1 false"}},
+		{"local ref and defs", `{"$defs": {"id": {"type": "integer"}}, "properties": {"id": {"$ref": "#/$defs/id"}}}`, `{"id": "x"}`, []string{"/id type"}},
+		{"anchor ref", `{"$defs": {"n": {"$anchor": "name", "type": "string"}}, "items": {"$ref": "#name"}}`, `["a", 2]`, []string{"/1 type"}},
+		{"recursive ref", `{"type": "object", "properties": {"child": {"$ref": "#"}}, "required": ["id"]}`, `{"id": 1, "child": {"id": 2, "child": {}}}`, []string{"/child/child/id required"}},
+		{"nullable", `{"type": "string", "nullable": true}`, `null`, nil},
+		{"pointer escaping", `{"properties": {"a/b": {"type": "string"}}}`, `{"a/b": 1}`, []string{"/a~1b type"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := New(decodeJSON(t, tt.schema)).Validate(decodeJSON(t, tt.instance))
+			if err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+			got := violationPaths(violations)
+			want := tt.want
+			if want == nil {
+				want = []string{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("expected violations %v, got %v (%+v)", want, got, violations)
+			}
+		})
+	}
+}
+
+func TestLoadFileResolvesRelativeRefs(t *testing.T) {
+	validator, err := LoadFile(filepath.Join("testdata", "user.json"))
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+
+	valid := decodeJSON(t, `{"id": 1, "email": "a@example.com", "address": {"city": "Paris", "zip": "75001"}, "tags": ["vip"]}`)
+	violations, err := validator.Validate(valid)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(violations) != 0 {
+		t.Fatalf("expected valid instance, got %+v", violations)
+	}
+
+	invalid := decodeJSON(t, `{"id": 0, "email": "nope", "address": {"zip": "1"}, "tags": ["a", "a"], "extra": true}`)
+	violations, err = validator.Validate(invalid)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	want := []string{
+		"/address/city required",
+		"/address/zip pattern",
+		"/email format",
+		"/extra additionalProperties",
+		"/id minimum",
+		"/tags/0 minLength",
+		"/tags/1 minLength",
+		"/tags uniqueItems",
+	}
+	sort.Strings(want)
+	if got := violationPaths(violations); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValidateYAMLSchemaAndErrors(t *testing.T) {
+	var schema interface{}
+	if err := yaml.Unmarshal([]byte("type: object\nproperties:\n  count:\n    type: integer\n    maximum: 3\n"), &schema); err != nil {
+		t.Fatalf("decode yaml: %v", err)
+	}
+	violations, err := New(schema).Validate(map[string]interface{}{"count": 4})
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(violations) != 1 || violations[0].InstancePath != "/count" || violations[0].Keyword != "maximum" {
+		t.Fatalf("unexpected violations: %+v", violations)
+	}
+
+	if _, err := New(decodeJSON(t, `{"$ref": "#/$defs/missing"}`)).Validate(1); err == nil {
+		t.Fatalf("expected unresolvable $ref error")
+	}
+	if _, err := New(decodeJSON(t, `{"pattern": "("}`)).Validate("x"); err == nil {
+		t.Fatalf("expected invalid pattern error")
+	}
+}
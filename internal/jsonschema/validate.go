@@ -0,0 +1,639 @@
+package jsonschema
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/Tresor-Kasend/apix/internal/jsonvalue"
+)
+
+var (
+	patternCacheMu sync.Mutex
+	patternCache   = make(map[string]*regexp.Regexp)
+)
+
+type evaluation struct {
+	v   *Validator
+	err error
+}
+
+// result carries the violations of one schema application together with the
+// annotations needed by unevaluatedProperties / unevaluatedItems.
+type result struct {
+	violations []Violation
+	props      map[string]bool
+	items      map[int]bool
+}
+
+func (r result) valid() bool {
+	return len(r.violations) == 0
+}
+
+func (r *result) fail(v Violation) {
+	r.violations = append(r.violations, v)
+}
+
+func (r *result) merge(other result, keepAnnotations bool) {
+	r.violations = append(r.violations, other.violations...)
+	if !keepAnnotations {
+		return
+	}
+	for key := range other.props {
+		r.markProp(key)
+	}
+	for index := range other.items {
+		r.markItem(index)
+	}
+}
+
+func (r *result) markProp(name string) {
+	if r.props == nil {
+		r.props = make(map[string]bool)
+	}
+	r.props[name] = true
+}
+
+func (r *result) markItem(index int) {
+	if r.items == nil {
+		r.items = make(map[int]bool)
+	}
+	r.items[index] = true
+}
+
+func (e *evaluation) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *evaluation) validate(schema interface{}, instance interface{}, base *url.URL, instPath, kwPath string, depth int) result {
+	var res result
+	if e.err != nil {
+		return res
+	}
+	if depth > maxDepth {
+		e.fail(fmt.Errorf("schema nesting deeper than %d levels (recursive $ref?)", maxDepth))
+		return res
+	}
+
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			res.fail(Violation{
+				InstancePath:    instPath,
+				KeywordLocation: kwPath,
+				Keyword:         "false",
+				Actual:          instance,
+				Message:         "no value is allowed here",
+			})
+		}
+		return res
+	case map[string]interface{}:
+		return e.validateObject(s, instance, base, instPath, kwPath, depth)
+	case nil:
+		return res
+	default:
+		e.fail(fmt.Errorf("invalid schema at %q: expected object or boolean, got %T", kwPath, schema))
+		return res
+	}
+}
+
+func (e *evaluation) validateObject(s map[string]interface{}, instance interface{}, base *url.URL, instPath, kwPath string, depth int) result {
+	var res result
+	if id, ok := s["$id"].(string); ok {
+		if ref, err := url.Parse(id); err == nil {
+			base = base.ResolveReference(ref)
+			base.Fragment = ""
+		}
+	}
+
+	violation := func(keyword string, expected interface{}, message string) Violation {
+		return Violation{
+			InstancePath:    instPath,
+			KeywordLocation: kwPath + "/" + keyword,
+			Keyword:         keyword,
+			Expected:        expected,
+			Actual:          instance,
+			Message:         message,
+		}
+	}
+
+	for _, keyword := range []string{"$ref", "$dynamicRef"} {
+		ref, ok := s[keyword].(string)
+		if !ok {
+			continue
+		}
+		target, targetBase, err := e.v.resolveRef(ref, base)
+		if err != nil {
+			e.fail(err)
+			return res
+		}
+		res.merge(e.validate(target, instance, targetBase, instPath, kwPath+"/"+keyword, depth+1), true)
+	}
+
+	nullable, _ := s["nullable"].(bool)
+	if instance == nil && nullable {
+		return res
+	}
+
+	if rawType, ok := s["type"]; ok {
+		allowed := typeList(rawType)
+		if !matchesAnyType(instance, allowed) {
+			res.fail(violation("type", strings.Join(allowed, "|"),
+				fmt.Sprintf("expected %s, got %s", strings.Join(allowed, " or "), instanceType(instance))))
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if jsonEqual(instance, candidate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res.fail(violation("enum", enum, "value is not one of the allowed values"))
+		}
+	}
+	if expected, ok := s["const"]; ok && !jsonEqual(instance, expected) {
+		res.fail(violation("const", expected, "value does not equal the required constant"))
+	}
+
+	switch inst := instance.(type) {
+	case float64:
+		e.validateNumber(s, inst, &res, violation)
+	case string:
+		e.validateString(s, inst, &res, violation)
+	case []interface{}:
+		e.validateArray(s, inst, base, instPath, kwPath, depth, &res, violation)
+	case map[string]interface{}:
+		e.validateProperties(s, inst, base, instPath, kwPath, depth, &res, violation)
+	}
+
+	e.validateApplicators(s, instance, base, instPath, kwPath, depth, &res, violation)
+
+	if e.err != nil {
+		return res
+	}
+	switch inst := instance.(type) {
+	case []interface{}:
+		if unevaluated, ok := s["unevaluatedItems"]; ok {
+			for i, item := range inst {
+				if res.items[i] {
+					continue
+				}
+				sub := e.validate(unevaluated, item, base, instPath+"/"+strconv.Itoa(i), kwPath+"/unevaluatedItems", depth+1)
+				res.merge(sub, false)
+				res.markItem(i)
+			}
+		}
+	case map[string]interface{}:
+		if unevaluated, ok := s["unevaluatedProperties"]; ok {
+			for _, key := range slices.Sorted(maps.Keys(inst)) {
+				if res.props[key] {
+					continue
+				}
+				sub := e.validate(unevaluated, inst[key], base, instPath+"/"+escapePointer(key), kwPath+"/unevaluatedProperties", depth+1)
+				if !sub.valid() && isFalseSchema(unevaluated) {
+					res.fail(Violation{
+						InstancePath:    instPath + "/" + escapePointer(key),
+						KeywordLocation: kwPath + "/unevaluatedProperties",
+						Keyword:         "unevaluatedProperties",
+						Actual:          inst[key],
+						Message:         fmt.Sprintf("property %q is not allowed", key),
+					})
+				} else {
+					res.merge(sub, false)
+				}
+				res.markProp(key)
+			}
+		}
+	}
+	return res
+}
+
+func (e *evaluation) validateNumber(s map[string]interface{}, value float64, res *result, violation func(string, interface{}, string) Violation) {
+	if raw, ok := s["multipleOf"]; ok {
+		if divisor, ok := jsonvalue.Float64(raw); ok && divisor > 0 {
+			quotient := value / divisor
+			if math.IsInf(quotient, 0) || math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+				res.fail(violation("multipleOf", divisor, fmt.Sprintf("value must be a multiple of %v", divisor)))
+			}
+		}
+	}
+
+	exclusiveMax, _ := s["exclusiveMaximum"].(bool)
+	exclusiveMin, _ := s["exclusiveMinimum"].(bool)
+	if limit, ok := jsonvalue.Float64(s["maximum"]); ok {
+		if exclusiveMax && value >= limit {
+			res.fail(violation("maximum", limit, fmt.Sprintf("value must be < %v", limit)))
+		} else if value > limit {
+			res.fail(violation("maximum", limit, fmt.Sprintf("value must be <= %v", limit)))
+		}
+	}
+	if limit, ok := jsonvalue.Float64(s["minimum"]); ok {
+		if exclusiveMin && value <= limit {
+			res.fail(violation("minimum", limit, fmt.Sprintf("value must be > %v", limit)))
+		} else if value < limit {
+			res.fail(violation("minimum", limit, fmt.Sprintf("value must be >= %v", limit)))
+		}
+	}
+	if limit, ok := jsonvalue.Float64(s["exclusiveMaximum"]); ok && value >= limit {
+		res.fail(violation("exclusiveMaximum", limit, fmt.Sprintf("value must be < %v", limit)))
+	}
+	if limit, ok := jsonvalue.Float64(s["exclusiveMinimum"]); ok && value <= limit {
+		res.fail(violation("exclusiveMinimum", limit, fmt.Sprintf("value must be > %v", limit)))
+	}
+}
+
+func (e *evaluation) validateString(s map[string]interface{}, value string, res *result, violation func(string, interface{}, string) Violation) {
+	length := utf8.RuneCountInString(value)
+	if limit, ok := toInt(s["maxLength"]); ok && length > limit {
+		res.fail(violation("maxLength", limit, fmt.Sprintf("string is longer than %d characters", limit)))
+	}
+	if limit, ok := toInt(s["minLength"]); ok && length < limit {
+		res.fail(violation("minLength", limit, fmt.Sprintf("string is shorter than %d characters", limit)))
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			e.fail(err)
+			return
+		}
+		if !re.MatchString(value) {
+			res.fail(violation("pattern", pattern, fmt.Sprintf("string does not match pattern %q", pattern)))
+		}
+	}
+	if format, ok := s["format"].(string); ok {
+		if check, known := formatCheckers[format]; known && !check(value) {
+			res.fail(violation("format", format, fmt.Sprintf("string is not a valid %s", format)))
+		}
+	}
+}
+
+func (e *evaluation) validateArray(s map[string]interface{}, items []interface{}, base *url.URL, instPath, kwPath string, depth int, res *result, violation func(string, interface{}, string) Violation) {
+	if limit, ok := toInt(s["maxItems"]); ok && len(items) > limit {
+		res.fail(violation("maxItems", limit, fmt.Sprintf("array has more than %d items", limit)))
+	}
+	if limit, ok := toInt(s["minItems"]); ok && len(items) < limit {
+		res.fail(violation("minItems", limit, fmt.Sprintf("array has fewer than %d items", limit)))
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+	outer:
+		for i := 0; i < len(items); i++ {
+			for j := i + 1; j < len(items); j++ {
+				if jsonEqual(items[i], items[j]) {
+					res.fail(violation("uniqueItems", true, fmt.Sprintf("items %d and %d are equal", i, j)))
+					break outer
+				}
+			}
+		}
+	}
+
+	prefixKeyword := "prefixItems"
+	prefix, _ := s["prefixItems"].([]interface{})
+	if legacy, ok := s["items"].([]interface{}); ok && prefix == nil {
+		// Draft 2019-09 and older tuple form.
+		prefix = legacy
+		prefixKeyword = "items"
+	}
+	for i, itemSchema := range prefix {
+		if i >= len(items) {
+			break
+		}
+		sub := e.validate(itemSchema, items[i], base, instPath+"/"+strconv.Itoa(i), kwPath+"/"+prefixKeyword+"/"+strconv.Itoa(i), depth+1)
+		res.merge(sub, false)
+		res.markItem(i)
+	}
+
+	rest, hasRest := s["items"]
+	restKeyword := "items"
+	if _, isList := rest.([]interface{}); isList {
+		rest, hasRest = s["additionalItems"]
+		restKeyword = "additionalItems"
+	}
+	if hasRest {
+		for i := len(prefix); i < len(items); i++ {
+			sub := e.validate(rest, items[i], base, instPath+"/"+strconv.Itoa(i), kwPath+"/"+restKeyword, depth+1)
+			res.merge(sub, false)
+			res.markItem(i)
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		matches := 0
+		for i, item := range items {
+			sub := e.validate(contains, item, base, instPath+"/"+strconv.Itoa(i), kwPath+"/contains", depth+1)
+			if sub.valid() {
+				matches++
+				res.markItem(i)
+			}
+		}
+		minContains := 1
+		if limit, ok := toInt(s["minContains"]); ok {
+			minContains = limit
+		}
+		if matches < minContains {
+			res.fail(violation("contains", minContains, fmt.Sprintf("array must contain at least %d matching item(s), found %d", minContains, matches)))
+		}
+		if limit, ok := toInt(s["maxContains"]); ok && matches > limit {
+			res.fail(violation("maxContains", limit, fmt.Sprintf("array must contain at most %d matching item(s), found %d", limit, matches)))
+		}
+	}
+}
+
+func (e *evaluation) validateProperties(s map[string]interface{}, obj map[string]interface{}, base *url.URL, instPath, kwPath string, depth int, res *result, violation func(string, interface{}, string) Violation) {
+	if limit, ok := toInt(s["maxProperties"]); ok && len(obj) > limit {
+		res.fail(violation("maxProperties", limit, fmt.Sprintf("object has more than %d properties", limit)))
+	}
+	if limit, ok := toInt(s["minProperties"]); ok && len(obj) < limit {
+		res.fail(violation("minProperties", limit, fmt.Sprintf("object has fewer than %d properties", limit)))
+	}
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, raw := range required {
+			name, ok := raw.(string)
+			if !ok {
+				continue
+			}
+			if _, present := obj[name]; !present {
+				res.fail(Violation{
+					InstancePath:    instPath + "/" + escapePointer(name),
+					KeywordLocation: kwPath + "/required",
+					Keyword:         "required",
+					Expected:        name,
+					Message:         fmt.Sprintf("missing required property %q", name),
+				})
+			}
+		}
+	}
+	if dependent, ok := s["dependentRequired"].(map[string]interface{}); ok {
+		for _, trigger := range slices.Sorted(maps.Keys(dependent)) {
+			if _, present := obj[trigger]; !present {
+				continue
+			}
+			names, _ := dependent[trigger].([]interface{})
+			for _, raw := range names {
+				name, _ := raw.(string)
+				if _, present := obj[name]; !present {
+					res.fail(violation("dependentRequired", name, fmt.Sprintf("property %q is required when %q is present", name, trigger)))
+				}
+			}
+		}
+	}
+
+	keys := slices.Sorted(maps.Keys(obj))
+	properties, _ := s["properties"].(map[string]interface{})
+	for _, key := range keys {
+		propSchema, ok := properties[key]
+		if !ok {
+			continue
+		}
+		sub := e.validate(propSchema, obj[key], base, instPath+"/"+escapePointer(key), kwPath+"/properties/"+escapePointer(key), depth+1)
+		res.merge(sub, false)
+		res.markProp(key)
+	}
+
+	matchedPattern := make(map[string]bool)
+	if patterns, ok := s["patternProperties"].(map[string]interface{}); ok {
+		for _, pattern := range slices.Sorted(maps.Keys(patterns)) {
+			re, err := compilePattern(pattern)
+			if err != nil {
+				e.fail(err)
+				return
+			}
+			for _, key := range keys {
+				if !re.MatchString(key) {
+					continue
+				}
+				sub := e.validate(patterns[pattern], obj[key], base, instPath+"/"+escapePointer(key), kwPath+"/patternProperties/"+escapePointer(pattern), depth+1)
+				res.merge(sub, false)
+				res.markProp(key)
+				matchedPattern[key] = true
+			}
+		}
+	}
+
+	if additional, ok := s["additionalProperties"]; ok {
+		for _, key := range keys {
+			if _, declared := properties[key]; declared || matchedPattern[key] {
+				continue
+			}
+			if isFalseSchema(additional) {
+				res.fail(Violation{
+					InstancePath:    instPath + "/" + escapePointer(key),
+					KeywordLocation: kwPath + "/additionalProperties",
+					Keyword:         "additionalProperties",
+					Actual:          obj[key],
+					Message:         fmt.Sprintf("additional property %q is not allowed", key),
+				})
+			} else {
+				sub := e.validate(additional, obj[key], base, instPath+"/"+escapePointer(key), kwPath+"/additionalProperties", depth+1)
+				res.merge(sub, false)
+			}
+			res.markProp(key)
+		}
+	}
+
+	if names, ok := s["propertyNames"]; ok {
+		for _, key := range keys {
+			sub := e.validate(names, key, base, instPath+"/"+escapePointer(key), kwPath+"/propertyNames", depth+1)
+			res.merge(sub, false)
+		}
+	}
+
+	if dependent, ok := s["dependentSchemas"].(map[string]interface{}); ok {
+		for _, trigger := range slices.Sorted(maps.Keys(dependent)) {
+			if _, present := obj[trigger]; !present {
+				continue
+			}
+			sub := e.validate(dependent[trigger], obj, base, instPath, kwPath+"/dependentSchemas/"+escapePointer(trigger), depth+1)
+			res.merge(sub, true)
+		}
+	}
+}
+
+func (e *evaluation) validateApplicators(s map[string]interface{}, instance interface{}, base *url.URL, instPath, kwPath string, depth int, res *result, violation func(string, interface{}, string) Violation) {
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for i, sub := range all {
+			res.merge(e.validate(sub, instance, base, instPath, kwPath+"/allOf/"+strconv.Itoa(i), depth+1), true)
+		}
+	}
+
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		var annotations result
+		for i, sub := range anyOf {
+			subRes := e.validate(sub, instance, base, instPath, kwPath+"/anyOf/"+strconv.Itoa(i), depth+1)
+			if subRes.valid() {
+				matched = true
+				annotations.merge(subRes, true)
+			}
+		}
+		if matched {
+			res.merge(annotations, true)
+		} else {
+			res.fail(violation("anyOf", nil, "value does not match any schema in anyOf"))
+		}
+	}
+
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		matches := make([]int, 0)
+		var annotations result
+		for i, sub := range oneOf {
+			subRes := e.validate(sub, instance, base, instPath, kwPath+"/oneOf/"+strconv.Itoa(i), depth+1)
+			if subRes.valid() {
+				matches = append(matches, i)
+				annotations.merge(subRes, true)
+			}
+		}
+		switch len(matches) {
+		case 1:
+			res.merge(annotations, true)
+		case 0:
+			res.fail(violation("oneOf", nil, "value does not match any schema in oneOf"))
+		default:
+			res.fail(violation("oneOf", nil, fmt.Sprintf("value matches more than one schema in oneOf (%v)", matches)))
+		}
+	}
+
+	if not, ok := s["not"]; ok {
+		if e.validate(not, instance, base, instPath, kwPath+"/not", depth+1).valid() {
+			res.fail(violation("not", nil, "value must not match the schema in not"))
+		}
+	}
+
+	if condition, ok := s["if"]; ok {
+		condRes := e.validate(condition, instance, base, instPath, kwPath+"/if", depth+1)
+		if condRes.valid() {
+			res.merge(condRes, true)
+			if then, ok := s["then"]; ok {
+				res.merge(e.validate(then, instance, base, instPath, kwPath+"/then", depth+1), true)
+			}
+		} else if otherwise, ok := s["else"]; ok {
+			res.merge(e.validate(otherwise, instance, base, instPath, kwPath+"/else", depth+1), true)
+		}
+	}
+}
+
+func typeList(raw interface{}) []string {
+	switch t := raw.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				out = append(out, name)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func matchesAnyType(instance interface{}, allowed []string) bool {
+	actual := instanceType(instance)
+	for _, name := range allowed {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func instanceType(instance interface{}) string {
+	switch v := instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", instance)
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	if an, ok := jsonvalue.Float64(a); ok {
+		bn, ok := jsonvalue.Float64(b)
+		return ok && an == bn
+	}
+	switch av := a.(type) {
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func toInt(value interface{}) (int, bool) {
+	n, ok := jsonvalue.Float64(value)
+	if !ok || n < 0 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+func isFalseSchema(schema interface{}) bool {
+	b, ok := schema.(bool)
+	return ok && !b
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternCacheMu.Lock()
+	defer patternCacheMu.Unlock()
+
+	if re, ok := patternCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema pattern %q: %w", pattern, err)
+	}
+	patternCache[pattern] = re
+	return re, nil
+}
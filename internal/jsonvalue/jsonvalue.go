@@ -0,0 +1,44 @@
+// Package jsonvalue holds helpers for values decoded from JSON or YAML
+// documents.
+package jsonvalue
+
+import "encoding/json"
+
+// Float64 returns value as a float64 when it holds a Go number or a
+// json.Number.
+func Float64(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int8:
+		return float64(number), true
+	case int16:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case uint:
+		return float64(number), true
+	case uint8:
+		return float64(number), true
+	case uint16:
+		return float64(number), true
+	case uint32:
+		return float64(number), true
+	case uint64:
+		return float64(number), true
+	case float32:
+		return float64(number), true
+	case float64:
+		return number, true
+	case json.Number:
+		parsed, err := number.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return parsed, true
+	default:
+		return 0, false
+	}
+}
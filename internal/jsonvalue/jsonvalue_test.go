@@ -0,0 +1,19 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFloat64(t *testing.T) {
+	for _, value := range []interface{}{int8(3), uint16(3), uint(3), int64(3), float32(3), 3.0, json.Number("3")} {
+		if got, ok := Float64(value); !ok || got != 3 {
+			t.Fatalf("Float64(%T) = %v, %v", value, got, ok)
+		}
+	}
+	for _, value := range []interface{}{"3", true, nil, json.Number("x")} {
+		if _, ok := Float64(value); ok {
+			t.Fatalf("expected %#v not to be a number", value)
+		}
+	}
+}
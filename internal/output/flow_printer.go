@@ -0,0 +1,58 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Tresor-Kasend/apix/internal/runner"
+)
+
+func PrintFlowStep(step runner.StepResult) {
+	ms := durationMs(step.Duration)
+
+	switch {
+	case step.Skipped:
+		yellow.Printf("  [SKIP] %s\n", step.Name)
+		return
+	case step.Passed:
+		green.Printf("  [PASS] %s", step.Name)
+		gray.Printf(" HTTP %d (%.0fms)\n", step.Status, ms)
+		return
+	}
+
+	red.Printf("  [FAIL] %s", step.Name)
+	if step.Status > 0 {
+		gray.Printf(" HTTP %d", step.Status)
+	}
+	gray.Printf(" (%.0fms)\n", ms)
+
+	if step.Error != "" {
+		fmt.Printf("    error: %s\n", step.Error)
+	}
+	for _, failure := range step.Failures {
+		fmt.Printf(
+			"    - %s %s expected=%s actual=%s (%s)\n",
+			failure.Target,
+			failure.Operator,
+			formatTestValue(failure.Expected),
+			formatTestValue(failure.Actual),
+			failure.Message,
+		)
+	}
+}
+
+func PrintFlowSummary(result runner.FlowResult) {
+	fmt.Println()
+	line := fmt.Sprintf("flow=%s passed=%d failed=%d skipped=%d duration=%.0fms",
+		result.Name, result.Passed, result.Failed, result.Skipped, durationMs(result.Duration))
+	switch {
+	case result.Interrupted:
+		line += " (interrupted)"
+	case result.Stopped:
+		line += " (stopped)"
+	}
+	if result.Success() {
+		success.Printf("  [PASS] %s\n", line)
+		return
+	}
+	red.Printf("  [FAIL] %s\n", line)
+}
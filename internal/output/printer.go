@@ -1,12 +1,15 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/Tresor-Kasend/apix/internal/graphql"
+	"github.com/Tresor-Kasend/apix/internal/grpc"
 	"github.com/fatih/color"
 )
 
@@ -29,6 +32,68 @@ func PrintStatus(method, path string, statusCode int, status string, duration ti
 	fmt.Println()
 }
 
+// PrintRetry reports a failed attempt that is about to be retried.
+func PrintRetry(attempt, attempts int, reason string, delay time.Duration, retryAfter bool) {
+	source := ""
+	if retryAfter {
+		source = ", Retry-After"
+	}
+	yellow.Printf("  ↻ attempt %d/%d failed: %s", attempt, attempts, reason)
+	gray.Printf(" (retrying in %s%s)\n", delay.Round(time.Millisecond), source)
+}
+
+// PrintConnectionStats reports the connection reuse of a run.
+func PrintConnectionStats(requests, opened, reused int64) {
+	rate := 0.0
+	if requests > 0 {
+		rate = float64(reused) / float64(requests) * 100
+	}
+	gray.Printf("  Connections: %d request(s), %d opened, %d reused (%.0f%%)\n", requests, opened, reused, rate)
+}
+
+// PrintRateLimitWait reports a request held back by the rate limit.
+func PrintRateLimitWait(host string, wait time.Duration) {
+	gray.Printf("  ⧗ rate limit: waiting %s for %s\n", wait.Round(time.Millisecond), host)
+}
+
+// TimingPhase is one bar of the --timing waterfall.
+type TimingPhase struct {
+	Name     string
+	Start    time.Duration
+	Duration time.Duration
+}
+
+const timingBarWidth = 40
+
+// PrintTiming prints phases as a waterfall scaled to total.
+func PrintTiming(phases []TimingPhase, total time.Duration, reused bool) {
+	bold.Print("  Timing:")
+	if reused {
+		gray.Print(" (reused connection)")
+	}
+	fmt.Println()
+	scale := func(d time.Duration) int {
+		if total <= 0 {
+			return 0
+		}
+		return int(float64(d) / float64(total) * timingBarWidth)
+	}
+	for _, phase := range phases {
+		offset := min(scale(phase.Start), timingBarWidth)
+		width := min(scale(phase.Duration), timingBarWidth-offset)
+		if width == 0 && phase.Duration > 0 && offset < timingBarWidth {
+			width = 1
+		}
+		cyan.Printf("    %-9s", phase.Name)
+		fmt.Printf("%s%s%s", strings.Repeat(" ", offset), strings.Repeat("█", width), strings.Repeat(" ", timingBarWidth-offset-width))
+		gray.Printf(" %8.1fms\n", float64(phase.Duration.Microseconds())/1000.0)
+	}
+	bold.Printf("    %-9s", "Total")
+	fmt.Print(strings.Repeat(" ", timingBarWidth))
+	gray.Printf(" %8.1fms\n", float64(total.Microseconds())/1000.0)
+	fmt.Println()
+}
+
 func PrintHeaders(headers map[string][]string) {
 	bold.Println("  Headers:")
 	keys := make([]string, 0, len(headers))
@@ -123,3 +188,116 @@ func formatBodySize(size int) string {
 	}
 	return fmt.Sprintf("%.1fMB", float64(size)/(1024.0*1024.0))
 }
+
+// PrintStreamOpen prints the status line of a streaming response before its
+// events arrive.
+func PrintStreamOpen(method, path string, statusCode int, status string) {
+	c := statusColor(statusCode)
+	fmt.Println()
+	c.Printf("  %s %s → %d %s", method, path, statusCode, statusText(status))
+	gray.Println(" (streaming)")
+	fmt.Println()
+}
+
+func PrintSSEEvent(id, event, data string, raw bool) {
+	if raw {
+		fmt.Println(data)
+		return
+	}
+	cyan.Printf("  event: %s", event)
+	if id != "" {
+		gray.Printf("  id: %s", id)
+	}
+	fmt.Println()
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Printf("  data: %s\n", line)
+	}
+	fmt.Println()
+}
+
+func PrintStreamSummary(events int, duration time.Duration) {
+	ms := float64(duration.Microseconds()) / 1000.0
+	gray.Printf("  %d event(s) in %.0fms\n", events, ms)
+}
+
+// PrintWSMessage prints a sent (">") or received ("<") WebSocket message.
+func PrintWSMessage(sent bool, data []byte, binary, raw bool) {
+	text := string(data)
+	if binary {
+		text = fmt.Sprintf("(binary, %s)", formatBodySize(len(data)))
+	}
+	if raw {
+		if !sent {
+			fmt.Println(text)
+		}
+		return
+	}
+	if sent {
+		cyan.Printf("  > ")
+	} else {
+		green.Printf("  < ")
+	}
+	fmt.Println(text)
+}
+
+// PrintGraphQLOperations lists introspected operations grouped by kind.
+func PrintGraphQLOperations(operations []graphql.Operation) {
+	if len(operations) == 0 {
+		gray.Println("  (no operations)")
+		return
+	}
+	kind := ""
+	for _, op := range operations {
+		if op.Kind != kind {
+			if kind != "" {
+				fmt.Println()
+			}
+			kind = op.Kind
+			bold.Printf("  %s\n", strings.ToUpper(kind[:1])+kind[1:])
+		}
+		cyan.Printf("    %s", op.Signature())
+		if op.Description != "" {
+			gray.Printf("  # %s", strings.SplitN(op.Description, "\n", 2)[0])
+		}
+		fmt.Println()
+	}
+}
+
+// PrintGRPCServices lists services with the signature of each method.
+func PrintGRPCServices(services []*grpc.Service) {
+	if len(services) == 0 {
+		gray.Println("  (no services)")
+		return
+	}
+	fmt.Println()
+	for _, service := range services {
+		bold.Printf("  %s\n", service.FullName)
+		for _, method := range service.Methods {
+			input, outputType := method.InputType, method.OutputType
+			if method.ClientStreaming {
+				input = "stream " + input
+			}
+			if method.ServerStreaming {
+				outputType = "stream " + outputType
+			}
+			cyan.Printf("    %s", method.Name)
+			gray.Printf("(%s) returns (%s)\n", strings.TrimPrefix(input, "."), strings.TrimPrefix(outputType, "."))
+		}
+		fmt.Println()
+	}
+}
+
+// PrintGRPCMessage prints one message of a server-streaming response.
+func PrintGRPCMessage(message []byte, raw bool) {
+	if raw {
+		fmt.Println(string(message))
+		return
+	}
+	green.Printf("  < ")
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, message, "    ", "  "); err == nil {
+		fmt.Println(pretty.String())
+		return
+	}
+	fmt.Println(string(message))
+}
@@ -13,7 +13,11 @@ func PrintTestResult(result tester.RequestResult) {
 
 	if result.Passed {
 		green.Printf("  [PASS] %s", result.Name)
-		gray.Printf(" (%.0fms)\n", ms)
+		gray.Printf(" (%.0fms)", ms)
+		if result.Snapshot != "" {
+			gray.Printf(" snapshot %s", result.Snapshot)
+		}
+		fmt.Println()
 		return
 	}
 
@@ -37,6 +41,11 @@ func PrintTestResult(result tester.RequestResult) {
 
 func PrintTestSummary(suite tester.SuiteResult) {
 	fmt.Println()
+	if suite.Interrupted {
+		yellow.Printf("  [INTERRUPTED] passed=%d failed=%d total=%d not run=%d duration=%.0fms\n",
+			suite.Passed, suite.Failed, suite.Total, suite.Total-len(suite.Results), durationMs(suite.Duration))
+		return
+	}
 	if suite.Failed == 0 {
 		success.Printf("  [PASS] passed=%d failed=%d total=%d duration=%.0fms\n",
 			suite.Passed, suite.Failed, suite.Total, durationMs(suite.Duration))
@@ -0,0 +1,284 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Tresor-Kasend/apix/internal/tester"
+	"gopkg.in/yaml.v3"
+)
+
+// Test reporters accepted by apix test --reporter.
+const (
+	ReporterPretty = "pretty"
+	ReporterJUnit  = "junit"
+	ReporterJSON   = "json"
+	ReporterTAP    = "tap"
+)
+
+func IsTestReporter(name string) bool {
+	switch name {
+	case ReporterPretty, ReporterJUnit, ReporterJSON, ReporterTAP:
+		return true
+	default:
+		return false
+	}
+}
+
+// WriteTestReport serialises suite in the given machine-readable format.
+func WriteTestReport(w io.Writer, reporter string, suite tester.SuiteResult) error {
+	switch reporter {
+	case ReporterJUnit:
+		return WriteJUnitReport(w, suite)
+	case ReporterJSON:
+		return WriteJSONReport(w, suite)
+	case ReporterTAP:
+		return WriteTAPReport(w, suite)
+	default:
+		return fmt.Errorf("unknown reporter %q (expected junit, json or tap)", reporter)
+	}
+}
+
+type jsonReport struct {
+	Total       int                 `json:"total"`
+	Passed      int                 `json:"passed"`
+	Failed      int                 `json:"failed"`
+	DurationMS  float64             `json:"duration_ms"`
+	Interrupted bool                `json:"interrupted"`
+	Results     []jsonRequestResult `json:"results"`
+}
+
+type jsonRequestResult struct {
+	Name       string          `json:"name"`
+	Dir        string          `json:"dir,omitempty"`
+	Passed     bool            `json:"passed"`
+	DurationMS float64         `json:"duration_ms"`
+	Error      string          `json:"error,omitempty"`
+	Failures   []reportFailure `json:"failures,omitempty"`
+}
+
+type reportFailure struct {
+	Target   string      `json:"target" yaml:"target"`
+	Operator string      `json:"operator" yaml:"operator"`
+	Expected interface{} `json:"expected" yaml:"expected"`
+	Actual   interface{} `json:"actual" yaml:"actual"`
+	Message  string      `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+func WriteJSONReport(w io.Writer, suite tester.SuiteResult) error {
+	report := jsonReport{
+		Total:       suite.Total,
+		Passed:      suite.Passed,
+		Failed:      suite.Failed,
+		DurationMS:  durationMs(suite.Duration),
+		Interrupted: suite.Interrupted,
+		Results:     make([]jsonRequestResult, 0, len(suite.Results)),
+	}
+	for _, result := range suite.Results {
+		report.Results = append(report.Results, jsonRequestResult{
+			Name:       result.Name,
+			Dir:        result.Dir,
+			Passed:     result.Passed,
+			DurationMS: durationMs(result.Duration),
+			Error:      result.Error,
+			Failures:   reportFailures(result.Failures),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("writing json report: %w", err)
+	}
+	return nil
+}
+
+func reportFailures(failures []tester.AssertionFailure) []reportFailure {
+	if len(failures) == 0 {
+		return nil
+	}
+	out := make([]reportFailure, 0, len(failures))
+	for _, failure := range failures {
+		out = append(out, reportFailure{
+			Target:   failure.Target,
+			Operator: failure.Operator,
+			Expected: failure.Expected,
+			Actual:   failure.Actual,
+			Message:  failure.Message,
+		})
+	}
+	return out
+}
+
+type junitTestSuites struct {
+	XMLName     xml.Name         `xml:"testsuites"`
+	Name        string           `xml:"name,attr"`
+	Tests       int              `xml:"tests,attr"`
+	Failures    int              `xml:"failures,attr"`
+	Errors      int              `xml:"errors,attr"`
+	Time        string           `xml:"time,attr"`
+	Interrupted bool             `xml:"interrupted,attr,omitempty"`
+	Suites      []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes JUnit XML with one <testsuite> per request
+// directory.
+func WriteJUnitReport(w io.Writer, suite tester.SuiteResult) error {
+	groups := make(map[string]*junitTestSuite)
+	groupMs := make(map[string]float64)
+	order := make([]string, 0)
+	for _, result := range suite.Results {
+		dir := result.Dir
+		if dir == "" {
+			dir = "requests"
+		}
+		group, ok := groups[dir]
+		if !ok {
+			group = &junitTestSuite{Name: dir}
+			groups[dir] = group
+			order = append(order, dir)
+		}
+
+		tc := junitTestCase{
+			Name:      result.Name,
+			ClassName: strings.ReplaceAll(strings.Trim(dir, "/"), "/", "."),
+			Time:      junitSeconds(durationMs(result.Duration)),
+		}
+		switch {
+		case result.Error != "":
+			tc.Error = &junitMessage{Message: result.Error, Type: "error", Body: result.Error}
+			group.Errors++
+		case !result.Passed:
+			tc.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d assertion(s) failed", len(result.Failures)),
+				Type:    "assertion",
+				Body:    failureLines(result.Failures),
+			}
+			group.Failures++
+		}
+		group.Tests++
+		groupMs[dir] += durationMs(result.Duration)
+		group.Cases = append(group.Cases, tc)
+	}
+	sort.Strings(order)
+
+	report := junitTestSuites{
+		Name:        "apix",
+		Time:        junitSeconds(durationMs(suite.Duration)),
+		Interrupted: suite.Interrupted,
+	}
+	for _, dir := range order {
+		group := groups[dir]
+		group.Time = junitSeconds(groupMs[dir])
+		report.Tests += group.Tests
+		report.Failures += group.Failures
+		report.Errors += group.Errors
+		report.Suites = append(report.Suites, *group)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("writing junit report: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("writing junit report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("writing junit report: %w", err)
+	}
+	return nil
+}
+
+func failureLines(failures []tester.AssertionFailure) string {
+	lines := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		lines = append(lines, fmt.Sprintf(
+			"%s %s expected=%s actual=%s (%s)",
+			failure.Target,
+			failure.Operator,
+			formatTestValue(failure.Expected),
+			formatTestValue(failure.Actual),
+			failure.Message,
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func junitSeconds(ms float64) string {
+	return fmt.Sprintf("%.3f", ms/1000.0)
+}
+
+// WriteTAPReport writes TAP version 13 with a YAML diagnostic block for each
+// failing test. An interrupted run ends with a "Bail out!" line.
+func WriteTAPReport(w io.Writer, suite tester.SuiteResult) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(suite.Results))
+
+	for i, result := range suite.Results {
+		status := "ok"
+		if !result.Passed {
+			status = "not ok"
+		}
+		fmt.Fprintf(&b, "%s %d - %s # time=%.0fms\n", status, i+1, result.Name, durationMs(result.Duration))
+		if result.Passed {
+			continue
+		}
+
+		diagnostic := struct {
+			Error    string          `yaml:"error,omitempty"`
+			Failures []reportFailure `yaml:"failures,omitempty"`
+		}{
+			Error:    result.Error,
+			Failures: reportFailures(result.Failures),
+		}
+		var encoded bytes.Buffer
+		encoder := yaml.NewEncoder(&encoded)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(diagnostic); err != nil {
+			return fmt.Errorf("writing tap report: %w", err)
+		}
+		b.WriteString("  ---\n")
+		for _, line := range strings.Split(strings.TrimRight(encoded.String(), "\n"), "\n") {
+			b.WriteString("  " + line + "\n")
+		}
+		b.WriteString("  ...\n")
+	}
+	if suite.Interrupted {
+		fmt.Fprintf(&b, "Bail out! Interrupted, %d test(s) not run\n", suite.Total-len(suite.Results))
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("writing tap report: %w", err)
+	}
+	return nil
+}
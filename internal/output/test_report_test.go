@@ -0,0 +1,143 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/tester"
+)
+
+func sampleSuite() tester.SuiteResult {
+	return tester.SuiteResult{
+		Total:    3,
+		Passed:   1,
+		Failed:   2,
+		Duration: 600 * time.Millisecond,
+		Results: []tester.RequestResult{
+			{Name: "login", Dir: "requests", Passed: true, Duration: 100 * time.Millisecond},
+			{
+				Name:     "users/get-user",
+				Dir:      "requests/users",
+				Duration: 200 * time.Millisecond,
+				Failures: []tester.AssertionFailure{
+					{Target: "status", Operator: "eq", Expected: 200, Actual: 404, Message: "equality check failed"},
+				},
+			},
+			{Name: "users/list", Dir: "requests/users", Duration: 300 * time.Millisecond, Error: "execution error: timeout"},
+		},
+	}
+}
+
+func TestWriteJUnitReportGroupsByDirectory(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnitReport(&buf, sampleSuite()); err != nil {
+		t.Fatalf("write junit: %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("parse junit: %v\n%s", err, buf.String())
+	}
+	if report.Tests != 3 || report.Failures != 1 || report.Errors != 1 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if len(report.Suites) != 2 || report.Suites[0].Name != "requests" || report.Suites[1].Name != "requests/users" {
+		t.Fatalf("expected suites per directory, got %+v", report.Suites)
+	}
+	users := report.Suites[1]
+	if users.Tests != 2 || users.Time != "0.500" {
+		t.Fatalf("unexpected users suite: %+v", users)
+	}
+	failure := users.Cases[0].Failure
+	if failure == nil || !strings.Contains(failure.Body, "status eq expected=200 actual=404") {
+		t.Fatalf("expected failure details, got %+v", users.Cases[0])
+	}
+	if users.Cases[0].ClassName != "requests.users" {
+		t.Fatalf("unexpected classname %q", users.Cases[0].ClassName)
+	}
+	if users.Cases[1].Error == nil {
+		t.Fatalf("expected error element, got %+v", users.Cases[1])
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONReport(&buf, sampleSuite()); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+
+	var report map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if report["failed"] != 2.0 || report["duration_ms"] != 600.0 || report["interrupted"] != false {
+		t.Fatalf("unexpected summary: %v", report)
+	}
+	results := report["results"].([]interface{})
+	failures := results[1].(map[string]interface{})["failures"].([]interface{})
+	first := failures[0].(map[string]interface{})
+	if first["target"] != "status" || first["operator"] != "eq" || first["expected"] != 200.0 || first["actual"] != 404.0 {
+		t.Fatalf("unexpected failure: %v", first)
+	}
+}
+
+func TestWriteTAPReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTAPReport(&buf, sampleSuite()); err != nil {
+		t.Fatalf("write tap: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"TAP version 13\n1..3\n",
+		"ok 1 - login # time=100ms\n",
+		"not ok 2 - users/get-user # time=200ms\n  ---\n  failures:\n    - target: status\n",
+		"not ok 3 - users/list # time=300ms\n  ---\n  error: 'execution error: timeout'\n  ...\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected TAP output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteReportsMarkInterruptedRuns(t *testing.T) {
+	suite := sampleSuite()
+	suite.Total = 5
+	suite.Interrupted = true
+
+	var buf bytes.Buffer
+	if err := WriteJSONReport(&buf, suite); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	var jsonOut jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &jsonOut); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if !jsonOut.Interrupted || jsonOut.Total != 5 || len(jsonOut.Results) != 3 {
+		t.Fatalf("expected an interrupted json report, got %+v", jsonOut)
+	}
+
+	buf.Reset()
+	if err := WriteJUnitReport(&buf, suite); err != nil {
+		t.Fatalf("write junit: %v", err)
+	}
+	var junitOut junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &junitOut); err != nil {
+		t.Fatalf("parse junit: %v", err)
+	}
+	if !junitOut.Interrupted || !strings.Contains(buf.String(), `interrupted="true"`) {
+		t.Fatalf("expected an interrupted junit report, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteTAPReport(&buf, suite); err != nil {
+		t.Fatalf("write tap: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "  ...\nBail out! Interrupted, 2 test(s) not run\n") {
+		t.Fatalf("expected TAP output to bail out, got:\n%s", buf.String())
+	}
+}
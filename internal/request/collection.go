@@ -2,6 +2,7 @@ package request
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -17,10 +18,32 @@ type SavedRequest struct {
 	Headers     map[string]string `yaml:"headers,omitempty"`
 	Query       map[string]string `yaml:"query,omitempty"`
 	Body        string            `yaml:"body,omitempty"`
+	GraphQL     *GraphQL          `yaml:"graphql,omitempty"`
 	Capture     map[string]string `yaml:"capture,omitempty"`
 	PreRequest  []Hook            `yaml:"pre_request,omitempty"`
 	PostRequest []Hook            `yaml:"post_request,omitempty"`
 	Expect      *Expect           `yaml:"expect,omitempty"`
+	Data        *DataSet          `yaml:"data,omitempty"`
+	Stream      *Stream           `yaml:"stream,omitempty"`
+	Protocol    string            `yaml:"protocol,omitempty"`
+	Messages    []WSMessage       `yaml:"messages,omitempty"`
+	GRPC        *GRPCOptions      `yaml:"grpc,omitempty"`
+	Retry       *Retry            `yaml:"retry,omitempty"`
+	// Dir is the directory of the file the request was loaded from. Files
+	// the request references are relative to it; empty means the working
+	// directory.
+	Dir string `yaml:"-"`
+}
+
+// Retry overrides the fields it sets of the retry: policy of apix.yaml and
+// the active environment for one request.
+type Retry struct {
+	Attempts int      `yaml:"attempts,omitempty"`
+	Delay    string   `yaml:"delay,omitempty"`
+	MaxDelay string   `yaml:"max_delay,omitempty"`
+	Jitter   *bool    `yaml:"jitter,omitempty"`
+	Statuses []int    `yaml:"statuses,omitempty"`
+	Methods  []string `yaml:"methods,omitempty"`
 }
 
 type Hook struct {
@@ -35,16 +58,37 @@ type Expect struct {
 	Body         map[string]AssertionRule `yaml:"body,omitempty"`
 	Headers      map[string]AssertionRule `yaml:"headers,omitempty"`
 	ResponseTime AssertionRule            `yaml:"response_time,omitempty"`
+	// Schema is a JSON Schema file for the whole body, relative to the
+	// directory of the file that declares the expect block.
+	Schema string `yaml:"schema,omitempty"`
+	// Events asserts on the events collected by a streaming request.
+	Events map[string]AssertionRule `yaml:"events,omitempty"`
+	// Timing asserts on request phases in milliseconds (dns, connect, tls,
+	// wait, ttfb, transfer, total).
+	Timing map[string]AssertionRule `yaml:"timing,omitempty"`
+	// Snapshot compares the response with a golden file under __snapshots__.
+	Snapshot        bool     `yaml:"snapshot,omitempty"`
+	SnapshotIgnore  []string `yaml:"snapshot_ignore,omitempty"`
+	SnapshotHeaders []string `yaml:"snapshot_headers,omitempty"`
 }
 
 func (r SavedRequest) HasExpect() bool {
+	for _, message := range r.Messages {
+		if message.Expect != nil {
+			return true
+		}
+	}
 	if r.Expect == nil {
 		return false
 	}
 	return len(r.Expect.Status) > 0 ||
 		len(r.Expect.Body) > 0 ||
 		len(r.Expect.Headers) > 0 ||
-		len(r.Expect.ResponseTime) > 0
+		len(r.Expect.ResponseTime) > 0 ||
+		r.Expect.Schema != "" ||
+		len(r.Expect.Events) > 0 ||
+		len(r.Expect.Timing) > 0 ||
+		r.Expect.Snapshot
 }
 
 func Save(name string, req SavedRequest) error {
@@ -59,6 +103,9 @@ func Save(name string, req SavedRequest) error {
 	}
 
 	path := filepath.Join("requests", name+".yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating request directory: %w", err)
+	}
 	if err := os.WriteFile(path, data, 0o644); err != nil {
 		return fmt.Errorf("writing request file: %w", err)
 	}
@@ -74,6 +121,34 @@ func Load(name string) (*SavedRequest, error) {
 	return req, nil
 }
 
+// SavedDir returns the directory, relative to the project, that holds the
+// file of the saved request name (users/get-user -> requests/users).
+func SavedDir(name string) string {
+	return filepath.ToSlash(filepath.Dir(filepath.Join("requests", filepath.FromSlash(name)+".yaml")))
+}
+
+// SetDir records dir as the directory of the request file, for the relative
+// paths of its blocks.
+func (r *SavedRequest) SetDir(dir string) {
+	r.Dir = dir
+	if r.GraphQL != nil {
+		r.GraphQL.Dir = dir
+	}
+	if r.Data != nil {
+		r.Data.Dir = dir
+	}
+}
+
+// ResolvePath returns path relative to dir, the directory of the file that
+// references it. Absolute paths and an empty dir leave path unchanged.
+func ResolvePath(dir, path string) string {
+	trimmed := strings.TrimSpace(path)
+	if dir == "" || trimmed == "" || filepath.IsAbs(trimmed) {
+		return path
+	}
+	return filepath.Join(dir, trimmed)
+}
+
 func LoadFromPath(path string) (*SavedRequest, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -84,6 +159,7 @@ func LoadFromPath(path string) (*SavedRequest, error) {
 	if err := yaml.Unmarshal(data, &req); err != nil {
 		return nil, fmt.Errorf("parsing request file %q: %w", path, err)
 	}
+	req.SetDir(filepath.Dir(path))
 	return &req, nil
 }
 
@@ -118,9 +194,10 @@ func LoadLast() (*SavedRequest, error) {
 	return &req, nil
 }
 
+// ListSaved returns the names of all saved requests, including those in
+// subdirectories of requests/ (as "dir/name").
 func ListSaved() ([]string, error) {
-	entries, err := os.ReadDir("requests")
-	if err != nil {
+	if _, err := os.Stat("requests"); err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
@@ -128,14 +205,27 @@ func ListSaved() ([]string, error) {
 	}
 
 	var names []string
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
+	err := filepath.WalkDir("requests", func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
 		}
-		name := e.Name()
-		if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
-			names = append(names, strings.TrimSuffix(strings.TrimSuffix(name, ".yaml"), ".yml"))
+		if entry.IsDir() {
+			return nil
 		}
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml") {
+			return nil
+		}
+		rel, err := filepath.Rel("requests", path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		names = append(names, strings.TrimSuffix(strings.TrimSuffix(rel, ".yaml"), ".yml"))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing requests: %w", err)
 	}
 	sort.Strings(names)
 	return names, nil
@@ -168,3 +168,14 @@ func withTempDirAsWorkingDirRequest(t *testing.T) {
 		t.Fatalf("changing to temp dir: %v", err)
 	}
 }
+
+func TestSavedDir(t *testing.T) {
+	for name, want := range map[string]string{
+		"login":          "requests",
+		"users/get-user": "requests/users",
+	} {
+		if got := SavedDir(name); got != want {
+			t.Fatalf("SavedDir(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
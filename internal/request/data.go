@@ -0,0 +1,162 @@
+package request
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DataSet drives data-driven iterations of a request. In YAML it is either a
+// path to a CSV/JSON/YAML file, relative to the request file, or an inline
+// list of rows:
+//
+//	data: ./fixtures/users.csv
+//	data:
+//	  - {EMAIL: a@example.com, STATUS: 201}
+//	  - {EMAIL: invalid, STATUS: 422}
+type DataSet struct {
+	File string
+	Rows []map[string]string
+	// Dir is the directory File is relative to, set with the request's.
+	Dir string
+}
+
+func (d *DataSet) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		d.File = strings.TrimSpace(node.Value)
+		return nil
+	case yaml.SequenceNode:
+		var raw []map[string]interface{}
+		if err := node.Decode(&raw); err != nil {
+			return fmt.Errorf("data rows must be mappings: %w", err)
+		}
+		d.Rows = make([]map[string]string, 0, len(raw))
+		for _, row := range raw {
+			d.Rows = append(d.Rows, stringifyRow(row))
+		}
+		return nil
+	default:
+		return fmt.Errorf("data must be a file path or a list of rows")
+	}
+}
+
+func (d DataSet) MarshalYAML() (interface{}, error) {
+	if d.File != "" {
+		return d.File, nil
+	}
+	return d.Rows, nil
+}
+
+// LoadRows returns the dataset rows, reading File when set.
+func (d *DataSet) LoadRows() ([]map[string]string, error) {
+	if d == nil {
+		return nil, nil
+	}
+	if d.File != "" {
+		return LoadDataFile(ResolvePath(d.Dir, d.File))
+	}
+	return d.Rows, nil
+}
+
+// LoadDataFile reads iteration rows from a CSV file (first line is the
+// header), a JSON array of objects or a YAML list of mappings.
+func LoadDataFile(path string) ([]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading data file %q: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err := parseCSVRows(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing data file %q: %w", path, err)
+		}
+		return rows, nil
+	case ".json":
+		var raw []map[string]interface{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parsing data file %q: expected an array of objects: %w", path, err)
+		}
+		return stringifyRows(raw), nil
+	case ".yaml", ".yml":
+		var raw []map[string]interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parsing data file %q: expected a list of mappings: %w", path, err)
+		}
+		return stringifyRows(raw), nil
+	default:
+		return nil, fmt.Errorf("unsupported data file %q (expected .csv, .json, .yaml or .yml)", path)
+	}
+}
+
+func parseCSVRows(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if column == "" || i >= len(record) {
+				continue
+			}
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func stringifyRows(raw []map[string]interface{}) []map[string]string {
+	rows := make([]map[string]string, 0, len(raw))
+	for _, row := range raw {
+		rows = append(rows, stringifyRow(row))
+	}
+	return rows
+}
+
+func stringifyRow(row map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(row))
+	for key, value := range row {
+		out[key] = stringifyValue(value)
+	}
+	return out
+}
+
+func stringifyValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,99 @@
+package request
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadDataFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"users.csv":  "\ufeffEMAIL,STATUS\na@example.com,201\n\"b,c@example.com\",422\n",
+		"users.json": `[{"EMAIL":"a@example.com","STATUS":201},{"EMAIL":"b,c@example.com","STATUS":422}]`,
+		"users.yaml": "- {EMAIL: a@example.com, STATUS: 201}\n- {EMAIL: 'b,c@example.com', STATUS: 422}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+
+		rows, err := LoadDataFile(path)
+		if err != nil {
+			t.Fatalf("%s: load failed: %v", name, err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("%s: expected 2 rows, got %d", name, len(rows))
+		}
+		if rows[0]["EMAIL"] != "a@example.com" || rows[0]["STATUS"] != "201" {
+			t.Fatalf("%s: unexpected first row %v", name, rows[0])
+		}
+		if rows[1]["EMAIL"] != "b,c@example.com" || rows[1]["STATUS"] != "422" {
+			t.Fatalf("%s: unexpected second row %v", name, rows[1])
+		}
+	}
+
+	if _, err := LoadDataFile(filepath.Join(dir, "users.txt")); err == nil {
+		t.Fatalf("expected error for unsupported extension")
+	}
+}
+
+func TestDataSetYAML(t *testing.T) {
+	var saved SavedRequest
+	if err := yaml.Unmarshal([]byte("data:\n  - {ID: 1, ACTIVE: true}\n"), &saved); err != nil {
+		t.Fatalf("unmarshal inline data: %v", err)
+	}
+	rows, err := saved.Data.LoadRows()
+	if err != nil {
+		t.Fatalf("load rows: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["ID"] != "1" || rows[0]["ACTIVE"] != "true" {
+		t.Fatalf("unexpected inline rows %v", rows)
+	}
+
+	saved = SavedRequest{}
+	if err := yaml.Unmarshal([]byte("data: ./fixtures/users.csv\n"), &saved); err != nil {
+		t.Fatalf("unmarshal data path: %v", err)
+	}
+	if saved.Data.File != "./fixtures/users.csv" {
+		t.Fatalf("expected data file path, got %+v", saved.Data)
+	}
+	out, err := yaml.Marshal(saved)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if want := "data: ./fixtures/users.csv"; !strings.Contains(string(out), want) {
+		t.Fatalf("expected %q in %s", want, out)
+	}
+}
+
+func TestDataSetFileRelativeToRequest(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll(filepath.Join(dir, "fixtures"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fixtures", "users.csv"), []byte("EMAIL\na@example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "create-user.yaml")
+	if err := os.WriteFile(path, []byte("method: POST\npath: /users\ndata: ./fixtures/users.csv\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := LoadFromPath(path)
+	if err != nil {
+		t.Fatalf("load request: %v", err)
+	}
+	rows, err := saved.Data.LoadRows()
+	if err != nil {
+		t.Fatalf("expected the data file next to the request to load: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["EMAIL"] != "a@example.com" {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+}
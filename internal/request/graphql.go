@@ -0,0 +1,224 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GraphQL is the graphql: block of a saved request. Query holds the document
+// itself or the path of a .graphql/.gql file, relative to Dir.
+type GraphQL struct {
+	Query         string                 `yaml:"query"`
+	Variables     map[string]interface{} `yaml:"variables,omitempty"`
+	OperationName string                 `yaml:"operation_name,omitempty"`
+	// Dir is the directory of the request file, set with the request's.
+	Dir string `yaml:"-"`
+}
+
+type graphQLPayload struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// IsQueryFile reports whether Query names a .graphql/.gql file.
+func (g *GraphQL) IsQueryFile() bool {
+	query := strings.TrimSpace(g.Query)
+	if strings.ContainsAny(query, "{}\n") {
+		return false
+	}
+	lower := strings.ToLower(query)
+	return strings.HasSuffix(lower, ".graphql") || strings.HasSuffix(lower, ".gql")
+}
+
+// LoadQuery returns the query document, reading it from disk when Query is a
+// file path.
+func (g *GraphQL) LoadQuery() (string, error) {
+	if !g.IsQueryFile() {
+		return g.Query, nil
+	}
+	path := ResolvePath(g.Dir, strings.TrimSpace(g.Query))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading graphql query file %q: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// BuildBody returns the JSON request body. ${VAR} placeholders are resolved in
+// the query and in variable values. Variable strings stay strings unless they
+// carry the json: prefix, e.g. "json:${LIMIT}", in which case the resolved
+// text is decoded as JSON (numbers, booleans, objects).
+func (g *GraphQL) BuildBody(vars map[string]string) (string, error) {
+	query, err := g.LoadQuery()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("graphql query is empty")
+	}
+
+	payload := graphQLPayload{
+		Query:         ResolveVariables(query, vars),
+		OperationName: ResolveVariables(g.OperationName, vars),
+	}
+	if len(g.Variables) > 0 {
+		payload.Variables = make(map[string]interface{}, len(g.Variables))
+		for k, v := range g.Variables {
+			resolved, err := resolveGraphQLValue(v, vars)
+			if err != nil {
+				return "", fmt.Errorf("graphql variable %q: %w", k, err)
+			}
+			payload.Variables[k] = resolved
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("encoding graphql body: %w", err)
+	}
+	return string(body), nil
+}
+
+// TemplateBody returns the JSON request body with its placeholders left
+// unresolved, for exports. A json: value is written unquoted as its raw text,
+// so "json:${LIMIT}" becomes ${LIMIT}.
+func (g *GraphQL) TemplateBody() (string, error) {
+	query, err := g.LoadQuery()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("graphql query is empty")
+	}
+
+	var raws []string
+	payload := graphQLPayload{Query: query, OperationName: g.OperationName}
+	if len(g.Variables) > 0 {
+		payload.Variables = templateGraphQLValue(g.Variables, &raws).(map[string]interface{})
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("encoding graphql body: %w", err)
+	}
+	return fillGraphQLTemplate(string(data), raws), nil
+}
+
+// TemplateVariables returns the indented JSON variables in the form of
+// TemplateBody, or "" when there are none.
+func (g *GraphQL) TemplateVariables() (string, error) {
+	if len(g.Variables) == 0 {
+		return "", nil
+	}
+	var raws []string
+	data, err := json.MarshalIndent(templateGraphQLValue(g.Variables, &raws), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding graphql variables: %w", err)
+	}
+	return fillGraphQLTemplate(string(data), raws), nil
+}
+
+// graphQLJSONPrefix marks a variable string decoded as JSON once resolved,
+// e.g. "json:${LIMIT}" to send a number. Other strings stay strings.
+const graphQLJSONPrefix = "json:"
+
+func resolveGraphQLValue(value interface{}, vars map[string]string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		raw, typed := strings.CutPrefix(v, graphQLJSONPrefix)
+		resolved := ResolveVariables(raw, vars)
+		if !typed {
+			return resolved, nil
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(resolved), &decoded); err != nil {
+			return nil, fmt.Errorf("%q is not valid JSON: %w", resolved, err)
+		}
+		return decoded, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := resolveGraphQLValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			resolved, err := resolveGraphQLValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolved
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// templateGraphQLValue replaces each json: string with a numbered marker and
+// records its raw text in raws, for fillGraphQLTemplate.
+func templateGraphQLValue(value interface{}, raws *[]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		raw, typed := strings.CutPrefix(v, graphQLJSONPrefix)
+		if !typed {
+			return v
+		}
+		*raws = append(*raws, raw)
+		return graphQLTemplateMarker(len(*raws) - 1)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = templateGraphQLValue(item, raws)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = templateGraphQLValue(item, raws)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func fillGraphQLTemplate(encoded string, raws []string) string {
+	for i, raw := range raws {
+		encoded = strings.Replace(encoded, `"`+graphQLTemplateMarker(i)+`"`, raw, 1)
+	}
+	return encoded
+}
+
+func graphQLTemplateMarker(i int) string {
+	return fmt.Sprintf("__apix_json_%d__", i)
+}
+
+// ParseGraphQLPayload maps a JSON GraphQL body ({"query", "variables",
+// "operationName"}) onto a GraphQL block. Variables may also be a JSON string,
+// as Postman stores them.
+func ParseGraphQLPayload(query string, variables interface{}, operationName string) (*GraphQL, error) {
+	gql := &GraphQL{Query: query, OperationName: operationName}
+	switch v := variables.(type) {
+	case nil:
+	case string:
+		if strings.TrimSpace(v) == "" {
+			break
+		}
+		if err := json.Unmarshal([]byte(v), &gql.Variables); err != nil {
+			return nil, fmt.Errorf("parsing graphql variables: %w", err)
+		}
+	case map[string]interface{}:
+		gql.Variables = v
+	default:
+		return nil, fmt.Errorf("graphql variables must be an object")
+	}
+	return gql, nil
+}
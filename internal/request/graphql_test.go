@@ -0,0 +1,158 @@
+package request
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGraphQLBuildBody(t *testing.T) {
+	dir := t.TempDir()
+	queryFile := filepath.Join(dir, "orders.graphql")
+	if err := os.WriteFile(queryFile, []byte("query Orders($limit: Int) { orders(limit: $limit) { id } }"), 0o644); err != nil {
+		t.Fatalf("writing query file: %v", err)
+	}
+
+	gql := &GraphQL{
+		Query:         queryFile,
+		OperationName: "Orders",
+		Variables: map[string]interface{}{
+			"limit":  "json:${LIMIT}",
+			"active": "json:${ACTIVE}",
+			"label":  "page ${LIMIT}",
+			"ids":    []interface{}{"json:${ID}", "x-${ID}"},
+			"code":   "${CODE}",
+			"userId": "${ID}",
+		},
+	}
+	if !gql.IsQueryFile() {
+		t.Fatalf("expected %q to be treated as a query file", queryFile)
+	}
+
+	body, err := gql.BuildBody(map[string]string{"LIMIT": "10", "ACTIVE": "true", "ID": "7", "CODE": "A1"})
+	if err != nil {
+		t.Fatalf("BuildBody returned error: %v", err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if payload["operationName"] != "Orders" {
+		t.Fatalf("unexpected operationName: %v", payload["operationName"])
+	}
+	if payload["query"] != "query Orders($limit: Int) { orders(limit: $limit) { id } }" {
+		t.Fatalf("expected query loaded from file, got %v", payload["query"])
+	}
+
+	variables := payload["variables"].(map[string]interface{})
+	if variables["limit"] != float64(10) || variables["active"] != true {
+		t.Fatalf("expected json: values to be decoded, got %#v", variables)
+	}
+	// A numeric-looking captured ID stays a string for ID!/String! arguments.
+	if variables["label"] != "page 10" || variables["code"] != "A1" || variables["userId"] != "7" {
+		t.Fatalf("expected string values, got %#v", variables)
+	}
+	ids := variables["ids"].([]interface{})
+	if ids[0] != float64(7) || ids[1] != "x-7" {
+		t.Fatalf("expected resolved list values, got %#v", ids)
+	}
+}
+
+func TestGraphQLBuildBodyRejectsInvalidJSONValue(t *testing.T) {
+	gql := &GraphQL{Query: "{ users { id } }", Variables: map[string]interface{}{"limit": "json:${LIMIT}"}}
+	if _, err := gql.BuildBody(map[string]string{"LIMIT": "ten"}); err == nil {
+		t.Fatalf("expected an error for a json: value that is not JSON")
+	}
+}
+
+func TestGraphQLTemplateBodyKeepsPlaceholders(t *testing.T) {
+	gql := &GraphQL{
+		Query: "query Users($limit: Int, $id: ID) { users(limit: $limit, id: $id) { id } }",
+		Variables: map[string]interface{}{
+			"limit":  "json:${LIMIT}",
+			"id":     "${ID}",
+			"filter": map[string]interface{}{"active": "json:true"},
+		},
+	}
+
+	body, err := gql.TemplateBody()
+	if err != nil {
+		t.Fatalf("template body: %v", err)
+	}
+	for _, want := range []string{`"limit":${LIMIT}`, `"id":"${ID}"`, `"filter":{"active":true}`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %s in %s", want, body)
+		}
+	}
+
+	variables, err := gql.TemplateVariables()
+	if err != nil {
+		t.Fatalf("template variables: %v", err)
+	}
+	if !strings.Contains(variables, `"limit": ${LIMIT}`) || strings.Contains(variables, "json:") {
+		t.Fatalf("unexpected template variables:\n%s", variables)
+	}
+}
+
+func TestGraphQLInlineQueryAndErrors(t *testing.T) {
+	inline := &GraphQL{Query: "{ users { id } }"}
+	if inline.IsQueryFile() {
+		t.Fatalf("inline query treated as a file")
+	}
+	body, err := inline.BuildBody(nil)
+	if err != nil {
+		t.Fatalf("BuildBody returned error: %v", err)
+	}
+	if body != `{"query":"{ users { id } }"}` {
+		t.Fatalf("unexpected body %s", body)
+	}
+
+	if _, err := (&GraphQL{Query: "missing.graphql"}).BuildBody(nil); err == nil {
+		t.Fatalf("expected error for a missing query file")
+	}
+	if _, err := (&GraphQL{Query: "  "}).BuildBody(nil); err == nil {
+		t.Fatalf("expected error for an empty query")
+	}
+}
+
+func TestParseGraphQLPayload(t *testing.T) {
+	gql, err := ParseGraphQLPayload("{ me { id } }", `{"id": 1}`, "Me")
+	if err != nil {
+		t.Fatalf("ParseGraphQLPayload returned error: %v", err)
+	}
+	if gql.OperationName != "Me" || gql.Variables["id"] != float64(1) {
+		t.Fatalf("unexpected graphql block: %+v", gql)
+	}
+
+	gql, err = ParseGraphQLPayload("{ me { id } }", map[string]interface{}{"id": "2"}, "")
+	if err != nil || gql.Variables["id"] != "2" {
+		t.Fatalf("expected object variables to be kept, got %+v (%v)", gql, err)
+	}
+
+	if _, err := ParseGraphQLPayload("{ me { id } }", `{"id": {{id}}}`, ""); err == nil {
+		t.Fatalf("expected error for invalid JSON variables")
+	}
+}
+
+func TestGraphQLQueryFileRelativeToRequest(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(filepath.Join(dir, "users.graphql"), []byte("{ users { id } }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "users.yaml")
+	if err := os.WriteFile(path, []byte("path: /graphql\ngraphql:\n  query: users.graphql\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := LoadFromPath(path)
+	if err != nil {
+		t.Fatalf("load request: %v", err)
+	}
+	query, err := saved.GraphQL.LoadQuery()
+	if err != nil || query != "{ users { id } }" {
+		t.Fatalf("expected the query file next to the request, got %q (%v)", query, err)
+	}
+}
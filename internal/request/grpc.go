@@ -0,0 +1,17 @@
+package request
+
+// ProtocolGRPC marks a saved request as a gRPC call: path holds the method
+// (package.Service/Method), body its JSON request message and headers the
+// call metadata.
+const ProtocolGRPC = "grpc"
+
+// GRPCOptions locates the server and its schema. Without protos the method is
+// resolved through the server reflection service.
+type GRPCOptions struct {
+	// Host is host:port or an http(s):// URL; it defaults to base_url.
+	Host        string   `yaml:"host,omitempty"`
+	Protos      []string `yaml:"protos,omitempty"`
+	ImportPaths []string `yaml:"import_paths,omitempty"`
+	// Plaintext uses HTTP/2 without TLS for bare host:port targets.
+	Plaintext bool `yaml:"plaintext,omitempty"`
+}
@@ -0,0 +1,76 @@
+package request
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const StreamSSE = "sse"
+
+// Stream turns a request into a streaming one. In YAML it is either the
+// stream type or a mapping with limits:
+//
+//	stream: sse
+//	stream:
+//	  type: sse
+//	  max_events: 10
+//	  duration: 30s
+//	  reconnect: 3
+type Stream struct {
+	Type        string `yaml:"type"`
+	MaxEvents   int    `yaml:"max_events,omitempty"`
+	Duration    string `yaml:"duration,omitempty"`
+	Reconnect   int    `yaml:"reconnect,omitempty"`
+	LastEventID string `yaml:"last_event_id,omitempty"`
+}
+
+func (s *Stream) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Type = strings.ToLower(strings.TrimSpace(node.Value))
+		return s.Validate()
+	}
+	type plain Stream
+	var decoded plain
+	if err := node.Decode(&decoded); err != nil {
+		return err
+	}
+	*s = Stream(decoded)
+	s.Type = strings.ToLower(strings.TrimSpace(s.Type))
+	return s.Validate()
+}
+
+func (s Stream) MarshalYAML() (interface{}, error) {
+	if s.MaxEvents == 0 && s.Duration == "" && s.Reconnect == 0 && s.LastEventID == "" {
+		return s.Type, nil
+	}
+	type plain Stream
+	return plain(s), nil
+}
+
+func (s Stream) Validate() error {
+	if s.Type != StreamSSE {
+		return fmt.Errorf("unsupported stream type %q (expected %q)", s.Type, StreamSSE)
+	}
+	if s.MaxEvents < 0 || s.Reconnect < 0 {
+		return fmt.Errorf("stream max_events and reconnect must be >= 0")
+	}
+	if _, err := s.ParsedDuration(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParsedDuration returns Duration as a time.Duration (0 when unset).
+func (s Stream) ParsedDuration() (time.Duration, error) {
+	if strings.TrimSpace(s.Duration) == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(s.Duration))
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid stream duration %q", s.Duration)
+	}
+	return d, nil
+}
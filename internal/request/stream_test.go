@@ -0,0 +1,44 @@
+package request
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStreamYAML(t *testing.T) {
+	var saved SavedRequest
+	if err := yaml.Unmarshal([]byte("stream: sse\n"), &saved); err != nil {
+		t.Fatalf("unmarshal scalar stream: %v", err)
+	}
+	if saved.Stream == nil || saved.Stream.Type != StreamSSE {
+		t.Fatalf("expected sse stream, got %+v", saved.Stream)
+	}
+	out, err := yaml.Marshal(saved)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(out), "stream: sse\n") {
+		t.Fatalf("expected scalar stream in %s", out)
+	}
+
+	saved = SavedRequest{}
+	if err := yaml.Unmarshal([]byte("stream:\n  type: SSE\n  max_events: 5\n  duration: 30s\n  reconnect: 2\n"), &saved); err != nil {
+		t.Fatalf("unmarshal stream mapping: %v", err)
+	}
+	duration, err := saved.Stream.ParsedDuration()
+	if err != nil || duration != 30*time.Second {
+		t.Fatalf("expected 30s duration, got %v (%v)", duration, err)
+	}
+	if saved.Stream.Type != StreamSSE || saved.Stream.MaxEvents != 5 || saved.Stream.Reconnect != 2 {
+		t.Fatalf("unexpected stream %+v", saved.Stream)
+	}
+
+	for _, invalid := range []string{"stream: websocket\n", "stream:\n  type: sse\n  duration: soon\n"} {
+		if err := yaml.Unmarshal([]byte(invalid), &SavedRequest{}); err == nil {
+			t.Fatalf("expected error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package request
+
+// ProtocolWebSocket marks a saved request as a scripted WebSocket session.
+const ProtocolWebSocket = "websocket"
+
+// WSMessage is one step of a WebSocket session: send a text frame, wait for a
+// received frame matching expect (the first frame when expect is empty) and
+// capture variables from it. Steps with only send do not wait.
+type WSMessage struct {
+	Send    string            `yaml:"send,omitempty"`
+	Expect  *Expect           `yaml:"expect,omitempty"`
+	Capture map[string]string `yaml:"capture,omitempty"`
+	Timeout string            `yaml:"timeout,omitempty"`
+}
+
+// Waits reports whether the step reads a frame.
+func (m WSMessage) Waits() bool {
+	return m.Expect != nil || len(m.Capture) > 0 || m.Send == ""
+}
@@ -1,13 +1,14 @@
 package runner
 
 import (
+	"context"
 	"fmt"
 
 	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
 	"github.com/Tresor-Kasend/apix/internal/request"
 )
 
-type ExecuteSavedRequestFunc func(name string, vars map[string]string, envOverride string) (*apixhttp.Response, error)
+type ExecuteSavedRequestFunc func(ctx context.Context, name string, vars map[string]string, envOverride string) (*apixhttp.Response, error)
 
 type ChainResult struct {
 	Total      int
@@ -17,7 +18,9 @@ type ChainResult struct {
 	LastStatus int
 }
 
-func RunChain(names []string, flagVars map[string]string, envOverride string, execute ExecuteSavedRequestFunc) (*ChainResult, error) {
+// RunChain executes the saved requests in order, stopping at the first
+// failure or once ctx is cancelled.
+func RunChain(ctx context.Context, names []string, flagVars map[string]string, envOverride string, execute ExecuteSavedRequestFunc) (*ChainResult, error) {
 	if len(names) == 0 {
 		return nil, fmt.Errorf("at least one request name is required")
 	}
@@ -25,16 +28,19 @@ func RunChain(names []string, flagVars map[string]string, envOverride string, ex
 		return nil, fmt.Errorf("chain executor callback is required")
 	}
 
-	ctx := NewRuntimeContext(flagVars)
+	runtime := NewRuntimeContext(flagVars)
 	result := &ChainResult{Total: len(names)}
 
 	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("chain interrupted before %q: %w", name, err)
+		}
 		saved, err := request.Load(name)
 		if err != nil {
 			return result, fmt.Errorf("chain request %q: %w", name, err)
 		}
 
-		resp, err := execute(name, ctx.Snapshot(), envOverride)
+		resp, err := execute(ctx, name, runtime.Snapshot(), envOverride)
 		if err != nil {
 			return result, fmt.Errorf("chain request %q failed: %w", name, err)
 		}
@@ -54,12 +60,12 @@ func RunChain(names []string, flagVars map[string]string, envOverride string, ex
 			return result, fmt.Errorf("chain request %q capture failed: %w", name, err)
 		}
 		if len(captured) > 0 {
-			ctx.Merge(captured)
+			runtime.Merge(captured)
 			result.Captured += len(captured)
 		}
 	}
 
-	result.FinalVars = ctx.Snapshot()
+	result.FinalVars = runtime.Snapshot()
 	return result, nil
 }
 
@@ -77,7 +83,7 @@ func CaptureVariables(capture map[string]string, resp *apixhttp.Response) (map[s
 			return nil, fmt.Errorf("capture path for %q cannot be empty", varName)
 		}
 
-		value, err := resp.ExtractField(path)
+		value, err := resp.Capture(path)
 		if err != nil {
 			return nil, fmt.Errorf("%s <- %s: %w", varName, path, err)
 		}
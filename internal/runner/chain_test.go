@@ -1,11 +1,14 @@
 package runner
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
 	"github.com/Tresor-Kasend/apix/internal/request"
@@ -21,7 +24,7 @@ func TestRunChainPropagatesCapturedVariables(t *testing.T) {
 	})
 	mustSaveRequest(t, "get-profile", request.SavedRequest{Method: "GET", Path: "/users/${USER_ID}"})
 
-	result, err := RunChain([]string{"login", "get-profile"}, map[string]string{}, "", func(name string, vars map[string]string, env string) (*apixhttp.Response, error) {
+	result, err := RunChain(context.Background(), []string{"login", "get-profile"}, map[string]string{}, "", func(_ context.Context, name string, vars map[string]string, env string) (*apixhttp.Response, error) {
 		switch name {
 		case "login":
 			return makeJSONResponse(http.StatusOK, `{"data":{"user":{"id":42}}}`), nil
@@ -58,7 +61,7 @@ func TestRunChainCapturedVariablesOverridePrevious(t *testing.T) {
 	})
 	mustSaveRequest(t, "followup", request.SavedRequest{Method: "GET", Path: "/users/${USER_ID}"})
 
-	_, err := RunChain([]string{"login", "followup"}, map[string]string{"USER_ID": "1"}, "", func(name string, vars map[string]string, env string) (*apixhttp.Response, error) {
+	_, err := RunChain(context.Background(), []string{"login", "followup"}, map[string]string{"USER_ID": "1"}, "", func(_ context.Context, name string, vars map[string]string, env string) (*apixhttp.Response, error) {
 		switch name {
 		case "login":
 			if got := vars["USER_ID"]; got != "1" {
@@ -87,7 +90,7 @@ func TestRunChainStopsOnHTTPError(t *testing.T) {
 	mustSaveRequest(t, "step3", request.SavedRequest{Method: "GET", Path: "/s3"})
 
 	calls := 0
-	result, err := RunChain([]string{"step1", "step2", "step3"}, nil, "", func(name string, vars map[string]string, env string) (*apixhttp.Response, error) {
+	result, err := RunChain(context.Background(), []string{"step1", "step2", "step3"}, nil, "", func(_ context.Context, name string, vars map[string]string, env string) (*apixhttp.Response, error) {
 		calls++
 		switch name {
 		case "step1":
@@ -112,6 +115,44 @@ func TestRunChainStopsOnHTTPError(t *testing.T) {
 	}
 }
 
+func TestRunChainStopsWhenCancelled(t *testing.T) {
+	withTempDirAsWorkingDirRunner(t)
+
+	mustSaveRequest(t, "step1", request.SavedRequest{Method: "GET", Path: "/s1"})
+	mustSaveRequest(t, "step2", request.SavedRequest{Method: "GET", Path: "/s2"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	result, err := RunChain(ctx, []string{"step1", "step2"}, nil, "", func(_ context.Context, name string, vars map[string]string, env string) (*apixhttp.Response, error) {
+		calls++
+		cancel()
+		return makeJSONResponse(http.StatusOK, `{"ok":true}`), nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 || result.Executed != 1 {
+		t.Fatalf("expected the chain to stop after step1, calls=%d executed=%d", calls, result.Executed)
+	}
+}
+
+func TestCaptureVariablesBareStatusReadsBody(t *testing.T) {
+	resp := makeJSONResponse(http.StatusOK, `{"status":"shipped","duration_ms":12}`)
+
+	captured, err := CaptureVariables(map[string]string{
+		"STATE":   "status",
+		"ELAPSED": "duration_ms",
+		"CODE":    "response:status",
+	}, resp)
+	if err != nil {
+		t.Fatalf("capture failed: %v", err)
+	}
+	if captured["STATE"] != "shipped" || captured["ELAPSED"] != "12" || captured["CODE"] != "200" {
+		t.Fatalf("expected body fields for bare names and the status code for response:status, got %v", captured)
+	}
+}
+
 func mustSaveRequest(t *testing.T, name string, req request.SavedRequest) {
 	t.Helper()
 	if err := request.Save(name, req); err != nil {
@@ -144,3 +185,69 @@ func withTempDirAsWorkingDirRunner(t *testing.T) {
 		t.Fatalf("changing to temp dir: %v", err)
 	}
 }
+
+func TestCaptureVariablesSupportsArrayPaths(t *testing.T) {
+	resp := makeJSONResponse(http.StatusCreated, `{"data":{"items":[{"id":7},{"id":8}]},"users":[{"id":"a","role":"admin"},{"id":"b","role":"member"}]}`)
+
+	captured, err := CaptureVariables(map[string]string{
+		"FIRST_ID":  "data.items.0.id",
+		"LAST_ID":   "data.items[-1].id",
+		"ADMIN_IDS": `users[?(@.role=="admin")].id`,
+	}, resp)
+	if err != nil {
+		t.Fatalf("capture failed: %v", err)
+	}
+	if captured["FIRST_ID"] != "7" {
+		t.Fatalf("expected FIRST_ID=7, got %q", captured["FIRST_ID"])
+	}
+	if captured["LAST_ID"] != "8" {
+		t.Fatalf("expected LAST_ID=8, got %q", captured["LAST_ID"])
+	}
+	if captured["ADMIN_IDS"] != `["a"]` {
+		t.Fatalf("expected ADMIN_IDS to be a JSON list, got %q", captured["ADMIN_IDS"])
+	}
+}
+
+func TestCaptureVariablesSourcePrefixes(t *testing.T) {
+	resp := &apixhttp.Response{
+		StatusCode: http.StatusCreated,
+		Status:     "201 Created",
+		Headers: http.Header{
+			"Location":   []string{"/orders/981"},
+			"Set-Cookie": []string{"session=abc; Path=/", "XSRF-TOKEN=t0k3n; Path=/; Secure"},
+		},
+		Body:     []byte(`<html><input name="csrf" value="h1dd3n"></html>`),
+		Duration: 1500 * time.Millisecond,
+	}
+
+	captured, err := CaptureVariables(map[string]string{
+		"ORDER_URL": "header:Location",
+		"XSRF":      "cookie:XSRF-TOKEN",
+		"CODE":      "response:status",
+		"CSRF":      `regex:name="csrf" value="([^"]+)"`,
+		"HTML":      "regex:<html>",
+		"ELAPSED":   "response:duration_ms",
+	}, resp)
+	if err != nil {
+		t.Fatalf("capture failed: %v", err)
+	}
+	want := map[string]string{
+		"ORDER_URL": "/orders/981",
+		"XSRF":      "t0k3n",
+		"CODE":      "201",
+		"CSRF":      "h1dd3n",
+		"HTML":      "<html>",
+		"ELAPSED":   "1500",
+	}
+	for key, value := range want {
+		if captured[key] != value {
+			t.Fatalf("expected %s=%q, got %q", key, value, captured[key])
+		}
+	}
+
+	for _, source := range []string{"header:X-Missing", "cookie:missing", "regex:nomatch(", "regex:absent", "response:size"} {
+		if _, err := CaptureVariables(map[string]string{"X": source}, resp); err == nil {
+			t.Fatalf("expected error for %q", source)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	conditionOperators = []string{"==", "!=", ">=", "<=", ">", "<", " contains "}
+	placeholderPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+)
+
+// EvaluateCondition evaluates a step `if:` expression. Placeholders are
+// replaced by the current variables (undefined ones become ""), then terms of
+// the form `a == b`, `a != b`, `a > b`, `a contains b` or a bare value are
+// combined with && and ||. A bare value is true unless it is empty, "false"
+// or "0"; a leading ! negates a term.
+//
+//	if: ${ROLE} == "admin" && ${CART_TOTAL} > 0
+func EvaluateCondition(expr string, vars map[string]string) (bool, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return false, fmt.Errorf("empty condition")
+	}
+
+	for _, alternative := range splitOutsideQuotes(expr, "||") {
+		matched := true
+		for _, term := range splitOutsideQuotes(alternative, "&&") {
+			ok, err := evaluateTerm(term, vars)
+			if err != nil {
+				return false, fmt.Errorf("condition %q: %w", expr, err)
+			}
+			if !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func evaluateTerm(term string, vars map[string]string) (bool, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return false, fmt.Errorf("empty term")
+	}
+
+	for _, op := range conditionOperators {
+		idx := indexOutsideQuotes(term, op)
+		if idx < 0 {
+			continue
+		}
+		left := conditionOperand(term[:idx], vars)
+		right := conditionOperand(term[idx+len(op):], vars)
+		return compareOperands(strings.TrimSpace(op), left, right), nil
+	}
+
+	if strings.HasPrefix(term, "!") {
+		ok, err := evaluateTerm(term[1:], vars)
+		return !ok, err
+	}
+	value := conditionOperand(term, vars)
+	return value != "" && value != "false" && value != "0", nil
+}
+
+func conditionOperand(raw string, vars map[string]string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[len(raw)-1] == raw[0] {
+		raw = raw[1 : len(raw)-1]
+	}
+	return placeholderPattern.ReplaceAllStringFunc(raw, func(match string) string {
+		return vars[placeholderPattern.FindStringSubmatch(match)[1]]
+	})
+}
+
+func compareOperands(op, left, right string) bool {
+	leftNumber, leftErr := strconv.ParseFloat(left, 64)
+	rightNumber, rightErr := strconv.ParseFloat(right, 64)
+	numeric := leftErr == nil && rightErr == nil
+
+	switch op {
+	case "==":
+		if numeric {
+			return leftNumber == rightNumber
+		}
+		return left == right
+	case "!=":
+		if numeric {
+			return leftNumber != rightNumber
+		}
+		return left != right
+	case "contains":
+		return strings.Contains(left, right)
+	}
+
+	if !numeric {
+		cmp := strings.Compare(left, right)
+		switch op {
+		case ">":
+			return cmp > 0
+		case ">=":
+			return cmp >= 0
+		case "<":
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	}
+	switch op {
+	case ">":
+		return leftNumber > rightNumber
+	case ">=":
+		return leftNumber >= rightNumber
+	case "<":
+		return leftNumber < rightNumber
+	default:
+		return leftNumber <= rightNumber
+	}
+}
+
+func splitOutsideQuotes(expr, sep string) []string {
+	parts := make([]string, 0, 1)
+	for {
+		idx := indexOutsideQuotes(expr, sep)
+		if idx < 0 {
+			return append(parts, expr)
+		}
+		parts = append(parts, expr[:idx])
+		expr = expr[idx+len(sep):]
+	}
+}
+
+func indexOutsideQuotes(expr, needle string) int {
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(expr[i:], needle):
+			return i
+		}
+	}
+	return -1
+}
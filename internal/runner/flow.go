@@ -0,0 +1,485 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"github.com/Tresor-Kasend/apix/internal/tester"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	flowsDir = "flows"
+
+	OnFailureStop     = "stop"
+	OnFailureContinue = "continue"
+
+	defaultForEachVar = "ITEM"
+	iterationVar      = "ITERATION"
+)
+
+// ExecuteDefinitionFunc executes a request definition (saved or inline).
+type ExecuteDefinitionFunc func(ctx context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error)
+
+// Flow is a scenario declared in flows/<name>.yaml.
+type Flow struct {
+	Name      string            `yaml:"name,omitempty"`
+	Vars      map[string]string `yaml:"vars,omitempty"`
+	OnFailure string            `yaml:"on_failure,omitempty"`
+	Steps     []FlowStep        `yaml:"steps"`
+}
+
+type FlowStep struct {
+	Name        string            `yaml:"name,omitempty"`
+	Request     StepRequest       `yaml:"request"`
+	Vars        map[string]string `yaml:"vars,omitempty"`
+	Expect      *request.Expect   `yaml:"expect,omitempty"`
+	Capture     map[string]string `yaml:"capture,omitempty"`
+	AllowStatus []int             `yaml:"allow_status,omitempty"`
+	If          string            `yaml:"if,omitempty"`
+	Repeat      int               `yaml:"repeat,omitempty"`
+	ForEach     string            `yaml:"for_each,omitempty"`
+	As          string            `yaml:"as,omitempty"`
+	OnFailure   string            `yaml:"on_failure,omitempty"`
+}
+
+// StepRequest is either the name of a saved request or an inline definition.
+type StepRequest struct {
+	Saved  string
+	Inline *request.SavedRequest
+}
+
+func (r *StepRequest) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		r.Saved = strings.TrimSpace(node.Value)
+		return nil
+	case yaml.MappingNode:
+		var inline request.SavedRequest
+		if err := node.Decode(&inline); err != nil {
+			return err
+		}
+		r.Inline = &inline
+		return nil
+	default:
+		return fmt.Errorf("step request must be a saved request name or an inline request")
+	}
+}
+
+func (r StepRequest) MarshalYAML() (interface{}, error) {
+	if r.Inline != nil {
+		return r.Inline, nil
+	}
+	return r.Saved, nil
+}
+
+type StepResult struct {
+	Name     string
+	Status   int
+	Passed   bool
+	Skipped  bool
+	Duration time.Duration
+	Failures []tester.AssertionFailure
+	Error    string
+}
+
+type FlowResult struct {
+	Name    string
+	Steps   []StepResult
+	Passed  int
+	Failed  int
+	Skipped int
+	Stopped bool
+	// Interrupted reports that ctx was cancelled before the flow ended.
+	Interrupted bool
+	Duration    time.Duration
+	FinalVars   map[string]string
+}
+
+func (r FlowResult) Success() bool {
+	return r.Failed == 0
+}
+
+// LoadFlow reads flows/<name>.yaml, or the file itself when name is a path.
+func LoadFlow(name string) (*Flow, error) {
+	candidates := []string{name}
+	if !strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml") {
+		candidates = []string{
+			filepath.Join(flowsDir, name+".yaml"),
+			filepath.Join(flowsDir, name+".yml"),
+		}
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading flow file %q: %w", path, err)
+		}
+
+		var flow Flow
+		if err := yaml.Unmarshal(data, &flow); err != nil {
+			return nil, fmt.Errorf("parsing flow file %q: %w", path, err)
+		}
+		if flow.Name == "" {
+			flow.Name = strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".yaml"), ".yml")
+		}
+		if err := flow.validate(); err != nil {
+			return nil, fmt.Errorf("flow %q: %w", flow.Name, err)
+		}
+		flow.resolvePaths(filepath.Dir(path))
+		return &flow, nil
+	}
+	return nil, fmt.Errorf("flow %q not found in %s/", name, flowsDir)
+}
+
+// ListFlows returns the names of the flows in flows/.
+func ListFlows() ([]string, error) {
+	entries, err := os.ReadDir(flowsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("listing flows: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(strings.TrimSuffix(name, ".yaml"), ".yml"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *Flow) validate() error {
+	if len(f.Steps) == 0 {
+		return fmt.Errorf("no steps defined")
+	}
+	if err := validateOnFailure(f.OnFailure); err != nil {
+		return err
+	}
+	for i, step := range f.Steps {
+		if step.Request.Saved == "" && step.Request.Inline == nil {
+			return fmt.Errorf("step %d: request is required", i+1)
+		}
+		if step.Repeat < 0 {
+			return fmt.Errorf("step %d: repeat must be positive", i+1)
+		}
+		if step.Repeat > 0 && step.ForEach != "" {
+			return fmt.Errorf("step %d: repeat and for_each are mutually exclusive", i+1)
+		}
+		if err := validateOnFailure(step.OnFailure); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// resolvePaths makes the files referenced by the steps, and by their inline
+// requests, relative to dir, the directory of the flow file.
+func (f *Flow) resolvePaths(dir string) {
+	for i := range f.Steps {
+		step := &f.Steps[i]
+		step.Expect = tester.ResolveSchemaPaths(step.Expect, dir)
+		if step.Request.Inline != nil {
+			step.Request.Inline.SetDir(dir)
+			step.Request.Inline.Expect = tester.ResolveSchemaPaths(step.Request.Inline.Expect, dir)
+		}
+	}
+}
+
+func validateOnFailure(value string) error {
+	switch value {
+	case "", OnFailureStop, OnFailureContinue:
+		return nil
+	default:
+		return fmt.Errorf("on_failure must be %q or %q, got %q", OnFailureStop, OnFailureContinue, value)
+	}
+}
+
+// RunFlow executes the steps of flow in order. Captured variables are visible
+// to every following step; a failing step stops the flow unless its
+// on_failure (or the flow's) is "continue". Cancelling ctx stops the flow with
+// the error of ctx and the results of the steps run so far.
+func RunFlow(ctx context.Context, flow *Flow, flagVars map[string]string, envOverride string, execute ExecuteDefinitionFunc) (*FlowResult, error) {
+	if flow == nil {
+		return nil, fmt.Errorf("flow is required")
+	}
+	if execute == nil {
+		return nil, fmt.Errorf("flow executor callback is required")
+	}
+
+	runtime := NewRuntimeContext(flow.Vars)
+	runtime.Merge(flagVars)
+	result := &FlowResult{Name: flow.Name}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+		result.FinalVars = runtime.Snapshot()
+	}()
+
+	for i, step := range flow.Steps {
+		stepName := step.displayName(i)
+
+		if step.If != "" {
+			ok, err := EvaluateCondition(step.If, runtime.Snapshot())
+			if err != nil {
+				return result, fmt.Errorf("step %q: %w", stepName, err)
+			}
+			if !ok {
+				result.Steps = append(result.Steps, StepResult{Name: stepName, Skipped: true, Passed: true})
+				result.Skipped++
+				continue
+			}
+		}
+
+		iterations, err := step.iterations(runtime.Snapshot())
+		if err != nil {
+			return result, fmt.Errorf("step %q: %w", stepName, err)
+		}
+
+		for _, iteration := range iterations {
+			name := stepName
+			if len(iterations) > 1 || step.ForEach != "" {
+				name = fmt.Sprintf("%s[%s]", stepName, iteration[iterationVar])
+			}
+			if err := ctx.Err(); err != nil {
+				result.Stopped, result.Interrupted = true, true
+				return result, err
+			}
+			runtime.Merge(iteration)
+
+			stepResult := runStep(ctx, name, step, runtime, envOverride, execute)
+			result.Steps = append(result.Steps, stepResult)
+			if err := ctx.Err(); err != nil && !stepResult.Passed {
+				result.Failed++
+				result.Stopped, result.Interrupted = true, true
+				return result, err
+			}
+			if stepResult.Passed {
+				result.Passed++
+				continue
+			}
+
+			result.Failed++
+			onFailure := step.OnFailure
+			if onFailure == "" {
+				onFailure = flow.OnFailure
+			}
+			if onFailure != OnFailureContinue {
+				result.Stopped = true
+				return result, nil
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s FlowStep) displayName(index int) string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Request.Saved != "":
+		return s.Request.Saved
+	case s.Request.Inline != nil && s.Request.Inline.Name != "":
+		return s.Request.Inline.Name
+	default:
+		return fmt.Sprintf("step %d", index+1)
+	}
+}
+
+// iterations returns the extra variables of each run of the step: one run by
+// default, N for repeat, one per element of the for_each array.
+func (s FlowStep) iterations(vars map[string]string) ([]map[string]string, error) {
+	if s.ForEach != "" {
+		key := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s.ForEach), "${"), "}")
+		raw, ok := vars[key]
+		if !ok {
+			return nil, fmt.Errorf("for_each variable %q is not defined", key)
+		}
+		var items []interface{}
+		if err := json.Unmarshal([]byte(raw), &items); err != nil {
+			return nil, fmt.Errorf("for_each variable %q is not a JSON array: %w", key, err)
+		}
+
+		as := s.As
+		if as == "" {
+			as = defaultForEachVar
+		}
+		out := make([]map[string]string, 0, len(items))
+		for i, item := range items {
+			out = append(out, map[string]string{
+				as:           stringifyItem(item),
+				iterationVar: strconv.Itoa(i + 1),
+			})
+		}
+		return out, nil
+	}
+
+	count := s.Repeat
+	if count == 0 {
+		count = 1
+	}
+	out := make([]map[string]string, 0, count)
+	for i := 0; i < count; i++ {
+		out = append(out, map[string]string{iterationVar: strconv.Itoa(i + 1)})
+	}
+	return out, nil
+}
+
+func stringifyItem(item interface{}) string {
+	switch v := item.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64, bool:
+		return fmt.Sprint(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+func runStep(ctx context.Context, name string, step FlowStep, runtime *RuntimeContext, envOverride string, execute ExecuteDefinitionFunc) StepResult {
+	result := StepResult{Name: name}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	requestName := step.Request.Saved
+	saved := step.Request.Inline
+	if saved == nil {
+		loaded, err := request.Load(requestName)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		loaded.Expect = tester.ResolveSchemaPaths(loaded.Expect, request.SavedDir(requestName))
+		saved = loaded
+	} else if requestName = saved.Name; requestName == "" {
+		requestName = name
+	}
+
+	vars := runtime.Snapshot()
+	for key, value := range step.Vars {
+		vars[key] = request.ResolveVariables(value, vars)
+	}
+
+	resp, err := execute(ctx, requestName, saved, vars, envOverride)
+	if err != nil {
+		result.Error = fmt.Sprintf("execution error: %v", err)
+		return result
+	}
+	if resp == nil {
+		result.Error = "execution error: empty response"
+		return result
+	}
+	result.Status = resp.StatusCode
+
+	expect := mergeExpect(saved.Expect, step.Expect)
+	if !statusAllowed(step, expect, resp.StatusCode) {
+		result.Error = fmt.Sprintf("returned HTTP %d", resp.StatusCode)
+		return result
+	}
+
+	failures, err := tester.EvaluateExpect(expect, resp)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if saved.GraphQL != nil {
+		failures = append(failures, tester.EvaluateGraphQLErrors(expect, resp)...)
+	}
+	result.Failures = failures
+
+	capture := make(map[string]string, len(saved.Capture)+len(step.Capture))
+	for key, path := range saved.Capture {
+		capture[key] = path
+	}
+	for key, path := range step.Capture {
+		capture[key] = path
+	}
+	captured, err := CaptureVariables(capture, resp)
+	if err != nil {
+		result.Error = fmt.Sprintf("capture failed: %v", err)
+		return result
+	}
+	runtime.Merge(captured)
+
+	result.Passed = len(failures) == 0
+	return result
+}
+
+// mergeExpect layers the step's expect block over the saved request's one.
+// Status, response_time and schema set by the step replace the saved ones;
+// body, headers, events and timing rules are replaced per target.
+func mergeExpect(saved, step *request.Expect) *request.Expect {
+	if saved == nil {
+		return step
+	}
+	if step == nil {
+		return saved
+	}
+	out := *saved
+	if len(step.Status) > 0 {
+		out.Status = step.Status
+	}
+	if len(step.ResponseTime) > 0 {
+		out.ResponseTime = step.ResponseTime
+	}
+	if step.Schema != "" {
+		out.Schema = step.Schema
+	}
+	out.Body = mergeRules(saved.Body, step.Body)
+	out.Headers = mergeRules(saved.Headers, step.Headers)
+	out.Events = mergeRules(saved.Events, step.Events)
+	out.Timing = mergeRules(saved.Timing, step.Timing)
+	return &out
+}
+
+func mergeRules(saved, step map[string]request.AssertionRule) map[string]request.AssertionRule {
+	if len(step) == 0 {
+		return saved
+	}
+	out := make(map[string]request.AssertionRule, len(saved)+len(step))
+	for target, rule := range saved {
+		out[target] = rule
+	}
+	for target, rule := range step {
+		out[target] = rule
+	}
+	return out
+}
+
+// statusAllowed applies the chain rule (HTTP >= 400 fails) unless the status
+// is listed in allow_status or asserted by the expect block.
+func statusAllowed(step FlowStep, expect *request.Expect, status int) bool {
+	for _, allowed := range step.AllowStatus {
+		if allowed == status {
+			return true
+		}
+	}
+	if expect != nil && len(expect.Status) > 0 {
+		return true
+	}
+	return status < 400
+}
@@ -0,0 +1,282 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+func writeFlowFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll("flows", 0o755); err != nil {
+		t.Fatalf("creating flows dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join("flows", name+".yaml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("writing flow %q: %v", name, err)
+	}
+}
+
+func TestRunFlowStepsConditionsAndLoops(t *testing.T) {
+	withTempDirAsWorkingDirRunner(t)
+	mustSaveRequest(t, "login", request.SavedRequest{
+		Method:  "POST",
+		Path:    "/login",
+		Capture: map[string]string{"ROLE": "data.role"},
+	})
+	mustSaveRequest(t, "remove-item", request.SavedRequest{Method: "DELETE", Path: "/cart/${ITEM_ID}"})
+	writeFlowFile(t, "checkout", ""+
+		"vars:\n"+
+		"  COUPON: ''\n"+
+		"steps:\n"+
+		"  - request: login\n"+
+		"  - name: cart\n"+
+		"    request:\n"+
+		"      method: GET\n"+
+		"      path: /cart\n"+
+		"    expect:\n"+
+		"      body:\n"+
+		"        items:\n"+
+		"          length: 2\n"+
+		"    capture:\n"+
+		"      ITEM_IDS: items[*].id\n"+
+		"  - request: remove-item\n"+
+		"    for_each: ITEM_IDS\n"+
+		"    as: ITEM_ID\n"+
+		"  - name: admin-report\n"+
+		"    request: {method: GET, path: /admin/report}\n"+
+		"    if: ${ROLE} == \"admin\" && ${COUPON} == ''\n"+
+		"  - name: apply-coupon\n"+
+		"    request: {method: POST, path: '/coupons/${COUPON}'}\n"+
+		"    if: ${COUPON}\n"+
+		"  - name: poll\n"+
+		"    request: {method: GET, path: /orders/latest}\n"+
+		"    repeat: 2\n"+
+		"  - name: missing-coupon\n"+
+		"    request: {method: GET, path: /coupons/none}\n"+
+		"    allow_status: [404]\n")
+
+	flow, err := LoadFlow("checkout")
+	if err != nil {
+		t.Fatalf("load flow: %v", err)
+	}
+
+	calls := make([]string, 0)
+	result, err := RunFlow(context.Background(), flow, nil, "", func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, env string) (*apixhttp.Response, error) {
+		path := request.ResolveVariables(saved.Path, vars)
+		calls = append(calls, saved.Method+" "+path)
+		switch path {
+		case "/login":
+			return makeJSONResponse(http.StatusOK, `{"data":{"role":"admin"}}`), nil
+		case "/cart":
+			return makeJSONResponse(http.StatusOK, `{"items":[{"id":"a1"},{"id":"b2"}]}`), nil
+		case "/coupons/none":
+			return makeJSONResponse(http.StatusNotFound, `{}`), nil
+		default:
+			return makeJSONResponse(http.StatusOK, `{}`), nil
+		}
+	})
+	if err != nil {
+		t.Fatalf("run flow failed: %v", err)
+	}
+
+	want := []string{
+		"POST /login",
+		"GET /cart",
+		"DELETE /cart/a1",
+		"DELETE /cart/b2",
+		"GET /admin/report",
+		"GET /orders/latest",
+		"GET /orders/latest",
+		"GET /coupons/none",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected calls:\n%s", strings.Join(calls, "\n"))
+	}
+	if !result.Success() || result.Passed != 8 || result.Skipped != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Steps[2].Name != "remove-item[1]" || result.Steps[7].Name != "poll[2]" {
+		t.Fatalf("unexpected iteration names: %q, %q", result.Steps[2].Name, result.Steps[7].Name)
+	}
+	if !result.Steps[5].Skipped || result.Steps[5].Name != "apply-coupon" {
+		t.Fatalf("expected apply-coupon to be skipped, got %+v", result.Steps[5])
+	}
+	if result.FinalVars["ITEM_IDS"] != `["a1","b2"]` {
+		t.Fatalf("expected captured ITEM_IDS, got %q", result.FinalVars["ITEM_IDS"])
+	}
+}
+
+func TestRunFlowOnFailure(t *testing.T) {
+	withTempDirAsWorkingDirRunner(t)
+	writeFlowFile(t, "orders", ""+
+		"steps:\n"+
+		"  - name: create\n"+
+		"    request: {method: POST, path: /orders}\n"+
+		"    expect:\n"+
+		"      status:\n"+
+		"        eq: 201\n"+
+		"    on_failure: continue\n"+
+		"  - name: fetch\n"+
+		"    request: {method: GET, path: /orders/1}\n"+
+		"  - name: never\n"+
+		"    request: {method: GET, path: /never}\n")
+
+	flow, err := LoadFlow("orders")
+	if err != nil {
+		t.Fatalf("load flow: %v", err)
+	}
+	calls := 0
+	result, err := RunFlow(context.Background(), flow, nil, "", func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, env string) (*apixhttp.Response, error) {
+		calls++
+		if saved.Path == "/orders/1" {
+			return makeJSONResponse(http.StatusInternalServerError, `{}`), nil
+		}
+		return makeJSONResponse(http.StatusOK, `{}`), nil
+	})
+	if err != nil {
+		t.Fatalf("run flow failed: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the flow to stop after fetch, calls=%d", calls)
+	}
+	if result.Success() || result.Failed != 2 || !result.Stopped {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Steps[0].Failures) != 1 || result.Steps[0].Failures[0].Target != "status" {
+		t.Fatalf("expected status assertion failure, got %+v", result.Steps[0])
+	}
+	if result.Steps[1].Error != "returned HTTP 500" {
+		t.Fatalf("expected HTTP error on fetch, got %q", result.Steps[1].Error)
+	}
+}
+
+func TestRunFlowMergesSavedExpect(t *testing.T) {
+	withTempDirAsWorkingDirRunner(t)
+	mustSaveRequest(t, "create-order", request.SavedRequest{
+		Method: "POST",
+		Path:   "/orders",
+		Expect: &request.Expect{
+			Status: request.AssertionRule{"eq": 201},
+			Body: map[string]request.AssertionRule{
+				"id":    {"eq": 1},
+				"state": {"eq": "new"},
+			},
+		},
+	})
+	writeFlowFile(t, "orders", ""+
+		"on_failure: continue\n"+
+		"steps:\n"+
+		"  - request: create-order\n"+
+		"  - request: create-order\n"+
+		"    expect:\n"+
+		"      body:\n"+
+		"        state:\n"+
+		"          eq: paid\n")
+
+	flow, err := LoadFlow("orders")
+	if err != nil {
+		t.Fatalf("load flow: %v", err)
+	}
+	result, err := RunFlow(context.Background(), flow, nil, "", func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, env string) (*apixhttp.Response, error) {
+		return makeJSONResponse(http.StatusOK, `{"id":2,"state":"paid"}`), nil
+	})
+	if err != nil {
+		t.Fatalf("run flow failed: %v", err)
+	}
+
+	targets := func(step StepResult) []string {
+		out := make([]string, 0, len(step.Failures))
+		for _, failure := range step.Failures {
+			out = append(out, failure.Target)
+		}
+		sort.Strings(out)
+		return out
+	}
+	if got := strings.Join(targets(result.Steps[0]), ","); got != "body.id,body.state,status" {
+		t.Fatalf("expected the saved expect to apply, got failures %s", got)
+	}
+	if got := strings.Join(targets(result.Steps[1]), ","); got != "body.id,status" {
+		t.Fatalf("expected the step to override body.state only, got failures %s", got)
+	}
+}
+
+func TestRunFlowResolvesSchemaFiles(t *testing.T) {
+	withTempDirAsWorkingDirRunner(t)
+	mustSaveRequest(t, "users/get-user", request.SavedRequest{
+		Method: "GET",
+		Path:   "/users/1",
+		Expect: &request.Expect{Schema: "user.json"},
+	})
+	if err := os.WriteFile(filepath.Join("requests", "users", "user.json"), []byte(`{"required":["id"]}`), 0o644); err != nil {
+		t.Fatalf("writing schema: %v", err)
+	}
+	writeFlowFile(t, "users", ""+
+		"steps:\n"+
+		"  - request: users/get-user\n"+
+		"    expect:\n"+
+		"      body:\n"+
+		"        id:\n"+
+		"          schema: id.json\n")
+	if err := os.WriteFile(filepath.Join("flows", "id.json"), []byte(`{"type":"integer"}`), 0o644); err != nil {
+		t.Fatalf("writing schema: %v", err)
+	}
+
+	flow, err := LoadFlow("users")
+	if err != nil {
+		t.Fatalf("load flow: %v", err)
+	}
+	result, err := RunFlow(context.Background(), flow, nil, "", func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, env string) (*apixhttp.Response, error) {
+		return makeJSONResponse(http.StatusOK, `{"id":1}`), nil
+	})
+	if err != nil {
+		t.Fatalf("run flow failed: %v", err)
+	}
+	if !result.Success() {
+		t.Fatalf("expected schema files next to the request and the flow, got %+v", result.Steps[0])
+	}
+}
+
+func TestLoadFlowValidation(t *testing.T) {
+	withTempDirAsWorkingDirRunner(t)
+	writeFlowFile(t, "bad", "steps:\n  - request: a\n    on_failure: retry\n")
+	if _, err := LoadFlow("bad"); err == nil || !strings.Contains(err.Error(), "on_failure") {
+		t.Fatalf("expected on_failure validation error, got %v", err)
+	}
+	if _, err := LoadFlow("missing"); err == nil {
+		t.Fatalf("expected missing flow error")
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	vars := map[string]string{"ROLE": "admin", "TOTAL": "12.5", "EMPTY": "", "TAGS": `["vip","new"]`}
+	cases := map[string]bool{
+		`${ROLE} == "admin"`:                   true,
+		`${ROLE} != admin`:                     false,
+		`${TOTAL} > 9`:                         true,
+		`${TOTAL} <= 12.5 && ${ROLE} == user`:  false,
+		`${TOTAL} < 1 || ${TAGS} contains vip`: true,
+		`${EMPTY}`:                             false,
+		`!${EMPTY}`:                            true,
+		`${UNDEFINED} == ''`:                   true,
+		`"a || b" == "a || b"`:                 true,
+	}
+	for expr, want := range cases {
+		got, err := EvaluateCondition(expr, vars)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if got != want {
+			t.Fatalf("%s: expected %v, got %v", expr, want, got)
+		}
+	}
+	if _, err := EvaluateCondition("  ", vars); err == nil {
+		t.Fatalf("expected error for empty condition")
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"strings"
 
 	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/jsonpath"
+	"github.com/Tresor-Kasend/apix/internal/jsonschema"
+	"github.com/Tresor-Kasend/apix/internal/jsonvalue"
 	"github.com/Tresor-Kasend/apix/internal/request"
 )
 
@@ -27,6 +30,7 @@ var operatorOrder = []string{
 	"lt",
 	"lte",
 	"length",
+	"schema",
 }
 
 var supportedOperators = map[string]struct{}{
@@ -43,6 +47,7 @@ var supportedOperators = map[string]struct{}{
 	"lt":        {},
 	"lte":       {},
 	"length":    {},
+	"schema":    {},
 }
 
 func EvaluateExpect(expect *request.Expect, resp *apixhttp.Response) ([]AssertionFailure, error) {
@@ -71,6 +76,14 @@ func EvaluateExpect(expect *request.Expect, resp *apixhttp.Response) ([]Assertio
 		failures = append(failures, ruleFailures...)
 	}
 
+	if len(expect.Timing) > 0 {
+		timingFailures, err := evaluateTiming(expect.Timing, resp)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, timingFailures...)
+	}
+
 	if len(expect.Headers) > 0 {
 		keys := sortedAssertionRuleKeys(expect.Headers)
 		for _, headerName := range keys {
@@ -83,12 +96,36 @@ func EvaluateExpect(expect *request.Expect, resp *apixhttp.Response) ([]Assertio
 		}
 	}
 
-	if len(expect.Body) > 0 {
-		var root interface{}
-		if err := json.Unmarshal(resp.Body, &root); err != nil {
-			return nil, fmt.Errorf("body assertions require a JSON response: %w", err)
+	if len(expect.Events) > 0 {
+		eventFailures, err := evaluateEvents(expect.Events, resp)
+		if err != nil {
+			return nil, err
 		}
+		failures = append(failures, eventFailures...)
+	}
+
+	if len(expect.Body) == 0 && strings.TrimSpace(expect.Schema) == "" {
+		return failures, nil
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(resp.Body, &root); err != nil {
+		return nil, fmt.Errorf("body assertions require a JSON response: %w", err)
+	}
 
+	if schemaPath := strings.TrimSpace(expect.Schema); schemaPath != "" {
+		validator, err := jsonschema.LoadFile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("schema: %w", err)
+		}
+		schemaFailures, err := schemaViolations("body", validator, root)
+		if err != nil {
+			return nil, fmt.Errorf("schema: %w", err)
+		}
+		failures = append(failures, schemaFailures...)
+	}
+
+	if len(expect.Body) > 0 {
 		keys := sortedAssertionRuleKeys(expect.Body)
 		for _, path := range keys {
 			value, exists, err := extractJSONPath(root, path)
@@ -106,6 +143,68 @@ func EvaluateExpect(expect *request.Expect, resp *apixhttp.Response) ([]Assertio
 	return failures, nil
 }
 
+// evaluateTiming checks the request phases in milliseconds. Streamed
+// responses carry no timing, so every phase is reported as missing.
+func evaluateTiming(rules map[string]request.AssertionRule, resp *apixhttp.Response) ([]AssertionFailure, error) {
+	phases := resp.Timing.Milliseconds()
+	failures := make([]AssertionFailure, 0)
+	for _, name := range sortedAssertionRuleKeys(rules) {
+		phase := strings.ToLower(strings.TrimSpace(name))
+		if !isTimingPhase(phase) {
+			return nil, fmt.Errorf("timing.%s: unknown phase (expected one of %s)", name, strings.Join(apixhttp.TimingPhases, ", "))
+		}
+		value, exists := phases[phase]
+		ruleFailures, err := evaluateRules("timing."+phase, rules[name], value, exists)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, ruleFailures...)
+	}
+	return failures, nil
+}
+
+func isTimingPhase(name string) bool {
+	for _, phase := range apixhttp.TimingPhases {
+		if phase == name {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateEvents checks the events collected by a streaming request. The
+// "count" key is the number of events; other keys are JSON paths into the
+// event list ([0].data, [-1].event, [*].id).
+func evaluateEvents(rules map[string]request.AssertionRule, resp *apixhttp.Response) ([]AssertionFailure, error) {
+	events := make([]interface{}, 0)
+	if len(resp.Events) > 0 {
+		if err := json.Unmarshal(resp.Body, &events); err != nil {
+			return nil, fmt.Errorf("events assertions: %w", err)
+		}
+	}
+
+	failures := make([]AssertionFailure, 0)
+	for _, path := range sortedAssertionRuleKeys(rules) {
+		var (
+			value  interface{} = len(events)
+			exists             = true
+		)
+		if path != "count" {
+			var err error
+			value, exists, err = extractJSONPath(events, path)
+			if err != nil {
+				return nil, fmt.Errorf("events.%s: %w", path, err)
+			}
+		}
+		ruleFailures, err := evaluateRules("events."+path, rules[path], value, exists)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, ruleFailures...)
+	}
+	return failures, nil
+}
+
 func evaluateRules(target string, rules request.AssertionRule, actual interface{}, exists bool) ([]AssertionFailure, error) {
 	for op := range rules {
 		if _, ok := supportedOperators[op]; !ok {
@@ -184,7 +283,7 @@ func evaluateRules(target string, rules request.AssertionRule, actual interface{
 			if err != nil {
 				return nil, err
 			}
-			_, isNumber := toFloat64(actual)
+			_, isNumber := jsonvalue.Float64(actual)
 			if isNumber != expectedBool {
 				failures = append(failures, AssertionFailure{
 					Target:   target,
@@ -272,11 +371,11 @@ func evaluateRules(target string, rules request.AssertionRule, actual interface{
 				continue
 			}
 
-			expectedNumber, ok := toFloat64(expected)
+			expectedNumber, ok := jsonvalue.Float64(expected)
 			if !ok {
 				return nil, fmt.Errorf("%s.%s expects numeric value, got %T", target, op, expected)
 			}
-			actualNumber, ok := toFloat64(actual)
+			actualNumber, ok := jsonvalue.Float64(actual)
 			if !ok {
 				failures = append(failures, AssertionFailure{
 					Target:   target,
@@ -321,7 +420,7 @@ func evaluateRules(target string, rules request.AssertionRule, actual interface{
 				continue
 			}
 
-			expectedLengthFloat, ok := toFloat64(expected)
+			expectedLengthFloat, ok := jsonvalue.Float64(expected)
 			if !ok || expectedLengthFloat < 0 || math.Trunc(expectedLengthFloat) != expectedLengthFloat {
 				return nil, fmt.Errorf("%s.length expects a non-negative integer, got %v", target, expected)
 			}
@@ -347,12 +446,91 @@ func evaluateRules(target string, rules request.AssertionRule, actual interface{
 					Message:  "length check failed",
 				})
 			}
+
+		case "schema":
+			if !exists {
+				failures = append(failures, AssertionFailure{
+					Target:   target,
+					Operator: op,
+					Expected: "value matching schema",
+					Actual:   nil,
+					Message:  "value does not exist",
+				})
+				continue
+			}
+
+			var validator *jsonschema.Validator
+			switch schema := expected.(type) {
+			case string:
+				loaded, err := jsonschema.LoadFile(schema)
+				if err != nil {
+					return nil, fmt.Errorf("%s.schema: %w", target, err)
+				}
+				validator = loaded
+			case map[string]interface{}, map[interface{}]interface{}, bool:
+				validator = jsonschema.New(schema)
+			default:
+				return nil, fmt.Errorf("%s.schema expects an inline schema or a file path, got %T", target, expected)
+			}
+
+			schemaFailures, err := schemaViolations(target, validator, actual)
+			if err != nil {
+				return nil, fmt.Errorf("%s.schema: %w", target, err)
+			}
+			failures = append(failures, schemaFailures...)
 		}
 	}
 
 	return failures, nil
 }
 
+// schemaViolations validates value and reports each violation against
+// target suffixed with the failing instance path (body.user/address/city).
+func schemaViolations(target string, validator *jsonschema.Validator, value interface{}) ([]AssertionFailure, error) {
+	violations, err := validator.Validate(value)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := make([]AssertionFailure, 0, len(violations))
+	for _, violation := range violations {
+		failures = append(failures, AssertionFailure{
+			Target:   target + violation.InstancePath,
+			Operator: "schema",
+			Expected: violation.Expected,
+			Actual:   violation.Actual,
+			Message:  violation.Message,
+		})
+	}
+	return failures, nil
+}
+
+// ResolveSchemaPaths returns expect with its schema file references, the
+// top-level schema and body schema rules naming a file, joined to dir, the
+// directory of the file that declares them. Absolute paths are kept.
+func ResolveSchemaPaths(expect *request.Expect, dir string) *request.Expect {
+	if expect == nil || dir == "" {
+		return expect
+	}
+	out := *expect
+	out.Schema = request.ResolvePath(dir, expect.Schema)
+	if len(expect.Body) > 0 {
+		out.Body = make(map[string]request.AssertionRule, len(expect.Body))
+		for target, rule := range expect.Body {
+			if path, ok := rule["schema"].(string); ok {
+				resolved := make(request.AssertionRule, len(rule))
+				for operator, value := range rule {
+					resolved[operator] = value
+				}
+				resolved["schema"] = request.ResolvePath(dir, path)
+				rule = resolved
+			}
+			out.Body[target] = rule
+		}
+	}
+	return &out
+}
+
 func expectedBoolValue(target, operator string, value interface{}) (bool, error) {
 	boolVal, ok := value.(bool)
 	if !ok {
@@ -380,29 +558,7 @@ func getHeaderValue(headers http.Header, name string) (string, bool) {
 }
 
 func extractJSONPath(root interface{}, path string) (interface{}, bool, error) {
-	trimmed := strings.TrimSpace(path)
-	if trimmed == "" {
-		return nil, false, fmt.Errorf("path cannot be empty")
-	}
-
-	current := root
-	for _, segment := range strings.Split(trimmed, ".") {
-		segment = strings.TrimSpace(segment)
-		if segment == "" {
-			return nil, false, fmt.Errorf("path segment cannot be empty")
-		}
-
-		object, ok := current.(map[string]interface{})
-		if !ok {
-			return nil, false, nil
-		}
-		next, ok := object[segment]
-		if !ok {
-			return nil, false, nil
-		}
-		current = next
-	}
-	return current, true, nil
+	return jsonpath.Lookup(root, path)
 }
 
 func containsValue(actual interface{}, expected interface{}) bool {
@@ -429,49 +585,42 @@ func containsValue(actual interface{}, expected interface{}) bool {
 }
 
 func valuesEqual(actual, expected interface{}) bool {
-	actualNumber, actualIsNumber := toFloat64(actual)
-	expectedNumber, expectedIsNumber := toFloat64(expected)
+	actualNumber, actualIsNumber := jsonvalue.Float64(actual)
+	expectedNumber, expectedIsNumber := jsonvalue.Float64(expected)
 	if actualIsNumber && expectedIsNumber {
 		return actualNumber == expectedNumber
 	}
-	return reflect.DeepEqual(actual, expected)
-}
 
-func toFloat64(value interface{}) (float64, bool) {
-	switch number := value.(type) {
-	case int:
-		return float64(number), true
-	case int8:
-		return float64(number), true
-	case int16:
-		return float64(number), true
-	case int32:
-		return float64(number), true
-	case int64:
-		return float64(number), true
-	case uint:
-		return float64(number), true
-	case uint8:
-		return float64(number), true
-	case uint16:
-		return float64(number), true
-	case uint32:
-		return float64(number), true
-	case uint64:
-		return float64(number), true
-	case float32:
-		return float64(number), true
-	case float64:
-		return number, true
-	case json.Number:
-		parsed, err := number.Float64()
-		if err != nil {
-			return 0, false
+	actualList, actualIsList := actual.([]interface{})
+	expectedList, expectedIsList := expected.([]interface{})
+	if actualIsList && expectedIsList {
+		if len(actualList) != len(expectedList) {
+			return false
 		}
-		return parsed, true
-	default:
-		return 0, false
+		for i := range actualList {
+			if !valuesEqual(actualList[i], expectedList[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	actualMap, actualIsMap := actual.(map[string]interface{})
+	expectedMap, expectedIsMap := expected.(map[string]interface{})
+	if actualIsMap && expectedIsMap {
+		if len(actualMap) != len(expectedMap) {
+			return false
+		}
+		for key, value := range actualMap {
+			other, ok := expectedMap[key]
+			if !ok || !valuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
 	}
+
+	return reflect.DeepEqual(actual, expected)
 }
 
 func valueLength(value interface{}) (int, bool) {
@@ -2,6 +2,10 @@ package tester
 
 import (
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -214,3 +218,168 @@ func TestEvaluateExpectBodyRequiresJSON(t *testing.T) {
 		t.Fatalf("expected json error, got %v", err)
 	}
 }
+
+func TestEvaluateExpectArrayPaths(t *testing.T) {
+	resp := &apixhttp.Response{
+		StatusCode: 200,
+		Headers:    http.Header{"Content-Type": []string{"application/json"}},
+		Body:       []byte(`{"users":[{"id":1,"role":"admin"},{"id":2,"role":"member"},{"id":3,"role":"admin"}]}`),
+	}
+
+	expect := &request.Expect{
+		Body: map[string]request.AssertionRule{
+			"users.0.id":                   {"eq": 1},
+			"users[-1].role":               {"eq": "admin"},
+			"users[*].role":                {"contains": "member", "length": 3},
+			`users[?(@.role=="admin")].id`: {"eq": []interface{}{1, 3}},
+			`$..id`:                        {"length": 3},
+			`users[?(@.role=="owner")]`:    {"length": 0},
+			"users[5].id":                  {"exists": false},
+		},
+	}
+
+	failures, err := EvaluateExpect(expect, resp)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("expected no failures, got %+v", failures)
+	}
+}
+
+func TestEvaluateExpectSchema(t *testing.T) {
+	resp := &apixhttp.Response{
+		StatusCode: 200,
+		Headers:    http.Header{"Content-Type": []string{"application/json"}},
+		Body:       []byte(`{"user":{"id":"7","email":"a@example.com","address":{}},"items":[1,2]}`),
+	}
+
+	schemaPath := filepath.Join(t.TempDir(), "body.json")
+	schemaFile := `{"type":"object","required":["user","total"],"properties":{"items":{"type":"array","items":{"type":"integer"}}}}`
+	if err := os.WriteFile(schemaPath, []byte(schemaFile), 0o644); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+
+	expect := &request.Expect{
+		Schema: schemaPath,
+		Body: map[string]request.AssertionRule{
+			"user": {"schema": map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"id", "email"},
+				"properties": map[string]interface{}{
+					"id":    map[string]interface{}{"type": "integer"},
+					"email": map[string]interface{}{"type": "string", "format": "email"},
+					"address": map[string]interface{}{
+						"type":     "object",
+						"required": []interface{}{"city"},
+					},
+				},
+			}},
+			"items": {"schema": map[string]interface{}{"maxItems": 1}},
+		},
+	}
+
+	failures, err := EvaluateExpect(expect, resp)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	got := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		if failure.Operator != "schema" {
+			t.Fatalf("expected schema operator, got %+v", failure)
+		}
+		got = append(got, failure.Target)
+	}
+	sort.Strings(got)
+	want := []string{"body.items", "body.user/address/city", "body.user/id", "body/total"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected failures at %v, got %v (%+v)", want, got, failures)
+	}
+}
+
+func TestEvaluateExpectSchemaErrors(t *testing.T) {
+	resp := &apixhttp.Response{StatusCode: 200, Body: []byte(`{"a":1}`)}
+
+	_, err := EvaluateExpect(&request.Expect{Schema: filepath.Join(t.TempDir(), "missing.json")}, resp)
+	if err == nil || !strings.Contains(err.Error(), "reading schema file") {
+		t.Fatalf("expected missing schema file error, got %v", err)
+	}
+
+	_, err = EvaluateExpect(&request.Expect{
+		Body: map[string]request.AssertionRule{"a": {"schema": 42}},
+	}, resp)
+	if err == nil || !strings.Contains(err.Error(), "expects an inline schema or a file path") {
+		t.Fatalf("expected invalid schema value error, got %v", err)
+	}
+}
+
+func TestEvaluateExpectEvents(t *testing.T) {
+	resp := &apixhttp.Response{
+		StatusCode: 200,
+		Headers:    http.Header{"Content-Type": []string{"text/event-stream"}},
+		Events: []apixhttp.SSEEvent{
+			{ID: "1", Event: "token", Data: `{"text":"Hel"}`},
+			{ID: "2", Event: "done", Data: "[DONE]"},
+		},
+		Body: []byte(`[{"id":"1","event":"token","data":{"text":"Hel"}},{"id":"2","event":"done","data":"[DONE]"}]`),
+	}
+
+	failures, err := EvaluateExpect(&request.Expect{
+		Events: map[string]request.AssertionRule{
+			"count":         {"gte": 2},
+			"[0].data.text": {"eq": "Hel"},
+			"[-1].event":    {"eq": "done"},
+			"[*].id":        {"contains": "2"},
+			"[1].data":      {"eq": "[DONE]"},
+			"[5].data":      {"exists": false},
+		},
+	}, resp)
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("expected no failures, got %+v", failures)
+	}
+
+	failures, err = EvaluateExpect(&request.Expect{
+		Events: map[string]request.AssertionRule{"count": {"eq": 3}},
+	}, resp)
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Target != "events.count" {
+		t.Fatalf("expected events.count failure, got %+v", failures)
+	}
+}
+
+func TestEvaluateExpectTiming(t *testing.T) {
+	resp := &apixhttp.Response{
+		StatusCode: http.StatusOK,
+		Timing: &apixhttp.Timing{
+			Connect: 3 * time.Millisecond,
+			TTFB:    250 * time.Millisecond,
+			Total:   260 * time.Millisecond,
+		},
+	}
+
+	failures, err := EvaluateExpect(&request.Expect{
+		Timing: map[string]request.AssertionRule{
+			"connect": {"lt": 10},
+			"ttfb":    {"lt": 200},
+		},
+	}, resp)
+	if err != nil {
+		t.Fatalf("EvaluateExpect returned error: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Target != "timing.ttfb" {
+		t.Fatalf("expected one timing.ttfb failure, got %+v", failures)
+	}
+
+	_, err = EvaluateExpect(&request.Expect{
+		Timing: map[string]request.AssertionRule{"latency": {"lt": 200}},
+	}, resp)
+	if err == nil || !strings.Contains(err.Error(), "unknown phase") {
+		t.Fatalf("expected unknown phase error, got %v", err)
+	}
+}
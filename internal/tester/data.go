@@ -0,0 +1,130 @@
+package tester
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"gopkg.in/yaml.v3"
+)
+
+// expandIterations turns every case with a dataset into one case per row.
+// dataFile, when set, overrides the dataset declared by the requests.
+func expandIterations(cases []testCase, dataFile string) ([]testCase, error) {
+	var override []map[string]string
+	if dataFile != "" {
+		rows, err := request.LoadDataFile(dataFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("data file %q has no rows", dataFile)
+		}
+		override = rows
+	}
+
+	expanded := make([]testCase, 0, len(cases))
+	for _, tc := range cases {
+		rows := override
+		if rows == nil && tc.Request.Data != nil {
+			loaded, err := tc.Request.Data.LoadRows()
+			if err != nil {
+				return nil, fmt.Errorf("request %q: %w", tc.Name, err)
+			}
+			if len(loaded) == 0 {
+				return nil, fmt.Errorf("request %q: data has no rows", tc.Name)
+			}
+			rows = loaded
+		}
+		if rows == nil {
+			expanded = append(expanded, tc)
+			continue
+		}
+
+		for i, row := range rows {
+			iteration := tc
+			iteration.Label = fmt.Sprintf("%s[row %d]", tc.Name, i+1)
+			iteration.Vars = row
+			expanded = append(expanded, iteration)
+		}
+	}
+	return expanded, nil
+}
+
+// resolveExpect substitutes ${VAR} placeholders in expected values so a
+// dataset row can carry its own expectations. A value that is exactly one
+// placeholder is re-typed ("201" becomes a number, "true" a bool).
+func resolveExpect(expect *request.Expect, vars map[string]string) *request.Expect {
+	if expect == nil || len(vars) == 0 {
+		return expect
+	}
+	out := *expect
+	out.Status = resolveRule(expect.Status, vars)
+	out.Body = resolveRules(expect.Body, vars)
+	out.Headers = resolveRules(expect.Headers, vars)
+	out.ResponseTime = resolveRule(expect.ResponseTime, vars)
+	out.Schema = request.ResolveVariables(expect.Schema, vars)
+	out.Events = resolveRules(expect.Events, vars)
+	out.Timing = resolveRules(expect.Timing, vars)
+	return &out
+}
+
+func resolveRules(rules map[string]request.AssertionRule, vars map[string]string) map[string]request.AssertionRule {
+	if rules == nil {
+		return nil
+	}
+	out := make(map[string]request.AssertionRule, len(rules))
+	for key, rule := range rules {
+		out[key] = resolveRule(rule, vars)
+	}
+	return out
+}
+
+func resolveRule(rule request.AssertionRule, vars map[string]string) request.AssertionRule {
+	if rule == nil {
+		return nil
+	}
+	out := make(request.AssertionRule, len(rule))
+	for op, value := range rule {
+		out[op] = resolveExpectedValue(value, vars)
+	}
+	return out
+}
+
+func resolveExpectedValue(value interface{}, vars map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "${") {
+			return v
+		}
+		resolved := request.ResolveVariables(v, vars)
+		if resolved == v || !isSinglePlaceholder(v) {
+			return resolved
+		}
+		node := yaml.Node{Kind: yaml.ScalarNode, Value: resolved}
+		var typed interface{}
+		if err := node.Decode(&typed); err != nil || typed == nil {
+			return resolved
+		}
+		return typed
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = resolveExpectedValue(item, vars)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = resolveExpectedValue(item, vars)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func isSinglePlaceholder(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") && strings.Count(value, "${") == 1
+}
@@ -0,0 +1,42 @@
+package tester
+
+import (
+	"strings"
+
+	"github.com/Tresor-Kasend/apix/internal/graphql"
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+// EvaluateGraphQLErrors fails a GraphQL response whose errors array is not
+// empty (even with HTTP 200), unless the expect block asserts on errors
+// itself.
+func EvaluateGraphQLErrors(expect *request.Expect, resp *apixhttp.Response) []AssertionFailure {
+	if resp == nil || expectsGraphQLErrors(expect) {
+		return nil
+	}
+	messages := graphql.ResponseErrors(resp.Body)
+	if len(messages) == 0 {
+		return nil
+	}
+	return []AssertionFailure{{
+		Target:   "body.errors",
+		Operator: "graphql",
+		Expected: "no errors",
+		Actual:   messages,
+		Message:  "GraphQL response contains errors",
+	}}
+}
+
+func expectsGraphQLErrors(expect *request.Expect) bool {
+	if expect == nil {
+		return false
+	}
+	for path := range expect.Body {
+		path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+		if path == "errors" || strings.HasPrefix(path, "errors.") || strings.HasPrefix(path, "errors[") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package tester
+
+import (
+	"net/http"
+	"testing"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+func TestEvaluateGraphQLErrors(t *testing.T) {
+	resp := &apixhttp.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       []byte(`{"data":{"user":null},"errors":[{"message":"user not found","path":["user"]}]}`),
+	}
+
+	failures := EvaluateGraphQLErrors(&request.Expect{Status: request.AssertionRule{"eq": 200}}, resp)
+	if len(failures) != 1 {
+		t.Fatalf("expected 1 failure for a 200 with errors, got %+v", failures)
+	}
+	if failures[0].Target != "body.errors" || failures[0].Operator != "graphql" {
+		t.Fatalf("unexpected failure: %+v", failures[0])
+	}
+
+	for _, path := range []string{"errors", "errors[0].message", "$.errors.0.message"} {
+		expect := &request.Expect{Body: map[string]request.AssertionRule{path: {"exists": true}}}
+		if failures := EvaluateGraphQLErrors(expect, resp); len(failures) != 0 {
+			t.Fatalf("expected errors asserted by %q to be allowed, got %+v", path, failures)
+		}
+	}
+
+	ok := &apixhttp.Response{StatusCode: http.StatusOK, Body: []byte(`{"data":{"user":{"id":1}},"errors":[]}`)}
+	if failures := EvaluateGraphQLErrors(nil, ok); len(failures) != 0 {
+		t.Fatalf("expected no failure for an empty errors array, got %+v", failures)
+	}
+}
@@ -0,0 +1,184 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/interop/openapi"
+	"github.com/Tresor-Kasend/apix/internal/jsonschema"
+)
+
+const openAPITarget = "openapi"
+
+// OpenAPIContract validates executed requests against the operation an
+// OpenAPI document declares for them.
+type OpenAPIContract struct {
+	spec      *openapi.Spec
+	validator *jsonschema.Validator
+}
+
+func NewOpenAPIContract(spec *openapi.Spec) *OpenAPIContract {
+	return &OpenAPIContract{
+		spec:      spec,
+		validator: jsonschema.New(spec.Root()),
+	}
+}
+
+func LoadOpenAPIContract(path string) (*OpenAPIContract, error) {
+	spec, err := openapi.LoadSpecFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewOpenAPIContract(spec), nil
+}
+
+// Evaluate checks the status code, response headers and body of resp against
+// the matching operation.
+func (c *OpenAPIContract) Evaluate(resp *apixhttp.Response) ([]AssertionFailure, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("response is nil")
+	}
+
+	op, ok := c.spec.MatchOperation(resp.Method, resp.URL)
+	if !ok {
+		return []AssertionFailure{{
+			Target:   openAPITarget + ".operation",
+			Operator: "exists",
+			Expected: resp.Method + " " + resp.URL,
+			Actual:   nil,
+			Message:  "no matching operation in the OpenAPI spec",
+		}}, nil
+	}
+
+	documented, _, ok := op.ResponseFor(resp.StatusCode)
+	if !ok {
+		codes := make([]string, 0, len(op.Responses))
+		for code := range op.Responses {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		return []AssertionFailure{{
+			Target:   openAPITarget + ".response.status",
+			Operator: "documented",
+			Expected: codes,
+			Actual:   resp.StatusCode,
+			Message:  fmt.Sprintf("status is not documented for %s %s", op.Method, op.Path),
+		}}, nil
+	}
+
+	failures := make([]AssertionFailure, 0)
+
+	headerNames := make([]string, 0, len(documented.Headers))
+	for name := range documented.Headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		if strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		header := documented.Headers[name]
+		target := openAPITarget + ".response.headers." + name
+		value, exists := getHeaderValue(resp.Headers, name)
+		if !exists {
+			if header.Required {
+				failures = append(failures, AssertionFailure{
+					Target:   target,
+					Operator: "exists",
+					Expected: true,
+					Actual:   false,
+					Message:  "required response header is missing",
+				})
+			}
+			continue
+		}
+		if header.Schema == nil {
+			continue
+		}
+		headerFailures, err := c.validate(target, header.Schema, coerceHeaderValue(value, c.spec.Resolve(header.Schema)))
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, headerFailures...)
+	}
+
+	if len(documented.Content) == 0 {
+		return failures, nil
+	}
+
+	contentType := resp.Headers.Get("Content-Type")
+	mediaType, media, ok := openapi.MediaTypeFor(documented.Content, contentType)
+	if !ok {
+		types := make([]string, 0, len(documented.Content))
+		for key := range documented.Content {
+			types = append(types, key)
+		}
+		sort.Strings(types)
+		failures = append(failures, AssertionFailure{
+			Target:   openAPITarget + ".response.headers.Content-Type",
+			Operator: "documented",
+			Expected: types,
+			Actual:   contentType,
+			Message:  "content type is not documented for this response",
+		})
+		return failures, nil
+	}
+	if media.Schema == nil || !openapi.IsJSONMediaType(contentType) {
+		return failures, nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		failures = append(failures, AssertionFailure{
+			Target:   openAPITarget + ".response.body",
+			Operator: "schema",
+			Expected: mediaType,
+			Actual:   string(resp.Body),
+			Message:  "response body is not valid JSON",
+		})
+		return failures, nil
+	}
+	bodyFailures, err := c.validate(openAPITarget+".response.body", media.Schema, body)
+	if err != nil {
+		return nil, err
+	}
+	return append(failures, bodyFailures...), nil
+}
+
+func (c *OpenAPIContract) validate(target string, schema map[string]interface{}, value interface{}) ([]AssertionFailure, error) {
+	violations, err := c.validator.ValidateNode(schema, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", target, err)
+	}
+	failures := make([]AssertionFailure, 0, len(violations))
+	for _, violation := range violations {
+		failures = append(failures, AssertionFailure{
+			Target:   target + violation.InstancePath,
+			Operator: "schema",
+			Expected: violation.Expected,
+			Actual:   violation.Actual,
+			Message:  violation.Message,
+		})
+	}
+	return failures, nil
+}
+
+// coerceHeaderValue converts a header string into the scalar type its schema
+// declares so that numeric and boolean headers can be validated.
+func coerceHeaderValue(value string, schema map[string]interface{}) interface{} {
+	switch schema["type"] {
+	case "integer", "number":
+		if number, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return number
+		}
+	case "boolean":
+		if flag, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			return flag
+		}
+	}
+	return value
+}
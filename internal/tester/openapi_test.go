@@ -0,0 +1,170 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/interop/openapi"
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+const contractSpec = `
+openapi: 3.0.3
+info: {title: Users, version: "1"}
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "200":
+          description: ok
+          headers:
+            X-Rate-Limit:
+              required: true
+              schema: {type: integer}
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [data]
+                properties:
+                  data:
+                    type: array
+                    items: {$ref: "#/components/schemas/User"}
+        "404":
+          description: missing
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, email]
+      properties:
+        id: {type: integer}
+        email: {type: string, format: email}
+        nickname: {type: string, nullable: true}
+`
+
+func newTestContract(t *testing.T) *OpenAPIContract {
+	t.Helper()
+	spec, err := openapi.LoadSpec([]byte(contractSpec))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	return NewOpenAPIContract(spec)
+}
+
+func contractTargets(failures []AssertionFailure) []string {
+	targets := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		targets = append(targets, failure.Target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func TestOpenAPIContractEvaluate(t *testing.T) {
+	contract := newTestContract(t)
+
+	tests := []struct {
+		name string
+		resp *apixhttp.Response
+		want []string
+	}{
+		{
+			name: "valid",
+			resp: &apixhttp.Response{
+				Method:     "GET",
+				URL:        "https://api.example.com/v1/users/1",
+				StatusCode: 200,
+				Headers:    http.Header{"Content-Type": {"application/json"}, "X-Rate-Limit": {"10"}},
+				Body:       []byte(`{"data":[{"id":1,"email":"a@example.com","nickname":null}]}`),
+			},
+			want: []string{},
+		},
+		{
+			name: "schema and header violations",
+			resp: &apixhttp.Response{
+				Method:     "GET",
+				URL:        "https://api.example.com/v1/users/1",
+				StatusCode: 200,
+				Headers:    http.Header{"Content-Type": {"application/json; charset=utf-8"}, "X-Rate-Limit": {"many"}},
+				Body:       []byte(`{"data":[{"id":1,"email":"not-an-email"},{"email":"b@example.com"}]}`),
+			},
+			want: []string{
+				"openapi.response.body/data/0/email",
+				"openapi.response.body/data/1/id",
+				"openapi.response.headers.X-Rate-Limit",
+			},
+		},
+		{
+			name: "undocumented status",
+			resp: &apixhttp.Response{Method: "GET", URL: "https://api.example.com/v1/users/1", StatusCode: 500},
+			want: []string{"openapi.response.status"},
+		},
+		{
+			name: "documented status without content",
+			resp: &apixhttp.Response{Method: "GET", URL: "https://api.example.com/v1/users/9", StatusCode: 404, Body: []byte("nope")},
+			want: []string{},
+		},
+		{
+			name: "undocumented content type",
+			resp: &apixhttp.Response{
+				Method:     "GET",
+				URL:        "https://api.example.com/v1/users/1",
+				StatusCode: 200,
+				Headers:    http.Header{"Content-Type": {"text/html"}, "X-Rate-Limit": {"1"}},
+			},
+			want: []string{"openapi.response.headers.Content-Type"},
+		},
+		{
+			name: "unknown operation",
+			resp: &apixhttp.Response{Method: "DELETE", URL: "https://api.example.com/v1/users/1", StatusCode: 204},
+			want: []string{"openapi.operation"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures, err := contract.Evaluate(tt.resp)
+			if err != nil {
+				t.Fatalf("evaluate: %v", err)
+			}
+			if got := contractTargets(failures); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v (%+v)", tt.want, got, failures)
+			}
+		})
+	}
+}
+
+func TestRunWithOpenAPIIncludesRequestsWithoutExpect(t *testing.T) {
+	dir := t.TempDir()
+	writeRequestYAML(t, filepath.Join(dir, "get-user.yaml"), ""+
+		"name: get-user\n"+
+		"method: GET\n"+
+		"path: /users/1\n")
+
+	suite, err := Run(context.Background(), RunnerOptions{Dir: dir, OpenAPI: newTestContract(t)}, func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+		return &apixhttp.Response{
+			Method:     "GET",
+			URL:        "http://localhost:8080/v1/users/1",
+			StatusCode: 200,
+			Headers:    http.Header{"Content-Type": {"application/json"}},
+			Body:       []byte(`{"data":[]}`),
+		}, nil
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if suite.Total != 1 || suite.Failed != 1 {
+		t.Fatalf("expected one failing contract test, got %+v", suite)
+	}
+	if got := contractTargets(suite.Results[0].Failures); !reflect.DeepEqual(got, []string{"openapi.response.headers.X-Rate-Limit"}) {
+		t.Fatalf("unexpected failures: %+v", suite.Results[0].Failures)
+	}
+}
@@ -1,12 +1,14 @@
 package tester
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
@@ -15,21 +17,39 @@ import (
 
 const defaultRequestsDir = "requests"
 
-type ExecuteFunc func(name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error)
+type ExecuteFunc func(ctx context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error)
 
 type RunnerOptions struct {
 	Name        string
 	Dir         string
 	Vars        map[string]string
 	EnvOverride string
+	// OpenAPI, when set, validates every response against the spec and makes
+	// requests without an expect block testable too.
+	OpenAPI *OpenAPIContract
+	// Parallel is the number of cases executed concurrently (default 1).
+	Parallel int
+	// UpdateSnapshots re-records the snapshot of every case with
+	// expect.snapshot instead of comparing against it.
+	UpdateSnapshots bool
+	// Data is a CSV/JSON/YAML file whose rows replace the data block of
+	// every selected request.
+	Data string
 }
 
 type testCase struct {
 	Name    string
+	Dir     string
 	Request *request.SavedRequest
+	// Label and Vars are set for data-driven iterations.
+	Label string
+	Vars  map[string]string
 }
 
-func Run(options RunnerOptions, execute ExecuteFunc) (*SuiteResult, error) {
+// Run executes the testable requests selected by options. Once ctx is
+// cancelled no further case starts; the suite then only holds the results
+// of the cases that ran and is marked interrupted.
+func Run(ctx context.Context, options RunnerOptions, execute ExecuteFunc) (*SuiteResult, error) {
 	if execute == nil {
 		return nil, fmt.Errorf("test runner execute callback is required")
 	}
@@ -38,6 +58,10 @@ func Run(options RunnerOptions, execute ExecuteFunc) (*SuiteResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	cases, err = expandIterations(cases, options.Data)
+	if err != nil {
+		return nil, err
+	}
 
 	suite := &SuiteResult{
 		Total: len(cases),
@@ -47,48 +71,130 @@ func Run(options RunnerOptions, execute ExecuteFunc) (*SuiteResult, error) {
 	}
 
 	startSuite := time.Now()
-	for _, tc := range cases {
-		startTest := time.Now()
-		result := RequestResult{
-			Name: tc.Name,
-		}
-
-		resp, execErr := execute(tc.Name, tc.Request, cloneVars(options.Vars), options.EnvOverride)
-		result.Duration = time.Since(startTest)
-		if execErr != nil {
-			result.Error = fmt.Sprintf("execution error: %v", execErr)
+	suite.Results = runCases(ctx, cases, options, execute)
+	suite.Interrupted = ctx.Err() != nil
+	for _, result := range suite.Results {
+		if result.Passed {
+			suite.Passed++
+		} else {
 			suite.Failed++
-			suite.Results = append(suite.Results, result)
-			continue
 		}
-		if resp == nil {
-			result.Error = "execution error: empty response"
-			suite.Failed++
-			suite.Results = append(suite.Results, result)
-			continue
+	}
+	suite.Duration = time.Since(startSuite)
+
+	return suite, nil
+}
+
+// runCases executes the cases with up to options.Parallel workers, until ctx
+// is cancelled. Results keep the order of cases regardless of completion
+// order; cases that never started are left out.
+func runCases(ctx context.Context, cases []testCase, options RunnerOptions, execute ExecuteFunc) []RequestResult {
+	results := make([]RequestResult, len(cases))
+	ran := make([]bool, len(cases))
+
+	workers := options.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(cases) {
+		workers = len(cases)
+	}
+	if workers == 1 {
+		for i, tc := range cases {
+			if ctx.Err() != nil {
+				break
+			}
+			results[i], ran[i] = runCase(ctx, tc, options, execute), true
 		}
+		return completedResults(results, ran)
+	}
 
-		failures, assertErr := EvaluateExpect(tc.Request.Expect, resp)
-		if assertErr != nil {
-			result.Error = assertErr.Error()
-			suite.Failed++
-			suite.Results = append(suite.Results, result)
-			continue
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], ran[i] = runCase(ctx, cases[i], options, execute), true
+			}
+		}()
+	}
+schedule:
+	for i := range cases {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break schedule
 		}
-		result.Failures = failures
-		if len(failures) > 0 {
-			suite.Failed++
-			suite.Results = append(suite.Results, result)
-			continue
+	}
+	close(jobs)
+	wg.Wait()
+	return completedResults(results, ran)
+}
+
+func completedResults(results []RequestResult, ran []bool) []RequestResult {
+	completed := results[:0]
+	for i, result := range results {
+		if ran[i] {
+			completed = append(completed, result)
 		}
+	}
+	return completed
+}
 
-		result.Passed = true
-		suite.Passed++
-		suite.Results = append(suite.Results, result)
+func runCase(ctx context.Context, tc testCase, options RunnerOptions, execute ExecuteFunc) RequestResult {
+	startTest := time.Now()
+	result := RequestResult{
+		Name: tc.Name,
+		Dir:  tc.Dir,
+	}
+	if tc.Label != "" {
+		result.Name = tc.Label
 	}
-	suite.Duration = time.Since(startSuite)
 
-	return suite, nil
+	vars := cloneVars(options.Vars)
+	for key, value := range tc.Vars {
+		vars[key] = value
+	}
+
+	resp, execErr := execute(ctx, tc.Name, tc.Request, cloneVars(vars), options.EnvOverride)
+	result.Duration = time.Since(startTest)
+	if execErr != nil && ctx.Err() != nil {
+		result.Error = "interrupted"
+		return result
+	}
+	if execErr != nil {
+		result.Error = fmt.Sprintf("execution error: %v", execErr)
+		return result
+	}
+	if resp == nil {
+		result.Error = "execution error: empty response"
+		return result
+	}
+
+	expect := ResolveSchemaPaths(resolveExpect(tc.Request.Expect, vars), tc.Dir)
+	failures, assertErr := EvaluateExpect(expect, resp)
+	if assertErr == nil && tc.Request.GraphQL != nil {
+		failures = append(failures, EvaluateGraphQLErrors(expect, resp)...)
+	}
+	if assertErr == nil && expect != nil && expect.Snapshot {
+		var snapshotFailures []AssertionFailure
+		snapshotFailures, result.Snapshot, assertErr = checkSnapshot(snapshotPath(tc), expect, resp, options.UpdateSnapshots)
+		failures = append(failures, snapshotFailures...)
+	}
+	if assertErr == nil && options.OpenAPI != nil {
+		var contractFailures []AssertionFailure
+		contractFailures, assertErr = options.OpenAPI.Evaluate(resp)
+		failures = append(failures, contractFailures...)
+	}
+	if assertErr != nil {
+		result.Error = assertErr.Error()
+		return result
+	}
+	result.Failures = failures
+	result.Passed = len(failures) == 0
+	return result
 }
 
 func loadTestCases(options RunnerOptions) ([]testCase, error) {
@@ -97,16 +203,20 @@ func loadTestCases(options RunnerOptions) ([]testCase, error) {
 		if err != nil {
 			return nil, err
 		}
-		if !tc.Request.HasExpect() {
+		if !options.testable(tc.Request) {
 			return nil, fmt.Errorf("request %q has no expect block", tc.Name)
 		}
 		return []testCase{tc}, nil
 	}
 
 	if options.Dir != "" {
-		return loadCasesFromDir(options.Dir)
+		return loadCasesFromDir(options)
 	}
-	return loadCasesFromDefaultDir()
+	return loadCasesFromDefaultDir(options)
+}
+
+func (o RunnerOptions) testable(saved *request.SavedRequest) bool {
+	return o.OpenAPI != nil || saved.HasExpect()
 }
 
 func loadSingleCase(name, dir string) (testCase, error) {
@@ -115,7 +225,7 @@ func loadSingleCase(name, dir string) (testCase, error) {
 		if err != nil {
 			return testCase{}, err
 		}
-		return testCase{Name: name, Request: saved}, nil
+		return testCase{Name: name, Dir: request.SavedDir(name), Request: saved}, nil
 	}
 
 	path, err := findRequestFileByName(dir, name)
@@ -131,10 +241,10 @@ func loadSingleCase(name, dir string) (testCase, error) {
 	if requestName == "" {
 		requestName = nameFromPath(path)
 	}
-	return testCase{Name: requestName, Request: saved}, nil
+	return testCase{Name: requestName, Dir: filepath.ToSlash(filepath.Dir(path)), Request: saved}, nil
 }
 
-func loadCasesFromDefaultDir() ([]testCase, error) {
+func loadCasesFromDefaultDir(options RunnerOptions) ([]testCase, error) {
 	names, err := request.ListSaved()
 	if err != nil {
 		return nil, err
@@ -146,20 +256,21 @@ func loadCasesFromDefaultDir() ([]testCase, error) {
 		if err != nil {
 			return nil, err
 		}
-		if !saved.HasExpect() {
+		if !options.testable(saved) {
 			continue
 		}
 		requestName := saved.Name
 		if requestName == "" {
 			requestName = name
 		}
-		cases = append(cases, testCase{Name: requestName, Request: saved})
+		cases = append(cases, testCase{Name: requestName, Dir: request.SavedDir(name), Request: saved})
 	}
 
 	return cases, nil
 }
 
-func loadCasesFromDir(dir string) ([]testCase, error) {
+func loadCasesFromDir(options RunnerOptions) ([]testCase, error) {
+	dir := options.Dir
 	paths := make([]string, 0)
 	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
@@ -184,7 +295,7 @@ func loadCasesFromDir(dir string) ([]testCase, error) {
 		if err != nil {
 			return nil, err
 		}
-		if !saved.HasExpect() {
+		if !options.testable(saved) {
 			continue
 		}
 		requestName := saved.Name
@@ -193,6 +304,7 @@ func loadCasesFromDir(dir string) ([]testCase, error) {
 		}
 		cases = append(cases, testCase{
 			Name:    requestName,
+			Dir:     filepath.ToSlash(filepath.Dir(path)),
 			Request: saved,
 		})
 	}
@@ -1,11 +1,14 @@
 package tester
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
 	"github.com/Tresor-Kasend/apix/internal/request"
@@ -32,7 +35,7 @@ func TestRunSummaryAndCounts(t *testing.T) {
 		"method: GET\n"+
 		"path: /ignored\n")
 
-	suite, err := Run(RunnerOptions{Dir: dir}, func(name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+	suite, err := Run(context.Background(), RunnerOptions{Dir: dir}, func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
 		return &apixhttp.Response{
 			StatusCode: http.StatusOK,
 			Status:     "200 OK",
@@ -68,7 +71,7 @@ func TestRunExitCodeAndSingleRequestSelection(t *testing.T) {
 		"  status:\n"+
 		"    eq: 200\n")
 
-	suite, err := Run(RunnerOptions{Name: "login", Dir: dir}, func(name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+	suite, err := Run(context.Background(), RunnerOptions{Name: "login", Dir: dir}, func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
 		if name != "login" {
 			return nil, fmt.Errorf("unexpected test name %q", name)
 		}
@@ -97,3 +100,206 @@ func writeRequestYAML(t *testing.T, path, content string) {
 		t.Fatalf("writing %s: %v", path, err)
 	}
 }
+
+func TestRunParallelKeepsCaseOrder(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 6; i++ {
+		writeRequestYAML(t, filepath.Join(dir, fmt.Sprintf("case-%d.yaml", i)), fmt.Sprintf(""+
+			"name: case-%d\n"+
+			"method: GET\n"+
+			"path: /case/%d\n"+
+			"expect:\n"+
+			"  status:\n"+
+			"    eq: 200\n", i, i))
+	}
+
+	var inFlight, maxInFlight int32
+	suite, err := Run(context.Background(), RunnerOptions{Dir: dir, Parallel: 3}, func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		// Later cases finish first so completion order differs from case order.
+		index := int(name[len(name)-1] - '0')
+		time.Sleep(time.Duration(6-index) * 5 * time.Millisecond)
+
+		status := http.StatusOK
+		if name == "case-4" {
+			status = http.StatusInternalServerError
+		}
+		return &apixhttp.Response{StatusCode: status, Headers: http.Header{}, Body: []byte(`{}`)}, nil
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if suite.Total != 6 || suite.Passed != 5 || suite.Failed != 1 {
+		t.Fatalf("unexpected counts: %+v", suite)
+	}
+	for i, result := range suite.Results {
+		if want := fmt.Sprintf("case-%d", i); result.Name != want {
+			t.Fatalf("expected result %d to be %s, got %s", i, want, result.Name)
+		}
+	}
+	if suite.Results[4].Passed {
+		t.Fatalf("expected case-4 to fail")
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got < 2 || got > 3 {
+		t.Fatalf("expected between 2 and 3 concurrent executions, got %d", got)
+	}
+}
+
+func TestRunStopsSchedulingWhenCancelled(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 4; i++ {
+		writeRequestYAML(t, filepath.Join(dir, fmt.Sprintf("case-%d.yaml", i)), fmt.Sprintf(""+
+			"name: case-%d\n"+
+			"method: GET\n"+
+			"path: /case/%d\n"+
+			"expect:\n"+
+			"  status:\n"+
+			"    eq: 200\n", i, i))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	suite, err := Run(ctx, RunnerOptions{Dir: dir}, func(ctx context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+		if name == "case-1" {
+			cancel()
+			return nil, ctx.Err()
+		}
+		return &apixhttp.Response{StatusCode: http.StatusOK, Headers: http.Header{}, Body: []byte(`{}`)}, nil
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if !suite.Interrupted || suite.Total != 4 || len(suite.Results) != 2 {
+		t.Fatalf("expected an interrupted suite with 2 of 4 results, got %+v", suite)
+	}
+	if suite.Passed != 1 || suite.Failed != 1 || suite.Results[1].Error != "interrupted" {
+		t.Fatalf("expected case-1 to be reported as interrupted, got %+v", suite.Results)
+	}
+}
+
+func TestRunDataIterations(t *testing.T) {
+	dir := t.TempDir()
+	writeRequestYAML(t, filepath.Join(dir, "create-user.yaml"), ""+
+		"name: create-user\n"+
+		"method: POST\n"+
+		"path: /users\n"+
+		"body: '{\"email\":\"${EMAIL}\"}'\n"+
+		"data:\n"+
+		"  - {EMAIL: a@example.com, STATUS: 201}\n"+
+		"  - {EMAIL: invalid, STATUS: 422}\n"+
+		"  - {EMAIL: '', STATUS: 201}\n"+
+		"expect:\n"+
+		"  status:\n"+
+		"    eq: ${STATUS}\n")
+
+	execute := func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+		if name != "create-user" {
+			return nil, fmt.Errorf("unexpected test name %q", name)
+		}
+		if vars["ENV_ONLY"] != "kept" {
+			return nil, fmt.Errorf("expected --var values to be passed, got %v", vars)
+		}
+		status := http.StatusCreated
+		if vars["EMAIL"] == "invalid" || vars["EMAIL"] == "" {
+			status = http.StatusUnprocessableEntity
+		}
+		return &apixhttp.Response{StatusCode: status, Headers: http.Header{}, Body: []byte(`{}`)}, nil
+	}
+
+	suite, err := Run(context.Background(), RunnerOptions{Dir: dir, Vars: map[string]string{"ENV_ONLY": "kept", "EMAIL": "overridden"}}, execute)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if suite.Total != 3 || suite.Passed != 2 || suite.Failed != 1 {
+		t.Fatalf("unexpected counts: %+v", suite)
+	}
+	for i, result := range suite.Results {
+		if want := fmt.Sprintf("create-user[row %d]", i+1); result.Name != want {
+			t.Fatalf("expected result %d to be %s, got %s", i, want, result.Name)
+		}
+	}
+	if suite.Results[2].Passed {
+		t.Fatalf("expected row 3 to fail, got %+v", suite.Results[2])
+	}
+
+	dataFile := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(dataFile, []byte("EMAIL,STATUS\ninvalid,422\n"), 0o644); err != nil {
+		t.Fatalf("write data file: %v", err)
+	}
+	suite, err = Run(context.Background(), RunnerOptions{Dir: dir, Data: dataFile, Vars: map[string]string{"ENV_ONLY": "kept"}}, execute)
+	if err != nil {
+		t.Fatalf("run with data override failed: %v", err)
+	}
+	if suite.Total != 1 || suite.Passed != 1 || suite.Results[0].Name != "create-user[row 1]" {
+		t.Fatalf("unexpected override result: %+v", suite)
+	}
+}
+
+func TestRunResolvesSchemaFilesFromRequestDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(t.TempDir())
+	for _, sub := range []string{"users", "schemas"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatalf("creating %s: %v", sub, err)
+		}
+	}
+	writeRequestYAML(t, filepath.Join(dir, "users", "user.json"), `{"type":"object","required":["id"]}`)
+	writeRequestYAML(t, filepath.Join(dir, "schemas", "items.json"), `{"type":"array","items":{"type":"integer"}}`)
+	writeRequestYAML(t, filepath.Join(dir, "users", "get-user.yaml"), ""+
+		"name: get-user\n"+
+		"method: GET\n"+
+		"path: /users/1\n"+
+		"expect:\n"+
+		"  schema: user.json\n"+
+		"  body:\n"+
+		"    items:\n"+
+		"      schema: ../schemas/items.json\n")
+
+	suite, err := Run(context.Background(), RunnerOptions{Dir: dir}, func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+		return &apixhttp.Response{
+			StatusCode: http.StatusOK,
+			Headers:    http.Header{"Content-Type": []string{"application/json"}},
+			Body:       []byte(`{"id":1,"items":[1,"two"]}`),
+		}, nil
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	result := suite.Results[0]
+	if result.Error != "" {
+		t.Fatalf("expected schema files to load from the request dir, got %q", result.Error)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].Target != "body.items/1" {
+		t.Fatalf("expected one items violation, got %+v", result.Failures)
+	}
+}
+
+func TestResolveExpectKeepsUnresolvedFields(t *testing.T) {
+	expect := &request.Expect{
+		Status:          request.AssertionRule{"eq": "${STATUS}"},
+		Snapshot:        true,
+		SnapshotIgnore:  []string{"id"},
+		SnapshotHeaders: []string{"Content-Type"},
+	}
+
+	got := resolveExpect(expect, map[string]string{"STATUS": "201"})
+	if got.Status["eq"] != 201 {
+		t.Fatalf("expected status placeholder to resolve to 201, got %#v", got.Status["eq"])
+	}
+	if !got.Snapshot || len(got.SnapshotIgnore) != 1 || len(got.SnapshotHeaders) != 1 {
+		t.Fatalf("expected snapshot settings to be kept, got %+v", got)
+	}
+	if expect.Status["eq"] != "${STATUS}" {
+		t.Fatalf("expected the original expect to be left untouched, got %#v", expect.Status["eq"])
+	}
+}
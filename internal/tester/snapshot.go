@@ -0,0 +1,310 @@
+package tester
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tresor-Kasend/apix/internal/fsutil"
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/jsonvalue"
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+const (
+	snapshotDirName = "__snapshots__"
+	ignoredValue    = "<ignored>"
+)
+
+// Snapshot outcomes reported on RequestResult.Snapshot.
+const (
+	SnapshotWritten = "written"
+	SnapshotUpdated = "updated"
+)
+
+var snapshotFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+type snapshotFile struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body"`
+}
+
+// snapshotPath returns where the golden file of a case lives:
+// <request dir>/__snapshots__/<name>.json.
+func snapshotPath(tc testCase) string {
+	name := tc.Name
+	if tc.Label != "" {
+		name = tc.Label
+	}
+	name = filepath.Base(filepath.FromSlash(name))
+	name = strings.Trim(snapshotFileChars.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		name = "request"
+	}
+	return filepath.Join(filepath.FromSlash(tc.Dir), snapshotDirName, name+".json")
+}
+
+// checkSnapshot compares resp with the stored snapshot. A missing snapshot is
+// recorded and passes; update overwrites the stored one.
+func checkSnapshot(path string, expect *request.Expect, resp *apixhttp.Response, update bool) ([]AssertionFailure, string, error) {
+	current := buildSnapshot(expect, resp)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || update {
+		if writeErr := writeSnapshot(path, current); writeErr != nil {
+			return nil, "", writeErr
+		}
+		if err != nil {
+			return nil, SnapshotWritten, nil
+		}
+		return nil, SnapshotUpdated, nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("reading snapshot %q: %w", path, err)
+	}
+
+	var stored snapshotFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, "", fmt.Errorf("parsing snapshot %q: %w", path, err)
+	}
+	for _, ignore := range expect.SnapshotIgnore {
+		stored.Body = removeIgnoredPath(stored.Body, ignorePathSegments(ignore))
+	}
+
+	// Round-trip through JSON so both sides use the same representation.
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return nil, "", fmt.Errorf("encoding snapshot: %w", err)
+	}
+	var actual snapshotFile
+	if err := json.Unmarshal(encoded, &actual); err != nil {
+		return nil, "", fmt.Errorf("encoding snapshot: %w", err)
+	}
+
+	failures := make([]AssertionFailure, 0)
+	if stored.Status != actual.Status {
+		failures = append(failures, snapshotFailure("snapshot.status", stored.Status, actual.Status, "value changed"))
+	}
+	headerNames := make([]string, 0, len(stored.Headers)+len(actual.Headers))
+	for name := range stored.Headers {
+		headerNames = append(headerNames, name)
+	}
+	for name := range actual.Headers {
+		if _, ok := stored.Headers[name]; !ok {
+			headerNames = append(headerNames, name)
+		}
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		expected, inSnapshot := stored.Headers[name]
+		got, inResponse := actual.Headers[name]
+		target := "snapshot.headers." + name
+		switch {
+		case !inResponse:
+			failures = append(failures, snapshotFailure(target, expected, nil, "missing from response"))
+		case !inSnapshot:
+			failures = append(failures, snapshotFailure(target, nil, got, "not in snapshot"))
+		case expected != got:
+			failures = append(failures, snapshotFailure(target, expected, got, "value changed"))
+		}
+	}
+	failures = diffSnapshotValues("snapshot.body", stored.Body, actual.Body, failures)
+	return failures, "", nil
+}
+
+func buildSnapshot(expect *request.Expect, resp *apixhttp.Response) snapshotFile {
+	snapshot := snapshotFile{Status: resp.StatusCode}
+
+	if len(expect.SnapshotHeaders) > 0 {
+		snapshot.Headers = make(map[string]string, len(expect.SnapshotHeaders))
+		for _, name := range expect.SnapshotHeaders {
+			if value, ok := getHeaderValue(resp.Headers, name); ok {
+				snapshot.Headers[strings.ToLower(name)] = value
+			}
+		}
+	}
+
+	if len(resp.Body) > 0 {
+		var body interface{}
+		if err := json.Unmarshal(resp.Body, &body); err == nil {
+			snapshot.Body = body
+		} else {
+			snapshot.Body = string(resp.Body)
+		}
+	}
+	for _, ignore := range expect.SnapshotIgnore {
+		snapshot.Body = removeIgnoredPath(snapshot.Body, ignorePathSegments(ignore))
+	}
+	return snapshot
+}
+
+func writeSnapshot(path string, snapshot snapshotFile) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating snapshot directory: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing snapshot %q: %w", path, err)
+	}
+	return nil
+}
+
+// ignorePathSegments splits data.items[*].id (or data.items.*.id) into
+// name, index and wildcard segments.
+func ignorePathSegments(path string) []string {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.TrimPrefix(path, ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(path, ".") {
+		segment = strings.Trim(segment, `"'`)
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// removeIgnoredPath drops the matching object fields and blanks matching array
+// elements so volatile values never reach the snapshot.
+func removeIgnoredPath(node interface{}, segments []string) interface{} {
+	if len(segments) == 0 {
+		return node
+	}
+	segment, last := segments[0], len(segments) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, child := range n {
+			if segment != "*" && segment != key {
+				continue
+			}
+			if last {
+				delete(n, key)
+			} else {
+				n[key] = removeIgnoredPath(child, segments[1:])
+			}
+		}
+	case []interface{}:
+		for i, child := range n {
+			if segment != "*" {
+				index, err := strconv.Atoi(segment)
+				if err != nil {
+					return node
+				}
+				if index < 0 {
+					index += len(n)
+				}
+				if index != i {
+					continue
+				}
+			}
+			if last {
+				n[i] = ignoredValue
+			} else {
+				n[i] = removeIgnoredPath(child, segments[1:])
+			}
+		}
+	}
+	return node
+}
+
+// diffSnapshotValues walks both values and reports one failure per differing
+// leaf, field or element.
+func diffSnapshotValues(path string, expected, actual interface{}, failures []AssertionFailure) []AssertionFailure {
+	expectedMap, expectedIsMap := expected.(map[string]interface{})
+	actualMap, actualIsMap := actual.(map[string]interface{})
+	if expectedIsMap && actualIsMap {
+		keys := make([]string, 0, len(expectedMap)+len(actualMap))
+		for key := range expectedMap {
+			keys = append(keys, key)
+		}
+		for key := range actualMap {
+			if _, ok := expectedMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			expectedValue, inSnapshot := expectedMap[key]
+			actualValue, inResponse := actualMap[key]
+			child := path + "." + key
+			switch {
+			case !inResponse:
+				failures = append(failures, snapshotFailure(child, expectedValue, nil, "missing from response"))
+			case !inSnapshot:
+				failures = append(failures, snapshotFailure(child, nil, actualValue, "not in snapshot"))
+			default:
+				failures = diffSnapshotValues(child, expectedValue, actualValue, failures)
+			}
+		}
+		return failures
+	}
+
+	expectedList, expectedIsList := expected.([]interface{})
+	actualList, actualIsList := actual.([]interface{})
+	if expectedIsList && actualIsList {
+		for i := 0; i < len(expectedList) || i < len(actualList); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(actualList):
+				failures = append(failures, snapshotFailure(child, expectedList[i], nil, "missing from response"))
+			case i >= len(expectedList):
+				failures = append(failures, snapshotFailure(child, nil, actualList[i], "not in snapshot"))
+			default:
+				failures = diffSnapshotValues(child, expectedList[i], actualList[i], failures)
+			}
+		}
+		return failures
+	}
+
+	if !valuesEqual(actual, expected) {
+		message := "value changed"
+		if jsonKind(expected) != jsonKind(actual) {
+			message = fmt.Sprintf("type changed from %s to %s", jsonKind(expected), jsonKind(actual))
+		}
+		failures = append(failures, snapshotFailure(path, expected, actual, message))
+	}
+	return failures
+}
+
+func snapshotFailure(target string, expected, actual interface{}, message string) AssertionFailure {
+	return AssertionFailure{
+		Target:   target,
+		Operator: "snapshot",
+		Expected: expected,
+		Actual:   actual,
+		Message:  message,
+	}
+}
+
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		if _, ok := jsonvalue.Float64(value); ok {
+			return "number"
+		}
+		return fmt.Sprintf("%T", value)
+	}
+}
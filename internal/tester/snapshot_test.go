@@ -0,0 +1,106 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+func TestRunSnapshotRecordCompareAndUpdate(t *testing.T) {
+	dir := t.TempDir()
+	writeRequestYAML(t, filepath.Join(dir, "list-users.yaml"), ""+
+		"name: list-users\n"+
+		"method: GET\n"+
+		"path: /users\n"+
+		"expect:\n"+
+		"  snapshot: true\n"+
+		"  snapshot_headers: [Content-Type]\n"+
+		"  snapshot_ignore: [meta.request_id, 'data[*].created_at']\n")
+
+	body := `{"data":[{"id":1,"name":"Ada","created_at":"2024-01-01"},{"id":2,"name":"Linus","created_at":"2024-01-02"}],"meta":{"request_id":"abc","total":2}}`
+	execute := func(_ context.Context, name string, saved *request.SavedRequest, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+		return &apixhttp.Response{
+			StatusCode: http.StatusOK,
+			Headers:    http.Header{"Content-Type": []string{"application/json"}, "Date": []string{"now"}},
+			Body:       []byte(body),
+		}, nil
+	}
+
+	suite, err := Run(context.Background(), RunnerOptions{Dir: dir}, execute)
+	if err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	if suite.Passed != 1 || suite.Results[0].Snapshot != SnapshotWritten {
+		t.Fatalf("expected snapshot to be written, got %+v", suite.Results[0])
+	}
+	snapshotFile := filepath.Join(dir, "__snapshots__", "list-users.json")
+	data, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	for _, unwanted := range []string{"request_id", "created_at", "date"} {
+		if strings.Contains(string(data), unwanted) {
+			t.Fatalf("expected %q to be left out of snapshot:\n%s", unwanted, data)
+		}
+	}
+
+	// Volatile fields change without failing the comparison.
+	body = `{"data":[{"id":1,"name":"Ada","created_at":"2025-05-05"},{"id":2,"name":"Linus","created_at":"2025-05-06"}],"meta":{"request_id":"xyz","total":2}}`
+	suite, err = Run(context.Background(), RunnerOptions{Dir: dir}, execute)
+	if err != nil {
+		t.Fatalf("second run failed: %v", err)
+	}
+	if suite.Passed != 1 || suite.Results[0].Snapshot != "" {
+		t.Fatalf("expected snapshot match, got %+v", suite.Results[0])
+	}
+
+	body = `{"data":[{"id":1,"name":"Grace","created_at":"x"}],"meta":{"request_id":"xyz","total":"1","page":1}}`
+	suite, err = Run(context.Background(), RunnerOptions{Dir: dir}, execute)
+	if err != nil {
+		t.Fatalf("third run failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, failure := range suite.Results[0].Failures {
+		got[failure.Target] = failure.Message
+	}
+	want := map[string]string{
+		"snapshot.body.data[0].name": "value changed",
+		"snapshot.body.data[1]":      "missing from response",
+		"snapshot.body.meta.page":    "not in snapshot",
+		"snapshot.body.meta.total":   "type changed from number to string",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d failures, got %v", len(want), got)
+	}
+	for target, message := range want {
+		if got[target] != message {
+			t.Fatalf("expected %s to fail with %q, got %q (all: %v)", target, message, got[target], got)
+		}
+	}
+
+	suite, err = Run(context.Background(), RunnerOptions{Dir: dir, UpdateSnapshots: true}, execute)
+	if err != nil {
+		t.Fatalf("update run failed: %v", err)
+	}
+	if suite.Passed != 1 || suite.Results[0].Snapshot != SnapshotUpdated {
+		t.Fatalf("expected snapshot to be updated, got %+v", suite.Results[0])
+	}
+	suite, err = Run(context.Background(), RunnerOptions{Dir: dir}, execute)
+	if err != nil || suite.Passed != 1 {
+		t.Fatalf("expected run after update to pass, got %+v (%v)", suite, err)
+	}
+}
+
+func TestSnapshotPathForIterations(t *testing.T) {
+	tc := testCase{Name: "users/create-user", Dir: "requests/users", Label: "users/create-user[row 2]"}
+	want := filepath.Join("requests", "users", "__snapshots__", "create-user-row-2.json")
+	if got := snapshotPath(tc); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
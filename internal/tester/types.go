@@ -12,10 +12,14 @@ type AssertionFailure struct {
 
 type RequestResult struct {
 	Name     string
+	Dir      string
 	Passed   bool
 	Duration time.Duration
 	Failures []AssertionFailure
 	Error    string
+	// Snapshot is SnapshotWritten or SnapshotUpdated when the golden file
+	// was (re)recorded by this run.
+	Snapshot string
 }
 
 type SuiteResult struct {
@@ -24,6 +28,9 @@ type SuiteResult struct {
 	Failed   int
 	Duration time.Duration
 	Results  []RequestResult
+	// Interrupted reports that the run was cancelled: Results only holds the
+	// cases that ran, Total counts every selected case.
+	Interrupted bool
 }
 
 func (s SuiteResult) ExitCode() int {
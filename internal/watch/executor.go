@@ -1,6 +1,7 @@
 package watch
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -36,6 +37,8 @@ type RunResult struct {
 }
 
 type executionState struct {
+	// ctx is the context of the Run, passed to every request it executes.
+	ctx        context.Context
 	stack      []string
 	visits     map[string]int
 	iterations int
@@ -69,19 +72,21 @@ func NewExecutor(execute runner.ExecuteSavedRequestFunc, opts ExecutorOptions) (
 	}, nil
 }
 
-func (e *Executor) Run(name string, vars map[string]string, envOverride string) (*RunResult, error) {
+// Run executes name and its hooks; cancelling ctx aborts the request in flight.
+func (e *Executor) Run(ctx context.Context, name string, vars map[string]string, envOverride string) (*RunResult, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return nil, fmt.Errorf("request name is required")
 	}
 
-	ctx := runner.NewRuntimeContext(vars)
+	runtime := runner.NewRuntimeContext(vars)
 	state := &executionState{
+		ctx:    ctx,
 		stack:  make([]string, 0, 8),
 		visits: make(map[string]int),
 	}
 
-	resp, executed, captured, err := e.runRequest(name, ctx, envOverride, state)
+	resp, executed, captured, err := e.runRequest(name, runtime, envOverride, state)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +95,7 @@ func (e *Executor) Run(name string, vars map[string]string, envOverride string)
 		Response:  resp,
 		Executed:  executed,
 		Captured:  captured,
-		FinalVars: ctx.Snapshot(),
+		FinalVars: runtime.Snapshot(),
 	}, nil
 }
 
@@ -132,7 +137,7 @@ func (e *Executor) runRequest(name string, ctx *runner.RuntimeContext, envOverri
 		}
 	}
 
-	resp, err := e.execute(name, ctx.Snapshot(), envOverride)
+	resp, err := e.execute(state.ctx, name, ctx.Snapshot(), envOverride)
 	if err != nil {
 		return nil, executed, capturedCount, fmt.Errorf("request %q failed: %w", name, err)
 	}
@@ -1,6 +1,7 @@
 package watch
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -31,7 +32,7 @@ func TestExecutorRunsPreMainPostInOrder(t *testing.T) {
 	})
 
 	order := make([]string, 0, 3)
-	executor, err := NewExecutor(func(name string, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+	executor, err := NewExecutor(func(_ context.Context, name string, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
 		order = append(order, name)
 		switch name {
 		case "login":
@@ -54,7 +55,7 @@ func TestExecutorRunsPreMainPostInOrder(t *testing.T) {
 		t.Fatalf("new executor: %v", err)
 	}
 
-	result, err := executor.Run("main", map[string]string{"INIT": "1"}, "")
+	result, err := executor.Run(context.Background(), "main", map[string]string{"INIT": "1"}, "")
 	if err != nil {
 		t.Fatalf("executor run failed: %v", err)
 	}
@@ -83,14 +84,14 @@ func TestExecutorDetectsRecursiveHooks(t *testing.T) {
 	mustSaveWatchRequest(t, "a", request.SavedRequest{Method: "GET", Path: "/a", PreRequest: []request.Hook{{Run: "b"}}})
 	mustSaveWatchRequest(t, "b", request.SavedRequest{Method: "GET", Path: "/b", PreRequest: []request.Hook{{Run: "a"}}})
 
-	executor, err := NewExecutor(func(name string, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+	executor, err := NewExecutor(func(_ context.Context, name string, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
 		return makeWatchJSONResponse(http.StatusOK, `{"ok":true}`), nil
 	}, ExecutorOptions{})
 	if err != nil {
 		t.Fatalf("new executor: %v", err)
 	}
 
-	_, err = executor.Run("a", nil, "")
+	_, err = executor.Run(context.Background(), "a", nil, "")
 	if err == nil {
 		t.Fatal("expected recursion guardrail error")
 	}
@@ -110,7 +111,7 @@ func TestExecutorStopsOnHookError(t *testing.T) {
 	})
 
 	calls := make([]string, 0, 2)
-	executor, err := NewExecutor(func(name string, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
+	executor, err := NewExecutor(func(_ context.Context, name string, vars map[string]string, envOverride string) (*apixhttp.Response, error) {
 		calls = append(calls, name)
 		switch name {
 		case "login":
@@ -125,7 +126,7 @@ func TestExecutorStopsOnHookError(t *testing.T) {
 		t.Fatalf("new executor: %v", err)
 	}
 
-	_, err = executor.Run("main", nil, "")
+	_, err = executor.Run(context.Background(), "main", nil, "")
 	if err == nil {
 		t.Fatal("expected pre hook failure")
 	}
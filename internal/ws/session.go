@@ -0,0 +1,179 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	apixhttp "github.com/Tresor-Kasend/apix/internal/http"
+	"github.com/Tresor-Kasend/apix/internal/request"
+	"github.com/Tresor-Kasend/apix/internal/runner"
+	"github.com/Tresor-Kasend/apix/internal/tester"
+)
+
+// DefaultMessageTimeout bounds how long a session step waits for a frame.
+const DefaultMessageTimeout = 10 * time.Second
+
+type Session struct {
+	URL      string
+	Dial     DialOptions
+	Messages []request.WSMessage
+	Vars     map[string]string
+
+	OnSend    func(text string)
+	OnReceive func(message Message)
+}
+
+type SessionResult struct {
+	Handshake *http.Response
+	Received  []Message
+	Captured  map[string]string
+	Duration  time.Duration
+}
+
+// RunSession connects, plays the scripted messages in order and closes the
+// connection. ${VAR} placeholders in sent frames are resolved with Vars and
+// with variables captured by earlier steps. Cancelling ctx closes the
+// connection and ends the session with the error of ctx.
+func RunSession(ctx context.Context, s Session) (result *SessionResult, err error) {
+	start := time.Now()
+	conn, handshake, err := Dial(ctx, s.URL, s.Dial)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	defer context.AfterFunc(ctx, func() { _ = conn.Close() })()
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	}()
+
+	result = &SessionResult{Handshake: handshake, Captured: make(map[string]string)}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+	vars := make(map[string]string, len(s.Vars))
+	for k, v := range s.Vars {
+		vars[k] = v
+	}
+
+	for i, step := range s.Messages {
+		if step.Send != "" {
+			text := request.ResolveVariables(step.Send, vars)
+			if err := conn.WriteText(text); err != nil {
+				return result, fmt.Errorf("message %d: %w", i+1, err)
+			}
+			if s.OnSend != nil {
+				s.OnSend(text)
+			}
+		}
+		if !step.Waits() {
+			continue
+		}
+
+		timeout := DefaultMessageTimeout
+		if strings.TrimSpace(step.Timeout) != "" {
+			timeout, err = time.ParseDuration(strings.TrimSpace(step.Timeout))
+			if err != nil {
+				return result, fmt.Errorf("message %d: invalid timeout %q", i+1, step.Timeout)
+			}
+		}
+
+		frame, err := waitForFrame(conn, step, handshake.Header, timeout, result, s.OnReceive)
+		if err != nil {
+			return result, fmt.Errorf("message %d: %w", i+1, err)
+		}
+		captured, err := runner.CaptureVariables(step.Capture, frame)
+		if err != nil {
+			return result, fmt.Errorf("message %d: capture failed: %w", i+1, err)
+		}
+		for k, v := range captured {
+			vars[k] = v
+			result.Captured[k] = v
+		}
+	}
+	return result, nil
+}
+
+// waitForFrame reads frames until one satisfies the step's expect block.
+// Frames that do not match are kept in the transcript and skipped.
+func waitForFrame(conn *Conn, step request.WSMessage, headers http.Header, timeout time.Duration, result *SessionResult, onReceive func(Message)) (*apixhttp.Response, error) {
+	deadline := time.Now().Add(timeout)
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer conn.SetReadDeadline(time.Time{})
+
+	var lastFailures []tester.AssertionFailure
+	for {
+		message, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if len(lastFailures) > 0 {
+					return nil, fmt.Errorf("no matching frame within %s (last frame: %s)", timeout, describeFailures(lastFailures))
+				}
+				return nil, fmt.Errorf("no frame received within %s", timeout)
+			}
+			return nil, err
+		}
+		result.Received = append(result.Received, message)
+		if onReceive != nil {
+			onReceive(message)
+		}
+
+		frame := &apixhttp.Response{
+			StatusCode: http.StatusSwitchingProtocols,
+			Status:     "101 Switching Protocols",
+			Headers:    headers,
+			Body:       message.Data,
+		}
+		failures, err := tester.EvaluateExpect(step.Expect, frame)
+		if err != nil {
+			lastFailures = []tester.AssertionFailure{{Message: err.Error()}}
+			continue
+		}
+		if len(failures) > 0 {
+			lastFailures = failures
+			continue
+		}
+		return frame, nil
+	}
+}
+
+func describeFailures(failures []tester.AssertionFailure) string {
+	parts := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		if failure.Target == "" {
+			parts = append(parts, failure.Message)
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %s expected=%v actual=%v", failure.Target, failure.Operator, failure.Expected, failure.Actual))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Transcript returns the received messages as a JSON array; frames holding
+// JSON are embedded as values, others as strings.
+func Transcript(messages []Message) ([]byte, error) {
+	out := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		var decoded interface{}
+		if message.Type == TextMessage && json.Unmarshal(message.Data, &decoded) == nil {
+			out = append(out, decoded)
+			continue
+		}
+		out = append(out, string(message.Data))
+	}
+	body, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("encoding websocket transcript: %w", err)
+	}
+	return body, nil
+}
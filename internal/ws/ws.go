@@ -0,0 +1,398 @@
+// Package ws implements the client side of the WebSocket protocol (RFC 6455)
+// used by apix ws and saved requests with protocol: websocket.
+package ws
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	acceptGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxMessageSize = 32 << 20
+)
+
+// Message types (frame opcodes).
+const (
+	opContinuation = 0x0
+	TextMessage    = 0x1
+	BinaryMessage  = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// CloseNormal is the status code sent by Close.
+const CloseNormal = 1000
+
+// CloseError is returned by ReadMessage once the peer closed the connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket closed (%d)", e.Code)
+	}
+	return fmt.Sprintf("websocket closed (%d): %s", e.Code, e.Reason)
+}
+
+type Message struct {
+	Type int
+	Data []byte
+}
+
+type DialOptions struct {
+	Headers      map[string]string
+	Timeout      time.Duration
+	Insecure     bool
+	Subprotocols []string
+}
+
+type Conn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	isClient bool
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// URLFromHTTP converts an http(s) URL into its ws(s) equivalent; ws(s) URLs
+// are returned unchanged.
+func URLFromHTTP(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid websocket URL %q: %w", rawURL, err)
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http":
+		parsed.Scheme = "ws"
+	case "https":
+		parsed.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("invalid websocket URL %q (expected ws://, wss://, http:// or https://)", rawURL)
+	}
+	return parsed.String(), nil
+}
+
+// Dial opens a WebSocket connection. The handshake response is returned
+// alongside, also when the server refuses the upgrade. ctx only bounds the
+// connection and the handshake.
+func Dial(ctx context.Context, rawURL string, opts DialOptions) (*Conn, *http.Response, error) {
+	target, err := URLFromHTTP(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid websocket URL %q: %w", rawURL, err)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "wss" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	if u.Scheme == "wss" {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: opts.Insecure,
+			NextProtos:         []string{"http/1.1"},
+		}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", host)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to %s: %w", u.Host, err)
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	resp, reader, err := handshake(conn, u, opts, timeout)
+	if !stop() && err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, resp, err
+	}
+	return newConn(conn, reader, true), resp, nil
+}
+
+// handshake performs the HTTP upgrade. The returned reader may already hold
+// the first frames sent by the server.
+func handshake(conn net.Conn, u *url.URL, opts DialOptions, timeout time.Duration) (*http.Response, *bufio.Reader, error) {
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, nil, fmt.Errorf("generating websocket key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	httpURL := *u
+	if u.Scheme == "wss" {
+		httpURL.Scheme = "https"
+	} else {
+		httpURL.Scheme = "http"
+	}
+	req, err := http.NewRequest(http.MethodGet, httpURL.String(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("building handshake: %w", err)
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(opts.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(opts.Subprotocols, ", "))
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	defer conn.SetDeadline(time.Time{})
+	if err := req.Write(conn); err != nil {
+		return nil, nil, fmt.Errorf("sending handshake: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading handshake response: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		return resp, nil, fmt.Errorf("websocket handshake failed: HTTP %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return resp, nil, fmt.Errorf("websocket handshake failed: missing Upgrade header")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return resp, nil, fmt.Errorf("websocket handshake failed: invalid Sec-WebSocket-Accept")
+	}
+	return resp, reader, nil
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func newConn(conn net.Conn, reader *bufio.Reader, isClient bool) *Conn {
+	return &Conn{conn: conn, reader: reader, isClient: isClient}
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) WriteText(text string) error {
+	return c.WriteMessage(TextMessage, []byte(text))
+}
+
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("unsupported websocket message type %d", messageType)
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// ReadMessage returns the next data message, answering pings and
+// reassembling fragmented messages. A close from the peer is acknowledged
+// and reported as *CloseError.
+func (c *Conn) ReadMessage() (Message, error) {
+	var (
+		message Message
+		started bool
+	)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return Message{}, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return Message{}, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr := &CloseError{Code: 1005}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload[:2]))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.writeMu.Lock()
+			if !c.closed {
+				_ = c.writeFrameLocked(opClose, payload[:min(len(payload), 2)])
+				c.closed = true
+			}
+			c.writeMu.Unlock()
+			_ = c.conn.Close()
+			return Message{}, closeErr
+		case TextMessage, BinaryMessage:
+			if started {
+				return Message{}, fmt.Errorf("websocket protocol error: new message inside a fragmented one")
+			}
+			message = Message{Type: opcode}
+			started = true
+		case opContinuation:
+			if !started {
+				return Message{}, fmt.Errorf("websocket protocol error: unexpected continuation frame")
+			}
+		default:
+			return Message{}, fmt.Errorf("websocket protocol error: unknown opcode %d", opcode)
+		}
+
+		if len(message.Data)+len(payload) > maxMessageSize {
+			return Message{}, fmt.Errorf("websocket message exceeds %d bytes", maxMessageSize)
+		}
+		message.Data = append(message.Data, payload...)
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// Close sends a normal close frame and closes the connection.
+func (c *Conn) Close() error {
+	c.writeMu.Lock()
+	if !c.closed {
+		payload := make([]byte, 2)
+		binary.BigEndian.PutUint16(payload, CloseNormal)
+		_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_ = c.writeFrameLocked(opClose, payload)
+		c.closed = true
+	}
+	c.writeMu.Unlock()
+	return c.conn.Close()
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return errors.New("websocket connection is closed")
+	}
+	return c.writeFrameLocked(opcode, payload)
+}
+
+func (c *Conn) writeFrameLocked(opcode int, payload []byte) error {
+	header := make([]byte, 2, 14)
+	header[0] = 0x80 | byte(opcode)
+
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	data := payload
+	if c.isClient {
+		header[1] |= 0x80
+		mask := make([]byte, 4)
+		if _, err := rand.Read(mask); err != nil {
+			return fmt.Errorf("generating websocket mask: %w", err)
+		}
+		header = append(header, mask...)
+		data = make([]byte, length)
+		for i := range payload {
+			data[i] = payload[i] ^ mask[i%4]
+		}
+	}
+
+	if _, err := c.conn.Write(append(header, data...)); err != nil {
+		return fmt.Errorf("writing websocket frame: %w", err)
+	}
+	return nil
+}
+
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, head); err != nil {
+		return false, 0, nil, err
+	}
+	fin := head[0]&0x80 != 0
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("websocket protocol error: reserved bits set")
+	}
+	opcode := int(head[0] & 0x0F)
+	masked := head[1]&0x80 != 0
+
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+	if opcode >= opClose && (length > 125 || !fin) {
+		return false, 0, nil, fmt.Errorf("websocket protocol error: invalid control frame")
+	}
+	if length > maxMessageSize {
+		return false, 0, nil, fmt.Errorf("websocket frame exceeds %d bytes", maxMessageSize)
+	}
+
+	var mask []byte
+	if masked {
+		mask = make([]byte, 4)
+		if _, err := io.ReadFull(c.reader, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
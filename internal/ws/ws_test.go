@@ -0,0 +1,240 @@
+package ws
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tresor-Kasend/apix/internal/request"
+)
+
+// newTestServer upgrades every request and hands the server side of the
+// connection to handle.
+func newTestServer(t *testing.T, handle func(r *http.Request, conn *Conn)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			t.Errorf("response writer does not support hijacking")
+			return
+		}
+		netConn, rw, err := hijacker.Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer netConn.Close()
+
+		response := "HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\n" +
+			"Connection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n"
+		if _, err := netConn.Write([]byte(response)); err != nil {
+			t.Errorf("writing handshake: %v", err)
+			return
+		}
+		handle(r, newConn(netConn, bufio.NewReader(rw), false))
+	}))
+}
+
+func echo(_ *http.Request, conn *Conn) {
+	for {
+		message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := conn.WriteMessage(message.Type, message.Data); err != nil {
+			return
+		}
+	}
+}
+
+func TestDialEcho(t *testing.T) {
+	server := newTestServer(t, echo)
+	defer server.Close()
+
+	conn, resp, err := Dial(context.Background(), server.URL+"/echo", DialOptions{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Dial returned error: %v", err)
+	}
+	defer conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+
+	long := strings.Repeat("x", 70000)
+	for _, text := range []string{"hello", long} {
+		if err := conn.WriteText(text); err != nil {
+			t.Fatalf("WriteText returned error: %v", err)
+		}
+		message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage returned error: %v", err)
+		}
+		if message.Type != TextMessage || string(message.Data) != text {
+			t.Fatalf("unexpected echo of %d bytes: type=%d len=%d", len(text), message.Type, len(message.Data))
+		}
+	}
+}
+
+func TestReadMessageFragmentsPingAndClose(t *testing.T) {
+	server := newTestServer(t, func(_ *http.Request, conn *Conn) {
+		// Ping, then "hel" + "lo" split over a text frame and a continuation.
+		_ = conn.writeFrame(opPing, []byte("p"))
+		_, _ = conn.conn.Write([]byte{0x01, 3, 'h', 'e', 'l'})
+		_, _ = conn.conn.Write([]byte{0x80, 2, 'l', 'o'})
+
+		// The client must answer the ping with a pong carrying its payload.
+		fin, opcode, payload, err := conn.readFrame()
+		if err != nil || !fin || opcode != opPong || string(payload) != "p" {
+			t.Errorf("expected pong, got fin=%v opcode=%d payload=%q err=%v", fin, opcode, payload, err)
+		}
+		_ = conn.writeFrame(opClose, []byte{0x03, 0xE8, 'b', 'y', 'e'})
+		_, _, _, _ = conn.readFrame()
+	})
+	defer server.Close()
+
+	conn, _, err := Dial(context.Background(), server.URL, DialOptions{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Dial returned error: %v", err)
+	}
+	defer conn.Close()
+
+	message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage returned error: %v", err)
+	}
+	if string(message.Data) != "hello" {
+		t.Fatalf("expected reassembled hello, got %q", message.Data)
+	}
+
+	_, err = conn.ReadMessage()
+	closeErr, ok := err.(*CloseError)
+	if !ok {
+		t.Fatalf("expected *CloseError, got %v", err)
+	}
+	if closeErr.Code != CloseNormal || closeErr.Reason != "bye" {
+		t.Fatalf("unexpected close error: %+v", closeErr)
+	}
+}
+
+func TestDialRejectedUpgrade(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, resp, err := Dial(context.Background(), server.URL, DialOptions{Timeout: 2 * time.Second})
+	if err == nil {
+		t.Fatalf("expected handshake error")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the 403 handshake response to be returned, got %+v", resp)
+	}
+}
+
+func TestRunSession(t *testing.T) {
+	var gotAuth string
+	server := newTestServer(t, func(r *http.Request, conn *Conn) {
+		gotAuth = r.Header.Get("Authorization")
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var payload map[string]interface{}
+			if err := json.Unmarshal(message.Data, &payload); err != nil {
+				return
+			}
+			switch payload["type"] {
+			case "subscribe":
+				// A heartbeat first: the step must skip it.
+				_ = conn.WriteText(`{"type":"heartbeat"}`)
+				_ = conn.WriteText(`{"type":"subscribed","id":"sub-42","channel":"` + payload["channel"].(string) + `"}`)
+			case "publish":
+				_ = conn.WriteText(`{"type":"ack","subscription":"` + payload["id"].(string) + `"}`)
+			}
+		}
+	})
+	defer server.Close()
+
+	var sent []string
+	result, err := RunSession(context.Background(), Session{
+		URL: server.URL + "/live",
+		Dial: DialOptions{
+			Headers: map[string]string{"Authorization": "Bearer secret"},
+			Timeout: 2 * time.Second,
+		},
+		Vars: map[string]string{"CHANNEL": "orders"},
+		Messages: []request.WSMessage{
+			{
+				Send: `{"type":"subscribe","channel":"${CHANNEL}"}`,
+				Expect: &request.Expect{Body: map[string]request.AssertionRule{
+					"type": {"eq": "subscribed"},
+				}},
+				Capture: map[string]string{"SUB_ID": "id"},
+			},
+			{
+				Send: `{"type":"publish","id":"${SUB_ID}"}`,
+				Expect: &request.Expect{Body: map[string]request.AssertionRule{
+					"subscription": {"eq": "sub-42"},
+				}},
+			},
+		},
+		OnSend: func(text string) { sent = append(sent, text) },
+	})
+	if err != nil {
+		t.Fatalf("RunSession returned error: %v", err)
+	}
+
+	if gotAuth != "Bearer secret" {
+		t.Fatalf("expected Authorization header on handshake, got %q", gotAuth)
+	}
+	if result.Captured["SUB_ID"] != "sub-42" {
+		t.Fatalf("expected SUB_ID capture, got %+v", result.Captured)
+	}
+	if len(sent) != 2 || sent[0] != `{"type":"subscribe","channel":"orders"}` || sent[1] != `{"type":"publish","id":"sub-42"}` {
+		t.Fatalf("unexpected sent messages: %v", sent)
+	}
+	if len(result.Received) != 3 {
+		t.Fatalf("expected 3 received messages, got %d", len(result.Received))
+	}
+
+	body, err := Transcript(result.Received)
+	if err != nil {
+		t.Fatalf("Transcript returned error: %v", err)
+	}
+	var transcript []map[string]interface{}
+	if err := json.Unmarshal(body, &transcript); err != nil {
+		t.Fatalf("transcript is not a JSON array: %v", err)
+	}
+	if transcript[0]["type"] != "heartbeat" || transcript[2]["type"] != "ack" {
+		t.Fatalf("unexpected transcript: %s", body)
+	}
+}
+
+func TestRunSessionTimeout(t *testing.T) {
+	server := newTestServer(t, echo)
+	defer server.Close()
+
+	_, err := RunSession(context.Background(), Session{
+		URL:  server.URL,
+		Dial: DialOptions{Timeout: 2 * time.Second},
+		Messages: []request.WSMessage{{
+			Send:    `{"type":"ping"}`,
+			Expect:  &request.Expect{Body: map[string]request.AssertionRule{"type": {"eq": "pong"}}},
+			Timeout: "200ms",
+		}},
+	})
+	if err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if !strings.Contains(err.Error(), "no matching frame within 200ms") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}